@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	cfg "github.com/merkulovlad/wbtech-go/internal/config/config"
+)
+
+// parseFlags reads the flags that come before the subcommand, if any:
+//
+//	main [-config .env] [-http.addr host:port] [-log.level debug] [-log.console] [-set KEY=VALUE]... [migrate|backfill|seed ...]
+//
+// Each flag sets a configuration variable ahead of the environment and the
+// env file; -set sets any. It returns the source to load and the remaining
+// arguments.
+func parseFlags(args []string) (cfg.Source, []string) {
+	fs := flag.NewFlagSet("main", flag.ExitOnError)
+	src := cfg.Source{Overrides: map[string]string{}}
+	fs.StringVar(&src.Path, "config", cfg.EnvFile, "env file to read; a missing file is skipped")
+	fs.Func("http.addr", "listen address host:port (BACKEND_HOST, BACKEND_PORT)", func(v string) error {
+		host, port, err := net.SplitHostPort(v)
+		if err != nil {
+			return err
+		}
+		if host != "" {
+			src.Overrides["BACKEND_HOST"] = host
+		}
+		src.Overrides["BACKEND_PORT"] = port
+		return nil
+	})
+	fs.Func("log.level", "debug, info, warn or error (LOG_LEVEL)", func(v string) error {
+		src.Overrides["LOG_LEVEL"] = v
+		return nil
+	})
+	fs.BoolFunc("log.console", "log to the console as well as the file (LOG_TO_CONSOLE)", func(v string) error {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return err
+		}
+		src.Overrides["LOG_TO_CONSOLE"] = v
+		return nil
+	})
+	fs.Func("set", "set any variable, as KEY=VALUE; repeatable", func(v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("%q is not KEY=VALUE", v)
+		}
+		src.Overrides[key] = value
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] [migrate|backfill|seed [flags]]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	return src, fs.Args()
+}
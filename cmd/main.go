@@ -2,35 +2,49 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/merkulovlad/wbtech-go/frontend"
+	"github.com/merkulovlad/wbtech-go/internal/api"
+	"github.com/merkulovlad/wbtech-go/internal/backfill"
 	cfg "github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/config/reload"
 	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/events"
+	"github.com/merkulovlad/wbtech-go/internal/gauges"
+	"github.com/merkulovlad/wbtech-go/internal/health"
+	"github.com/merkulovlad/wbtech-go/internal/ingest"
 	"github.com/merkulovlad/wbtech-go/internal/kafka"
 	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/nats"
+	"github.com/merkulovlad/wbtech-go/internal/pubsub"
+	"github.com/merkulovlad/wbtech-go/internal/rabbitmq"
+	"github.com/merkulovlad/wbtech-go/internal/retention"
+	"github.com/merkulovlad/wbtech-go/internal/seed"
 	"github.com/merkulovlad/wbtech-go/internal/server"
 	"github.com/merkulovlad/wbtech-go/internal/service/cache"
 	"github.com/merkulovlad/wbtech-go/internal/service/order"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/merkulovlad/wbtech-go/internal/warmer"
+	"github.com/merkulovlad/wbtech-go/internal/webhook"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
-	_ "github.com/merkulovlad/wbtech-go/docs"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// @title           Order Service API
-// @version         1.0
-// @description     API for managing and retrieving orders.
-// @host            localhost:8080
-// @BasePath        /
-// @schemes         http
 func main() {
-	config := cfg.MustLoad()
+	src, args := parseFlags(os.Args[1:])
+	config := cfg.MustLoad(src)
 	log, err := logger.NewLogger(&config.Log)
 	if err != nil {
 		log.Fatalf("failed to initialize logger: %v", err)
@@ -46,40 +60,245 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
-	defer func(db *sql.DB) {
-		err := db.Close()
-		if err != nil {
-			fmt.Printf("failed to close database connection: %v", err)
+	defer db.Close()
+	if len(args) > 0 && args[0] == "migrate" {
+		runMigrate(db, &config.Database, log, args[1:])
+		return
+	}
+	repository.PublishPoolStats("primary", db)
+	degraded := checkMigrations(migrateOnStart(db, &config.Database, log), "primary", &config.Database, log)
+
+	timeouts := repository.WithTimeouts(repository.Timeouts{
+		Read:  config.Database.ReadTimeout,
+		Write: config.Database.WriteTimeout,
+		Batch: config.Database.BatchTimeout,
+		Stats: config.Database.StatsTimeout,
+	})
+	orderRepo := repository.NewOrderRepository(db, log, timeouts)
+	if len(config.Database.ShardDSNs) > 0 {
+		shards := make(map[string]repository.Repository, len(config.Database.ShardDSNs))
+		for key, dsn := range config.Database.ShardDSNs {
+			log.Infof("connecting shard %s", key)
+			shardDB, err := repository.ConnectDSN(dsn, &config.Database)
+			if err != nil {
+				log.Fatalf("failed to connect to shard %s: %v", key, err)
+			}
+			defer shardDB.Close()
+			repository.PublishPoolStats("shard-"+key, shardDB)
+			if checkMigrations(migrateOnStart(shardDB, &config.Database, log), "shard "+key, &config.Database, log) {
+				degraded = true
+			}
+			shards[key] = repository.NewOrderRepository(shardDB, log, timeouts)
 		}
-	}(db)
-	log.Info("Migrating database ")
-	err = repository.RunMigrations(db)
+		orderRepo = repository.NewShardedRepository(orderRepo, shards)
+	}
+	if len(config.Database.ReplicaDSNs) > 0 {
+		if len(config.Database.ShardDSNs) > 0 {
+			log.Fatalf("POSTGRES_REPLICA_DSNS replicate the primary only and cannot be combined with POSTGRES_SHARD_DSNS")
+		}
+		replicas := make([]repository.Repository, 0, len(config.Database.ReplicaDSNs))
+		for i, dsn := range config.Database.ReplicaDSNs {
+			log.Infof("connecting replica %d", i)
+			replicaDB, err := repository.ConnectDSN(dsn, &config.Database)
+			if err != nil {
+				// the primary serves reads until the replica comes back
+				log.Errorf("failed to connect to replica %d: %v", i, err)
+				continue
+			}
+			defer replicaDB.Close()
+			repository.PublishPoolStats(fmt.Sprintf("replica-%d", i), replicaDB)
+			replicas = append(replicas, repository.NewOrderRepository(replicaDB, log, timeouts))
+		}
+		orderRepo = repository.NewReplicaRepository(orderRepo, replicas, config.Database.ReplicaCooldown, log)
+	}
+	orderRepo = repository.NewRetryRepository(orderRepo, repository.RetryPolicy{
+		Attempts:  config.Database.RetryAttempts,
+		BaseDelay: config.Database.RetryBaseDelay,
+		MaxDelay:  config.Database.RetryMaxDelay,
+	}, log)
+	orderRepo = repository.NewBreakerRepository(orderRepo, repository.BreakerPolicy{
+		Threshold: config.Database.BreakerThreshold,
+		Cooldown:  config.Database.BreakerCooldown,
+	}, log)
+	orderRepo = repository.NewMetricsRepository(orderRepo)
+	if degraded {
+		orderRepo = repository.NewReadOnlyRepository(orderRepo)
+	}
+
+	rules, err := order.LookupValidator(config.Ingest.Rules)
 	if err != nil {
-		log.Fatalf("failed to run migrations: %v", err)
+		log.Fatalf("INGEST_RULES: %v", err)
+	}
+	consistency, err := order.ParseConsistencyMode(config.Ingest.Consistency)
+	if err != nil {
+		log.Fatalf("INGEST_CONSISTENCY: %v", err)
+	}
+	codeChecks, err := order.ParseConsistencyMode(config.Ingest.CodeChecks)
+	if err != nil {
+		log.Fatalf("INGEST_CODE_CHECKS: %v", err)
+	}
+	// the checks every order is stored with, by the service or the backfill
+	checks := []order.Option{
+		order.WithValidator(rules),
+		order.WithConsistency(consistency, config.Ingest.ConsistencyTolerance),
+		order.WithCodeChecks(codeChecks, config.Ingest.Locales),
 	}
 
-	orderRepo := repository.NewOrderRepository(db, log)
-	c := cache.NewCache(log)
+	if len(args) > 0 && args[0] == "backfill" {
+		runBackfill(orderRepo, order.NewValidator(checks...), log, args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "seed" {
+		runSeed(orderRepo, log, args[1:])
+		return
+	}
 
-	orderService := order.NewOrderService(orderRepo, c)
-	consumer := kafka.NewConsumer(config.Kafka.Brokers, config.Kafka.Topic, config.Kafka.Group, "kafka.DLQ", orderService, log)
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
-	if err := consumer.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
-		log.Errorf("consumer stopped: %v", err)
+	c := newCache(&config.Cache, log)
+	sized, _ := c.(cache.Sized)
+	if sized != nil {
+		gauges.Register("cache_entries", func() int64 { return int64(sized.Len()) })
+	}
+	expiring, _ := c.(cache.Expiring)
+
+	shardKeys := make([]string, 0, len(config.Database.ShardDSNs))
+	for key := range config.Database.ShardDSNs {
+		shardKeys = append(shardKeys, key)
+	}
+	enrichers := make([]order.Enricher, 0, len(config.Server.Enrichers))
+	for _, name := range config.Server.Enrichers {
+		e, err := order.LookupEnricher(name, shardKeys)
+		if err != nil {
+			log.Fatalf("BACKEND_ENRICHERS: %v", err)
+		}
+		enrichers = append(enrichers, e)
+	}
+	// orders stored by this process; with BACKEND_PREFORK the consumer runs in
+	// the parent, so children only stream orders POSTed to them
+	feed := pubsub.NewHub[*model.Order]()
+	gauges.Register("order_stream_subscribers", feed.Len)
+	bus := events.NewBus()
+	bus.Subscribe(events.ToHub(feed))
+	var hooks *webhook.Dispatcher
+	if len(config.Webhook.URLs) > 0 && !degraded {
+		hooks = webhook.NewDispatcher(&config.Webhook, orderRepo, log)
+		bus.Subscribe(hooks.Subscriber())
 	}
-	ctxUpdate, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	orderService := order.NewOrderService(orderRepo, c, append(checks,
+		order.WithEvents(bus),
+		order.WithRefreshAfter(config.Cache.RefreshAfter),
+		order.WithWarmCount(config.Cache.WarmCount),
+		order.WithEnrichers(enrichers...),
+	)...)
+
+	ctxUpdate, cancel := context.WithTimeout(context.Background(), config.Cache.WarmTimeout)
 	defer cancel()
 
 	if err = orderService.UpdateCache(ctxUpdate); err != nil {
 		log.Errorf("failed to update cache: %v", err)
 	}
 
+	ingestCtx, stopIngest := context.WithCancel(context.Background())
+	defer stopIngest()
+	// closed once the consumer has stored and acked the message in hand
+	ingestDone := make(chan struct{})
+	var readyChecks []server.ReadyCheck
+	serverOpts := []server.Option{server.WithOrderFeed(feed), server.WithWebUI(frontend.FS), server.WithLogLevel(log)}
+	switch {
+	case degraded:
+		// every write would fail and land in the DLQ; leave messages on the broker instead
+		log.Warn("degraded mode: ingestion disabled until migrations are applied")
+		close(ingestDone)
+	case fiber.IsChild():
+		// BACKEND_PREFORK: the parent consumes, children only serve HTTP
+		close(ingestDone)
+	default:
+		broker, err := newBroker(ingestCtx, config, log)
+		if err != nil {
+			log.Fatalf("failed to initialize %s broker: %v", config.Broker, err)
+		}
+		if b, ok := broker.(ingest.Backlogger); ok {
+			gauges.Register("ingest_backlog", b.Backlog)
+		}
+		if p, ok := broker.(ingest.Pinger); ok {
+			readyChecks = append(readyChecks, server.ReadyCheck{Name: config.Broker, Check: p.Ping, Severity: health.Critical})
+		}
+		opts := append(ingestOptions(&config.Ingest, log), ingest.WithCircuitBackoff(config.Database.BreakerCooldown))
+		if config.Broker == cfg.BrokerKafka {
+			opts = append(opts, ingest.WithWorkers(config.Kafka.Workers))
+		}
+		processor := ingest.NewProcessor(broker, orderService, log, opts...)
+		serverOpts = append(serverOpts, server.WithConsumer(processor))
+		if d, ok := broker.(ingest.DLQPeeker); ok {
+			serverOpts = append(serverOpts, server.WithDLQ(d))
+		}
+		go func() {
+			defer close(ingestDone)
+			if err := processor.Run(ingestCtx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Errorf("consumer stopped: %v", err)
+			}
+		}()
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if config.Retention.Days > 0 && !degraded && !fiber.IsChild() {
+		job := retention.NewJob(orderRepo, c, &config.Retention, log)
+		go func() { _ = job.Run(jobsCtx) }()
+	}
+	// every prefork child delivers the webhooks of the orders it stores
+	if hooks != nil {
+		go func() { _ = hooks.Run(jobsCtx) }()
+	}
+	// every prefork child has a cache of its own to keep warm
+	var warmJob *warmer.Job
+	if config.Cache.WarmInterval > 0 && !degraded {
+		warmJob, err = warmer.NewJob(orderService, &config.Cache, log)
+		if err != nil {
+			log.Fatalf("CACHE_WARM_STRATEGY: %v", err)
+		}
+		go func() { _ = warmJob.Run(jobsCtx) }()
+	}
+
+	// SIGHUP, or a changed env file with CONFIG_RELOAD_INTERVAL, applies the
+	// tunable settings without a restart; flags keep their value
+	reloader := reload.New(config, src, log)
+	reloader.Subscribe(func(old, new cfg.Tunables) {
+		if old.LogLevel != new.LogLevel {
+			if err := log.SetLevel(new.LogLevel); err != nil {
+				log.Errorf("reload: LOG_LEVEL: %v", err)
+			}
+		}
+		if old.CacheCapacity != new.CacheCapacity && sized != nil {
+			sized.SetLimit(new.CacheCapacity)
+		}
+		if old.CacheTTL != new.CacheTTL && expiring != nil {
+			expiring.SetTTL(new.CacheTTL)
+		}
+		if old.CacheRefreshAfter != new.CacheRefreshAfter {
+			orderService.SetRefreshAfter(new.CacheRefreshAfter)
+		}
+		if old.CacheWarmInterval != new.CacheWarmInterval {
+			if warmJob == nil || new.CacheWarmInterval <= 0 {
+				log.Warnf("reload: CACHE_WARM_INTERVAL changed to %s, which takes a restart to start or stop the warmer", new.CacheWarmInterval)
+			} else {
+				warmJob.SetInterval(new.CacheWarmInterval)
+			}
+		}
+	})
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() { _ = reloader.Run(jobsCtx, hup, config.ReloadInterval) }()
+	serverOpts = append(serverOpts, server.WithReloader(reloader))
+
 	log.Info("starting server")
-	app := server.NewServer(orderService, log)
-	app.Get("/swagger/*", swagger.HandlerDefault)
+	app := server.NewServer(orderService, log, &config.Server, append(serverOpts, server.WithReadyChecks(readyChecks...))...)
+	app.Get("/openapi.yaml", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "application/yaml")
+		return c.Send(api.Spec)
+	})
+	app.Get("/swagger/*", swagger.New(swagger.Config{URL: "/openapi.yaml"}))
 	go func() {
-		if err := app.Listen(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := server.Listen(app, &config.Server); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatal(err)
 		}
 	}()
@@ -88,7 +307,211 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("Shutting down...")
-	if err := app.Shutdown(); err != nil {
-		log.Fatal(err)
+	// HTTP and the consumer drain side by side within one timeout
+	drainStart := time.Now()
+	stopIngest()
+	stopJobs()
+	feed.Close() // ends open streams, which would otherwise hold up Shutdown
+	if err := app.ShutdownWithTimeout(config.Server.ShutdownTimeout); err != nil {
+		log.Errorf("in-flight requests cut off after %s: %v", config.Server.ShutdownTimeout, err)
+	}
+	select {
+	case <-ingestDone:
+	case <-time.After(config.Server.ShutdownTimeout - time.Since(drainStart)):
+		log.Errorf("consumer did not drain within %s; the message in hand is redelivered", config.Server.ShutdownTimeout)
+	}
+}
+
+// migrateOnStart applies pending migrations on pool, or only checks for them
+// when POSTGRES_AUTO_MIGRATE is off.
+func migrateOnStart(pool *pgxpool.Pool, c *cfg.DatabaseConfig, log logger.InterfaceLogger) error {
+	if !c.AutoMigrate {
+		return repository.VerifyMigrations(pool)
+	}
+	log.Info("Migrating database ")
+	return repository.RunMigrations(pool)
+}
+
+// checkMigrations reports a migration failure on db and exits unless degraded
+// mode is allowed and the schema is behind by additive migrations only.
+// It returns true when the service must run read-only.
+func checkMigrations(err error, db string, c *cfg.DatabaseConfig, log logger.InterfaceLogger) bool {
+	if err == nil {
+		return false
+	}
+	var merr *repository.MigrationError
+	if !errors.As(err, &merr) {
+		log.Fatalf("failed to run migrations on %s: %v", db, err)
+	}
+	log.Errorf("migration failed on %s: migration=%s version=%d db_version=%d pending=%v additive=%t: %v",
+		db, merr.Source, merr.Version, merr.Current, merr.Pending, merr.Additive, merr.Err)
+	if errors.Is(merr, repository.ErrMigrationsPending) && !c.AllowDegraded {
+		log.Fatalf("%s schema at version %d is behind, run \"migrate up\" or set POSTGRES_AUTO_MIGRATE=true", db, merr.Current)
+	}
+	if !c.AllowDegraded || !merr.Additive {
+		log.Fatalf("failed to run migrations on %s: schema at version %d, set POSTGRES_ALLOW_DEGRADED=true to serve reads when only additive migrations are pending", db, merr.Current)
+	}
+	log.Warnf("starting in read-only degraded mode: %s schema at version %d", db, merr.Current)
+	return true
+}
+
+// newCache builds the order cache selected by c.Backend.
+func newCache(c *cfg.CacheConfig, log logger.InterfaceLogger) cache.InterfaceCache {
+	memory := func() *cache.Sharded { return cache.NewSharded(c.Shards, c.Capacity, c.TTL, log) }
+	if c.Backend == cfg.CacheBackendMemory {
+		return memory()
+	}
+	r := c.Redis
+	redis := cache.NewRedis(cache.RedisOptions{
+		Addr:      r.Addr,
+		Username:  r.Username,
+		Password:  r.Password,
+		DB:        r.DB,
+		TLS:       r.TLS,
+		KeyPrefix: r.KeyPrefix,
+		TTL:       c.TTL,
+		Timeout:   r.Timeout,
+		PoolSize:  r.PoolSize,
+	}, log)
+	if err := redis.Ping(); err != nil {
+		// reads go to the database until Redis comes back
+		log.Errorf("failed to reach redis cache at %s: %v", r.Addr, err)
+	}
+	if c.Backend == cfg.CacheBackendLayered {
+		return cache.NewLayered(memory(), redis)
+	}
+	return redis
+}
+
+// newBroker builds the ingestion transport selected by config.Broker.
+func newBroker(ctx context.Context, config *cfg.Config, log logger.InterfaceLogger) (ingest.Broker, error) {
+	switch config.Broker {
+	case cfg.BrokerNATS:
+		n := config.NATS
+		return nats.NewConsumer(ctx, n.URL, n.Stream, n.Subject, n.Durable, n.DLQSubject, log)
+	case cfg.BrokerRabbitMQ:
+		r := config.RabbitMQ
+		return rabbitmq.NewConsumer(r.URL, r.Exchange, r.Queue, r.RoutingKey, r.DLX, r.DLQ, r.Prefetch, log)
+	default:
+		return kafka.NewConsumer(&config.Kafka, log)
+	}
+}
+
+// runBackfill implements the "backfill" subcommand: main backfill -file dump.ndjson [flags].
+// Records are checked by valid, as the service would check them.
+func runBackfill(repo repository.Repository, valid order.Validator, log logger.InterfaceLogger, args []string) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	var opts backfill.Options
+	fs.StringVar(&opts.Path, "file", "", "NDJSON or CSV dump to import (required)")
+	fs.StringVar(&opts.Format, "format", "", "dump format: ndjson or csv (default: by file extension)")
+	fs.StringVar(&opts.CheckpointPath, "checkpoint", "", "checkpoint file (default: <file>.checkpoint)")
+	fs.IntVar(&opts.Rate, "rate", 0, "max orders per second, 0 = unlimited")
+	fs.IntVar(&opts.BatchSize, "batch", 100, "orders per batch/checkpoint")
+	fs.IntVar(&opts.ProgressEvery, "progress", 1000, "log progress every N records")
+	fs.BoolVar(&opts.Overwrite, "overwrite", false, "replace stored orders even if they changed after the dump")
+	_ = fs.Parse(args)
+	if opts.Path == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	opts.Validate = valid.Validate
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	stats, err := backfill.NewRunner(repo, log).Run(ctx, opts)
+	if err != nil {
+		log.Fatalf("backfill stopped after %d upserts: %v", stats.Upserted, err)
+	}
+	log.Infof("backfill finished: %d upserted, %d skipped, %d invalid", stats.Upserted, stats.Skipped, stats.Invalid)
+}
+
+// runSeed implements the "seed" subcommand: main seed [-n 1000] [flags].
+func runSeed(repo repository.Repository, log logger.InterfaceLogger, args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	var opts seed.Options
+	fs.IntVar(&opts.Count, "n", 1000, "number of orders to insert")
+	fs.IntVar(&opts.BatchSize, "batch", 100, "orders per transaction")
+	fs.Uint64Var(&opts.Seed, "seed", 1, "data set to generate; the same seed inserts the same orders")
+	fs.IntVar(&opts.Days, "days", 30, "spread date_created over this many past days")
+	fs.IntVar(&opts.Customers, "customers", 0, "size of the customer pool (default: n/3+1)")
+	fs.StringVar(&opts.Tenant, "tenant", tenant.Default, "tenant owning the orders")
+	_ = fs.Parse(args)
+	if opts.Count <= 0 || !tenant.Valid(opts.Tenant) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	n, err := seed.Run(ctx, repo, log, opts)
+	if err != nil {
+		log.Fatalf("seed stopped after %d orders: %v", n, err)
+	}
+	log.Infof("seed finished: %d orders for tenant %s", n, opts.Tenant)
+}
+
+// runMigrate implements the "migrate" subcommand: main migrate <command> [args].
+// Commands other than create run on the primary and then on every shard, so
+// all databases end up on the same version.
+func runMigrate(db *pgxpool.Pool, c *cfg.DatabaseConfig, log logger.InterfaceLogger, args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := fs.String("dir", "internal/db/repository/migrations", "migrations directory for create")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: main migrate [-dir path] <%s|create> [version|name]\n", strings.Join(repository.MigrateCommands, "|"))
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command, rest := fs.Arg(0), fs.Args()[1:]
+
+	if command == "create" {
+		if len(rest) != 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		if err := repository.CreateMigration(*dir, rest[0]); err != nil {
+			log.Fatalf("migrate create: %v", err)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := repository.Migrate(ctx, db, command, rest...); err != nil {
+		log.Fatalf("migrate %s on primary: %v", command, err)
+	}
+	for key, dsn := range c.ShardDSNs {
+		shardDB, err := repository.ConnectDSN(dsn, c)
+		if err != nil {
+			log.Fatalf("failed to connect to shard %s: %v", key, err)
+		}
+		err = repository.Migrate(ctx, shardDB, command, rest...)
+		shardDB.Close()
+		if err != nil {
+			log.Fatalf("migrate %s on shard %s: %v", command, key, err)
+		}
+	}
+}
+
+// ingestOptions resolves the canary rule set and payload signing; the active
+// rule set is enforced by the order service itself.
+func ingestOptions(c *cfg.IngestConfig, log logger.InterfaceLogger) []ingest.Option {
+	var opts []ingest.Option
+	if c.CanaryRules != "" {
+		canary, err := order.LookupValidator(c.CanaryRules)
+		if err != nil {
+			log.Fatalf("INGEST_CANARY_RULES: %v", err)
+		}
+		log.Infof("validation canary: active=%s candidate=%s", c.Rules, c.CanaryRules)
+		opts = append(opts, ingest.WithCanaryRules(c.CanaryRules, canary))
+	}
+	if c.SignatureKey != "" {
+		opts = append(opts, ingest.WithSignatureKey([]byte(c.SignatureKey), c.RequireSignature))
+	} else if c.RequireSignature {
+		log.Fatalf("INGEST_REQUIRE_SIGNATURE needs INGEST_SIGNATURE_KEY")
 	}
+	return opts
 }
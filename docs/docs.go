@@ -1,263 +0,0 @@
-// Package docs Code generated by swaggo/swag. DO NOT EDIT
-package docs
-
-import "github.com/swaggo/swag"
-
-const docTemplate = `{
-    "schemes": {{ marshal .Schemes }},
-    "swagger": "2.0",
-    "info": {
-        "description": "{{escape .Description}}",
-        "title": "{{.Title}}",
-        "contact": {},
-        "version": "{{.Version}}"
-    },
-    "host": "{{.Host}}",
-    "basePath": "{{.BasePath}}",
-    "paths": {
-        "/healthz": {
-            "get": {
-                "description": "Returns service health status",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/order/{order_uid}": {
-            "get": {
-                "description": "Retrieves order details by order_uid",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "order"
-                ],
-                "summary": "Get order by ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Order UID",
-                        "name": "order_uid",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/model.Order"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "model.Delivery": {
-            "type": "object",
-            "properties": {
-                "address": {
-                    "type": "string"
-                },
-                "city": {
-                    "type": "string"
-                },
-                "email": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "phone": {
-                    "type": "string"
-                },
-                "region": {
-                    "type": "string"
-                },
-                "zip": {
-                    "type": "string"
-                }
-            }
-        },
-        "model.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "msg": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "integer"
-                }
-            }
-        },
-        "model.Item": {
-            "type": "object",
-            "properties": {
-                "brand": {
-                    "type": "string"
-                },
-                "chrt_id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "nm_id": {
-                    "type": "integer"
-                },
-                "price": {
-                    "type": "integer"
-                },
-                "rid": {
-                    "type": "string"
-                },
-                "sale": {
-                    "type": "integer"
-                },
-                "size": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "integer"
-                },
-                "total_price": {
-                    "type": "integer"
-                },
-                "track_number": {
-                    "type": "string"
-                }
-            }
-        },
-        "model.Order": {
-            "type": "object",
-            "properties": {
-                "customer_id": {
-                    "type": "string"
-                },
-                "date_created": {
-                    "type": "string"
-                },
-                "delivery": {
-                    "$ref": "#/definitions/model.Delivery"
-                },
-                "delivery_service": {
-                    "type": "string"
-                },
-                "entry": {
-                    "type": "string"
-                },
-                "internal_signature": {
-                    "type": "string"
-                },
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/model.Item"
-                    }
-                },
-                "locale": {
-                    "type": "string"
-                },
-                "oof_shard": {
-                    "type": "string"
-                },
-                "order_uid": {
-                    "type": "string"
-                },
-                "payment": {
-                    "$ref": "#/definitions/model.Payment"
-                },
-                "shardkey": {
-                    "type": "string"
-                },
-                "sm_id": {
-                    "type": "integer"
-                },
-                "track_number": {
-                    "type": "string"
-                }
-            }
-        },
-        "model.Payment": {
-            "type": "object",
-            "properties": {
-                "amount": {
-                    "type": "integer"
-                },
-                "bank": {
-                    "type": "string"
-                },
-                "currency": {
-                    "type": "string"
-                },
-                "custom_fee": {
-                    "type": "integer"
-                },
-                "delivery_cost": {
-                    "type": "integer"
-                },
-                "goods_total": {
-                    "type": "integer"
-                },
-                "payment_dt": {
-                    "type": "integer"
-                },
-                "provider": {
-                    "type": "string"
-                },
-                "request_id": {
-                    "type": "string"
-                },
-                "transaction": {
-                    "type": "string"
-                }
-            }
-        }
-    }
-}`
-
-// SwaggerInfo holds exported Swagger Info so clients can modify it
-var SwaggerInfo = &swag.Spec{
-	Version:          "1.0",
-	Host:             "localhost:8080",
-	BasePath:         "/",
-	Schemes:          []string{"http"},
-	Title:            "Order Service API",
-	Description:      "API for managing and retrieving orders.",
-	InfoInstanceName: "swagger",
-	SwaggerTemplate:  docTemplate,
-	LeftDelim:        "{{",
-	RightDelim:       "}}",
-}
-
-func init() {
-	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
-}
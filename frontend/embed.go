@@ -0,0 +1,10 @@
+// Package frontend embeds the order lookup page, so the API server can serve
+// it at / without a separate web server.
+package frontend
+
+import "embed"
+
+// FS holds index.html and the assets it loads.
+//
+//go:embed index.html script.js styles.css
+var FS embed.FS
@@ -1,7 +1,7 @@
 
 /**
  * Frontend Order Lookup (fetch-based)
- * Expects a backend endpoint: GET /order/:id -> returns JSON order
+ * Expects a backend endpoint: GET /api/v1/order/:id -> returns JSON order
  */
 
 const API_BASE = window.API_BASE || "";
@@ -153,14 +153,14 @@ async function lookupOrder() {
 
   showLoading(true);
   try {
-    const url = `${API_BASE}/order/${encodeURIComponent(id)}`;
+    const url = `${API_BASE}/api/v2/order/${encodeURIComponent(id)}`;
     const res = await fetch(url, { headers: { "Accept": "application/json" } });
     if (!res.ok) {
       if (res.status === 404) throw new Error("Заказ не найден");
       throw new Error(`Ошибка запроса: ${res.status}`);
     }
-    const data = await res.json();
-    renderOrder(data);
+    const body = await res.json();
+    renderOrder(body.data);
   } catch (e) {
     hideOrder();
     showError(e.message || "Не удалось получить заказ");
@@ -0,0 +1,276 @@
+// Package adminapi provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.5.1 DO NOT EDIT.
+package adminapi
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/ingest"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/oapi-codegen/runtime"
+)
+
+const (
+	AdminTokenScopes = "AdminToken.Scopes"
+)
+
+// CacheFlushResult defines model for CacheFlushResult.
+type CacheFlushResult = model.CacheFlushResult
+
+// ConsumerStatus defines model for ConsumerStatus.
+type ConsumerStatus = ingest.Status
+
+// DLQMessage defines model for DLQMessage.
+type DLQMessage = ingest.DLQMessage
+
+// ErasureResult defines model for ErasureResult.
+type ErasureResult = model.ErasureResult
+
+// ErrorResponse defines model for ErrorResponse.
+type ErrorResponse = model.ErrorResponse
+
+// LogLevel defines model for LogLevel.
+type LogLevel = model.LogLevel
+
+// CustomerID defines model for CustomerID.
+type CustomerID = string
+
+// OrderUID defines model for OrderUID.
+type OrderUID = string
+
+// BadRequest defines model for BadRequest.
+type BadRequest = ErrorResponse
+
+// Erased defines model for Erased.
+type Erased = ErasureResult
+
+// InternalError defines model for InternalError.
+type InternalError = ErrorResponse
+
+// Unauthorized defines model for Unauthorized.
+type Unauthorized = ErrorResponse
+
+// Unavailable defines model for Unavailable.
+type Unavailable = ErrorResponse
+
+// PeekDLQParams defines parameters for PeekDLQ.
+type PeekDLQParams struct {
+	// Limit Messages to return (1-100)
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// SetLogLevelJSONRequestBody defines body for SetLogLevel for application/json ContentType.
+type SetLogLevelJSONRequestBody = LogLevel
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// Flush the order cache
+	// (POST /admin/cache/flush)
+	FlushCache(c *fiber.Ctx) error
+	// Re-warm the order cache
+	// (POST /admin/cache/warm)
+	WarmCache(c *fiber.Ctx) error
+	// Consumer status
+	// (GET /admin/consumer)
+	GetConsumerStatus(c *fiber.Ctx) error
+	// Pause the consumer
+	// (POST /admin/consumer/pause)
+	PauseConsumer(c *fiber.Ctx) error
+	// Resume the consumer
+	// (POST /admin/consumer/resume)
+	ResumeConsumer(c *fiber.Ctx) error
+	// Erase customer personal data
+	// (POST /admin/customers/{customer_id}/erase)
+	EraseCustomer(c *fiber.Ctx, customerId CustomerID) error
+	// Peek the dead-letter queue
+	// (GET /admin/dlq)
+	PeekDLQ(c *fiber.Ctx, params PeekDLQParams) error
+	// Current log level
+	// (GET /admin/log-level)
+	GetLogLevel(c *fiber.Ctx) error
+	// Change the log level
+	// (PUT /admin/log-level)
+	SetLogLevel(c *fiber.Ctx) error
+	// Erase customer personal data of a tenant
+	// (POST /admin/tenants/{tenant_id}/customers/{customer_id}/erase)
+	EraseTenantCustomer(c *fiber.Ctx, tenantId string, customerId CustomerID) error
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler ServerInterface
+}
+
+type MiddlewareFunc fiber.Handler
+
+// FlushCache operation middleware
+func (siw *ServerInterfaceWrapper) FlushCache(c *fiber.Ctx) error {
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.FlushCache(c)
+}
+
+// WarmCache operation middleware
+func (siw *ServerInterfaceWrapper) WarmCache(c *fiber.Ctx) error {
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.WarmCache(c)
+}
+
+// GetConsumerStatus operation middleware
+func (siw *ServerInterfaceWrapper) GetConsumerStatus(c *fiber.Ctx) error {
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.GetConsumerStatus(c)
+}
+
+// PauseConsumer operation middleware
+func (siw *ServerInterfaceWrapper) PauseConsumer(c *fiber.Ctx) error {
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.PauseConsumer(c)
+}
+
+// ResumeConsumer operation middleware
+func (siw *ServerInterfaceWrapper) ResumeConsumer(c *fiber.Ctx) error {
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.ResumeConsumer(c)
+}
+
+// EraseCustomer operation middleware
+func (siw *ServerInterfaceWrapper) EraseCustomer(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "customer_id" -------------
+	var customerId CustomerID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "customer_id", c.Params("customer_id"), &customerId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter customer_id: %w", err).Error())
+	}
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.EraseCustomer(c, customerId)
+}
+
+// PeekDLQ operation middleware
+func (siw *ServerInterfaceWrapper) PeekDLQ(c *fiber.Ctx) error {
+
+	var err error
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PeekDLQParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", query, &params.Limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter limit: %w", err).Error())
+	}
+
+	return siw.Handler.PeekDLQ(c, params)
+}
+
+// GetLogLevel operation middleware
+func (siw *ServerInterfaceWrapper) GetLogLevel(c *fiber.Ctx) error {
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.GetLogLevel(c)
+}
+
+// SetLogLevel operation middleware
+func (siw *ServerInterfaceWrapper) SetLogLevel(c *fiber.Ctx) error {
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.SetLogLevel(c)
+}
+
+// EraseTenantCustomer operation middleware
+func (siw *ServerInterfaceWrapper) EraseTenantCustomer(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "tenant_id" -------------
+	var tenantId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "tenant_id", c.Params("tenant_id"), &tenantId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter tenant_id: %w", err).Error())
+	}
+
+	// ------------- Path parameter "customer_id" -------------
+	var customerId CustomerID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "customer_id", c.Params("customer_id"), &customerId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter customer_id: %w", err).Error())
+	}
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.EraseTenantCustomer(c, tenantId, customerId)
+}
+
+// FiberServerOptions provides options for the Fiber server.
+type FiberServerOptions struct {
+	BaseURL     string
+	Middlewares []MiddlewareFunc
+}
+
+// RegisterHandlers creates http.Handler with routing matching OpenAPI spec.
+func RegisterHandlers(router fiber.Router, si ServerInterface) {
+	RegisterHandlersWithOptions(router, si, FiberServerOptions{})
+}
+
+// RegisterHandlersWithOptions creates http.Handler with additional options
+func RegisterHandlersWithOptions(router fiber.Router, si ServerInterface, options FiberServerOptions) {
+	wrapper := ServerInterfaceWrapper{
+		Handler: si,
+	}
+
+	for _, m := range options.Middlewares {
+		router.Use(fiber.Handler(m))
+	}
+
+	router.Post(options.BaseURL+"/admin/cache/flush", wrapper.FlushCache)
+
+	router.Post(options.BaseURL+"/admin/cache/warm", wrapper.WarmCache)
+
+	router.Get(options.BaseURL+"/admin/consumer", wrapper.GetConsumerStatus)
+
+	router.Post(options.BaseURL+"/admin/consumer/pause", wrapper.PauseConsumer)
+
+	router.Post(options.BaseURL+"/admin/consumer/resume", wrapper.ResumeConsumer)
+
+	router.Post(options.BaseURL+"/admin/customers/:customer_id/erase", wrapper.EraseCustomer)
+
+	router.Get(options.BaseURL+"/admin/dlq", wrapper.PeekDLQ)
+
+	router.Get(options.BaseURL+"/admin/log-level", wrapper.GetLogLevel)
+
+	router.Put(options.BaseURL+"/admin/log-level", wrapper.SetLogLevel)
+
+	router.Post(options.BaseURL+"/admin/tenants/:tenant_id/customers/:customer_id/erase", wrapper.EraseTenantCustomer)
+
+}
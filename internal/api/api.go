@@ -0,0 +1,16 @@
+// Package api holds the OpenAPI spec of the HTTP API. The spec is the source
+// of the server interfaces and request and response models in the orderapi,
+// adminapi and healthapi packages, which internal/server implements; change
+// the spec and regenerate them rather than editing the generated files.
+package api
+
+import _ "embed"
+
+//go:generate go tool oapi-codegen -config orderapi/oapi-codegen.yaml -o orderapi/orderapi.gen.go openapi.yaml
+//go:generate go tool oapi-codegen -config adminapi/oapi-codegen.yaml -o adminapi/adminapi.gen.go openapi.yaml
+//go:generate go tool oapi-codegen -config healthapi/oapi-codegen.yaml -o healthapi/healthapi.gen.go openapi.yaml
+
+// Spec is openapi.yaml, served next to the Swagger UI.
+//
+//go:embed openapi.yaml
+var Spec []byte
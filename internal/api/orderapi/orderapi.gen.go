@@ -0,0 +1,830 @@
+// Package orderapi provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.5.1 DO NOT EDIT.
+package orderapi
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/oapi-codegen/runtime"
+)
+
+const (
+	AdminTokenScopes = "AdminToken.Scopes"
+)
+
+// BatchGetRequest defines model for BatchGetRequest.
+type BatchGetRequest = model.BatchGetRequest
+
+// BatchGetResponse defines model for BatchGetResponse.
+type BatchGetResponse = model.BatchGetResponse
+
+// CreateOrderResponse defines model for CreateOrderResponse.
+type CreateOrderResponse struct {
+	OrderUid string `json:"order_uid"`
+}
+
+// CustomerTotal defines model for CustomerTotal.
+type CustomerTotal = model.CustomerTotal
+
+// DailyCount defines model for DailyCount.
+type DailyCount = model.DailyCount
+
+// Delivery defines model for Delivery.
+type Delivery = model.Delivery
+
+// DeliveryServiceTotal defines model for DeliveryServiceTotal.
+type DeliveryServiceTotal = model.DeliveryServiceTotal
+
+// ErrorResponse defines model for ErrorResponse.
+type ErrorResponse = model.ErrorResponse
+
+// GraphQLRequest defines model for GraphQLRequest.
+type GraphQLRequest struct {
+	OperationName string                 `json:"operationName,omitempty"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQLResponse What gqlgen renders; data is absent when the query did not run.
+type GraphQLResponse struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors *[]struct {
+		Locations *[]struct {
+			Column *int `json:"column,omitempty"`
+			Line   *int `json:"line,omitempty"`
+		} `json:"locations,omitempty"`
+		Message *string        `json:"message,omitempty"`
+		Path    *[]interface{} `json:"path,omitempty"`
+	} `json:"errors,omitempty"`
+}
+
+// Item defines model for Item.
+type Item = model.Item
+
+// Order defines model for Order.
+type Order = model.Order
+
+// OrderPage defines model for OrderPage.
+type OrderPage = model.OrderPage
+
+// OrderRevision defines model for OrderRevision.
+type OrderRevision = model.OrderRevision
+
+// OrderStatus defines model for OrderStatus.
+type OrderStatus = model.OrderStatus
+
+// Payment defines model for Payment.
+type Payment = model.Payment
+
+// SearchHit defines model for SearchHit.
+type SearchHit = model.SearchHit
+
+// SearchPage defines model for SearchPage.
+type SearchPage = model.SearchPage
+
+// Sort defines model for Sort.
+type Sort = model.Sort
+
+// StatusUpdate defines model for StatusUpdate.
+type StatusUpdate = model.StatusUpdate
+
+// Cursor defines model for Cursor.
+type Cursor = string
+
+// CustomerFilter defines model for CustomerFilter.
+type CustomerFilter = string
+
+// CustomerID defines model for CustomerID.
+type CustomerID = string
+
+// From defines model for From.
+type From = string
+
+// Limit defines model for Limit.
+type Limit = int
+
+// OrderUID defines model for OrderUID.
+type OrderUID = string
+
+// StatusFilter defines model for StatusFilter.
+type StatusFilter = OrderStatus
+
+// To defines model for To.
+type To = string
+
+// BadRequest defines model for BadRequest.
+type BadRequest = ErrorResponse
+
+// Conflict defines model for Conflict.
+type Conflict = ErrorResponse
+
+// GatewayTimeout defines model for GatewayTimeout.
+type GatewayTimeout = ErrorResponse
+
+// GraphQL What gqlgen renders; data is absent when the query did not run.
+type GraphQL = GraphQLResponse
+
+// InternalError defines model for InternalError.
+type InternalError = ErrorResponse
+
+// NotFound defines model for NotFound.
+type NotFound = ErrorResponse
+
+// Unauthorized defines model for Unauthorized.
+type Unauthorized = ErrorResponse
+
+// Unavailable defines model for Unavailable.
+type Unavailable = ErrorResponse
+
+// ListCustomerOrdersParams defines parameters for ListCustomerOrders.
+type ListCustomerOrdersParams struct {
+	// Limit Page size (default 20, max 100)
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor next_cursor of the previous page
+	Cursor *Cursor `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Sort -date_created (newest first, default) or date_created
+	Sort *Sort `form:"sort,omitempty" json:"sort,omitempty"`
+}
+
+// QueryGraphQLParams defines parameters for QueryGraphQL.
+type QueryGraphQLParams struct {
+	Query         *string `form:"query,omitempty" json:"query,omitempty"`
+	OperationName *string `form:"operationName,omitempty" json:"operationName,omitempty"`
+
+	// Variables The variables as a JSON object
+	Variables *string `form:"variables,omitempty" json:"variables,omitempty"`
+}
+
+// ListOrdersParams defines parameters for ListOrders.
+type ListOrdersParams struct {
+	// Limit Page size (default 20, max 100)
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor next_cursor of the previous page
+	Cursor *Cursor `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Sort -date_created (newest first, default) or date_created
+	Sort *Sort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// CustomerId Only orders of this customer
+	CustomerId *CustomerFilter `form:"customer_id,omitempty" json:"customer_id,omitempty"`
+
+	// Status Only orders in this status
+	Status *StatusFilter `form:"status,omitempty" json:"status,omitempty"`
+
+	// From date_created >= from (RFC 3339)
+	From *From `form:"from,omitempty" json:"from,omitempty"`
+
+	// To date_created < to (RFC 3339)
+	To *To `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// SearchOrdersParams defines parameters for SearchOrders.
+type SearchOrdersParams struct {
+	// Q Track number or words to look for, at most 200 characters
+	Q *string `form:"q,omitempty" json:"q,omitempty"`
+
+	// Limit Page size (default 20, max 100)
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor next_cursor of the previous page
+	Cursor *Cursor `form:"cursor,omitempty" json:"cursor,omitempty"`
+}
+
+// GetDeliveryServicesParams defines parameters for GetDeliveryServices.
+type GetDeliveryServicesParams struct {
+	// CustomerId Only orders of this customer
+	CustomerId *CustomerFilter `form:"customer_id,omitempty" json:"customer_id,omitempty"`
+
+	// Status Only orders in this status
+	Status *StatusFilter `form:"status,omitempty" json:"status,omitempty"`
+
+	// From date_created >= from (RFC 3339)
+	From *From `form:"from,omitempty" json:"from,omitempty"`
+
+	// To date_created < to (RFC 3339)
+	To *To `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// GetOrdersPerDayParams defines parameters for GetOrdersPerDay.
+type GetOrdersPerDayParams struct {
+	// CustomerId Only orders of this customer
+	CustomerId *CustomerFilter `form:"customer_id,omitempty" json:"customer_id,omitempty"`
+
+	// Status Only orders in this status
+	Status *StatusFilter `form:"status,omitempty" json:"status,omitempty"`
+
+	// From date_created >= from (RFC 3339)
+	From *From `form:"from,omitempty" json:"from,omitempty"`
+
+	// To date_created < to (RFC 3339)
+	To *To `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// GetTopCustomersParams defines parameters for GetTopCustomers.
+type GetTopCustomersParams struct {
+	// Limit Number of customers (default 10, max 100)
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// From date_created >= from (RFC 3339)
+	From *From `form:"from,omitempty" json:"from,omitempty"`
+
+	// To date_created < to (RFC 3339)
+	To *To `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// PostGraphQLJSONRequestBody defines body for PostGraphQL for application/json ContentType.
+type PostGraphQLJSONRequestBody = GraphQLRequest
+
+// CreateOrderJSONRequestBody defines body for CreateOrder for application/json ContentType.
+type CreateOrderJSONRequestBody = Order
+
+// UpdateOrderStatusJSONRequestBody defines body for UpdateOrderStatus for application/json ContentType.
+type UpdateOrderStatusJSONRequestBody = StatusUpdate
+
+// BatchGetOrdersJSONRequestBody defines body for BatchGetOrders for application/json ContentType.
+type BatchGetOrdersJSONRequestBody = BatchGetRequest
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// List customer orders
+	// (GET /customers/{customer_id}/orders)
+	ListCustomerOrders(c *fiber.Ctx, customerId CustomerID, params ListCustomerOrdersParams) error
+	// GraphQL query
+	// (GET /graphql)
+	QueryGraphQL(c *fiber.Ctx, params QueryGraphQLParams) error
+	// GraphQL query
+	// (POST /graphql)
+	PostGraphQL(c *fiber.Ctx) error
+	// Create order
+	// (POST /order)
+	CreateOrder(c *fiber.Ctx) error
+	// Delete order
+	// (DELETE /order/{order_uid})
+	DeleteOrder(c *fiber.Ctx, orderUid OrderUID) error
+	// Get order by ID
+	// (GET /order/{order_uid})
+	GetOrder(c *fiber.Ctx, orderUid OrderUID) error
+	// Archive order
+	// (POST /order/{order_uid}/archive)
+	ArchiveOrder(c *fiber.Ctx, orderUid OrderUID) error
+	// Order history
+	// (GET /order/{order_uid}/history)
+	GetOrderHistory(c *fiber.Ctx, orderUid OrderUID) error
+	// Raw order payload
+	// (GET /order/{order_uid}/raw)
+	GetRawOrder(c *fiber.Ctx, orderUid OrderUID) error
+	// Restore order
+	// (POST /order/{order_uid}/restore)
+	RestoreOrder(c *fiber.Ctx, orderUid OrderUID) error
+	// Update order status
+	// (PUT /order/{order_uid}/status)
+	UpdateOrderStatus(c *fiber.Ctx, orderUid OrderUID) error
+	// List orders
+	// (GET /orders)
+	ListOrders(c *fiber.Ctx, params ListOrdersParams) error
+	// Get several orders
+	// (POST /orders/batch-get)
+	BatchGetOrders(c *fiber.Ctx) error
+	// Search orders
+	// (GET /orders/search)
+	SearchOrders(c *fiber.Ctx, params SearchOrdersParams) error
+	// Stream new orders
+	// (GET /orders/stream)
+	StreamOrders(c *fiber.Ctx) error
+	// Goods value per delivery service
+	// (GET /stats/delivery-services)
+	GetDeliveryServices(c *fiber.Ctx, params GetDeliveryServicesParams) error
+	// Orders per day
+	// (GET /stats/orders-per-day)
+	GetOrdersPerDay(c *fiber.Ctx, params GetOrdersPerDayParams) error
+	// Top customers
+	// (GET /stats/top-customers)
+	GetTopCustomers(c *fiber.Ctx, params GetTopCustomersParams) error
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler ServerInterface
+}
+
+type MiddlewareFunc fiber.Handler
+
+// ListCustomerOrders operation middleware
+func (siw *ServerInterfaceWrapper) ListCustomerOrders(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "customer_id" -------------
+	var customerId CustomerID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "customer_id", c.Params("customer_id"), &customerId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter customer_id: %w", err).Error())
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListCustomerOrdersParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", query, &params.Limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter limit: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", query, &params.Cursor)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter cursor: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", query, &params.Sort)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter sort: %w", err).Error())
+	}
+
+	return siw.Handler.ListCustomerOrders(c, customerId, params)
+}
+
+// QueryGraphQL operation middleware
+func (siw *ServerInterfaceWrapper) QueryGraphQL(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params QueryGraphQLParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "query" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "query", query, &params.Query)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter query: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "operationName" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "operationName", query, &params.OperationName)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter operationName: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "variables" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "variables", query, &params.Variables)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter variables: %w", err).Error())
+	}
+
+	return siw.Handler.QueryGraphQL(c, params)
+}
+
+// PostGraphQL operation middleware
+func (siw *ServerInterfaceWrapper) PostGraphQL(c *fiber.Ctx) error {
+
+	return siw.Handler.PostGraphQL(c)
+}
+
+// CreateOrder operation middleware
+func (siw *ServerInterfaceWrapper) CreateOrder(c *fiber.Ctx) error {
+
+	return siw.Handler.CreateOrder(c)
+}
+
+// DeleteOrder operation middleware
+func (siw *ServerInterfaceWrapper) DeleteOrder(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "order_uid" -------------
+	var orderUid OrderUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_uid", c.Params("order_uid"), &orderUid, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter order_uid: %w", err).Error())
+	}
+
+	c.Context().SetUserValue(AdminTokenScopes, []string{})
+
+	return siw.Handler.DeleteOrder(c, orderUid)
+}
+
+// GetOrder operation middleware
+func (siw *ServerInterfaceWrapper) GetOrder(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "order_uid" -------------
+	var orderUid OrderUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_uid", c.Params("order_uid"), &orderUid, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter order_uid: %w", err).Error())
+	}
+
+	return siw.Handler.GetOrder(c, orderUid)
+}
+
+// ArchiveOrder operation middleware
+func (siw *ServerInterfaceWrapper) ArchiveOrder(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "order_uid" -------------
+	var orderUid OrderUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_uid", c.Params("order_uid"), &orderUid, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter order_uid: %w", err).Error())
+	}
+
+	return siw.Handler.ArchiveOrder(c, orderUid)
+}
+
+// GetOrderHistory operation middleware
+func (siw *ServerInterfaceWrapper) GetOrderHistory(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "order_uid" -------------
+	var orderUid OrderUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_uid", c.Params("order_uid"), &orderUid, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter order_uid: %w", err).Error())
+	}
+
+	return siw.Handler.GetOrderHistory(c, orderUid)
+}
+
+// GetRawOrder operation middleware
+func (siw *ServerInterfaceWrapper) GetRawOrder(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "order_uid" -------------
+	var orderUid OrderUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_uid", c.Params("order_uid"), &orderUid, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter order_uid: %w", err).Error())
+	}
+
+	return siw.Handler.GetRawOrder(c, orderUid)
+}
+
+// RestoreOrder operation middleware
+func (siw *ServerInterfaceWrapper) RestoreOrder(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "order_uid" -------------
+	var orderUid OrderUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_uid", c.Params("order_uid"), &orderUid, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter order_uid: %w", err).Error())
+	}
+
+	return siw.Handler.RestoreOrder(c, orderUid)
+}
+
+// UpdateOrderStatus operation middleware
+func (siw *ServerInterfaceWrapper) UpdateOrderStatus(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "order_uid" -------------
+	var orderUid OrderUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_uid", c.Params("order_uid"), &orderUid, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter order_uid: %w", err).Error())
+	}
+
+	return siw.Handler.UpdateOrderStatus(c, orderUid)
+}
+
+// ListOrders operation middleware
+func (siw *ServerInterfaceWrapper) ListOrders(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListOrdersParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", query, &params.Limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter limit: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", query, &params.Cursor)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter cursor: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", query, &params.Sort)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter sort: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "customer_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "customer_id", query, &params.CustomerId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter customer_id: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", query, &params.Status)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter status: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", query, &params.From)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter from: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", query, &params.To)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter to: %w", err).Error())
+	}
+
+	return siw.Handler.ListOrders(c, params)
+}
+
+// BatchGetOrders operation middleware
+func (siw *ServerInterfaceWrapper) BatchGetOrders(c *fiber.Ctx) error {
+
+	return siw.Handler.BatchGetOrders(c)
+}
+
+// SearchOrders operation middleware
+func (siw *ServerInterfaceWrapper) SearchOrders(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SearchOrdersParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "q" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "q", query, &params.Q)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter q: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", query, &params.Limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter limit: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", query, &params.Cursor)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter cursor: %w", err).Error())
+	}
+
+	return siw.Handler.SearchOrders(c, params)
+}
+
+// StreamOrders operation middleware
+func (siw *ServerInterfaceWrapper) StreamOrders(c *fiber.Ctx) error {
+
+	return siw.Handler.StreamOrders(c)
+}
+
+// GetDeliveryServices operation middleware
+func (siw *ServerInterfaceWrapper) GetDeliveryServices(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDeliveryServicesParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "customer_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "customer_id", query, &params.CustomerId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter customer_id: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", query, &params.Status)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter status: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", query, &params.From)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter from: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", query, &params.To)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter to: %w", err).Error())
+	}
+
+	return siw.Handler.GetDeliveryServices(c, params)
+}
+
+// GetOrdersPerDay operation middleware
+func (siw *ServerInterfaceWrapper) GetOrdersPerDay(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetOrdersPerDayParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "customer_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "customer_id", query, &params.CustomerId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter customer_id: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", query, &params.Status)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter status: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", query, &params.From)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter from: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", query, &params.To)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter to: %w", err).Error())
+	}
+
+	return siw.Handler.GetOrdersPerDay(c, params)
+}
+
+// GetTopCustomers operation middleware
+func (siw *ServerInterfaceWrapper) GetTopCustomers(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetTopCustomersParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", query, &params.Limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter limit: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", query, &params.From)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter from: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", query, &params.To)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter to: %w", err).Error())
+	}
+
+	return siw.Handler.GetTopCustomers(c, params)
+}
+
+// FiberServerOptions provides options for the Fiber server.
+type FiberServerOptions struct {
+	BaseURL     string
+	Middlewares []MiddlewareFunc
+}
+
+// RegisterHandlers creates http.Handler with routing matching OpenAPI spec.
+func RegisterHandlers(router fiber.Router, si ServerInterface) {
+	RegisterHandlersWithOptions(router, si, FiberServerOptions{})
+}
+
+// RegisterHandlersWithOptions creates http.Handler with additional options
+func RegisterHandlersWithOptions(router fiber.Router, si ServerInterface, options FiberServerOptions) {
+	wrapper := ServerInterfaceWrapper{
+		Handler: si,
+	}
+
+	for _, m := range options.Middlewares {
+		router.Use(fiber.Handler(m))
+	}
+
+	router.Get(options.BaseURL+"/customers/:customer_id/orders", wrapper.ListCustomerOrders)
+
+	router.Get(options.BaseURL+"/graphql", wrapper.QueryGraphQL)
+
+	router.Post(options.BaseURL+"/graphql", wrapper.PostGraphQL)
+
+	router.Post(options.BaseURL+"/order", wrapper.CreateOrder)
+
+	router.Delete(options.BaseURL+"/order/:order_uid", wrapper.DeleteOrder)
+
+	router.Get(options.BaseURL+"/order/:order_uid", wrapper.GetOrder)
+
+	router.Post(options.BaseURL+"/order/:order_uid/archive", wrapper.ArchiveOrder)
+
+	router.Get(options.BaseURL+"/order/:order_uid/history", wrapper.GetOrderHistory)
+
+	router.Get(options.BaseURL+"/order/:order_uid/raw", wrapper.GetRawOrder)
+
+	router.Post(options.BaseURL+"/order/:order_uid/restore", wrapper.RestoreOrder)
+
+	router.Put(options.BaseURL+"/order/:order_uid/status", wrapper.UpdateOrderStatus)
+
+	router.Get(options.BaseURL+"/orders", wrapper.ListOrders)
+
+	router.Post(options.BaseURL+"/orders/batch-get", wrapper.BatchGetOrders)
+
+	router.Get(options.BaseURL+"/orders/search", wrapper.SearchOrders)
+
+	router.Get(options.BaseURL+"/orders/stream", wrapper.StreamOrders)
+
+	router.Get(options.BaseURL+"/stats/delivery-services", wrapper.GetDeliveryServices)
+
+	router.Get(options.BaseURL+"/stats/orders-per-day", wrapper.GetOrdersPerDay)
+
+	router.Get(options.BaseURL+"/stats/top-customers", wrapper.GetTopCustomers)
+
+}
@@ -0,0 +1,198 @@
+// Package backfill imports historical orders from NDJSON/CSV dumps.
+//
+// Records are validated like the order service validates them (Options.Validate), upserted in
+// batches (one transaction each), and the number of the last committed record is persisted to a
+// checkpoint file after each batch so an interrupted run resumes where it stopped.
+// Invalid records are logged and skipped; a database error aborts the run.
+//
+// A record without a version is versioned by its date_created, so replaying a
+// dump replaces orders stored from the same or older data but not orders
+// changed since; Options.Overwrite replaces them too.
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/service/order"
+)
+
+// Options controls a backfill run.
+type Options struct {
+	// Path is the dump file to import.
+	Path string
+	// Format is FormatNDJSON or FormatCSV; empty means detect from the extension.
+	Format string
+	// CheckpointPath stores progress; empty means Path + ".checkpoint".
+	CheckpointPath string
+	// Rate caps upserted orders per second; 0 disables rate limiting.
+	Rate int
+	// BatchSize is the number of orders flushed (and checkpointed) at once.
+	BatchSize int
+	// ProgressEvery logs progress every N records; 0 disables progress logs.
+	ProgressEvery int
+	// Overwrite versions every record with the time it is read, so the dump
+	// replaces the stored orders whatever their version, e.g. to correct them.
+	Overwrite bool
+	// Validate checks each record, e.g. with the service's configured rules
+	// (order.NewValidator); nil means order.ValidateOrder.
+	Validate func(*model.Order) error
+}
+
+// Stats summarizes a run.
+type Stats struct {
+	Read     int
+	Resumed  int // records skipped because the checkpoint already covered them
+	Invalid  int
+	Upserted int
+	Skipped  int // valid records not written: stored with a newer version or the same content
+}
+
+// Runner imports dumps through the repository.
+type Runner struct {
+	repo repository.Repository
+	log  logger.InterfaceLogger
+}
+
+func NewRunner(repo repository.Repository, log logger.InterfaceLogger) *Runner {
+	return &Runner{repo: repo, log: log}
+}
+
+// Run imports opts.Path and blocks until the dump is exhausted, ctx is canceled
+// or an upsert fails.
+func (r *Runner) Run(ctx context.Context, opts Options) (Stats, error) {
+	var stats Stats
+	if opts.Format == "" {
+		opts.Format = DetectFormat(opts.Path)
+	}
+	if opts.CheckpointPath == "" {
+		opts.CheckpointPath = opts.Path + ".checkpoint"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Validate == nil {
+		opts.Validate = order.ValidateOrder
+	}
+
+	f, err := os.Open(opts.Path)
+	if err != nil {
+		return stats, fmt.Errorf("open dump: %w", err)
+	}
+	defer func(f *os.File) {
+		if err := f.Close(); err != nil {
+			r.log.Errorf("backfill: close %s: %v", opts.Path, err)
+		}
+	}(f)
+
+	rr, err := newRecordReader(f, opts.Format)
+	if err != nil {
+		return stats, err
+	}
+	done, err := readCheckpoint(opts.CheckpointPath)
+	if err != nil {
+		return stats, err
+	}
+	if done > 0 {
+		r.log.Infof("backfill: resuming %s after record %d", opts.Path, done)
+	}
+
+	var tick <-chan time.Time
+	if opts.Rate > 0 {
+		t := time.NewTicker(time.Second / time.Duration(opts.Rate))
+		defer t.Stop()
+		tick = t.C
+	}
+
+	start := time.Now()
+	batch := make([]*model.Order, 0, opts.BatchSize)
+	last := done
+
+	flush := func() error {
+		if tick != nil {
+			for range batch {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-tick:
+				}
+			}
+		}
+		if len(batch) > 0 {
+			n, err := r.repo.UpsertOrders(ctx, batch)
+			if err != nil {
+				return fmt.Errorf("upsert batch ending at record %d: %w", last, err)
+			}
+			stats.Upserted += n
+			stats.Skipped += len(batch) - n
+		}
+		batch = batch[:0]
+		return writeCheckpoint(opts.CheckpointPath, last)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		n, payload, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stats, fmt.Errorf("read record %d: %w", n, err)
+		}
+		stats.Read++
+		if n <= done {
+			stats.Resumed++
+			continue
+		}
+
+		var o model.Order
+		if err := json.Unmarshal(payload, &o); err != nil {
+			stats.Invalid++
+			r.log.Warnf("backfill: record %d: invalid JSON: %v", n, err)
+		} else if err := opts.Validate(&o); err != nil {
+			stats.Invalid++
+			r.log.Warnf("backfill: record %d (order=%s): %v", n, o.OrderUID, err)
+		} else {
+			o.Raw = payload
+			if opts.Overwrite {
+				o.Version = time.Now().UnixNano()
+			} else if o.Version == 0 {
+				o.Version = o.DateCreated.UnixNano()
+			}
+			batch = append(batch, &o)
+		}
+		last = n
+
+		if len(batch) >= opts.BatchSize {
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+		if opts.ProgressEvery > 0 && stats.Read%opts.ProgressEvery == 0 {
+			r.logProgress(stats, start)
+		}
+	}
+
+	if err := flush(); err != nil {
+		return stats, err
+	}
+	r.logProgress(stats, start)
+	return stats, nil
+}
+
+func (r *Runner) logProgress(s Stats, start time.Time) {
+	elapsed := time.Since(start)
+	rate := float64(s.Upserted) / elapsed.Seconds()
+	r.log.Infof("backfill: read=%d resumed=%d invalid=%d upserted=%d skipped=%d (%.1f orders/s, %s)",
+		s.Read, s.Resumed, s.Invalid, s.Upserted, s.Skipped, rate, elapsed.Truncate(time.Millisecond))
+}
@@ -0,0 +1,141 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/service/order"
+	"github.com/stretchr/testify/require"
+)
+
+func orderJSON(t *testing.T, uid string) string {
+	t.Helper()
+	b, err := json.Marshal(&model.Order{
+		OrderUID:        uid,
+		TrackNumber:     "TRK",
+		Entry:           "WBIL",
+		CustomerID:      "c1",
+		DeliveryService: "meest",
+		ShardKey:        "9",
+		OofShard:        "1",
+		DateCreated:     time.Now(),
+		Items:           []model.Item{{ChrtID: 1}},
+	})
+	require.NoError(t, err)
+	return string(b)
+}
+
+func newTestRunner(t *testing.T) (*Runner, *mocks.MockRepository) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+	return NewRunner(repo, log), repo
+}
+
+func TestRunner_NDJSON_SkipsInvalidAndCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "orders.ndjson")
+	lines := []string{orderJSON(t, "a"), "{broken", `{"order_uid":"no-items"}`, "", orderJSON(t, "b")}
+	require.NoError(t, os.WriteFile(dump, []byte(strings.Join(lines, "\n")), 0644))
+
+	r, repo := newTestRunner(t)
+	var got []string
+	repo.EXPECT().UpsertOrders(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, orders []*model.Order) (int, error) {
+		for _, o := range orders {
+			got = append(got, o.OrderUID)
+		}
+		return len(orders), nil
+	}).Times(1)
+
+	stats, err := r.Run(context.Background(), Options{Path: dump, BatchSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, got)
+	require.Equal(t, 2, stats.Invalid)
+
+	n, err := readCheckpoint(dump + ".checkpoint")
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+}
+
+func TestRunner_ValidatesWithTheConfiguredRules(t *testing.T) {
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "orders.ndjson")
+	require.NoError(t, os.WriteFile(dump, []byte(orderJSON(t, "a")+"\n"+orderJSON(t, "b")), 0644))
+
+	// passes the default rules but has no delivery or payment for the strict ones
+	strict, err := order.LookupValidator(order.ValidatorStrict)
+	require.NoError(t, err)
+	r, _ := newTestRunner(t)
+	stats, err := r.Run(context.Background(), Options{Path: dump, Validate: order.NewValidator(order.WithValidator(strict)).Validate})
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Invalid)
+	require.Zero(t, stats.Upserted)
+}
+
+func TestRunner_ResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "orders.csv")
+	csv := "id,payload\n" +
+		`1,"` + strings.ReplaceAll(orderJSON(t, "a"), `"`, `""`) + `"` + "\n" +
+		`2,"` + strings.ReplaceAll(orderJSON(t, "b"), `"`, `""`) + `"` + "\n"
+	require.NoError(t, os.WriteFile(dump, []byte(csv), 0644))
+	require.NoError(t, writeCheckpoint(dump+".checkpoint", 1))
+
+	r, repo := newTestRunner(t)
+	repo.EXPECT().UpsertOrders(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, orders []*model.Order) (int, error) {
+		require.Len(t, orders, 1)
+		require.Equal(t, "b", orders[0].OrderUID)
+		return 1, nil
+	}).Times(1)
+
+	stats, err := r.Run(context.Background(), Options{Path: dump})
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Resumed)
+	require.Equal(t, 1, stats.Upserted)
+}
+
+func TestRunner_ReimportReplacesOlderOrders(t *testing.T) {
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "orders.ndjson")
+	require.NoError(t, os.WriteFile(dump, []byte(orderJSON(t, "migrated")+"\n"+orderJSON(t, "edited")), 0644))
+
+	// like the repository: an order is written only over an older version
+	stored := map[string]int64{"migrated": 0, "edited": time.Now().UnixNano()}
+	upsert := func(_ context.Context, orders []*model.Order) (int, error) {
+		n := 0
+		for _, o := range orders {
+			if v, ok := stored[o.OrderUID]; !ok || v < o.Version {
+				stored[o.OrderUID] = o.Version
+				n++
+			}
+		}
+		return n, nil
+	}
+
+	r, repo := newTestRunner(t)
+	repo.EXPECT().UpsertOrders(gomock.Any(), gomock.Any()).DoAndReturn(upsert).Times(2)
+
+	stats, err := r.Run(context.Background(), Options{Path: dump})
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Upserted)
+	require.Equal(t, 1, stats.Skipped)
+	require.NotZero(t, stored["migrated"])
+
+	// a corrective replay replaces the order edited after the dump too
+	require.NoError(t, os.Remove(dump+".checkpoint"))
+	stats, err = r.Run(context.Background(), Options{Path: dump, Overwrite: true})
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Upserted)
+	require.Zero(t, stats.Skipped)
+}
@@ -0,0 +1,105 @@
+package backfill
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// maxRecordSize bounds a single NDJSON line; large multi-item orders are a few hundred KB at most.
+const maxRecordSize = 16 << 20
+
+// recordReader yields raw order payloads together with their 1-based record number,
+// which is what the checkpoint file stores.
+type recordReader interface {
+	Next() (n int, payload []byte, err error)
+}
+
+// DetectFormat infers the dump format from the file extension.
+func DetectFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	default:
+		return FormatNDJSON
+	}
+}
+
+func newRecordReader(r io.Reader, format string) (recordReader, error) {
+	switch format {
+	case FormatNDJSON:
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64<<10), maxRecordSize)
+		return &ndjsonReader{sc: sc}, nil
+	case FormatCSV:
+		return newCSVReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// ndjsonReader reads one JSON order per line; blank lines are skipped but still counted.
+type ndjsonReader struct {
+	sc *bufio.Scanner
+	n  int
+}
+
+func (r *ndjsonReader) Next() (int, []byte, error) {
+	for r.sc.Scan() {
+		r.n++
+		line := r.sc.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		// Scanner reuses its buffer, the caller may keep the payload.
+		return r.n, append([]byte(nil), line...), nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return r.n, nil, err
+	}
+	return r.n, nil, io.EOF
+}
+
+// csvReader reads dumps with a header row and a "payload" column holding the
+// order JSON, which is what `COPY (...) TO ... CSV HEADER` exports produce.
+type csvReader struct {
+	r   *csv.Reader
+	col int
+	n   int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), "payload") {
+			return &csvReader{r: cr, col: i}, nil
+		}
+	}
+	return nil, errors.New(`csv header has no "payload" column`)
+}
+
+func (r *csvReader) Next() (int, []byte, error) {
+	rec, err := r.r.Read()
+	if err != nil {
+		return r.n, nil, err
+	}
+	r.n++
+	if r.col >= len(rec) {
+		return r.n, nil, fmt.Errorf("record %d: missing payload column", r.n)
+	}
+	return r.n, []byte(rec[r.col]), nil
+}
@@ -1,25 +1,116 @@
 package config
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/joho/godotenv"
+	"github.com/merkulovlad/wbtech-go/internal/config/secret"
+	"go.uber.org/zap/zapcore"
 )
 
 type Config struct {
 	Server   ServerConfig
 	Log      LogConfig
 	Database DatabaseConfig
-	Kafka    KafkaConfig
+	// Broker selects the ingestion transport: "kafka" (default), "nats" or "rabbitmq".
+	Broker    string
+	Ingest    IngestConfig
+	Kafka     KafkaConfig
+	NATS      NATSConfig
+	RabbitMQ  RabbitMQConfig
+	Retention RetentionConfig
+	Cache     CacheConfig
+	Webhook   WebhookConfig
+	// ReloadInterval is how often the env file is checked for changes to the
+	// Tunables; 0 reloads on SIGHUP only.
+	ReloadInterval time.Duration
 }
 
 type ServerConfig struct {
+	// Host and Port are the HTTP listen address.
 	Host string
 	Port int
+	// ReadTimeout bounds reading a request, WriteTimeout writing its response
+	// and IdleTimeout how long a keep-alive connection waits for the next
+	// request; zero means no limit.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// BodyLimit is the largest request body accepted, in bytes.
+	BodyLimit int
+	// Prefork serves from one child process per CPU sharing the port. Each
+	// child has its own order cache; ingestion and background jobs run in the
+	// parent only.
+	Prefork bool
+	// CompressLevel gzips or deflates JSON and text responses of at least
+	// CompressMinSize bytes for clients that accept it, from 1 (fastest) to
+	// 9 (smallest); 0 disables compression.
+	CompressLevel   int
+	CompressMinSize int
+	// TLSCertFile and TLSKeyFile are PEM paths; setting both serves HTTPS
+	// instead of HTTP. TLSClientCAFile additionally requires clients to
+	// present a certificate signed by one of the CAs in that PEM bundle.
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	// OrderMaxAge, when positive, lets clients reuse a GET /order/:order_uid
+	// response for that long (Cache-Control: private, max-age), and
+	// OrderStaleWhileRevalidate for that much longer while they refetch it.
+	OrderMaxAge               time.Duration
+	OrderStaleWhileRevalidate time.Duration
+	// Enrichers name the built-in enrichers (order.LookupEnricher) run on
+	// every order the API returns, in order.
+	Enrichers []string
+	// ShutdownTimeout is how long a stopping server waits for in-flight
+	// requests and the message the consumer has in hand; new connections
+	// are refused meanwhile.
+	ShutdownTimeout time.Duration
+	// OrderUIDMaxLength caps order_uid values in paths and bodies, which
+	// are otherwise limited to letters, digits, _ and -. OrderUIDPattern, if
+	// set, is a regular expression they must match as well, e.g. a
+	// producer's prefix scheme.
+	OrderUIDMaxLength int
+	OrderUIDPattern   string
+	// MaxRequestTimeout caps the deadline a caller may request via X-Deadline/Grpc-Timeout
+	// and is the deadline applied when the caller sends none.
+	MaxRequestTimeout time.Duration
+	// AdminToken guards the /admin endpoints, sent as "Authorization: Bearer <token>".
+	// Empty leaves them unmounted, along with DELETE /order/:order_uid.
+	AdminToken string
+	// HardDelete makes DELETE /order/:order_uid remove orders permanently
+	// instead of archiving them.
+	HardDelete bool
+	// Pprof mounts net/http/pprof under /debug/pprof behind AdminToken; it is
+	// ignored while AdminToken is empty.
+	Pprof bool
+	// RateLimit is the sustained number of API requests per second one client
+	// may make, RateBurst how many it may make at once; 0 disables limiting.
+	// Burst defaults to RateLimit.
+	RateLimit int
+	RateBurst int
+	// APIKeys maps a caller name to the hex SHA-256 of the key it sends as
+	// X-API-Key. Requests carrying an unknown key are rejected; requests
+	// without one are too when RequireAPIKey is set.
+	APIKeys       map[string]string
+	RequireAPIKey bool
+	// APIKeyRateLimits overrides RateLimit (and burst) for the named callers.
+	APIKeyRateLimits map[string]int
 }
 
 type LogConfig struct {
@@ -29,82 +120,906 @@ type LogConfig struct {
 }
 
 type DatabaseConfig struct {
-	Host              string
-	Port              int
-	User              string
-	Password          string
-	Name              string
-	SSLMode           string
-	MaxConnections    int
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+	// MaxConnections and MinConnections bound the pgx pool size; pgxpool has
+	// no separate idle cap, MinConnections is the number kept open when idle.
+	MaxConnections int
+	MinConnections int
+	// ConnectionTimeout is the dial timeout in milliseconds.
 	ConnectionTimeout int
+	// ConnMaxLifetime and ConnMaxIdleTime recycle pooled connections; zero
+	// keeps the pgxpool defaults (1h and 30m).
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ShardDSNs maps a shardkey value to the DSN of the database holding it.
+	// Empty means every order lives in the primary database.
+	ShardDSNs map[string]string
+	// ReplicaDSNs are read replicas of the primary; reads rotate across them
+	// and a failed replica is skipped for ReplicaCooldown.
+	ReplicaDSNs     []string
+	ReplicaCooldown time.Duration
+	// RetryAttempts, RetryBaseDelay and RetryMaxDelay bound the retries of
+	// transient errors (serialization failures, deadlocks, dropped connections).
+	RetryAttempts  int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// BreakerThreshold consecutive failures open the circuit breaker, which
+	// fails repository calls fast for BreakerCooldown before probing again.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// AllowDegraded starts the service read-only instead of exiting when
+	// migrations fail and every pending one is tagged additive.
+	AllowDegraded bool
+	// ReadTimeout, WriteTimeout, BatchTimeout and StatsTimeout bound each
+	// repository call by kind; zero keeps the repository defaults.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	BatchTimeout time.Duration
+	StatsTimeout time.Duration
+	// AutoMigrate applies pending migrations at startup. When false the
+	// schema is only checked and is managed with the migrate subcommand.
+	AutoMigrate bool
+}
+
+type CacheConfig struct {
+	// Backend is where cached orders live: "memory" (default), "redis",
+	// shared by every instance, or "layered", memory in front of Redis.
+	Backend string
+	// Capacity is the number of orders the cache holds in memory before it
+	// drops the oldest. Redis bounds itself with its maxmemory policy.
+	Capacity int
+	// TTL expires cached orders; 0 keeps them until evicted. A layered cache
+	// needs one, as it bounds how long the memory layer can miss a change
+	// made through another instance.
+	TTL time.Duration
+	// Shards splits the memory cache by order UID to cut lock contention.
+	Shards int
+	Redis  RedisConfig
+	// RefreshAfter is the age past which a cached order is still served but
+	// reloaded from the database in the background; 0 never reloads.
+	RefreshAfter time.Duration
+	// WarmCount is the number of orders loaded into the cache at startup,
+	// by POST /admin/cache/warm and by each timed warm-up.
+	WarmCount int
+	// WarmTimeout bounds the warm-up at startup.
+	WarmTimeout time.Duration
+	// WarmInterval re-warms the cache periodically with WarmCount orders
+	// picked by WarmStrategy ("recent" or "popular"); 0 only warms at startup.
+	WarmInterval time.Duration
+	WarmStrategy string
+}
+
+type RedisConfig struct {
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	TLS      bool
+	// KeyPrefix namespaces the cache keys; flushing the cache deletes only
+	// keys under it.
+	KeyPrefix string
+	Timeout   time.Duration
+	PoolSize  int
+}
+
+const (
+	CacheBackendMemory  = "memory"
+	CacheBackendRedis   = "redis"
+	CacheBackendLayered = "layered"
+)
+
+type RetentionConfig struct {
+	// Days is the age after which orders move to orders_archive; 0 disables the job.
+	Days int
+	// Interval is the pause between passes.
+	Interval time.Duration
+	// BatchSize is the number of orders moved per transaction.
+	BatchSize int
+}
+
+type WebhookConfig struct {
+	// URLs are POSTed every stored order and status change; empty disables
+	// webhooks.
+	URLs []string
+	// Secret is the HMAC key for X-Webhook-Signature; required with URLs.
+	Secret string
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+	// Attempts is the total number of tries per delivery. The jittered
+	// backoff between them doubles from RetryBaseDelay up to RetryMaxDelay.
+	Attempts       int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// Queue is how many deliveries may wait for one of the Workers; events
+	// arriving while it is full are dropped.
+	Queue   int
+	Workers int
+}
+
+type IngestConfig struct {
+	// Rules names the active validation rule set.
+	Rules string
+	// CanaryRules names a candidate rule set evaluated for comparison only; empty disables it.
+	CanaryRules string
+	// SignatureKey is the shared HMAC key for X-Payload-Signature; empty disables verification.
+	SignatureKey string
+	// RequireSignature routes unsigned messages to the DLQ; needs SignatureKey.
+	RequireSignature bool
+	// Consistency is what happens to orders whose payment totals don't add
+	// up: "off", "warn" (stored with warnings) or "reject" (DLQ).
+	// ConsistencyTolerance is how far sums may be off.
+	Consistency          string
+	ConsistencyTolerance int
+	// CodeChecks is the same choice for a payment.currency that is not an
+	// ISO 4217 code or a locale outside Locales; empty Locales allows any.
+	// Unless "off", both are normalized to upper and lower case first.
+	CodeChecks string
+	Locales    []string
 }
 
 type KafkaConfig struct {
 	Brokers []string
 	Topic   string
 	Group   string
+	// DLQTopic receives the messages ingestion gives up on.
+	DLQTopic string
+	// RetryTopics, when set, take messages that failed for a transient
+	// reason (ingest.Retryable) before the DLQ does: a failure in Topic goes
+	// to the first, a failure there to the next and so on. Each is consumed
+	// by its own group, Group + "." + the topic, RetryDelay after the
+	// failure, twice that for the second and so on.
+	RetryTopics []string
+	RetryDelay  time.Duration
+	// Workers is the number of messages handled at once. Messages of one
+	// partition are still handled one at a time, in order.
+	Workers int
+	// BatchSize is the number of messages fetched ahead of the workers.
+	BatchSize int
+	// CommitInterval batches offset commits; 0 commits every message as it
+	// is acked. A crash redelivers up to an interval's worth of messages.
+	CommitInterval time.Duration
+	// StartOffset is where a group without committed offsets starts:
+	// "earliest" (default) or "latest".
+	StartOffset string
+	// RateLimit caps the messages consumed per second; 0 is unlimited.
+	RateLimit int
+	// TLS connects to the brokers with TLS, verified against TLSCAFile or
+	// the system roots, presenting TLSCertFile and TLSKeyFile if set.
+	TLS         bool
+	TLSCAFile   string
+	TLSCertFile string
+	TLSKeyFile  string
+	// SASLMechanism authenticates with SASLUsername and SASLPassword:
+	// "plain", "scram-sha-256" or "scram-sha-512"; empty connects without.
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+}
+
+const (
+	KafkaStartEarliest = "earliest"
+	KafkaStartLatest   = "latest"
+)
+
+const (
+	KafkaSASLPlain       = "plain"
+	KafkaSASLSCRAMSHA256 = "scram-sha-256"
+	KafkaSASLSCRAMSHA512 = "scram-sha-512"
+)
+
+type NATSConfig struct {
+	URL        string
+	Stream     string
+	Subject    string
+	Durable    string
+	DLQSubject string
+}
+
+type RabbitMQConfig struct {
+	URL        string
+	Exchange   string
+	Queue      string
+	RoutingKey string
+	DLX        string
+	DLQ        string
+	Prefetch   int
+}
+
+const (
+	BrokerKafka    = "kafka"
+	BrokerNATS     = "nats"
+	BrokerRabbitMQ = "rabbitmq"
+)
+
+// EnvPrefix marks overrides: when ORDERS_<NAME> is set it is used instead of
+// <NAME>, e.g. ORDERS_POSTGRES_HOST instead of POSTGRES_HOST. Every setting
+// can be overridden this way, so a deployment can change one without
+// clashing with the variables other containers of the pod read.
+//
+// A setting comes from, in order of precedence: Source.Overrides (command-line
+// flags), ORDERS_<NAME> and <NAME> in the process environment, ORDERS_<NAME>
+// and <NAME> in the env file, the default. A source that sets a setting to
+// the empty string does not count, so ORDERS_<NAME>= falls back to <NAME>.
+const EnvPrefix = "ORDERS_"
+
+// loader reads settings and collects the missing and malformed ones rather
+// than stopping at the first, so Load can report them all at once.
+type loader struct {
+	// overrides are Source.Overrides, which win over everything else.
+	overrides map[string]string
+	// file holds the variables of the env file, which the environment
+	// overrides.
+	file     map[string]string
+	problems Problems
+	// secrets resolves the secret references of getSecret; nil until needed.
+	secrets *secret.Resolver
+}
+
+// lookupEnv returns the first non-empty value of key, taking
+// Source.Overrides and EnvPrefix overrides into account. Empty values are
+// skipped rather than returned, so an empty override never hides a value set
+// further down, which would make mustGetEnv fail for a required key.
+func (l *loader) lookupEnv(key string) string {
+	if v := l.overrides[key]; v != "" {
+		return v
+	}
+	if v := os.Getenv(EnvPrefix + key); v != "" {
+		return v
+	}
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	if v := l.file[EnvPrefix+key]; v != "" {
+		return v
+	}
+	return l.file[key]
+}
+
+func (l *loader) fail(format string, args ...any) {
+	l.problems = append(l.problems, fmt.Sprintf(format, args...))
 }
 
-func mustGetEnv(key string) string {
-	v := os.Getenv(key)
+func (l *loader) mustGetEnv(key string) string {
+	v := l.lookupEnv(key)
 	if v == "" {
-		log.Fatalf("missing required env var %s", key)
+		l.fail("%s is required", key)
 	}
 	return v
 }
 
-func mustGetEnvBool(key string) bool {
-	s := mustGetEnv(key)
-	b, err := strconv.ParseBool(s)
+func (l *loader) getEnv(key, def string) string {
+	if v := l.lookupEnv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getSecret is getEnv for settings that may be a secret reference such as
+// "vault://secret/data/orders#password" (see package secret), which it
+// resolves.
+func (l *loader) getSecret(key, def string) string {
+	v := l.getEnv(key, def)
+	if !secret.IsRef(v) {
+		return v
+	}
+	if l.secrets == nil {
+		l.secrets = secret.NewResolver(l.lookupEnv)
+	}
+	s, err := l.secrets.Resolve(context.Background(), v)
 	if err != nil {
-		log.Fatalf("invalid boolean for %s: %v", key, err)
+		l.fail("%s: %v", key, err)
+		return ""
 	}
-	return b
+	return s
 }
 
-func mustGetEnvInt(key string) int {
-	s := mustGetEnv(key)
+func (l *loader) mustGetSecret(key string) string {
+	if l.lookupEnv(key) == "" {
+		return l.mustGetEnv(key)
+	}
+	return l.getSecret(key, "")
+}
+
+func (l *loader) getEnvInt(key string, def int) int {
+	s := l.lookupEnv(key)
+	if s == "" {
+		return def
+	}
+	return l.parseInt(key, s, def)
+}
+
+func (l *loader) parseInt(key, s string, def int) int {
 	i, err := strconv.Atoi(s)
 	if err != nil {
-		log.Fatalf("invalid integer for %s: %v", key, err)
+		l.fail("%s: %q is not an integer", key, s)
+		return def
 	}
 	return i
 }
 
-func MustLoad() *Config {
-	// ignore error if there's no .env in CI/etc
-	_ = godotenv.Load()
+func (l *loader) getEnvDuration(key string, def time.Duration) time.Duration {
+	s := l.lookupEnv(key)
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		l.fail("%s: %q is not a duration such as 10s or 2m", key, s)
+		return def
+	}
+	return d
+}
+
+func (l *loader) getEnvBool(key string, def bool) bool {
+	s := l.lookupEnv(key)
+	if s == "" {
+		return def
+	}
+	return l.parseBool(key, s, def)
+}
+
+func (l *loader) parseBool(key, s string, def bool) bool {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		l.fail("%s: %q is not a boolean (true or false)", key, s)
+		return def
+	}
+	return b
+}
+
+// getEnvMap parses "k1=v1,k2=v2" into a map. Values may contain '=' themselves,
+// only the first one separates the key.
+func (l *loader) getEnvMap(key string) map[string]string {
+	s := l.lookupEnv(key)
+	if s == "" {
+		return nil
+	}
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" || v == "" {
+			l.fail("%s: invalid entry %q, expected key=value", key, pair)
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
+
+// getEnvIntMap is getEnvMap with integer values.
+func (l *loader) getEnvIntMap(key string) map[string]int {
+	var m map[string]int
+	for k, v := range l.getEnvMap(key) {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			l.fail("%s: value %q of %s is not an integer", key, v, k)
+			continue
+		}
+		if m == nil {
+			m = make(map[string]int)
+		}
+		m[k] = i
+	}
+	return m
+}
+
+// getEnvList parses "a,b,c", dropping empty entries.
+func (l *loader) getEnvList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(l.lookupEnv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (l *loader) mustGetEnvBool(key string) bool {
+	if s := l.mustGetEnv(key); s != "" {
+		return l.parseBool(key, s, false)
+	}
+	return false
+}
+
+func (l *loader) mustGetEnvInt(key string) int {
+	if s := l.mustGetEnv(key); s != "" {
+		return l.parseInt(key, s, 0)
+	}
+	return 0
+}
+
+// Problems lists everything wrong with a configuration, each naming the
+// variable to fix.
+type Problems []string
+
+func (p Problems) Error() string { return strings.Join(p, "; ") }
+
+func (p *Problems) add(format string, args ...any) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+// EnvFile is the env file read unless Source.Path names another.
+const EnvFile = ".env"
+
+// Source is where a configuration is loaded from.
+type Source struct {
+	// Path is the env file; empty is EnvFile. It need not exist.
+	Path string
+	// Overrides set variables by name, e.g. from command-line flags, ahead
+	// of the environment and the file.
+	Overrides map[string]string
+}
+
+// EnvFile returns the env file s reads.
+func (s Source) EnvFile() string {
+	if s.Path == "" {
+		return EnvFile
+	}
+	return s.Path
+}
+
+// MustLoad loads src and exits listing every problem Load found.
+func MustLoad(src Source) *Config {
+	c, err := Load(src)
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	return c
+}
 
-	return &Config{
+// Load reads the configuration from src and the environment. It returns
+// every missing, malformed or inconsistent setting (see Validate) as
+// Problems.
+func Load(src Source) (*Config, error) {
+	l := &loader{overrides: src.Overrides}
+	path := src.EnvFile()
+	file, err := godotenv.Read(path)
+	switch {
+	case err == nil:
+		l.file = file
+	case !errors.Is(err, fs.ErrNotExist):
+		l.fail("%s: %v", path, err)
+	}
+	c := &Config{
+		Broker:         l.getEnv("BROKER", BrokerKafka),
+		ReloadInterval: l.getEnvDuration("CONFIG_RELOAD_INTERVAL", 0),
+		Ingest: IngestConfig{
+			Rules:                l.getEnv("INGEST_RULES", "default"),
+			CanaryRules:          l.getEnv("INGEST_CANARY_RULES", ""),
+			SignatureKey:         l.getEnv("INGEST_SIGNATURE_KEY", ""),
+			RequireSignature:     l.getEnvBool("INGEST_REQUIRE_SIGNATURE", false),
+			Consistency:          l.getEnv("INGEST_CONSISTENCY", "warn"),
+			ConsistencyTolerance: l.getEnvInt("INGEST_CONSISTENCY_TOLERANCE", 0),
+			CodeChecks:           l.getEnv("INGEST_CODE_CHECKS", "warn"),
+			Locales:              l.getEnvList("INGEST_LOCALES"),
+		},
+		Cache: CacheConfig{
+			Backend:      l.getEnv("CACHE_BACKEND", CacheBackendMemory),
+			Capacity:     l.getEnvInt("CACHE_CAPACITY", 10),
+			TTL:          l.getEnvDuration("CACHE_TTL", 0),
+			Shards:       l.getEnvInt("CACHE_SHARDS", 1),
+			RefreshAfter: l.getEnvDuration("CACHE_REFRESH_AFTER", 0),
+			WarmInterval: l.getEnvDuration("CACHE_WARM_INTERVAL", 0),
+			WarmStrategy: l.getEnv("CACHE_WARM_STRATEGY", "recent"),
+			WarmCount:    l.getEnvInt("CACHE_WARM_COUNT", 10),
+			WarmTimeout:  l.getEnvDuration("CACHE_WARM_TIMEOUT", 2*time.Second),
+		},
+		Retention: RetentionConfig{
+			Days:      l.getEnvInt("RETENTION_DAYS", 0),
+			Interval:  l.getEnvDuration("RETENTION_INTERVAL", time.Hour),
+			BatchSize: l.getEnvInt("RETENTION_BATCH_SIZE", 500),
+		},
+		Webhook: WebhookConfig{
+			URLs:           l.getEnvList("WEBHOOK_URLS"),
+			Secret:         l.getEnv("WEBHOOK_SECRET", ""),
+			Timeout:        l.getEnvDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+			Attempts:       l.getEnvInt("WEBHOOK_ATTEMPTS", 5),
+			RetryBaseDelay: l.getEnvDuration("WEBHOOK_RETRY_BASE_DELAY", time.Second),
+			RetryMaxDelay:  l.getEnvDuration("WEBHOOK_RETRY_MAX_DELAY", time.Minute),
+			Queue:          l.getEnvInt("WEBHOOK_QUEUE", 1000),
+			Workers:        l.getEnvInt("WEBHOOK_WORKERS", 4),
+		},
 		Server: ServerConfig{
-			Host: mustGetEnv("BACKEND_HOST"),
-			Port: mustGetEnvInt("BACKEND_PORT"),
+			Host:                      l.mustGetEnv("BACKEND_HOST"),
+			Port:                      l.mustGetEnvInt("BACKEND_PORT"),
+			ReadTimeout:               l.getEnvDuration("BACKEND_READ_TIMEOUT", 10*time.Second),
+			WriteTimeout:              l.getEnvDuration("BACKEND_WRITE_TIMEOUT", 0),
+			IdleTimeout:               l.getEnvDuration("BACKEND_IDLE_TIMEOUT", 2*time.Minute),
+			BodyLimit:                 l.getEnvInt("BACKEND_BODY_LIMIT", 4<<20),
+			Prefork:                   l.getEnvBool("BACKEND_PREFORK", false),
+			CompressLevel:             l.getEnvInt("BACKEND_COMPRESS_LEVEL", 5),
+			CompressMinSize:           l.getEnvInt("BACKEND_COMPRESS_MIN_SIZE", 1024),
+			TLSCertFile:               l.getEnv("BACKEND_TLS_CERT", ""),
+			TLSKeyFile:                l.getEnv("BACKEND_TLS_KEY", ""),
+			TLSClientCAFile:           l.getEnv("BACKEND_TLS_CLIENT_CA", ""),
+			OrderMaxAge:               l.getEnvDuration("BACKEND_ORDER_MAX_AGE", 0),
+			OrderStaleWhileRevalidate: l.getEnvDuration("BACKEND_ORDER_STALE_WHILE_REVALIDATE", 0),
+			Enrichers:                 l.getEnvList("BACKEND_ENRICHERS"),
+			ShutdownTimeout:           l.getEnvDuration("BACKEND_SHUTDOWN_TIMEOUT", 10*time.Second),
+			OrderUIDMaxLength:         l.getEnvInt("BACKEND_ORDER_UID_MAX_LENGTH", 64),
+			OrderUIDPattern:           l.getEnv("BACKEND_ORDER_UID_PATTERN", ""),
+			MaxRequestTimeout:         l.getEnvDuration("BACKEND_MAX_REQUEST_TIMEOUT", 5*time.Second),
+			AdminToken:                l.getEnv("BACKEND_ADMIN_TOKEN", ""),
+			HardDelete:                l.getEnvBool("BACKEND_HARD_DELETE", false),
+			Pprof:                     l.getEnvBool("BACKEND_PPROF", false),
+			RateLimit:                 l.getEnvInt("BACKEND_RATE_LIMIT", 0),
+			RateBurst:                 l.getEnvInt("BACKEND_RATE_BURST", 0),
+			APIKeys:                   l.getEnvMap("BACKEND_API_KEYS"),
+			RequireAPIKey:             l.getEnvBool("BACKEND_REQUIRE_API_KEY", false),
+			APIKeyRateLimits:          l.getEnvIntMap("BACKEND_API_KEY_RATE_LIMITS"),
 		},
 		Log: LogConfig{
-			Filename:  mustGetEnv("LOG_FILE"),
-			Level:     mustGetEnv("LOG_LEVEL"),
-			ToConsole: mustGetEnvBool("LOG_TO_CONSOLE"),
+			Filename:  l.mustGetEnv("LOG_FILE"),
+			Level:     l.mustGetEnv("LOG_LEVEL"),
+			ToConsole: l.mustGetEnvBool("LOG_TO_CONSOLE"),
 		},
 		Database: DatabaseConfig{
-			Host:              mustGetEnv("POSTGRES_HOST"),
-			Port:              mustGetEnvInt("POSTGRES_PORT"),
-			User:              mustGetEnv("POSTGRES_USER"),
-			Password:          mustGetEnv("POSTGRES_PASSWORD"),
-			Name:              mustGetEnv("POSTGRES_DB"),
-			SSLMode:           mustGetEnv("POSTGRES_SSLMODE"),
-			MaxConnections:    mustGetEnvInt("POSTGRES_MAX_CONNECTIONS"),
-			ConnectionTimeout: mustGetEnvInt("POSTGRES_CONNECTION_TIMEOUT"),
-		},
-		Kafka: KafkaConfig{
-			Brokers: strings.Split(mustGetEnv("KAFKA_BROKERS"), ","),
-			Topic:   mustGetEnv("KAFKA_TOPIC"),
-			Group:   mustGetEnv("KAFKA_GROUP"),
+			Host:              l.mustGetEnv("POSTGRES_HOST"),
+			Port:              l.mustGetEnvInt("POSTGRES_PORT"),
+			User:              l.mustGetEnv("POSTGRES_USER"),
+			Password:          l.mustGetSecret("POSTGRES_PASSWORD"),
+			Name:              l.mustGetEnv("POSTGRES_DB"),
+			SSLMode:           l.mustGetEnv("POSTGRES_SSLMODE"),
+			MaxConnections:    l.mustGetEnvInt("POSTGRES_MAX_CONNECTIONS"),
+			MinConnections:    l.getEnvInt("POSTGRES_MIN_CONNECTIONS", 0),
+			ConnectionTimeout: l.mustGetEnvInt("POSTGRES_CONNECTION_TIMEOUT"),
+			ConnMaxLifetime:   l.getEnvDuration("POSTGRES_CONN_MAX_LIFETIME", 0),
+			ConnMaxIdleTime:   l.getEnvDuration("POSTGRES_CONN_MAX_IDLE_TIME", 0),
+			ShardDSNs:         l.getEnvMap("POSTGRES_SHARD_DSNS"),
+			ReplicaDSNs:       l.getEnvList("POSTGRES_REPLICA_DSNS"),
+			ReplicaCooldown:   l.getEnvDuration("POSTGRES_REPLICA_COOLDOWN", 30*time.Second),
+			RetryAttempts:     l.getEnvInt("POSTGRES_RETRY_ATTEMPTS", 3),
+			RetryBaseDelay:    l.getEnvDuration("POSTGRES_RETRY_BASE_DELAY", 50*time.Millisecond),
+			RetryMaxDelay:     l.getEnvDuration("POSTGRES_RETRY_MAX_DELAY", time.Second),
+			BreakerThreshold:  l.getEnvInt("POSTGRES_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:   l.getEnvDuration("POSTGRES_BREAKER_COOLDOWN", 10*time.Second),
+			AllowDegraded:     l.getEnvBool("POSTGRES_ALLOW_DEGRADED", false),
+			AutoMigrate:       l.getEnvBool("POSTGRES_AUTO_MIGRATE", true),
+			ReadTimeout:       l.getEnvDuration("POSTGRES_READ_TIMEOUT", 0),
+			WriteTimeout:      l.getEnvDuration("POSTGRES_WRITE_TIMEOUT", 0),
+			BatchTimeout:      l.getEnvDuration("POSTGRES_BATCH_TIMEOUT", 0),
+			StatsTimeout:      l.getEnvDuration("POSTGRES_STATS_TIMEOUT", 0),
 		},
 	}
+
+	if c.Cache.Backend == CacheBackendRedis || c.Cache.Backend == CacheBackendLayered {
+		c.Cache.Redis = RedisConfig{
+			Addr:      l.mustGetEnv("CACHE_REDIS_ADDR"),
+			Username:  l.getSecret("CACHE_REDIS_USERNAME", ""),
+			Password:  l.getSecret("CACHE_REDIS_PASSWORD", ""),
+			DB:        l.getEnvInt("CACHE_REDIS_DB", 0),
+			TLS:       l.getEnvBool("CACHE_REDIS_TLS", false),
+			KeyPrefix: l.getEnv("CACHE_REDIS_KEY_PREFIX", "orders:cache:"),
+			Timeout:   l.getEnvDuration("CACHE_REDIS_TIMEOUT", time.Second),
+			PoolSize:  l.getEnvInt("CACHE_REDIS_POOL_SIZE", 10),
+		}
+	}
+
+	switch c.Broker {
+	case BrokerKafka:
+		c.Kafka = KafkaConfig{
+			Brokers: l.getEnvList("KAFKA_BROKERS"),
+			Topic:   l.mustGetEnv("KAFKA_TOPIC"),
+			Group:   l.mustGetEnv("KAFKA_GROUP"),
+
+			DLQTopic:       l.getEnv("KAFKA_DLQ_TOPIC", "kafka.DLQ"),
+			RetryTopics:    l.getEnvList("KAFKA_RETRY_TOPICS"),
+			RetryDelay:     l.getEnvDuration("KAFKA_RETRY_DELAY", 30*time.Second),
+			Workers:        l.getEnvInt("KAFKA_WORKERS", 1),
+			BatchSize:      l.getEnvInt("KAFKA_BATCH_SIZE", 100),
+			CommitInterval: l.getEnvDuration("KAFKA_COMMIT_INTERVAL", 0),
+			StartOffset:    l.getEnv("KAFKA_START_OFFSET", KafkaStartEarliest),
+			RateLimit:      l.getEnvInt("KAFKA_RATE_LIMIT", 0),
+
+			TLS:         l.getEnvBool("KAFKA_TLS", false),
+			TLSCAFile:   l.getEnv("KAFKA_TLS_CA_FILE", ""),
+			TLSCertFile: l.getEnv("KAFKA_TLS_CERT_FILE", ""),
+			TLSKeyFile:  l.getEnv("KAFKA_TLS_KEY_FILE", ""),
+
+			SASLMechanism: l.getEnv("KAFKA_SASL_MECHANISM", ""),
+			SASLUsername:  l.getSecret("KAFKA_SASL_USERNAME", ""),
+			SASLPassword:  l.getSecret("KAFKA_SASL_PASSWORD", ""),
+		}
+	case BrokerNATS:
+		c.NATS = NATSConfig{
+			URL:        l.mustGetEnv("NATS_URL"),
+			Stream:     l.getEnv("NATS_STREAM", "ORDERS"),
+			Subject:    l.getEnv("NATS_SUBJECT", "orders"),
+			Durable:    l.getEnv("NATS_DURABLE", "order_service"),
+			DLQSubject: l.getEnv("NATS_DLQ_SUBJECT", "orders.dlq"),
+		}
+	case BrokerRabbitMQ:
+		c.RabbitMQ = RabbitMQConfig{
+			URL:        l.mustGetEnv("RABBITMQ_URL"),
+			Exchange:   l.getEnv("RABBITMQ_EXCHANGE", "orders"),
+			Queue:      l.getEnv("RABBITMQ_QUEUE", "orders"),
+			RoutingKey: l.getEnv("RABBITMQ_ROUTING_KEY", "orders"),
+			DLX:        l.getEnv("RABBITMQ_DLX", "orders.dlx"),
+			DLQ:        l.getEnv("RABBITMQ_DLQ", "orders.dlq"),
+			Prefetch:   l.getEnvInt("RABBITMQ_PREFETCH", 10),
+		}
+	}
+
+	problems := l.problems
+	for _, problem := range c.problems() {
+		// a setting missing or malformed is not reported twice
+		if !slices.ContainsFunc(l.problems, func(s string) bool { return problemKey(s) == problemKey(problem) }) {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return c, nil
+}
+
+// problemKey is the variable a problem starts with.
+func problemKey(problem string) string {
+	key, _, _ := strings.Cut(problem, " ")
+	return strings.TrimSuffix(key, ":")
+}
+
+// Validate checks the settings against each other and for values the
+// service cannot run with, and returns every problem found as Problems.
+func (c *Config) Validate() error {
+	if p := c.problems(); len(p) > 0 {
+		return p
+	}
+	return nil
 }
+
+func (c *Config) problems() Problems {
+	var p Problems
+	checkPort(&p, "BACKEND_PORT", c.Server.Port)
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		p.add("LOG_LEVEL: %q is not one of debug, info, warn, error", c.Log.Level)
+	}
+	c.Database.validate(&p)
+	if c.Server.CompressLevel < 0 || c.Server.CompressLevel > 9 {
+		p.add("BACKEND_COMPRESS_LEVEL must be between 0 and 9, got %d", c.Server.CompressLevel)
+	}
+	if c.Server.OrderMaxAge < 0 || c.Server.OrderStaleWhileRevalidate < 0 {
+		p.add("BACKEND_ORDER_MAX_AGE and BACKEND_ORDER_STALE_WHILE_REVALIDATE must not be negative")
+	}
+	if c.Ingest.ConsistencyTolerance < 0 {
+		p.add("INGEST_CONSISTENCY_TOLERANCE must not be negative, got %d", c.Ingest.ConsistencyTolerance)
+	}
+	if c.Cache.Capacity < 1 {
+		p.add("CACHE_CAPACITY must be positive, got %d", c.Cache.Capacity)
+	}
+	if c.Cache.Shards < 1 {
+		p.add("CACHE_SHARDS must be positive, got %d", c.Cache.Shards)
+	}
+	if c.Cache.TTL < 0 {
+		p.add("CACHE_TTL must not be negative, got %s", c.Cache.TTL)
+	}
+	switch c.Cache.Backend {
+	case CacheBackendMemory:
+	case CacheBackendRedis, CacheBackendLayered:
+		r := c.Cache.Redis
+		if host, port, err := net.SplitHostPort(r.Addr); err != nil || host == "" || !validPort(port) {
+			p.add("CACHE_REDIS_ADDR: %q is not a host:port address", r.Addr)
+		}
+		if r.DB < 0 || r.Timeout <= 0 || r.PoolSize < 1 {
+			p.add("CACHE_REDIS_DB must not be negative, CACHE_REDIS_TIMEOUT and CACHE_REDIS_POOL_SIZE must be positive")
+		}
+		if c.Cache.Backend == CacheBackendLayered && c.Cache.TTL == 0 {
+			p.add("CACHE_TTL is required with CACHE_BACKEND=layered")
+		}
+	default:
+		p.add("CACHE_BACKEND: unsupported %q, expected %s, %s or %s",
+			c.Cache.Backend, CacheBackendMemory, CacheBackendRedis, CacheBackendLayered)
+	}
+	if c.ReloadInterval < 0 {
+		p.add("CONFIG_RELOAD_INTERVAL must not be negative, got %s", c.ReloadInterval)
+	}
+	if c.Cache.WarmInterval < 0 || c.Cache.WarmCount < 1 || c.Cache.WarmTimeout <= 0 {
+		p.add("CACHE_WARM_INTERVAL must not be negative, CACHE_WARM_COUNT and CACHE_WARM_TIMEOUT must be positive")
+	}
+	for _, u := range c.Webhook.URLs {
+		checkURL(&p, "WEBHOOK_URLS", u, "http", "https")
+	}
+	if len(c.Webhook.URLs) > 0 && c.Webhook.Secret == "" {
+		p.add("WEBHOOK_URLS is set but WEBHOOK_SECRET is empty")
+	}
+	if c.Webhook.Timeout <= 0 || c.Webhook.Attempts < 1 || c.Webhook.Queue < 1 || c.Webhook.Workers < 1 {
+		p.add("WEBHOOK_TIMEOUT, WEBHOOK_ATTEMPTS, WEBHOOK_QUEUE and WEBHOOK_WORKERS must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		p.add("BACKEND_SHUTDOWN_TIMEOUT must be positive, got %s", c.Server.ShutdownTimeout)
+	}
+	if c.Server.OrderUIDMaxLength < 1 || c.Server.OrderUIDMaxLength > 255 {
+		p.add("BACKEND_ORDER_UID_MAX_LENGTH must be between 1 and 255, got %d", c.Server.OrderUIDMaxLength)
+	}
+	if _, err := regexp.Compile(c.Server.OrderUIDPattern); err != nil {
+		p.add("BACKEND_ORDER_UID_PATTERN: %v", err)
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		p.add("BACKEND_TLS_CERT and BACKEND_TLS_KEY must be set together")
+	}
+	if c.Server.TLSClientCAFile != "" && c.Server.TLSCertFile == "" {
+		p.add("BACKEND_TLS_CLIENT_CA needs BACKEND_TLS_CERT and BACKEND_TLS_KEY")
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Server.APIKeys)) {
+		if b, err := hex.DecodeString(c.Server.APIKeys[name]); err != nil || len(b) != sha256.Size {
+			p.add("BACKEND_API_KEYS: key of %q must be a hex SHA-256", name)
+		}
+	}
+	if c.Server.RequireAPIKey && len(c.Server.APIKeys) == 0 {
+		p.add("BACKEND_REQUIRE_API_KEY is set but BACKEND_API_KEYS is empty")
+	}
+
+	switch c.Broker {
+	case BrokerKafka:
+		if len(c.Kafka.Brokers) == 0 {
+			p.add("KAFKA_BROKERS is required")
+		}
+		for _, b := range c.Kafka.Brokers {
+			if host, port, err := net.SplitHostPort(b); err != nil || host == "" || !validPort(port) {
+				p.add("KAFKA_BROKERS: %q is not a host:port address", b)
+			}
+		}
+		c.Kafka.validate(&p)
+		switch c.Kafka.SASLMechanism {
+		case "":
+		case KafkaSASLPlain, KafkaSASLSCRAMSHA256, KafkaSASLSCRAMSHA512:
+			if c.Kafka.SASLUsername == "" {
+				p.add("KAFKA_SASL_USERNAME is required with KAFKA_SASL_MECHANISM")
+			}
+		default:
+			p.add("KAFKA_SASL_MECHANISM: unsupported %q, expected %s, %s or %s",
+				c.Kafka.SASLMechanism, KafkaSASLPlain, KafkaSASLSCRAMSHA256, KafkaSASLSCRAMSHA512)
+		}
+	case BrokerNATS:
+		checkURL(&p, "NATS_URL", c.NATS.URL, "nats", "tls")
+	case BrokerRabbitMQ:
+		checkURL(&p, "RABBITMQ_URL", c.RabbitMQ.URL, "amqp", "amqps")
+		if c.RabbitMQ.Prefetch < 1 {
+			p.add("RABBITMQ_PREFETCH must be positive, got %d", c.RabbitMQ.Prefetch)
+		}
+	default:
+		p.add("BROKER: unsupported %q, expected %s, %s or %s", c.Broker, BrokerKafka, BrokerNATS, BrokerRabbitMQ)
+	}
+	return p
+}
+
+// sslModes are the sslmode values libpq accepts.
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// validate checks the consumer tuning, retry topics and TLS files.
+func (c *KafkaConfig) validate(p *Problems) {
+	if c.Workers < 1 || c.BatchSize < 1 {
+		p.add("KAFKA_WORKERS and KAFKA_BATCH_SIZE must be positive, got %d and %d", c.Workers, c.BatchSize)
+	}
+	if c.CommitInterval < 0 || c.RateLimit < 0 {
+		p.add("KAFKA_COMMIT_INTERVAL and KAFKA_RATE_LIMIT must not be negative")
+	}
+	if c.StartOffset != KafkaStartEarliest && c.StartOffset != KafkaStartLatest {
+		p.add("KAFKA_START_OFFSET: %q is not %s or %s", c.StartOffset, KafkaStartEarliest, KafkaStartLatest)
+	}
+	if len(c.RetryTopics) > 0 && c.RetryDelay <= 0 {
+		p.add("KAFKA_RETRY_DELAY must be positive with KAFKA_RETRY_TOPICS, got %s", c.RetryDelay)
+	}
+	seen := map[string]bool{c.Topic: true, c.DLQTopic: true}
+	for _, t := range c.RetryTopics {
+		if seen[t] {
+			p.add("KAFKA_RETRY_TOPICS: %q is used twice or is KAFKA_TOPIC or KAFKA_DLQ_TOPIC", t)
+		}
+		seen[t] = true
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		p.add("KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE must be set together")
+	}
+	if !c.TLS && (c.TLSCAFile != "" || c.TLSCertFile != "") {
+		p.add("KAFKA_TLS_CA_FILE and KAFKA_TLS_CERT_FILE need KAFKA_TLS=true")
+	}
+}
+
+// validate checks that DSN is complete and the pool and shard settings usable.
+func (c *DatabaseConfig) validate(p *Problems) {
+	for _, f := range []struct{ key, value string }{{"POSTGRES_HOST", c.Host}, {"POSTGRES_USER", c.User}, {"POSTGRES_DB", c.Name}} {
+		if f.value == "" {
+			p.add("%s is required", f.key)
+		}
+	}
+	checkPort(p, "POSTGRES_PORT", c.Port)
+	if !slices.Contains(sslModes, c.SSLMode) {
+		p.add("POSTGRES_SSLMODE: %q is not one of %s", c.SSLMode, strings.Join(sslModes, ", "))
+	}
+	if c.MaxConnections < 1 {
+		p.add("POSTGRES_MAX_CONNECTIONS must be positive, got %d", c.MaxConnections)
+	}
+	if c.MinConnections < 0 || c.MinConnections > c.MaxConnections {
+		p.add("POSTGRES_MIN_CONNECTIONS must be between 0 and POSTGRES_MAX_CONNECTIONS, got %d", c.MinConnections)
+	}
+	if c.ConnectionTimeout < 1 {
+		p.add("POSTGRES_CONNECTION_TIMEOUT must be positive, got %d", c.ConnectionTimeout)
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.ShardDSNs)) {
+		if _, err := pgconn.ParseConfig(c.ShardDSNs[key]); err != nil {
+			p.add("POSTGRES_SHARD_DSNS: DSN of shardkey %q: %v", key, err)
+		}
+	}
+	for i, dsn := range c.ReplicaDSNs {
+		if _, err := pgconn.ParseConfig(dsn); err != nil {
+			p.add("POSTGRES_REPLICA_DSNS: DSN %d: %v", i+1, err)
+		}
+	}
+}
+
+func checkPort(p *Problems, key string, port int) {
+	if port < 1 || port > 65535 {
+		p.add("%s must be between 1 and 65535, got %d", key, port)
+	}
+}
+
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// checkURL requires value to be a URL with a host and one of schemes.
+func checkURL(p *Problems, key, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || !slices.Contains(schemes, u.Scheme) {
+		p.add("%s: %q is not a %s:// URL", key, value, strings.Join(schemes, ":// or "))
+	}
+}
+
+// Tunables are the settings a running service applies when they change; the
+// others need a restart.
+type Tunables struct {
+	LogLevel          string
+	CacheCapacity     int
+	CacheTTL          time.Duration
+	CacheRefreshAfter time.Duration
+	CacheWarmInterval time.Duration
+	RateLimit         int
+	RateBurst         int
+	APIKeyRateLimits  map[string]int
+}
+
+// Tunables returns the tunable settings of c.
+func (c *Config) Tunables() Tunables {
+	return Tunables{
+		LogLevel:          c.Log.Level,
+		CacheCapacity:     c.Cache.Capacity,
+		CacheTTL:          c.Cache.TTL,
+		CacheRefreshAfter: c.Cache.RefreshAfter,
+		CacheWarmInterval: c.Cache.WarmInterval,
+		RateLimit:         c.Server.RateLimit,
+		RateBurst:         c.Server.RateBurst,
+		APIKeyRateLimits:  c.Server.APIKeyRateLimits,
+	}
+}
+
+// Addr is the host:port the HTTP server listens on.
+func (c *ServerConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// TLS reports whether the server terminates TLS itself.
+func (c *ServerConfig) TLS() bool {
+	return c.TLSCertFile != ""
+}
+
 func (c *DatabaseConfig) DSN() string {
 	return fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode,
+		dsnValue(c.Host), c.Port, dsnValue(c.User), dsnValue(c.Password), dsnValue(c.Name), dsnValue(c.SSLMode),
 	)
 }
+
+// dsnValue quotes v for a keyword/value DSN, so generated passwords may hold
+// spaces, quotes and backslashes.
+func dsnValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
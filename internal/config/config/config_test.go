@@ -0,0 +1,162 @@
+package config
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() *Config {
+	return &Config{
+		Broker: BrokerKafka,
+		Kafka: KafkaConfig{
+			Brokers: []string{"kafka:9092"}, Topic: "orders", Group: "order_service", DLQTopic: "orders.dlq",
+			Workers: 1, BatchSize: 100, StartOffset: KafkaStartEarliest,
+		},
+		Server: ServerConfig{Host: "0.0.0.0", Port: 8080, ShutdownTimeout: time.Second, OrderUIDMaxLength: 64},
+		Log:    LogConfig{Level: "info"},
+		Cache:  CacheConfig{Backend: CacheBackendMemory, Capacity: 10, Shards: 1, WarmCount: 10, WarmTimeout: time.Second},
+		Database: DatabaseConfig{
+			Host: "db", Port: 5432, User: "user", Name: "orders", SSLMode: "disable",
+			MaxConnections: 10, ConnectionTimeout: 5,
+		},
+		Webhook: WebhookConfig{Timeout: time.Second, Attempts: 1, Queue: 1, Workers: 1},
+	}
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	require.NoError(t, validConfig().Validate())
+
+	c := validConfig()
+	c.Kafka.Brokers = []string{"kafka:9092", "kafka"}
+	c.Database.Port = 70000
+	c.Database.User = ""
+	c.Database.SSLMode = "on"
+	c.Log.Level = "loud"
+	err := c.Validate()
+	require.Equal(t, Problems{
+		`LOG_LEVEL: "loud" is not one of debug, info, warn, error`,
+		"POSTGRES_USER is required",
+		"POSTGRES_PORT must be between 1 and 65535, got 70000",
+		`POSTGRES_SSLMODE: "on" is not one of disable, allow, prefer, require, verify-ca, verify-full`,
+		`KAFKA_BROKERS: "kafka" is not a host:port address`,
+	}, err)
+
+	c = validConfig()
+	c.Broker = BrokerNATS
+	c.NATS.URL = "localhost:4222"
+	require.EqualError(t, c.Validate(), `NATS_URL: "localhost:4222" is not a nats:// or tls:// URL`)
+
+	c = validConfig()
+	c.Cache.Backend = CacheBackendLayered
+	c.Cache.Redis = RedisConfig{Addr: "redis", Timeout: time.Second, PoolSize: 1}
+	require.Equal(t, Problems{
+		`CACHE_REDIS_ADDR: "redis" is not a host:port address`,
+		"CACHE_TTL is required with CACHE_BACKEND=layered",
+	}, c.Validate())
+
+	c = validConfig()
+	c.Kafka.Workers = 0
+	c.Kafka.StartOffset = "newest"
+	c.Kafka.RetryTopics = []string{"orders.retry", "orders.dlq"}
+	c.Kafka.TLSCertFile = "client.pem"
+	require.Equal(t, Problems{
+		"KAFKA_WORKERS and KAFKA_BATCH_SIZE must be positive, got 0 and 100",
+		`KAFKA_START_OFFSET: "newest" is not earliest or latest`,
+		"KAFKA_RETRY_DELAY must be positive with KAFKA_RETRY_TOPICS, got 0s",
+		`KAFKA_RETRY_TOPICS: "orders.dlq" is used twice or is KAFKA_TOPIC or KAFKA_DLQ_TOPIC`,
+		"KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE must be set together",
+		"KAFKA_TLS_CA_FILE and KAFKA_TLS_CERT_FILE need KAFKA_TLS=true",
+	}, c.Validate())
+}
+
+func TestLoader_CollectsMalformedSettings(t *testing.T) {
+	t.Setenv("BACKEND_PORT", "eighty")
+	t.Setenv("BACKEND_PREFORK", "maybe")
+	t.Setenv("ORDERS_BACKEND_READ_TIMEOUT", "10")
+	t.Setenv("BACKEND_READ_TIMEOUT", "10s")
+
+	l := &loader{}
+	require.Zero(t, l.mustGetEnvInt("BACKEND_PORT"))
+	require.False(t, l.getEnvBool("BACKEND_PREFORK", false))
+	require.Equal(t, 10*time.Second, l.getEnvDuration("BACKEND_READ_TIMEOUT", 10*time.Second))
+	require.Empty(t, l.mustGetEnv("BACKEND_HOST_UNSET"))
+	require.Equal(t, Problems{
+		`BACKEND_PORT: "eighty" is not an integer`,
+		`BACKEND_PREFORK: "maybe" is not a boolean (true or false)`,
+		`BACKEND_READ_TIMEOUT: "10" is not a duration such as 10s or 2m`,
+		"BACKEND_HOST_UNSET is required",
+	}, l.problems)
+	require.Equal(t, "BACKEND_PORT", problemKey(l.problems[0]))
+}
+
+func TestLoader_OverridesWin(t *testing.T) {
+	t.Setenv("ORDERS_LOG_LEVEL", "warn")
+	t.Setenv("BACKEND_PORT", "8080")
+
+	l := &loader{
+		overrides: map[string]string{"LOG_LEVEL": "debug", "BACKEND_PORT": "9090"},
+		file:      map[string]string{"LOG_LEVEL": "info", "BACKEND_HOST": "file-host"},
+	}
+	require.Equal(t, "debug", l.mustGetEnv("LOG_LEVEL"))
+	require.Equal(t, 9090, l.mustGetEnvInt("BACKEND_PORT"))
+	require.Equal(t, "file-host", l.mustGetEnv("BACKEND_HOST"))
+	require.Empty(t, l.problems)
+}
+
+func TestLoader_Precedence(t *testing.T) {
+	t.Setenv("ORDERS_POSTGRES_HOST", "env-prefixed")
+	t.Setenv("POSTGRES_HOST", "env")
+	t.Setenv("POSTGRES_USER", "env-user")
+	t.Setenv("ORDERS_POSTGRES_USER", "")
+	t.Setenv("POSTGRES_DB", "")
+	t.Setenv("ORDERS_LOG_LEVEL", "")
+
+	l := &loader{
+		overrides: map[string]string{"POSTGRES_PORT": "", "BACKEND_HOST": "flag-host"},
+		file: map[string]string{
+			"ORDERS_POSTGRES_HOST": "file-prefixed", "POSTGRES_HOST": "file",
+			"POSTGRES_PORT": "6543", "POSTGRES_DB": "file-db", "BACKEND_HOST": "file-host",
+			"ORDERS_POSTGRES_SSLMODE": "require", "POSTGRES_SSLMODE": "disable",
+		},
+	}
+	require.Equal(t, "flag-host", l.mustGetEnv("BACKEND_HOST"))
+	require.Equal(t, "env-prefixed", l.mustGetEnv("POSTGRES_HOST"))
+	require.Equal(t, "require", l.mustGetEnv("POSTGRES_SSLMODE"))
+	// empty overrides fall through instead of hiding a required value
+	require.Equal(t, "env-user", l.mustGetEnv("POSTGRES_USER"))
+	require.Equal(t, 6543, l.mustGetEnvInt("POSTGRES_PORT"))
+	require.Equal(t, "file-db", l.mustGetEnv("POSTGRES_DB"))
+	require.Equal(t, "info", l.getEnv("LOG_LEVEL", "info"))
+	require.Empty(t, l.problems)
+}
+
+func TestLoader_ResolvesSecrets(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data":{"data":{"password":"it's a pa55"},"metadata":{}}}`)
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("POSTGRES_PASSWORD", "vault://secret/data/orders#password")
+	t.Setenv("KAFKA_SASL_PASSWORD", "vault://secret/data/orders#missing")
+	t.Setenv("KAFKA_SASL_USERNAME", "plain-user")
+
+	l := &loader{}
+	password := l.mustGetSecret("POSTGRES_PASSWORD")
+	require.Equal(t, "it's a pa55", password)
+	require.Equal(t, "plain-user", l.getSecret("KAFKA_SASL_USERNAME", ""))
+	require.Empty(t, l.getSecret("KAFKA_SASL_PASSWORD", ""))
+	require.Equal(t, Problems{
+		`KAFKA_SASL_PASSWORD: vault://secret/data/orders#missing: secret has no key "missing"`,
+	}, l.problems)
+
+	dsn := (&DatabaseConfig{Host: "db", Port: 5432, User: "orders", Password: password, Name: "orders", SSLMode: "disable"}).DSN()
+	cfg, err := pgconn.ParseConfig(dsn)
+	require.NoError(t, err)
+	require.Equal(t, password, cfg.Password)
+}
@@ -0,0 +1,137 @@
+// Package reload applies changes to the tunable settings (config.Tunables) of
+// a running service: on SIGHUP, and every config.Config.ReloadInterval when
+// the env file has changed, it loads the configuration again and hands the
+// old and new tunables to every subscriber. A configuration that fails to
+// load or validate is logged and ignored; the service keeps the settings it
+// has. Changes to other settings wait for a restart.
+package reload
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+)
+
+// Subscriber applies what changed from old to new. It runs on the reloading
+// goroutine, after the subscribers before it.
+type Subscriber func(old, new config.Tunables)
+
+// Reloader reloads the configuration from a config.Source.
+type Reloader struct {
+	src  config.Source
+	path string
+	log  logger.InterfaceLogger
+	load func(src config.Source) (*config.Config, error)
+
+	mu      sync.Mutex
+	current config.Tunables
+	modTime time.Time
+	subs    []Subscriber
+}
+
+// New returns a Reloader starting from the tunables of cfg, which was loaded
+// from src. Settings src overrides keep their value.
+func New(cfg *config.Config, src config.Source, log logger.InterfaceLogger) *Reloader {
+	r := &Reloader{src: src, path: src.EnvFile(), log: log, load: config.Load, current: cfg.Tunables()}
+	r.modTime, _ = modTime(r.path)
+	return r
+}
+
+// Subscribe adds s to the subscribers of later reloads.
+func (r *Reloader) Subscribe(s Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = append(r.subs, s)
+}
+
+// Current returns the tunables in effect.
+func (r *Reloader) Current() config.Tunables {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the configuration and, if its tunables differ from the
+// current ones, hands both to the subscribers. It reports whether they
+// differed.
+func (r *Reloader) Reload() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modTime, _ = modTime(r.path)
+	cfg, err := r.load(r.src)
+	if err != nil {
+		return false, err
+	}
+	next := cfg.Tunables()
+	if reflect.DeepEqual(next, r.current) {
+		return false, nil
+	}
+	old := r.current
+	r.current = next
+	for _, s := range r.subs {
+		s(old, next)
+	}
+	return true, nil
+}
+
+// Run reloads on every signal from hup and, with a positive interval,
+// whenever the env file's modification time changes, until ctx is done.
+func (r *Reloader) Run(ctx context.Context, hup <-chan os.Signal, interval time.Duration) error {
+	var poll <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		poll = t.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-hup:
+			r.logReload("SIGHUP")
+		case <-poll:
+			if r.changed() {
+				r.logReload(r.path + " changed")
+			}
+		}
+	}
+}
+
+func (r *Reloader) changed() bool {
+	t, err := modTime(r.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		r.log.Warnf("reload: %v", err)
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !t.Equal(r.modTime)
+}
+
+func (r *Reloader) logReload(cause string) {
+	changed, err := r.Reload()
+	switch {
+	case err != nil:
+		r.log.Errorf("reload (%s): keeping the current settings: %v", cause, err)
+	case changed:
+		r.log.Infof("reload (%s): settings applied", cause)
+	default:
+		r.log.Infof("reload (%s): no tunable setting changed", cause)
+	}
+}
+
+// modTime is the zero time for a missing file.
+func modTime(path string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
@@ -0,0 +1,101 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// baseEnv is the smallest env file config.Load accepts.
+const baseEnv = `BACKEND_HOST=0.0.0.0
+BACKEND_PORT=8080
+LOG_FILE=app.log
+LOG_TO_CONSOLE=false
+POSTGRES_HOST=db
+POSTGRES_PORT=5432
+POSTGRES_USER=user
+POSTGRES_PASSWORD=secret
+POSTGRES_DB=orders
+POSTGRES_SSLMODE=disable
+POSTGRES_MAX_CONNECTIONS=10
+POSTGRES_CONNECTION_TIMEOUT=5
+KAFKA_BROKERS=kafka:9092
+KAFKA_TOPIC=orders
+KAFKA_GROUP=order_service
+`
+
+func writeEnv(t *testing.T, path string, extra ...string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(baseEnv+strings.Join(extra, "\n")+"\n"), 0o600))
+}
+
+func TestReloader_NotifiesChangedTunables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	writeEnv(t, path, "LOG_LEVEL=info", "CACHE_CAPACITY=10")
+	cfg, err := config.Load(config.Source{Path: path})
+	require.NoError(t, err)
+
+	r := New(cfg, config.Source{Path: path}, nil)
+	var got [][2]config.Tunables
+	r.Subscribe(func(old, new config.Tunables) { got = append(got, [2]config.Tunables{old, new}) })
+
+	changed, err := r.Reload()
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Empty(t, got)
+
+	writeEnv(t, path, "LOG_LEVEL=debug", "CACHE_CAPACITY=500", "CACHE_TTL=10m")
+	changed, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Len(t, got, 1)
+	require.Equal(t, "info", got[0][0].LogLevel)
+	require.Equal(t, "debug", got[0][1].LogLevel)
+	require.Equal(t, 500, got[0][1].CacheCapacity)
+	require.Zero(t, got[0][0].CacheTTL)
+	require.Equal(t, 10*time.Minute, got[0][1].CacheTTL)
+
+	// a broken file changes nothing
+	writeEnv(t, path, "LOG_LEVEL=loud")
+	_, err = r.Reload()
+	require.ErrorContains(t, err, "LOG_LEVEL")
+	require.Equal(t, "debug", r.Current().LogLevel)
+	require.Len(t, got, 1)
+}
+
+func TestReloader_RunReloadsOnSignalAndFileChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	writeEnv(t, path, "LOG_LEVEL=info")
+	cfg, err := config.Load(config.Source{Path: path})
+	require.NoError(t, err)
+	r := New(cfg, config.Source{Path: path}, log)
+	levels := make(chan string, 2)
+	r.Subscribe(func(_, new config.Tunables) { levels <- new.LogLevel })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hup := make(chan os.Signal, 1)
+	go func() { _ = r.Run(ctx, hup, 10*time.Millisecond) }()
+
+	writeEnv(t, path, "LOG_LEVEL=warn")
+	// the poll may see the change before the signal does
+	hup <- os.Interrupt
+	require.Equal(t, "warn", <-levels)
+
+	later := time.Now().Add(time.Minute)
+	writeEnv(t, path, "LOG_LEVEL=error")
+	require.NoError(t, os.Chtimes(path, later, later))
+	require.Equal(t, "error", <-levels)
+}
@@ -0,0 +1,125 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// aws reads secret id, a name or an ARN, with GetSecretValue of AWS Secrets
+// Manager, signing the request with Signature Version 4.
+func (r *Resolver) aws(ctx context.Context, id string) (string, error) {
+	region := r.lookup("AWS_REGION")
+	if parts := strings.Split(id, ":"); len(parts) > 3 && parts[0] == "arn" {
+		region = parts[3]
+	}
+	if region == "" {
+		region = r.lookup("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		return "", errors.New("AWS_REGION is not set")
+	}
+	keyID, secretKey := r.lookup("AWS_ACCESS_KEY_ID"), r.lookup("AWS_SECRET_ACCESS_KEY")
+	if keyID == "" || secretKey == "" {
+		return "", errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+
+	endpoint := r.awsEndpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if token := r.lookup("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signV4(req, body, keyID, secretKey, region, "secretsmanager", r.now().UTC().Format("20060102T150405Z"))
+
+	var out struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := r.do(req, &out); err != nil {
+		return "", err
+	}
+	if out.SecretString == "" && out.SecretBinary != "" {
+		b, err := base64.StdEncoding.DecodeString(out.SecretBinary)
+		return string(b), err
+	}
+	return out.SecretString, nil
+}
+
+// signV4 adds the X-Amz-Date and Authorization headers of Signature Version
+// 4 to req, signing every header it has.
+func signV4(req *http.Request, body []byte, keyID, secretKey, region, service, amzDate string) {
+	req.Header.Set("X-Amz-Date", amzDate)
+	date := amzDate[:8]
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	var canonical strings.Builder
+	for _, k := range names {
+		canonical.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	request := strings.Join([]string{
+		req.Method, path, canonicalQuery(req.URL.Query()), canonical.String(), signed, hexSHA256(body),
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(request))
+	signature := hex.EncodeToString(hmacSHA256(signingKey(secretKey, date, region, service), toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+keyID+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+}
+
+func signingKey(secretKey, date, region, service string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secretKey), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, service)
+	return hmacSHA256(k, "aws4_request")
+}
+
+func canonicalQuery(q url.Values) string {
+	// Encode sorts by key; SigV4 wants %20 rather than +
+	return strings.ReplaceAll(q.Encode(), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
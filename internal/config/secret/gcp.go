@@ -0,0 +1,58 @@
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// gcp accesses a version of a GCP Secret Manager secret; name is
+// "projects/<project>/secrets/<secret>", ending in "/versions/<version>"
+// for another version than latest.
+func (r *Resolver) gcp(ctx context.Context, name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	token, err := r.gcpToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.gcpEndpoint+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	var out struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := r.do(req, &out); err != nil {
+		return "", err
+	}
+	b, err := base64.StdEncoding.DecodeString(out.Payload.Data)
+	return string(b), err
+}
+
+// gcpToken returns GCP_ACCESS_TOKEN or else the token of the service account
+// the instance runs as.
+func (r *Resolver) gcpToken(ctx context.Context) (string, error) {
+	if token := r.lookup("GCP_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		r.gcpMetadata+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var out struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := r.do(req, &out); err != nil {
+		return "", err
+	}
+	return out.AccessToken, nil
+}
@@ -0,0 +1,195 @@
+// Package secret resolves secret references in configuration values, so
+// passwords and credentials can stay in a secret manager instead of the env
+// file.
+//
+// A reference is "<scheme>://<path>[#<key>]":
+//
+//	vault://secret/data/orders#password        HashiCorp Vault, GET $VAULT_ADDR/v1/<path>
+//	awssm://prod/orders/db#password            AWS Secrets Manager, secret ID or ARN
+//	gcpsm://projects/p/secrets/orders-db#password  GCP Secret Manager, version latest unless given
+//
+// The key picks a field of a secret that holds a JSON object; without it the
+// whole secret is the value. Vault secrets are always objects, so their
+// references need a key. Any other value is not a reference and is used as
+// it is.
+//
+// The providers are configured with their usual variables: VAULT_ADDR,
+// VAULT_TOKEN and VAULT_NAMESPACE; AWS_REGION (or AWS_DEFAULT_REGION),
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and
+// AWS_ENDPOINT_URL_SECRETS_MANAGER; GCP_ACCESS_TOKEN, without which the token
+// of the instance service account is fetched from the metadata server.
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	SchemeVault = "vault"
+	SchemeAWS   = "awssm"
+	SchemeGCP   = "gcpsm"
+)
+
+// requestTimeout bounds every call to a secret manager.
+const requestTimeout = 10 * time.Second
+
+// Ref is a parsed secret reference.
+type Ref struct {
+	Scheme string
+	Path   string
+	Key    string
+}
+
+func (r Ref) String() string {
+	s := r.Scheme + "://" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+	return s
+}
+
+// Parse parses value as a reference; ok is false for any other value.
+func Parse(value string) (ref Ref, ok bool) {
+	scheme, rest, found := strings.Cut(value, "://")
+	if !found {
+		return Ref{}, false
+	}
+	switch scheme {
+	case SchemeVault, SchemeAWS, SchemeGCP:
+	default:
+		return Ref{}, false
+	}
+	ref.Scheme = scheme
+	ref.Path, ref.Key, _ = strings.Cut(rest, "#")
+	return ref, true
+}
+
+// IsRef reports whether value is a secret reference.
+func IsRef(value string) bool {
+	_, ok := Parse(value)
+	return ok
+}
+
+// Resolver fetches referenced secrets. It keeps every secret it fetched, so
+// several keys of one secret cost one call; use a Resolver per load.
+type Resolver struct {
+	lookup  func(key string) string
+	client  *http.Client
+	now     func() time.Time
+	fetched map[string]string
+
+	// endpoints, overridden in tests
+	awsEndpoint string
+	gcpEndpoint string
+	gcpMetadata string
+}
+
+// NewResolver returns a Resolver reading the provider settings with lookup,
+// which returns "" for unset variables.
+func NewResolver(lookup func(key string) string) *Resolver {
+	return &Resolver{
+		lookup:      lookup,
+		client:      &http.Client{Timeout: requestTimeout},
+		now:         time.Now,
+		fetched:     map[string]string{},
+		awsEndpoint: lookup("AWS_ENDPOINT_URL_SECRETS_MANAGER"),
+		gcpEndpoint: "https://secretmanager.googleapis.com",
+		gcpMetadata: "http://metadata.google.internal",
+	}
+}
+
+// Resolve returns the secret value references, or value itself if it is
+// not a reference.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := Parse(value)
+	if !ok {
+		return value, nil
+	}
+	if ref.Path == "" {
+		return "", fmt.Errorf("%s: no secret path", ref)
+	}
+	if ref.Scheme == SchemeVault && ref.Key == "" {
+		return "", fmt.Errorf("%s: a vault reference needs a #key", ref)
+	}
+	doc, err := r.fetch(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	if ref.Key == "" {
+		return doc, nil
+	}
+	v, err := field(doc, ref.Key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	return v, nil
+}
+
+func (r *Resolver) fetch(ctx context.Context, ref Ref) (string, error) {
+	id := ref.Scheme + "://" + ref.Path
+	if doc, ok := r.fetched[id]; ok {
+		return doc, nil
+	}
+	var (
+		doc string
+		err error
+	)
+	switch ref.Scheme {
+	case SchemeVault:
+		doc, err = r.vault(ctx, ref.Path)
+	case SchemeAWS:
+		doc, err = r.aws(ctx, ref.Path)
+	case SchemeGCP:
+		doc, err = r.gcp(ctx, ref.Path)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.fetched[id] = doc
+	return doc, nil
+}
+
+// field returns key of the JSON object doc; values that are not strings are
+// returned as JSON.
+func field(doc, key string) (string, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(doc), &obj); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, so it has no key %q", key)
+	}
+	raw, ok := obj[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no key %q", key)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	return string(raw), nil
+}
+
+// do sends req and decodes a 2xx JSON answer into out.
+func (r *Resolver) do(req *http.Request, out any) error {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// error bodies describe the failure, never the secret
+		return fmt.Errorf("answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode answer: %w", err)
+	}
+	return nil
+}
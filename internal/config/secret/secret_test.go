@@ -0,0 +1,121 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lookupIn(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestParse(t *testing.T) {
+	ref, ok := Parse("awssm://arn:aws:secretsmanager:eu-west-1:123:secret:db#password")
+	require.True(t, ok)
+	require.Equal(t, Ref{Scheme: SchemeAWS, Path: "arn:aws:secretsmanager:eu-west-1:123:secret:db", Key: "password"}, ref)
+
+	for _, v := range []string{"s3cret", "postgres://u:p@host/db", "https://example.com#x", ""} {
+		require.False(t, IsRef(v), v)
+	}
+}
+
+func TestResolve_Vault(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, "/v1/secret/data/orders", r.URL.Path)
+		require.Equal(t, "root", r.Header.Get("X-Vault-Token"))
+		_, _ = io.WriteString(w, `{"data":{"data":{"user":"orders","password":"pa55","port":5432},"metadata":{"version":3}}}`)
+	}))
+	defer srv.Close()
+
+	r := NewResolver(lookupIn(map[string]string{"VAULT_ADDR": srv.URL + "/", "VAULT_TOKEN": "root"}))
+	v, err := r.Resolve(context.Background(), "vault://secret/data/orders#password")
+	require.NoError(t, err)
+	require.Equal(t, "pa55", v)
+	v, err = r.Resolve(context.Background(), "vault://secret/data/orders#port")
+	require.NoError(t, err)
+	require.Equal(t, "5432", v)
+	require.Equal(t, 1, calls)
+
+	_, err = r.Resolve(context.Background(), "vault://secret/data/orders#missing")
+	require.EqualError(t, err, `vault://secret/data/orders#missing: secret has no key "missing"`)
+	_, err = r.Resolve(context.Background(), "vault://secret/data/orders")
+	require.EqualError(t, err, "vault://secret/data/orders: a vault reference needs a #key")
+
+	v, err = r.Resolve(context.Background(), "plain")
+	require.NoError(t, err)
+	require.Equal(t, "plain", v)
+}
+
+func TestResolve_AWS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		require.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKID/20250101/eu-west-1/secretsmanager/aws4_request, "))
+		var in struct{ SecretId string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		require.Equal(t, "prod/orders", in.SecretId)
+		_, _ = io.WriteString(w, `{"SecretString":"{\"password\":\"pa55\"}"}`)
+	}))
+	defer srv.Close()
+
+	r := NewResolver(lookupIn(map[string]string{
+		"AWS_REGION": "eu-west-1", "AWS_ACCESS_KEY_ID": "AKID", "AWS_SECRET_ACCESS_KEY": "key",
+		"AWS_SESSION_TOKEN": "token", "AWS_ENDPOINT_URL_SECRETS_MANAGER": srv.URL,
+	}))
+	r.now = func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC) }
+	v, err := r.Resolve(context.Background(), "awssm://prod/orders#password")
+	require.NoError(t, err)
+	require.Equal(t, "pa55", v)
+	v, err = r.Resolve(context.Background(), "awssm://prod/orders")
+	require.NoError(t, err)
+	require.Equal(t, `{"password":"pa55"}`, v)
+}
+
+// TestSignV4 checks the signature against the example of the AWS Signature
+// Version 4 documentation.
+func TestSignV4(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signV4(req, nil, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "iam", "20150830T123600Z")
+	require.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7", req.Header.Get("Authorization"))
+}
+
+func TestResolve_GCP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			require.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_, _ = io.WriteString(w, `{"access_token":"ya29","expires_in":3599}`)
+		case "/v1/projects/p/secrets/db/versions/latest:access":
+			require.Equal(t, "Bearer ya29", r.Header.Get("Authorization"))
+			_, _ = io.WriteString(w, `{"payload":{"data":"cGE1NQ=="}}`) // pa55
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":{"code":404}}`)
+		}
+	}))
+	defer srv.Close()
+
+	r := NewResolver(lookupIn(nil))
+	r.gcpEndpoint, r.gcpMetadata = srv.URL, srv.URL
+	v, err := r.Resolve(context.Background(), "gcpsm://projects/p/secrets/db")
+	require.NoError(t, err)
+	require.Equal(t, "pa55", v)
+
+	_, err = r.Resolve(context.Background(), "gcpsm://projects/p/secrets/other/versions/2")
+	require.EqualError(t, err, `gcpsm://projects/p/secrets/other/versions/2: answered 404 Not Found: {"error":{"code":404}}`)
+}
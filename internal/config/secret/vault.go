@@ -0,0 +1,41 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// vault reads path with the Vault HTTP API and returns its data as a JSON
+// object. KV version 2 paths include "data/", e.g. "secret/data/orders".
+func (r *Resolver) vault(ctx context.Context, path string) (string, error) {
+	addr := r.lookup("VAULT_ADDR")
+	if addr == "" {
+		return "", errors.New("VAULT_ADDR is not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if token := r.lookup("VAULT_TOKEN"); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if ns := r.lookup("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	var out struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := r.do(req, &out); err != nil {
+		return "", err
+	}
+	data := out.Data
+	// KV version 2 nests the secret under data.data, next to its metadata
+	if inner, ok := data["data"]; ok && len(data) == 2 && data["metadata"] != nil {
+		return string(inner), nil
+	}
+	b, err := json.Marshal(data)
+	return string(b), err
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// maxBindParams is the PostgreSQL limit on parameters in one statement.
+const maxBindParams = 65535
+
+const (
+	qInsOrders = `
+INSERT INTO orders (order_uid, track_number, entry, locale, internal_signature, customer_id,
+                    delivery_service, shardkey, sm_id, date_created, oof_shard, version, tenant_id, status, content_hash,
+                    warnings)
+VALUES %s
+ON CONFLICT (order_uid) DO UPDATE SET
+  track_number=EXCLUDED.track_number,
+  entry=EXCLUDED.entry,
+  locale=EXCLUDED.locale,
+  internal_signature=EXCLUDED.internal_signature,
+  customer_id=EXCLUDED.customer_id,
+  delivery_service=EXCLUDED.delivery_service,
+  shardkey=EXCLUDED.shardkey,
+  sm_id=EXCLUDED.sm_id,
+  date_created=EXCLUDED.date_created,
+  oof_shard=EXCLUDED.oof_shard,
+  version=EXCLUDED.version,
+  content_hash=EXCLUDED.content_hash,
+  warnings=EXCLUDED.warnings
+WHERE orders.version < EXCLUDED.version AND orders.tenant_id = EXCLUDED.tenant_id`
+
+	qInsDeliveries = `
+INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email, tenant_id)
+VALUES %s
+ON CONFLICT (order_uid) DO UPDATE SET
+  name=EXCLUDED.name, phone=EXCLUDED.phone, zip=EXCLUDED.zip, city=EXCLUDED.city,
+  address=EXCLUDED.address, region=EXCLUDED.region, email=EXCLUDED.email`
+
+	qInsPayments = `
+INSERT INTO payments (order_uid, transaction, request_id, currency, provider,
+                      amount, payment_dt, bank, delivery_cost, goods_total, custom_fee, tenant_id)
+VALUES %s
+ON CONFLICT (order_uid) DO UPDATE SET
+  transaction=EXCLUDED.transaction, request_id=EXCLUDED.request_id,
+  currency=EXCLUDED.currency, provider=EXCLUDED.provider, amount=EXCLUDED.amount,
+  payment_dt=EXCLUDED.payment_dt, bank=EXCLUDED.bank,
+  delivery_cost=EXCLUDED.delivery_cost, goods_total=EXCLUDED.goods_total, custom_fee=EXCLUDED.custom_fee`
+
+	qInsItems = `
+INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status, tenant_id)
+VALUES %s`
+
+	qDelItemsAny = `DELETE FROM items WHERE order_uid = ANY($1)`
+
+	qSelVersionsForUpdate = `SELECT order_uid, version, tenant_id, content_hash FROM orders WHERE order_uid = ANY($1) FOR UPDATE`
+)
+
+// UpsertOrders stores many orders in one transaction using multi-row VALUES,
+// with the same semantics as UpsertOrder: child rows are replaced, items fully.
+// If an order_uid occurs more than once, the last occurrence wins. Orders
+// whose version is stale, whose uid is stored for another tenant or whose
+// content is already stored are skipped rather than failing the batch; the
+// count returned is of the orders written.
+func (o *OrderRepository) UpsertOrders(ctx context.Context, orders []*model.Order) (int, error) {
+	orders = lastByUID(orders)
+	if len(orders) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Batch)
+	defer cancel()
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // no-op if already committed
+
+	hashes := make(map[string][]byte, len(orders))
+	for _, ord := range orders {
+		if hashes[ord.OrderUID], err = contentHash(ord); err != nil {
+			return 0, err
+		}
+	}
+	if orders, err = dropStale(ctx, tx, orders, hashes); err != nil {
+		return 0, err
+	}
+	if len(orders) == 0 {
+		return 0, nil
+	}
+
+	uids := make([]string, len(orders))
+	var orderRows, deliveryRows, paymentRows, itemRows, rawRows, eventRows [][]any
+	for i, ord := range orders {
+		uids[i] = ord.OrderUID
+		tenantID := tenantOf(ctx, ord)
+		orderRows = append(orderRows, []any{
+			ord.OrderUID, ord.TrackNumber, ord.Entry, ord.Locale, ord.InternalSignature,
+			ord.CustomerID, ord.DeliveryService, ord.ShardKey, ord.SmID, ord.DateCreated, ord.OofShard, ord.Version,
+			tenantID, initialStatus(ord), hashes[ord.OrderUID], ord.Warnings,
+		})
+		deliveryRows = append(deliveryRows, []any{
+			ord.OrderUID, ord.Delivery.Name, ord.Delivery.Phone, ord.Delivery.Zip, ord.Delivery.City,
+			ord.Delivery.Address, ord.Delivery.Region, ord.Delivery.Email, tenantID,
+		})
+		paymentRows = append(paymentRows, []any{
+			ord.OrderUID, ord.Payment.Transaction, ord.Payment.RequestID, ord.Payment.Currency,
+			ord.Payment.Provider, ord.Payment.Amount, ord.Payment.PaymentDT, ord.Payment.Bank,
+			ord.Payment.DeliveryCost, ord.Payment.GoodsTotal, ord.Payment.CustomFee, tenantID,
+		})
+		for _, it := range ord.Items {
+			itemRows = append(itemRows, []any{
+				ord.OrderUID, it.ChrtID, it.TrackNumber, it.Price, it.RID, it.Name,
+				it.Sale, it.Size, it.TotalPrice, it.NmID, it.Brand, it.Status, tenantID,
+			})
+		}
+		if len(ord.Raw) > 0 {
+			rawRows = append(rawRows, []any{ord.OrderUID, tenantID, []byte(ord.Raw)})
+		}
+		event, err := upsertedEvent(ord, tenantID)
+		if err != nil {
+			return 0, err
+		}
+		eventRows = append(eventRows, event)
+	}
+
+	if err := snapshotRevisions(ctx, tx, uids); err != nil {
+		return 0, err
+	}
+	if err := execValues(ctx, tx, qInsOrders, orderRows); err != nil {
+		return 0, fmt.Errorf("upsert orders: %w", err)
+	}
+	if err := execValues(ctx, tx, qInsDeliveries, deliveryRows); err != nil {
+		return 0, fmt.Errorf("upsert deliveries: %w", err)
+	}
+	if err := execValues(ctx, tx, qInsPayments, paymentRows); err != nil {
+		return 0, fmt.Errorf("upsert payments: %w", err)
+	}
+	if _, err := tx.Exec(ctx, qDelItemsAny, uids); err != nil {
+		return 0, fmt.Errorf("delete items: %w", err)
+	}
+	if err := execValues(ctx, tx, qInsItems, itemRows); err != nil {
+		return 0, fmt.Errorf("insert items: %w", err)
+	}
+	if err := upsertRaw(ctx, tx, rawRows); err != nil {
+		return 0, err
+	}
+	if err := insertOutboxEvents(ctx, tx, eventRows); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return len(orders), nil
+}
+
+// dropStale locks the stored rows of orders and removes the orders whose
+// version is not newer than the stored one, that are stored for another
+// tenant or whose content hash matches the stored one, so their children
+// aren't rewritten.
+func dropStale(ctx context.Context, tx pgx.Tx, orders []*model.Order, hashes map[string][]byte) ([]*model.Order, error) {
+	uids := make([]string, len(orders))
+	for i, ord := range orders {
+		uids[i] = ord.OrderUID
+	}
+	rows, err := tx.Query(ctx, qSelVersionsForUpdate, uids)
+	if err != nil {
+		return nil, fmt.Errorf("select versions: %w", err)
+	}
+	defer rows.Close()
+
+	type storedRow struct {
+		version  int64
+		tenantID string
+		hash     []byte
+	}
+	stored := make(map[string]storedRow, len(orders))
+	for rows.Next() {
+		var (
+			uid string
+			row storedRow
+		)
+		if err := rows.Scan(&uid, &row.version, &row.tenantID, &row.hash); err != nil {
+			return nil, fmt.Errorf("scan version: %w", err)
+		}
+		stored[uid] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("versions rows: %w", err)
+	}
+
+	fresh := orders[:0:0]
+	for _, ord := range orders {
+		s, ok := stored[ord.OrderUID]
+		if !ok || (s.version < ord.Version && s.tenantID == tenantOf(ctx, ord) && !bytes.Equal(s.hash, hashes[ord.OrderUID])) {
+			fresh = append(fresh, ord)
+		}
+	}
+	return fresh, nil
+}
+
+// execValues runs query (with a %s placeholder for the VALUES list) over rows,
+// splitting into as few statements as the bind parameter limit allows.
+func execValues(ctx context.Context, tx pgx.Tx, query string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	cols := len(rows[0])
+	per := maxBindParams / cols
+	for start := 0; start < len(rows); start += per {
+		chunk := rows[start:min(start+per, len(rows))]
+		args := make([]any, 0, len(chunk)*cols)
+		for _, r := range chunk {
+			args = append(args, r...)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(query, valuesList(len(chunk), cols)), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valuesList renders "($1,$2),($3,$4)" for rows x cols placeholders.
+func valuesList(rows, cols int) string {
+	var b strings.Builder
+	n := 1
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('(')
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString("$" + strconv.Itoa(n))
+			n++
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// lastByUID drops earlier duplicates: ON CONFLICT cannot update the same row
+// twice in one statement.
+func lastByUID(orders []*model.Order) []*model.Order {
+	idx := make(map[string]int, len(orders))
+	out := make([]*model.Order, 0, len(orders))
+	for _, ord := range orders {
+		if i, ok := idx[ord.OrderUID]; ok {
+			out[i] = ord
+			continue
+		}
+		idx[ord.OrderUID] = len(out)
+		out = append(out, ord)
+	}
+	return out
+}
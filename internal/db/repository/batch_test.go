@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValuesList(t *testing.T) {
+	require.Equal(t, "($1,$2,$3),($4,$5,$6)", valuesList(2, 3))
+	require.Equal(t, "($1)", valuesList(1, 1))
+}
+
+func TestLastByUID(t *testing.T) {
+	a1, b, a2 := &model.Order{OrderUID: "a"}, &model.Order{OrderUID: "b"}, &model.Order{OrderUID: "a"}
+	require.Equal(t, []*model.Order{a2, b}, lastByUID([]*model.Order{a1, b, a2}))
+}
+
+func TestUpsertOrders_OneStatementPerTable(t *testing.T) {
+	orders := []*model.Order{
+		{OrderUID: "a", DateCreated: time.Unix(0, 0), Items: []model.Item{{ChrtID: 1}, {ChrtID: 2}}},
+		{OrderUID: "b", DateCreated: time.Unix(0, 0), Items: []model.Item{{ChrtID: 3}}},
+	}
+	calls := recordQueries(t, func(r Repository) error {
+		n, err := r.UpsertOrders(context.Background(), orders)
+		require.Equal(t, 2, n)
+		return err
+	})
+
+	require.Len(t, calls, 8)
+	require.Contains(t, calls[0].query, "FOR UPDATE")
+	require.Contains(t, calls[1].query, "INSERT INTO order_revisions")
+	require.Equal(t, []any{[]string{"a", "b"}}, calls[1].args)
+	require.Contains(t, calls[2].query, "INSERT INTO orders")
+	require.Len(t, calls[2].args, 2*16)
+	require.Equal(t, model.StatusCreated, calls[2].args[13])
+	require.Contains(t, calls[3].query, "INSERT INTO deliveries")
+	require.Contains(t, calls[4].query, "INSERT INTO payments")
+	require.True(t, strings.HasPrefix(calls[5].query, "DELETE FROM items"))
+	require.Contains(t, calls[6].query, "INSERT INTO items")
+	require.Len(t, calls[6].args, 3*13)
+	require.Contains(t, calls[7].query, "INSERT INTO events_outbox")
+	require.Len(t, calls[7].args, 2*5)
+}
+
+func TestUpsertOrder_CopiesItems(t *testing.T) {
+	ord := &model.Order{OrderUID: "a", DateCreated: time.Unix(0, 0), Items: []model.Item{{ChrtID: 1}, {ChrtID: 2}, {ChrtID: 3}}}
+	calls := recordQueries(t, func(r Repository) error {
+		return r.UpsertOrder(context.Background(), ord)
+	})
+
+	var copies []recordedCall
+	for _, c := range calls {
+		require.NotContains(t, c.query, "INSERT INTO items")
+		if strings.HasPrefix(c.query, `COPY "items"`) {
+			copies = append(copies, c)
+		}
+	}
+	// a single COPY carries every item
+	require.Len(t, copies, 1)
+	require.Len(t, copies[0].args, len(ord.Items)*len(itemColumns))
+}
+
+func TestUpsertOrder_WritesTenantOfContext(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "shop-1")
+	ord := &model.Order{OrderUID: "a", DateCreated: time.Unix(0, 0), Items: []model.Item{{ChrtID: 1}}}
+	calls := recordQueries(t, func(r Repository) error {
+		return r.UpsertOrder(ctx, ord)
+	})
+
+	for _, c := range calls {
+		if strings.Contains(c.query, "INSERT INTO orders") {
+			require.Equal(t, []any{"shop-1", model.StatusCreated}, c.args[12:14], c.query)
+		}
+		if strings.HasPrefix(c.query, `COPY "items"`) {
+			require.Equal(t, "shop-1", c.args[len(c.args)-1], c.query)
+		}
+	}
+	require.Equal(t, []any{"a", "shop-1"}, calls[0].args)
+	require.Contains(t, calls[2].query, "orders.tenant_id = EXCLUDED.tenant_id")
+}
+
+func TestReplaceOrder_LocksTheVersionRead(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "shop-1")
+	ord := &model.Order{OrderUID: "a", DateCreated: time.Unix(0, 0), Version: 8}
+	var err error
+	calls := recordQueries(t, func(r Repository) error {
+		err = r.ReplaceOrder(ctx, ord, 7)
+		return err
+	})
+
+	// the recorder stores nothing, so the row to replace is missing
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Len(t, calls, 1)
+	require.Contains(t, calls[0].query, "FOR UPDATE")
+	require.Equal(t, []any{"a", "shop-1"}, calls[0].args)
+}
+
+func TestContentHash_IgnoresVersionTenantAndRaw(t *testing.T) {
+	ord := &model.Order{OrderUID: "a", DateCreated: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), Items: []model.Item{{ChrtID: 1}}}
+	want, err := contentHash(ord)
+	require.NoError(t, err)
+
+	redelivered := *ord
+	redelivered.Version, redelivered.TenantID, redelivered.Raw = 42, "shop-1", []byte(`{"order_uid": "a"}`)
+	redelivered.DateCreated = ord.DateCreated.In(time.FixedZone("MSK", 3*3600))
+	got, err := contentHash(&redelivered)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	redelivered.Items = []model.Item{{ChrtID: 2}}
+	got, err = contentHash(&redelivered)
+	require.NoError(t, err)
+	require.NotEqual(t, want, got)
+}
+
+// storedDB answers the version lookup of UpsertOrders with fixed rows.
+type storedDB struct {
+	*recordingDB
+	rows [][]any
+}
+
+func (d *storedDB) Begin(context.Context) (pgx.Tx, error) {
+	return &storedTx{recordingTx: &recordingTx{d: d.recordingDB}, rows: d.rows}, nil
+}
+
+type storedTx struct {
+	*recordingTx
+	rows [][]any
+}
+
+func (t *storedTx) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	t.d.record(query, args)
+	if query == qSelVersionsForUpdate {
+		return &valueRows{rows: t.rows}, nil
+	}
+	return emptyRows{}, nil
+}
+
+type valueRows struct {
+	emptyRows
+	rows [][]any
+	cur  []any
+}
+
+func (r *valueRows) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	r.cur, r.rows = r.rows[0], r.rows[1:]
+	return true
+}
+
+func (r *valueRows) Scan(dest ...any) error {
+	for i, v := range r.cur {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+func TestUpsertOrders_SkipsUnchangedContent(t *testing.T) {
+	same := &model.Order{OrderUID: "a", DateCreated: time.Unix(0, 0), Version: 2, Items: []model.Item{{ChrtID: 1}}}
+	hash, err := contentHash(same)
+	require.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	db := &storedDB{recordingDB: openRecorder(), rows: [][]any{{"a", int64(1), tenant.Default, hash}}}
+
+	n, err := NewOrderRepository(db, mocks.NewMockInterfaceLogger(ctrl)).UpsertOrders(context.Background(), []*model.Order{same})
+	require.NoError(t, err)
+	require.Zero(t, n)
+	// only the lookup ran: no revision, rows or outbox event for a redelivery
+	calls := db.snapshot()
+	require.Len(t, calls, 1)
+	require.Equal(t, qSelVersionsForUpdate, calls[0].query)
+}
+
+func TestGetOrders_SingleQueryForAllIDs(t *testing.T) {
+	calls := recordQueries(t, func(r Repository) error {
+		orders, err := r.GetOrders(tenant.WithID(context.Background(), "shop-1"), []string{"a", "b", "a"})
+		require.Empty(t, orders)
+		return err
+	})
+
+	// nothing found, so no child tables are queried
+	require.Len(t, calls, 1)
+	require.Contains(t, calls[0].query, "order_uid = ANY($1::varchar[])")
+	require.Equal(t, []any{[]string{"a", "b"}, "shop-1"}, calls[0].args)
+
+	require.Empty(t, recordQueries(t, func(r Repository) error {
+		_, err := r.GetOrders(context.Background(), nil)
+		return err
+	}))
+}
+
+func TestUpsertOrder_SnapshotsBeforeOverwrite(t *testing.T) {
+	calls := recordQueries(t, func(r Repository) error {
+		return r.UpsertOrder(context.Background(), &model.Order{OrderUID: "a", DateCreated: time.Unix(0, 0)})
+	})
+
+	// the prior version is read for the outbox event and captured in the
+	// same transaction, before any write
+	require.Contains(t, calls[0].query, "name: GetOrder")
+	require.Contains(t, calls[1].query, "INSERT INTO order_revisions")
+	require.Contains(t, calls[2].query, "INSERT INTO orders")
+}
+
+func TestExpireOrders_ArchivesBeforeDeleting(t *testing.T) {
+	cutoff := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	calls := recordQueries(t, func(r Repository) error {
+		uids, err := r.ExpireOrders(context.Background(), cutoff, 100)
+		require.Empty(t, uids)
+		return err
+	})
+
+	// nothing is old enough, so nothing is archived or deleted
+	require.Len(t, calls, 1)
+	require.Contains(t, calls[0].query, "FOR UPDATE SKIP LOCKED")
+	require.Equal(t, []any{cutoff, 100}, calls[0].args)
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// ErrCircuitOpen is returned without asking the database while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("database circuit breaker is open")
+
+// BreakerPolicy configures BreakerRepository.
+type BreakerPolicy struct {
+	// Threshold is the number of consecutive failed calls that opens the
+	// circuit.
+	Threshold int
+	// Cooldown is how long the circuit stays open before one call is let
+	// through to probe the database.
+	Cooldown time.Duration
+}
+
+// DefaultBreakerPolicy is used for zero fields of the policy passed to NewBreakerRepository.
+var DefaultBreakerPolicy = BreakerPolicy{Threshold: 5, Cooldown: 10 * time.Second}
+
+// BreakerRepository fails calls fast with ErrCircuitOpen once the wrapped
+// repository has failed Threshold times in a row, so requests don't queue up
+// behind timeouts while the database is down. After Cooldown one call probes
+// the database: success closes the circuit, failure keeps it open for another
+// Cooldown.
+//
+// Only Transient errors and timeouts count as failures; answers such as
+// ErrNotFound, and calls the caller gave up on, don't. It wraps
+// RetryRepository, so a call counts once however often it was retried.
+// HealthCheck bypasses the breaker, so readiness reports the database itself.
+type BreakerRepository struct {
+	Repository
+	policy BreakerPolicy
+	logger logger.InterfaceLogger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time // zero while closed
+	probing   bool
+}
+
+var _ Repository = (*BreakerRepository)(nil)
+
+// NewBreakerRepository wraps r; zero policy fields fall back to DefaultBreakerPolicy.
+func NewBreakerRepository(r Repository, policy BreakerPolicy, log logger.InterfaceLogger) *BreakerRepository {
+	if policy.Threshold <= 0 {
+		policy.Threshold = DefaultBreakerPolicy.Threshold
+	}
+	if policy.Cooldown <= 0 {
+		policy.Cooldown = DefaultBreakerPolicy.Cooldown
+	}
+	return &BreakerRepository{Repository: r, policy: policy, logger: log, now: time.Now}
+}
+
+// Open reports whether calls are currently failed fast.
+func (b *BreakerRepository) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero()
+}
+
+// allow admits a call, or the single probe once an open circuit has cooled down.
+func (b *BreakerRepository) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return false, nil
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false, ErrCircuitOpen
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record counts the outcome of a call allow admitted.
+func (b *BreakerRepository) record(ctx context.Context, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if ctx.Err() != nil {
+		return // says nothing about the database
+	}
+	failed := Transient(err) || errors.Is(err, context.DeadlineExceeded)
+	switch {
+	case probe && !failed:
+		b.failures, b.openUntil = 0, time.Time{}
+		b.logger.WithContext(ctx).Info("database circuit breaker closed")
+	case probe:
+		b.openUntil = b.now().Add(b.policy.Cooldown)
+		b.logger.WithContext(ctx).Warnf("database circuit breaker stays open for %s: %v", b.policy.Cooldown, err)
+	case !b.openUntil.IsZero():
+		// admitted before the circuit opened
+	case !failed:
+		b.failures = 0
+	default:
+		b.failures++
+		if b.failures >= b.policy.Threshold {
+			b.openUntil = b.now().Add(b.policy.Cooldown)
+			b.logger.WithContext(ctx).Errorf("database circuit breaker open for %s after %d failures: %v", b.policy.Cooldown, b.failures, err)
+		}
+	}
+}
+
+func guard[T any](ctx context.Context, b *BreakerRepository, fn func() (T, error)) (T, error) {
+	probe, err := b.allow()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	res, err := fn()
+	b.record(ctx, probe, err)
+	return res, err
+}
+
+// guardErr is guard for methods that only return an error.
+func guardErr(ctx context.Context, b *BreakerRepository, fn func() error) error {
+	_, err := guard(ctx, b, func() (struct{}, error) { return struct{}{}, fn() })
+	return err
+}
+
+func (b *BreakerRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
+	return guard(ctx, b, func() (*model.Order, error) { return b.Repository.GetOrder(ctx, id) })
+}
+
+func (b *BreakerRepository) GetPrimaryOrder(ctx context.Context, id string) (*model.Order, error) {
+	return guard(ctx, b, func() (*model.Order, error) { return b.Repository.GetPrimaryOrder(ctx, id) })
+}
+
+func (b *BreakerRepository) GetRawPayload(ctx context.Context, id string) ([]byte, error) {
+	return guard(ctx, b, func() ([]byte, error) { return b.Repository.GetRawPayload(ctx, id) })
+}
+
+func (b *BreakerRepository) GetOrders(ctx context.Context, ids []string) ([]*model.Order, error) {
+	return guard(ctx, b, func() ([]*model.Order, error) { return b.Repository.GetOrders(ctx, ids) })
+}
+
+func (b *BreakerRepository) GetRecent(ctx context.Context, limit int) ([]*model.Order, error) {
+	return guard(ctx, b, func() ([]*model.Order, error) { return b.Repository.GetRecent(ctx, limit) })
+}
+
+func (b *BreakerRepository) UpsertOrder(ctx context.Context, o *model.Order) error {
+	return guardErr(ctx, b, func() error { return b.Repository.UpsertOrder(ctx, o) })
+}
+
+func (b *BreakerRepository) ReplaceOrder(ctx context.Context, o *model.Order, current int64) error {
+	return guardErr(ctx, b, func() error { return b.Repository.ReplaceOrder(ctx, o, current) })
+}
+
+func (b *BreakerRepository) UpsertOrders(ctx context.Context, orders []*model.Order) (int, error) {
+	return guard(ctx, b, func() (int, error) { return b.Repository.UpsertOrders(ctx, orders) })
+}
+
+func (b *BreakerRepository) OrderHistory(ctx context.Context, id string) ([]model.OrderRevision, error) {
+	return guard(ctx, b, func() ([]model.OrderRevision, error) { return b.Repository.OrderHistory(ctx, id) })
+}
+
+func (b *BreakerRepository) OrderExists(ctx context.Context, id string) (bool, error) {
+	return guard(ctx, b, func() (bool, error) { return b.Repository.OrderExists(ctx, id) })
+}
+
+func (b *BreakerRepository) DeleteOrder(ctx context.Context, id string) error {
+	return guardErr(ctx, b, func() error { return b.Repository.DeleteOrder(ctx, id) })
+}
+
+func (b *BreakerRepository) UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error {
+	return guardErr(ctx, b, func() error { return b.Repository.UpdateStatus(ctx, id, status) })
+}
+
+func (b *BreakerRepository) ArchiveOrder(ctx context.Context, id string) error {
+	return guardErr(ctx, b, func() error { return b.Repository.ArchiveOrder(ctx, id) })
+}
+
+func (b *BreakerRepository) RestoreOrder(ctx context.Context, id string) error {
+	return guardErr(ctx, b, func() error { return b.Repository.RestoreOrder(ctx, id) })
+}
+
+func (b *BreakerRepository) ExpireOrders(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	return guard(ctx, b, func() ([]string, error) { return b.Repository.ExpireOrders(ctx, cutoff, limit) })
+}
+
+func (b *BreakerRepository) AnonymizeCustomer(ctx context.Context, customerID string) ([]string, error) {
+	return guard(ctx, b, func() ([]string, error) { return b.Repository.AnonymizeCustomer(ctx, customerID) })
+}
+
+func (b *BreakerRepository) StreamOrders(ctx context.Context, fn func(batch []*model.Order) error) error {
+	return guardErr(ctx, b, func() error { return b.Repository.StreamOrders(ctx, fn) })
+}
+
+func (b *BreakerRepository) SearchOrders(ctx context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	return guard(ctx, b, func() (*model.SearchPage, error) { return b.Repository.SearchOrders(ctx, query, page) })
+}
+
+func (b *BreakerRepository) FetchUnsentEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	return guard(ctx, b, func() ([]model.OutboxEvent, error) { return b.Repository.FetchUnsentEvents(ctx, limit) })
+}
+
+func (b *BreakerRepository) MarkSent(ctx context.Context, events []model.OutboxEvent) error {
+	return guardErr(ctx, b, func() error { return b.Repository.MarkSent(ctx, events) })
+}
+
+func (b *BreakerRepository) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	return guardErr(ctx, b, func() error { return b.Repository.SaveWebhookDelivery(ctx, d) })
+}
+
+func (b *BreakerRepository) ListOrders(ctx context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	return guard(ctx, b, func() (*model.OrderPage, error) { return b.Repository.ListOrders(ctx, f, page) })
+}
+
+func (b *BreakerRepository) GetOrdersByCustomer(ctx context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	return guard(ctx, b, func() (*model.OrderPage, error) { return b.Repository.GetOrdersByCustomer(ctx, customerID, page) })
+}
+
+func (b *BreakerRepository) OrdersPerDay(ctx context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	return guard(ctx, b, func() ([]model.DailyCount, error) { return b.Repository.OrdersPerDay(ctx, f) })
+}
+
+func (b *BreakerRepository) GoodsByDeliveryService(ctx context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	return guard(ctx, b, func() ([]model.DeliveryServiceTotal, error) { return b.Repository.GoodsByDeliveryService(ctx, f) })
+}
+
+func (b *BreakerRepository) TopCustomers(ctx context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	return guard(ctx, b, func() ([]model.CustomerTotal, error) { return b.Repository.TopCustomers(ctx, f, limit) })
+}
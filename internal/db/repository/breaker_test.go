@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerRepository_OpensAfterConsecutiveFailuresAndProbes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := mocks.NewMockRepository(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().WithContext(gomock.Any()).Return(log).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+	log.EXPECT().Warnf(gomock.Any(), gomock.Any()).Times(1)
+	log.EXPECT().Info(gomock.Any()).Times(1)
+
+	repo := NewBreakerRepository(inner, BreakerPolicy{Threshold: 2, Cooldown: time.Minute}, log)
+	now := time.Date(2025, 8, 18, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+	down := &pgconn.PgError{Code: "08006"}
+
+	// answers are not failures and reset the count
+	gomock.InOrder(
+		inner.EXPECT().GetOrder(gomock.Any(), "a").Return(nil, down),
+		inner.EXPECT().GetOrder(gomock.Any(), "a").Return(nil, ErrNotFound),
+		inner.EXPECT().GetOrder(gomock.Any(), "a").Return(nil, down).Times(2),
+	)
+	for i := 0; i < 4; i++ {
+		_, _ = repo.GetOrder(ctx, "a")
+	}
+	require.True(t, repo.Open())
+
+	// open: nothing reaches the database
+	_, err := repo.GetOrder(ctx, "a")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.ErrorIs(t, repo.DeleteOrder(ctx, "a"), ErrCircuitOpen)
+
+	// a failed probe keeps it open for another cooldown
+	now = now.Add(time.Minute)
+	inner.EXPECT().OrderExists(gomock.Any(), "a").Return(false, down)
+	_, err = repo.OrderExists(ctx, "a")
+	require.ErrorAs(t, err, &down)
+	_, err = repo.OrderExists(ctx, "a")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	// a successful probe closes it
+	now = now.Add(time.Minute)
+	inner.EXPECT().OrderExists(gomock.Any(), "a").Return(true, nil).Times(2)
+	ok, err := repo.OrderExists(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.False(t, repo.Open())
+	_, err = repo.OrderExists(ctx, "a")
+	require.NoError(t, err)
+}
+
+func TestBreakerRepository_IgnoresCallsTheCallerGaveUpOn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := mocks.NewMockRepository(ctrl)
+	repo := NewBreakerRepository(inner, BreakerPolicy{Threshold: 1}, mocks.NewMockInterfaceLogger(ctrl))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inner.EXPECT().GetOrder(gomock.Any(), "a").Return(nil, &pgconn.PgError{Code: "08006"})
+	_, _ = repo.GetOrder(ctx, "a")
+	require.False(t, repo.Open())
+}
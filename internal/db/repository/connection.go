@@ -2,23 +2,67 @@
 package repository
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/merkulovlad/wbtech-go/internal/config/config"
 )
 
-const Driver = "postgres"
+func ConnectDB(cfg *config.DatabaseConfig) (*pgxpool.Pool, error) {
+	return ConnectDSN(cfg.DSN(), cfg)
+}
+
+// ConnectDSN opens and pings a pool for a raw DSN (used for shard databases);
+// pool sizing and timeouts come from cfg.
+func ConnectDSN(dsn string, cfg *config.DatabaseConfig) (*pgxpool.Pool, error) {
+	pc, err := poolConfig(dsn, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if timeout := pc.ConnConfig.ConnectTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, pc)
+	if err != nil {
+		return nil, fmt.Errorf("pgxpool.New: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("pool.Ping: %w", err)
+	}
+	return pool, nil
+}
 
-func ConnectDB(cfg *config.DatabaseConfig) (*sql.DB, error) {
-	dsn := cfg.DSN()
-	db, err := sql.Open(Driver, dsn)
+// poolConfig applies cfg on top of the settings parsed from dsn; zero values
+// leave the DSN (or pgxpool) defaults in place.
+func poolConfig(dsn string, cfg *config.DatabaseConfig) (*pgxpool.Config, error) {
+	pc, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("sql.Open: %w", err)
+		return nil, fmt.Errorf("pgxpool.ParseConfig: %w", err)
+	}
+	if cfg.MaxConnections > 0 {
+		pc.MaxConns = int32(cfg.MaxConnections)
+	}
+	if cfg.MinConnections > 0 {
+		pc.MinConns = int32(cfg.MinConnections)
+	}
+	if timeout := time.Duration(cfg.ConnectionTimeout) * time.Millisecond; timeout > 0 {
+		pc.ConnConfig.ConnectTimeout = timeout
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		pc.MaxConnLifetime = cfg.ConnMaxLifetime
+		// spread expiry so connections opened together are not all
+		// re-dialed in the same instant
+		pc.MaxConnLifetimeJitter = cfg.ConnMaxLifetime / 10
 	}
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("db.Ping: %w", err)
+	if cfg.ConnMaxIdleTime > 0 {
+		pc.MaxConnIdleTime = cfg.ConnMaxIdleTime
 	}
-	return db, nil
+	return pc, nil
 }
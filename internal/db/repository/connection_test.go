@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolConfig(t *testing.T) {
+	const dsn = "postgres://u:p@localhost:5432/db?sslmode=disable"
+
+	pc, err := poolConfig(dsn, &config.DatabaseConfig{
+		MaxConnections:    20,
+		MinConnections:    4,
+		ConnectionTimeout: 1500,
+		ConnMaxLifetime:   30 * time.Minute,
+		ConnMaxIdleTime:   5 * time.Minute,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 20, pc.MaxConns)
+	require.EqualValues(t, 4, pc.MinConns)
+	require.Equal(t, 1500*time.Millisecond, pc.ConnConfig.ConnectTimeout)
+	require.Equal(t, 30*time.Minute, pc.MaxConnLifetime)
+	require.Equal(t, 3*time.Minute, pc.MaxConnLifetimeJitter)
+	require.Equal(t, 5*time.Minute, pc.MaxConnIdleTime)
+
+	// zero values keep the pgxpool defaults
+	def, err := poolConfig(dsn, &config.DatabaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, def.MaxConnLifetime)
+	require.Equal(t, 30*time.Minute, def.MaxConnIdleTime)
+	require.Zero(t, def.MaxConnLifetimeJitter)
+}
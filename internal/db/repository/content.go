@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// contentHash digests what an upsert writes for ord: the order, delivery,
+// payment and items. Version, tenant and status are left out, since a
+// redelivery carries a new version and the status is not overwritten by
+// upserts, and so is the raw payload, which may differ in formatting only.
+func contentHash(ord *model.Order) ([]byte, error) {
+	c := *ord
+	c.Version, c.TenantID, c.Status, c.Raw = 0, "", "", nil
+	c.DateCreated = c.DateCreated.UTC()
+	b, err := json.Marshal(&c)
+	if err != nil {
+		return nil, fmt.Errorf("hash order %s: %w", ord.OrderUID, err)
+	}
+	sum := sha256.Sum256(b)
+	return sum[:], nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+// The personal fields are overwritten with model.Erased; prices, items, dates
+// and the rest of the delivery are left alone so stats stay correct.
+const (
+	qEraseDeliveries = `
+UPDATE deliveries d SET name = $3, phone = $3, email = $3, address = $3
+FROM orders o
+WHERE o.order_uid = d.order_uid AND o.customer_id = $1 AND o.tenant_id = $2
+RETURNING d.order_uid`
+
+	qEraseArchive = `
+UPDATE orders_archive SET snapshot = jsonb_set(snapshot, '{delivery}', coalesce(snapshot->'delivery', '{}') || $3::jsonb)
+WHERE snapshot->>'customer_id' = $1 AND tenant_id = $2
+RETURNING order_uid`
+
+	qEraseRevisions = `
+UPDATE order_revisions SET snapshot = jsonb_set(snapshot, '{delivery}', snapshot->'delivery' || $3::jsonb)
+WHERE order_uid = ANY($1) AND tenant_id = $2 AND snapshot ? 'delivery'`
+
+	qEraseOutbox = `
+UPDATE events_outbox SET payload = jsonb_set(payload, '{delivery}', payload->'delivery' || $3::jsonb)
+WHERE order_uid = ANY($1) AND tenant_id = $2 AND payload ? 'delivery'`
+
+	// raw payloads are arbitrary producer JSON, so they are dropped rather than scrubbed
+	qEraseRaw = `DELETE FROM orders_raw WHERE order_uid = ANY($1) AND tenant_id = $2`
+)
+
+// erasedDelivery is merged over the delivery object of JSON snapshots.
+var erasedDelivery, _ = json.Marshal(map[string]string{
+	"name": model.Erased, "phone": model.Erased, "email": model.Erased, "address": model.Erased,
+})
+
+// AnonymizeCustomer erases the recipient's personal data from every order of
+// customerID, archived and expired ones included: the delivery rows, the
+// revision, archive and outbox snapshots, and the raw payloads. It returns
+// the uids of the orders it touched; none is not an error.
+func (o *OrderRepository) AnonymizeCustomer(ctx context.Context, customerID string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Batch)
+	defer cancel()
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // no-op if already committed
+
+	tenantID := tenant.FromContext(ctx)
+	uids, err := erasedUIDs(ctx, tx, "deliveries", qEraseDeliveries, customerID, tenantID, model.Erased)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := erasedUIDs(ctx, tx, "orders_archive", qEraseArchive, customerID, tenantID, erasedDelivery)
+	if err != nil {
+		return nil, err
+	}
+	uids = append(uids, archived...)
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec(ctx, qEraseRevisions, uids, tenantID, erasedDelivery); err != nil {
+		return nil, fmt.Errorf("erase order_revisions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, qEraseOutbox, uids, tenantID, erasedDelivery); err != nil {
+		return nil, fmt.Errorf("erase events_outbox: %w", err)
+	}
+	if _, err := tx.Exec(ctx, qEraseRaw, uids, tenantID); err != nil {
+		return nil, fmt.Errorf("erase orders_raw: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return uids, nil
+}
+
+// erasedUIDs runs one of the RETURNING order_uid updates.
+func erasedUIDs(ctx context.Context, tx pgx.Tx, table, query string, args ...any) ([]string, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erase %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, fmt.Errorf("scan erased %s: %w", table, err)
+		}
+		uids = append(uids, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erased %s rows: %w", table, err)
+	}
+	return uids, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+// erasureDB answers the RETURNING order_uid updates with fixed uids per table.
+type erasureDB struct {
+	*recordingDB
+	returning map[string][]string
+}
+
+func (d *erasureDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &erasureTx{recordingTx: &recordingTx{d: d.recordingDB}, returning: d.returning}, nil
+}
+
+type erasureTx struct {
+	*recordingTx
+	returning map[string][]string
+}
+
+func (t *erasureTx) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	t.d.record(query, args)
+	for table, uids := range t.returning {
+		if strings.Contains(query, "UPDATE "+table+" ") {
+			return &uidRows{uids: uids}, nil
+		}
+	}
+	return emptyRows{}, nil
+}
+
+type uidRows struct {
+	emptyRows
+	uids []string
+	cur  string
+}
+
+func (r *uidRows) Next() bool {
+	if len(r.uids) == 0 {
+		return false
+	}
+	r.cur, r.uids = r.uids[0], r.uids[1:]
+	return true
+}
+
+func (r *uidRows) Scan(dest ...any) error {
+	*dest[0].(*string) = r.cur
+	return nil
+}
+
+func TestAnonymizeCustomer_ScrubsEveryCopy(t *testing.T) {
+	db := &erasureDB{recordingDB: openRecorder(), returning: map[string][]string{
+		"deliveries":     {"a", "b"},
+		"orders_archive": {"c"},
+	}}
+	ctx := tenant.WithID(context.Background(), "shop-1")
+
+	uids, err := NewOrderRepository(db, nil).AnonymizeCustomer(ctx, "c1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, uids)
+
+	calls := db.snapshot()
+	require.Len(t, calls, 5)
+	require.Equal(t, []any{"c1", "shop-1", model.Erased}, calls[0].args)
+	require.Equal(t, "c1", calls[1].args[0])
+	require.JSONEq(t, `{"name":"[erased]","phone":"[erased]","email":"[erased]","address":"[erased]"}`, string(calls[1].args[2].([]byte)))
+	for _, c := range calls[2:] {
+		// revisions, outbox and raw payloads are matched on the collected uids within the tenant
+		require.Equal(t, []any{[]string{"a", "b", "c"}, "shop-1"}, c.args[:2])
+	}
+	require.Equal(t, qEraseRaw, calls[4].query)
+}
+
+func TestAnonymizeCustomer_UnknownCustomer(t *testing.T) {
+	calls := recordQueries(t, func(r Repository) error {
+		uids, err := r.AnonymizeCustomer(context.Background(), "nobody")
+		require.Empty(t, uids)
+		return err
+	})
+	// nothing matched, so the snapshot tables are left alone
+	require.Len(t, calls, 2)
+}
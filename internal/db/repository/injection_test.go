@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+var injectionSeeds = []string{
+	"b563feb7b2b84b6test",
+	"' OR '1'='1",
+	"'; DROP TABLE orders; --",
+	"1 UNION SELECT * FROM payments",
+	"$1",
+	"\\x27",
+	"",
+}
+
+// recordQueries runs op against a recording DB and returns the statements it issued.
+func recordQueries(t *testing.T, op func(r Repository) error) []recordedCall {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	rec := openRecorder()
+	_ = op(NewOrderRepository(rec, log))
+	return rec.snapshot()
+}
+
+func queryTexts(calls []recordedCall) []string {
+	out := make([]string, len(calls))
+	for i, c := range calls {
+		out[i] = c.query
+	}
+	return out
+}
+
+func argsContain(calls []recordedCall, want string) bool {
+	for _, c := range calls {
+		for _, a := range c.args {
+			if s, ok := a.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requireParameterized asserts that the SQL text issued for input is exactly the
+// SQL issued for a harmless baseline, i.e. input never leaks into the statement.
+func requireParameterized(t *testing.T, input string, op func(r Repository, in string) error) {
+	t.Helper()
+	baseline := recordQueries(t, func(r Repository) error { return op(r, "baseline") })
+	got := recordQueries(t, func(r Repository) error { return op(r, input) })
+
+	require.Equal(t, queryTexts(baseline), queryTexts(got), "statement text depends on input %q", input)
+	require.True(t, argsContain(got, input), "input %q not passed as a bind parameter", input)
+}
+
+func FuzzGetOrder_Parameterized(f *testing.F) {
+	for _, s := range injectionSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, id string) {
+		requireParameterized(t, id, func(r Repository, in string) error {
+			_, err := r.GetOrder(context.Background(), in)
+			return err
+		})
+	})
+}
+
+func FuzzUpsertOrder_Parameterized(f *testing.F) {
+	for _, s := range injectionSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		requireParameterized(t, s, func(r Repository, in string) error {
+			return r.UpsertOrder(context.Background(), &model.Order{
+				OrderUID:    in,
+				CustomerID:  in,
+				DateCreated: time.Unix(0, 0),
+				Delivery:    model.Delivery{Name: in, Address: in},
+				Payment:     model.Payment{Transaction: in},
+				Items:       []model.Item{{Name: in, Brand: in}},
+			})
+		})
+	})
+}
+
+func FuzzDeleteOrder_Parameterized(f *testing.F) {
+	for _, s := range injectionSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, id string) {
+		requireParameterized(t, id, func(r Repository, in string) error {
+			return r.DeleteOrder(context.Background(), in)
+		})
+	})
+}
+
+func FuzzArchiveOrder_Parameterized(f *testing.F) {
+	for _, s := range injectionSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, id string) {
+		requireParameterized(t, id, func(r Repository, in string) error {
+			return errors.Join(r.ArchiveOrder(context.Background(), in), r.RestoreOrder(context.Background(), in))
+		})
+	})
+}
+
+func FuzzOrderHistory_Parameterized(f *testing.F) {
+	for _, s := range injectionSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, id string) {
+		requireParameterized(t, id, func(r Repository, in string) error {
+			_, err := r.OrderHistory(context.Background(), in)
+			return err
+		})
+	})
+}
+
+func FuzzListOrders_FilterParameterized(f *testing.F) {
+	for _, s := range injectionSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, uid string) {
+		if uid == "" {
+			t.Skip("empty uid is not a valid cursor")
+		}
+		requireParameterized(t, uid, func(r Repository, in string) error {
+			cur := encodeCursor(&model.Order{OrderUID: in, DateCreated: time.Unix(0, 0)})
+			_, err := r.ListOrders(context.Background(), model.OrderFilter{CustomerID: in}, model.Page{Cursor: cur})
+			return err
+		})
+	})
+}
+
+func FuzzSearchOrders_Parameterized(f *testing.F) {
+	for _, s := range injectionSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, q string) {
+		if strings.TrimSpace(q) == "" {
+			t.Skip("blank queries don't reach the database")
+		}
+		requireParameterized(t, q, func(r Repository, in string) error {
+			_, err := r.SearchOrders(context.Background(), in, model.Page{})
+			return err
+		})
+	})
+}
@@ -2,12 +2,44 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/merkulovlad/wbtech-go/internal/model"
 )
 
 type Repository interface {
 	GetOrder(ctx context.Context, id string) (*model.Order, error)
+	// GetPrimaryOrder is GetOrder that is never answered by a read replica,
+	// for reads a write is based on.
+	GetPrimaryOrder(ctx context.Context, id string) (*model.Order, error)
+	GetOrders(ctx context.Context, ids []string) ([]*model.Order, error)
 	GetRecent(ctx context.Context, limit int) ([]*model.Order, error)
+	GetRawPayload(ctx context.Context, id string) ([]byte, error)
 	UpsertOrder(ctx context.Context, o *model.Order) error
+	// ReplaceOrder is UpsertOrder for an order read at version current; it
+	// is ErrStaleVersion once another write has stored a different version.
+	ReplaceOrder(ctx context.Context, o *model.Order, current int64) error
+	// UpsertOrders returns how many of orders it wrote; stale and unchanged
+	// orders are skipped without an error.
+	UpsertOrders(ctx context.Context, orders []*model.Order) (int, error)
+	OrderHistory(ctx context.Context, id string) ([]model.OrderRevision, error)
+	OrderExists(ctx context.Context, id string) (bool, error)
+	DeleteOrder(ctx context.Context, id string) error
+	UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error
+	ArchiveOrder(ctx context.Context, id string) error
+	RestoreOrder(ctx context.Context, id string) error
+	ExpireOrders(ctx context.Context, cutoff time.Time, limit int) ([]string, error)
+	AnonymizeCustomer(ctx context.Context, customerID string) ([]string, error)
+	StreamOrders(ctx context.Context, fn func(batch []*model.Order) error) error
+	FetchUnsentEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	MarkSent(ctx context.Context, events []model.OutboxEvent) error
+	SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error
+	SearchOrders(ctx context.Context, query string, page model.Page) (*model.SearchPage, error)
+	ListOrders(ctx context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error)
+	GetOrdersByCustomer(ctx context.Context, customerID string, page model.Page) (*model.OrderPage, error)
+	OrdersPerDay(ctx context.Context, f model.OrderFilter) ([]model.DailyCount, error)
+	GoodsByDeliveryService(ctx context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error)
+	TopCustomers(ctx context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error)
+	// HealthCheck reports whether the database answers a trivial query.
+	HealthCheck(ctx context.Context) error
 }
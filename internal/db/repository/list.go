@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the keyset position (date_created, order_uid) of the last order on a page.
+type cursor struct {
+	DateCreated time.Time
+	OrderUID    string
+}
+
+func encodeCursor(o *model.Order) string {
+	raw := o.DateCreated.UTC().Format(time.RFC3339Nano) + "|" + o.OrderUID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, uid, ok := strings.Cut(string(raw), "|")
+	if !ok || uid == "" {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor{DateCreated: t, OrderUID: uid}, nil
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
+
+// selectOrderColumns matches the Scan order used for orders rows throughout the repository.
+const selectOrderColumns = `order_uid, track_number, entry, locale, internal_signature, customer_id,
+       delivery_service, shardkey, sm_id, date_created, oof_shard, version, tenant_id, status, warnings`
+
+// filterConditions renders f as SQL conditions, binding each value through arg.
+// Archived orders are always excluded.
+func filterConditions(f model.OrderFilter, arg func(any) string) []string {
+	where := []string{"deleted_at IS NULL"}
+	if f.TenantID != "" {
+		where = append(where, "tenant_id = "+arg(f.TenantID))
+	}
+	if f.CustomerID != "" {
+		where = append(where, "customer_id = "+arg(f.CustomerID))
+	}
+	if f.Status != "" {
+		where = append(where, "status = "+arg(f.Status))
+	}
+	if !f.From.IsZero() {
+		where = append(where, "date_created >= "+arg(f.From))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "date_created < "+arg(f.To))
+	}
+	return where
+}
+
+// listQuery builds the keyset-paginated orders query. Every caller-controlled
+// value is bound as a parameter; only fixed SQL fragments are concatenated.
+func listQuery(f model.OrderFilter, c *cursor, sort model.Sort, limit int) (string, []any) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	cmp, dir := "<", "DESC"
+	if sort == model.SortOldest {
+		cmp, dir = ">", "ASC"
+	}
+	where := filterConditions(f, arg)
+	if c != nil {
+		where = append(where, fmt.Sprintf("(date_created, order_uid) %s (%s, %s)", cmp, arg(c.DateCreated), arg(c.OrderUID)))
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + selectOrderColumns + "\nFROM orders")
+	b.WriteString("\nWHERE " + strings.Join(where, " AND "))
+	b.WriteString(fmt.Sprintf("\nORDER BY date_created %s, order_uid %s\nLIMIT %s", dir, dir, arg(limit)))
+	return b.String(), args
+}
+
+// withTenant restricts f to the tenant ctx acts for.
+func withTenant(ctx context.Context, f model.OrderFilter) model.OrderFilter {
+	f.TenantID = tenant.FromContext(ctx)
+	return f
+}
+
+// ListOrders returns one page of fully hydrated orders of the tenant matching
+// f, newest first unless page.Sort says otherwise. page.Shallow skips the
+// child tables.
+// Pagination is keyset-based on (date_created, order_uid), so deep pages cost
+// the same as the first one.
+func (o *OrderRepository) ListOrders(ctx context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
+	defer cancel()
+
+	c, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := clampLimit(page.Limit)
+
+	// fetch one extra row to know whether there is a next page
+	query, args := listQuery(withTenant(ctx, f), c, page.Sort, limit+1)
+	rows, err := o.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select orders page: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]*model.Order, 0, limit+1)
+	for rows.Next() {
+		var ord model.Order
+		if err := rows.Scan(
+			&ord.OrderUID, &ord.TrackNumber, &ord.Entry, &ord.Locale, &ord.InternalSignature,
+			&ord.CustomerID, &ord.DeliveryService, &ord.ShardKey, &ord.SmID, &ord.DateCreated, &ord.OofShard, &ord.Version,
+			&ord.TenantID, &ord.Status, &ord.Warnings,
+		); err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		orders = append(orders, &ord)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return o.finishPage(ctx, orders, limit, page.Shallow)
+}
+
+// finishPage trims the look-ahead row, hydrates child tables unless shallow
+// and sets the next cursor.
+func (o *OrderRepository) finishPage(ctx context.Context, orders []*model.Order, limit int, shallow bool) (*model.OrderPage, error) {
+	res := &model.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		res.Orders = orders[:limit]
+		res.NextCursor = encodeCursor(res.Orders[limit-1])
+	}
+	if shallow {
+		return res, nil
+	}
+	if err := o.hydrate(ctx, res.Orders); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetOrdersByCustomer returns one page of a customer's orders.
+func (o *OrderRepository) GetOrdersByCustomer(ctx context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	return o.ListOrders(ctx, model.OrderFilter{CustomerID: customerID}, page)
+}
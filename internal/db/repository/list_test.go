@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	o := &model.Order{OrderUID: "b563feb7b2b84b6test", DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 123, time.UTC)}
+	c, err := decodeCursor(encodeCursor(o))
+	require.NoError(t, err)
+	require.Equal(t, o.OrderUID, c.OrderUID)
+	require.True(t, o.DateCreated.Equal(c.DateCreated))
+
+	for _, bad := range []string{"!!!", "bm8tc2VwYXJhdG9y", "eHx5"} {
+		_, err := decodeCursor(bad)
+		require.ErrorIs(t, err, ErrInvalidCursor, bad)
+	}
+}
+
+func TestListQuery_BindsFilterAndCursor(t *testing.T) {
+	from, to := time.Unix(100, 0), time.Unix(200, 0)
+	f := model.OrderFilter{CustomerID: "c1", From: from, To: to}
+	q, args := listQuery(f, &cursor{DateCreated: time.Unix(0, 0), OrderUID: "x"}, "", 21)
+	require.Contains(t, q, "customer_id = $1 AND date_created >= $2 AND date_created < $3 AND (date_created, order_uid) < ($4, $5)")
+	require.Contains(t, q, "LIMIT $6")
+	require.Equal(t, []any{"c1", from, to, time.Unix(0, 0), "x", 21}, args)
+}
+
+func TestListQuery_OldestFirst(t *testing.T) {
+	c := &cursor{DateCreated: time.Unix(0, 0), OrderUID: "x"}
+	q, args := listQuery(model.OrderFilter{Status: model.StatusShipped}, c, model.SortOldest, 21)
+	require.Contains(t, q, "status = $1 AND (date_created, order_uid) > ($2, $3)")
+	require.Contains(t, q, "ORDER BY date_created ASC, order_uid ASC\nLIMIT $4")
+	require.Equal(t, []any{model.StatusShipped, time.Unix(0, 0), "x", 21}, args)
+
+	q, _ = listQuery(model.OrderFilter{}, nil, model.SortNewest, 21)
+	require.Contains(t, q, "ORDER BY date_created DESC, order_uid DESC")
+}
+
+func TestMergePage_SetsCursorFromLastKept(t *testing.T) {
+	now := time.Now()
+	a := &model.Order{OrderUID: "a", DateCreated: now}
+	b := &model.Order{OrderUID: "b", DateCreated: now.Add(-time.Minute)}
+	c := &model.Order{OrderUID: "c", DateCreated: now.Add(-time.Hour)}
+
+	page := mergePage([]*model.Order{c, a, b}, "", 2, false)
+	require.Equal(t, []*model.Order{a, b}, page.Orders)
+	require.Equal(t, encodeCursor(b), page.NextCursor)
+
+	page = mergePage([]*model.Order{a, c, b}, model.SortOldest, 2, false)
+	require.Equal(t, []*model.Order{c, b}, page.Orders)
+	require.Equal(t, encodeCursor(b), page.NextCursor)
+}
+
+func TestReadsExcludeArchived(t *testing.T) {
+	ops := map[string]func(r Repository) error{
+		"GetOrder": func(r Repository) error { _, err := r.GetOrder(context.Background(), "a"); return err },
+		"GetOrders": func(r Repository) error {
+			_, err := r.GetOrders(context.Background(), []string{"a"})
+			return err
+		},
+		"GetRecent": func(r Repository) error { _, err := r.GetRecent(context.Background(), 10); return err },
+		"ListOrders": func(r Repository) error {
+			_, err := r.ListOrders(context.Background(), model.OrderFilter{}, model.Page{})
+			return err
+		},
+	}
+	for name, op := range ops {
+		calls := recordQueries(t, op)
+		require.Contains(t, calls[0].query, "deleted_at IS NULL", name)
+	}
+}
+
+func TestReadsScopedToTenant(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "shop-1")
+	ops := map[string]func(r Repository) error{
+		"GetOrder": func(r Repository) error { _, err := r.GetOrder(ctx, "a"); return err },
+		"GetOrders": func(r Repository) error {
+			_, err := r.GetOrders(ctx, []string{"a"})
+			return err
+		},
+		"GetRecent":    func(r Repository) error { _, err := r.GetRecent(ctx, 10); return err },
+		"OrderHistory": func(r Repository) error { _, err := r.OrderHistory(ctx, "a"); return err },
+		"ListOrders": func(r Repository) error {
+			_, err := r.ListOrders(ctx, model.OrderFilter{}, model.Page{})
+			return err
+		},
+		"OrdersPerDay": func(r Repository) error { _, err := r.OrdersPerDay(ctx, model.OrderFilter{}); return err },
+		"SearchOrders": func(r Repository) error { _, err := r.SearchOrders(ctx, "Moscow", model.Page{}); return err },
+	}
+	for name, op := range ops {
+		calls := recordQueries(t, op)
+		require.Contains(t, calls[0].query, "tenant_id = $", name)
+		require.Contains(t, calls[0].args, "shop-1", name)
+	}
+}
+
+func TestStreamOrders_ReadsOneSnapshotThroughCursor(t *testing.T) {
+	called := false
+	calls := recordQueries(t, func(r Repository) error {
+		return r.StreamOrders(context.Background(), func([]*model.Order) error {
+			called = true
+			return nil
+		})
+	})
+
+	require.Equal(t, []string{qStreamSnapshot, qDeclareStream, qFetchStream}, queryTexts(calls))
+	require.Equal(t, []any{StreamBatchSize}, calls[2].args)
+	require.False(t, called, "an empty table yields no batches")
+}
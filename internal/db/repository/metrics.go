@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// repoStats holds one entry per repository operation (get_order,
+// upsert_orders, ...). Published at /debug/vars under "repository".
+var (
+	repoStats   = expvar.NewMap("repository")
+	repoStatsMu sync.Mutex
+)
+
+// latencyBuckets are the upper bounds of the duration histogram.
+var latencyBuckets = [...]time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond,
+	50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond,
+}
+
+// opMetrics counts calls and errors of one operation and keeps a latency
+// histogram. ErrNotFound is an answer rather than a failure and is counted
+// separately; ErrUnchanged is not counted as one either.
+type opMetrics struct {
+	calls     atomic.Int64
+	errors    atomic.Int64
+	notFound  atomic.Int64
+	sumMicros atomic.Int64
+	buckets   [len(latencyBuckets) + 1]atomic.Int64 // last one is +Inf
+}
+
+func (m *opMetrics) observe(d time.Duration, err error) {
+	m.calls.Add(1)
+	m.sumMicros.Add(d.Microseconds())
+	switch {
+	case errors.Is(err, ErrNotFound):
+		m.notFound.Add(1)
+	case err != nil && !errors.Is(err, ErrUnchanged):
+		m.errors.Add(1)
+	}
+	i := 0
+	for i < len(latencyBuckets) && d > latencyBuckets[i] {
+		i++
+	}
+	m.buckets[i].Add(1)
+}
+
+// String renders the histogram with cumulative "le" buckets in milliseconds,
+// the way Prometheus exposes them.
+func (m *opMetrics) String() string {
+	hist := make(map[string]int64, len(m.buckets))
+	var total int64
+	for i := range m.buckets {
+		total += m.buckets[i].Load()
+		le := "+Inf"
+		if i < len(latencyBuckets) {
+			le = strconv.FormatFloat(float64(latencyBuckets[i])/float64(time.Millisecond), 'f', -1, 64)
+		}
+		hist[le] = total
+	}
+	b, _ := json.Marshal(map[string]any{
+		"calls":       m.calls.Load(),
+		"errors":      m.errors.Load(),
+		"not_found":   m.notFound.Load(),
+		"duration_ms": float64(m.sumMicros.Load()) / 1000,
+		"buckets_ms":  hist,
+	})
+	return string(b)
+}
+
+// opStats returns the metrics for op, publishing them on first use.
+func opStats(op string) *opMetrics {
+	repoStatsMu.Lock()
+	defer repoStatsMu.Unlock()
+	if m, ok := repoStats.Get(op).(*opMetrics); ok {
+		return m
+	}
+	m := &opMetrics{}
+	repoStats.Set(op, m)
+	return m
+}
+
+// MetricsRepository records the latency and outcome of every call of the
+// wrapped repository in repoStats.
+type MetricsRepository struct {
+	Repository
+	now func() time.Time
+}
+
+var _ Repository = (*MetricsRepository)(nil)
+
+func NewMetricsRepository(r Repository) *MetricsRepository {
+	return &MetricsRepository{Repository: r, now: time.Now}
+}
+
+func measure[T any](r *MetricsRepository, op string, fn func() (T, error)) (T, error) {
+	start := r.now()
+	res, err := fn()
+	opStats(op).observe(r.now().Sub(start), err)
+	return res, err
+}
+
+// measureErr is measure for methods that only return an error.
+func measureErr(r *MetricsRepository, op string, fn func() error) error {
+	_, err := measure(r, op, func() (struct{}, error) { return struct{}{}, fn() })
+	return err
+}
+
+func (r *MetricsRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
+	return measure(r, "get_order", func() (*model.Order, error) {
+		return r.Repository.GetOrder(ctx, id)
+	})
+}
+
+func (r *MetricsRepository) GetPrimaryOrder(ctx context.Context, id string) (*model.Order, error) {
+	return measure(r, "get_primary_order", func() (*model.Order, error) {
+		return r.Repository.GetPrimaryOrder(ctx, id)
+	})
+}
+
+func (r *MetricsRepository) GetRawPayload(ctx context.Context, id string) ([]byte, error) {
+	return measure(r, "get_raw_payload", func() ([]byte, error) {
+		return r.Repository.GetRawPayload(ctx, id)
+	})
+}
+
+func (r *MetricsRepository) GetOrders(ctx context.Context, ids []string) ([]*model.Order, error) {
+	return measure(r, "get_orders", func() ([]*model.Order, error) {
+		return r.Repository.GetOrders(ctx, ids)
+	})
+}
+
+func (r *MetricsRepository) GetRecent(ctx context.Context, limit int) ([]*model.Order, error) {
+	return measure(r, "get_recent", func() ([]*model.Order, error) {
+		return r.Repository.GetRecent(ctx, limit)
+	})
+}
+
+func (r *MetricsRepository) UpsertOrder(ctx context.Context, o *model.Order) error {
+	return measureErr(r, "upsert_order", func() error {
+		return r.Repository.UpsertOrder(ctx, o)
+	})
+}
+
+func (r *MetricsRepository) ReplaceOrder(ctx context.Context, o *model.Order, current int64) error {
+	return measureErr(r, "replace_order", func() error {
+		return r.Repository.ReplaceOrder(ctx, o, current)
+	})
+}
+
+func (r *MetricsRepository) UpsertOrders(ctx context.Context, orders []*model.Order) (int, error) {
+	return measure(r, "upsert_orders", func() (int, error) {
+		return r.Repository.UpsertOrders(ctx, orders)
+	})
+}
+
+func (r *MetricsRepository) OrderHistory(ctx context.Context, id string) ([]model.OrderRevision, error) {
+	return measure(r, "order_history", func() ([]model.OrderRevision, error) {
+		return r.Repository.OrderHistory(ctx, id)
+	})
+}
+
+func (r *MetricsRepository) OrderExists(ctx context.Context, id string) (bool, error) {
+	return measure(r, "order_exists", func() (bool, error) {
+		return r.Repository.OrderExists(ctx, id)
+	})
+}
+
+func (r *MetricsRepository) DeleteOrder(ctx context.Context, id string) error {
+	return measureErr(r, "delete_order", func() error {
+		return r.Repository.DeleteOrder(ctx, id)
+	})
+}
+
+func (r *MetricsRepository) UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error {
+	return measureErr(r, "update_status", func() error {
+		return r.Repository.UpdateStatus(ctx, id, status)
+	})
+}
+
+func (r *MetricsRepository) ArchiveOrder(ctx context.Context, id string) error {
+	return measureErr(r, "archive_order", func() error {
+		return r.Repository.ArchiveOrder(ctx, id)
+	})
+}
+
+func (r *MetricsRepository) RestoreOrder(ctx context.Context, id string) error {
+	return measureErr(r, "restore_order", func() error {
+		return r.Repository.RestoreOrder(ctx, id)
+	})
+}
+
+func (r *MetricsRepository) ExpireOrders(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	return measure(r, "expire_orders", func() ([]string, error) {
+		return r.Repository.ExpireOrders(ctx, cutoff, limit)
+	})
+}
+
+func (r *MetricsRepository) AnonymizeCustomer(ctx context.Context, customerID string) ([]string, error) {
+	return measure(r, "anonymize_customer", func() ([]string, error) {
+		return r.Repository.AnonymizeCustomer(ctx, customerID)
+	})
+}
+
+func (r *MetricsRepository) StreamOrders(ctx context.Context, fn func(batch []*model.Order) error) error {
+	return measureErr(r, "stream_orders", func() error {
+		return r.Repository.StreamOrders(ctx, fn)
+	})
+}
+
+func (r *MetricsRepository) SearchOrders(ctx context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	return measure(r, "search_orders", func() (*model.SearchPage, error) {
+		return r.Repository.SearchOrders(ctx, query, page)
+	})
+}
+
+func (r *MetricsRepository) FetchUnsentEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	return measure(r, "fetch_unsent_events", func() ([]model.OutboxEvent, error) {
+		return r.Repository.FetchUnsentEvents(ctx, limit)
+	})
+}
+
+func (r *MetricsRepository) MarkSent(ctx context.Context, events []model.OutboxEvent) error {
+	return measureErr(r, "mark_sent", func() error {
+		return r.Repository.MarkSent(ctx, events)
+	})
+}
+
+func (r *MetricsRepository) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	return measureErr(r, "save_webhook_delivery", func() error {
+		return r.Repository.SaveWebhookDelivery(ctx, d)
+	})
+}
+
+func (r *MetricsRepository) ListOrders(ctx context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	return measure(r, "list_orders", func() (*model.OrderPage, error) {
+		return r.Repository.ListOrders(ctx, f, page)
+	})
+}
+
+func (r *MetricsRepository) GetOrdersByCustomer(ctx context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	return measure(r, "orders_by_customer", func() (*model.OrderPage, error) {
+		return r.Repository.GetOrdersByCustomer(ctx, customerID, page)
+	})
+}
+
+func (r *MetricsRepository) OrdersPerDay(ctx context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	return measure(r, "orders_per_day", func() ([]model.DailyCount, error) {
+		return r.Repository.OrdersPerDay(ctx, f)
+	})
+}
+
+func (r *MetricsRepository) GoodsByDeliveryService(ctx context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	return measure(r, "goods_by_delivery_service", func() ([]model.DeliveryServiceTotal, error) {
+		return r.Repository.GoodsByDeliveryService(ctx, f)
+	})
+}
+
+func (r *MetricsRepository) TopCustomers(ctx context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	return measure(r, "top_customers", func() ([]model.CustomerTotal, error) {
+		return r.Repository.TopCustomers(ctx, f, limit)
+	})
+}
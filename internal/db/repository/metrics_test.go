@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRepository_RecordsLatencyAndErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := mocks.NewMockRepository(ctrl)
+	repo := NewMetricsRepository(inner)
+	clock := time.Unix(0, 0)
+	repo.now = func() time.Time {
+		clock = clock.Add(20 * time.Millisecond)
+		return clock
+	}
+
+	inner.EXPECT().DeleteOrder(gomock.Any(), "a").Return(nil)
+	inner.EXPECT().DeleteOrder(gomock.Any(), "b").Return(ErrNotFound)
+	inner.EXPECT().DeleteOrder(gomock.Any(), "c").Return(errors.New("db down"))
+	for _, id := range []string{"a", "b", "c"} {
+		_ = repo.DeleteOrder(context.Background(), id)
+	}
+
+	var got struct {
+		Calls      int64            `json:"calls"`
+		Errors     int64            `json:"errors"`
+		NotFound   int64            `json:"not_found"`
+		DurationMs float64          `json:"duration_ms"`
+		Buckets    map[string]int64 `json:"buckets_ms"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("repository").(*expvar.Map).Get("delete_order").String()), &got))
+	require.EqualValues(t, 3, got.Calls)
+	require.EqualValues(t, 1, got.Errors)
+	require.EqualValues(t, 1, got.NotFound)
+	require.InDelta(t, 60, got.DurationMs, 0.001)
+	require.EqualValues(t, 0, got.Buckets["10"])
+	require.EqualValues(t, 3, got.Buckets["25"])
+	require.EqualValues(t, 3, got.Buckets["+Inf"])
+}
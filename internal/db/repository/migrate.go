@@ -1,23 +1,181 @@
 package repository
 
 import (
+	"bufio"
+	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 )
 
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
-func RunMigrations(db *sql.DB) error {
+// additiveMarker tags a migration that only adds schema (tables, columns,
+// indexes) so that code built for the previous schema keeps working without it.
+const additiveMarker = "-- +wbtech additive"
+
+// ErrMigrationsPending is the cause of the MigrationError VerifyMigrations
+// returns for a schema that is behind the embedded migrations.
+var ErrMigrationsPending = errors.New("migrations pending")
+
+// MigrationError reports which migration failed and where the schema was left.
+type MigrationError struct {
+	// Version and Source identify the migration that failed.
+	Version int64
+	Source  string
+	// Current is the schema version recorded in the database after the failure.
+	Current int64
+	// Pending lists every migration not applied, the failed one included.
+	Pending []string
+	// Additive is true when every pending migration is tagged additive.
+	Additive bool
+	Err      error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration %s (version %d) failed, database at version %d with %d pending: %v",
+		e.Source, e.Version, e.Current, len(e.Pending), e.Err)
+}
+
+func (e *MigrationError) Unwrap() error { return e.Err }
+
+// openGoose returns a database/sql handle on top of pool, since goose speaks
+// database/sql only, with goose pointed at the embedded migrations.
+func openGoose(pool *pgxpool.Pool) (*sql.DB, error) {
 	goose.SetBaseFS(migrationsFS)
 	if err := goose.SetDialect("postgres"); err != nil {
+		return nil, err
+	}
+	return stdlib.OpenDBFromPool(pool), nil
+}
+
+// pendingMigrations returns the schema version of db and the migrations above it.
+func pendingMigrations(db *sql.DB) (int64, goose.Migrations, error) {
+	current, err := goose.EnsureDBVersion(db)
+	if err != nil {
+		return 0, nil, fmt.Errorf("goose version: %w", err)
+	}
+	pending, err := goose.CollectMigrations("migrations", current, goose.MaxVersion)
+	if err != nil {
+		if errors.Is(err, goose.ErrNoMigrationFiles) {
+			return current, nil, nil
+		}
+		return 0, nil, fmt.Errorf("goose collect: %w", err)
+	}
+	return current, pending, nil
+}
+
+// pendingError describes pending[from:] as a MigrationError caused by err.
+func pendingError(current int64, pending goose.Migrations, from int, err error) *MigrationError {
+	m := pending[from]
+	merr := &MigrationError{Version: m.Version, Source: m.Source, Current: current, Additive: true, Err: err}
+	for _, p := range pending[from:] {
+		merr.Pending = append(merr.Pending, p.Source)
+		ok, aerr := isAdditive(migrationsFS, p.Source)
+		merr.Additive = merr.Additive && ok && aerr == nil
+	}
+	return merr
+}
+
+// RunMigrations applies pending migrations on pool.
+func RunMigrations(pool *pgxpool.Pool) error {
+	db, err := openGoose(pool)
+	if err != nil {
 		return err
 	}
-	if err := goose.Up(db, "migrations"); err != nil {
-		return fmt.Errorf("goose up: %w", err)
+	defer func() { _ = db.Close() }()
+	return runUp(db)
+}
+
+func runUp(db *sql.DB) error {
+	current, pending, err := pendingMigrations(db)
+	if err != nil {
+		return err
+	}
+	// apply one by one so a failure can be attributed to its migration
+	for i, m := range pending {
+		if err := m.Up(db); err != nil {
+			return pendingError(current, pending, i, err)
+		}
+		current = m.Version
 	}
 	return nil
 }
+
+// VerifyMigrations checks pool against the embedded migrations without
+// applying anything, for deployments that migrate before rolling out. A
+// schema that is behind is a MigrationError wrapping ErrMigrationsPending,
+// so it gets the same degraded-mode treatment as a failed migration.
+func VerifyMigrations(pool *pgxpool.Pool) error {
+	db, err := openGoose(pool)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	current, pending, err := pendingMigrations(db)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+	return pendingError(current, pending, 0, ErrMigrationsPending)
+}
+
+// MigrateCommands are the goose commands Migrate accepts.
+var MigrateCommands = []string{"up", "up-by-one", "up-to", "down", "down-to", "redo", "status", "version"}
+
+// Migrate runs a goose command against pool with the embedded migrations;
+// up-to and down-to take the target version as their argument. up reports
+// failures as a MigrationError like RunMigrations. status and version print
+// through the goose logger.
+func Migrate(ctx context.Context, pool *pgxpool.Pool, command string, args ...string) error {
+	if !slices.Contains(MigrateCommands, command) {
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+	db, err := openGoose(pool)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	if command == "up" {
+		return runUp(db)
+	}
+	return goose.RunContext(ctx, command, db, "migrations", args...)
+}
+
+// CreateMigration writes an empty timestamped SQL migration called name into
+// dir, the migrations directory of a source checkout; it is embedded on the
+// next build.
+func CreateMigration(dir, name string) error {
+	return goose.Create(nil, dir, name, "sql")
+}
+
+// isAdditive reports whether the migration file carries additiveMarker in its header comments.
+func isAdditive(fsys fs.FS, source string) (bool, error) {
+	f, err := fsys.Open(source)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == additiveMarker {
+			return true, nil
+		}
+		if line != "" && !strings.HasPrefix(line, "--") {
+			break
+		}
+	}
+	return false, sc.Err()
+}
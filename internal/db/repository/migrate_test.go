@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsAdditive(t *testing.T) {
+	fsys := fstest.MapFS{
+		"add.sql":    {Data: []byte("-- +goose Up\n-- +wbtech additive\nCREATE INDEX i ON orders (locale);\n")},
+		"plain.sql":  {Data: []byte("-- +goose Up\nALTER TABLE orders DROP COLUMN locale;\n")},
+		"inline.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n-- +wbtech additive\n")},
+	}
+	for name, want := range map[string]bool{"add.sql": true, "plain.sql": false, "inline.sql": false} {
+		got, err := isAdditive(fsys, name)
+		require.NoError(t, err)
+		require.Equal(t, want, got, name)
+	}
+
+	_, err := isAdditive(fsys, "missing.sql")
+	require.Error(t, err)
+}
+
+func TestEmbeddedMigrationsMarkers(t *testing.T) {
+	// the customer index only adds an index; reads and writes work without it
+	ok, err := isAdditive(migrationsFS, "migrations/20250806100000_add_orders_customer_index.sql")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = isAdditive(migrationsFS, "migrations/20250815100000_add_query_path_indexes.sql")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestMigrationError(t *testing.T) {
+	cause := errors.New("syntax error")
+	err := error(&MigrationError{
+		Version: 3, Source: "migrations/3_x.sql", Current: 2,
+		Pending: []string{"migrations/3_x.sql", "migrations/4_y.sql"}, Err: cause,
+	})
+	require.ErrorIs(t, err, cause)
+	require.EqualError(t, err, "migration migrations/3_x.sql (version 3) failed, database at version 2 with 2 pending: syntax error")
+}
+
+func TestMigrate_RejectsUnknownCommand(t *testing.T) {
+	// checked before touching the pool: reset and fix are not offered
+	for _, cmd := range []string{"reset", "fix", "create", ""} {
+		require.ErrorContains(t, Migrate(context.Background(), nil, cmd), "unknown migrate command")
+	}
+}
+
+func TestPendingError_AdditiveOnlyWhenAllAre(t *testing.T) {
+	pending := goose.Migrations{
+		{Version: 20250806100000, Source: "migrations/20250806100000_add_orders_customer_index.sql"},
+		{Version: 20250811100000, Source: "migrations/20250811100000_add_tenant_id.sql"},
+	}
+	merr := pendingError(20250805100000, pending, 0, ErrMigrationsPending)
+	require.ErrorIs(t, merr, ErrMigrationsPending)
+	require.EqualValues(t, 20250806100000, merr.Version)
+	require.Len(t, merr.Pending, 2)
+	require.False(t, merr.Additive)
+
+	require.True(t, pendingError(20250805100000, pending[:1], 0, ErrMigrationsPending).Additive)
+}
@@ -0,0 +1,7 @@
+-- +goose Up
+-- +wbtech additive
+-- Supports keyset pagination of a customer's orders (GET /customers/:customer_id/orders)
+CREATE INDEX IF NOT EXISTS idx_orders_customer_date ON orders (customer_id, date_created DESC, order_uid DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_customer_date;
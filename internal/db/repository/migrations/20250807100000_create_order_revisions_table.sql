@@ -0,0 +1,13 @@
+-- +goose Up
+-- Prior versions of an order, written by every upsert that overwrites one.
+-- No foreign key: the history outlives the order it describes.
+CREATE TABLE order_revisions (
+    id          BIGSERIAL PRIMARY KEY,
+    order_uid   VARCHAR NOT NULL,
+    replaced_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    snapshot    JSONB NOT NULL
+);
+CREATE INDEX idx_order_revisions_order ON order_revisions (order_uid, id DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS order_revisions;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Soft delete: archived orders keep their rows but are hidden from reads.
+ALTER TABLE orders ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX idx_orders_live_date ON orders (date_created DESC, order_uid DESC) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_live_date;
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Optimistic concurrency: upserts only apply when their version is newer.
+ALTER TABLE orders ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN IF EXISTS version;
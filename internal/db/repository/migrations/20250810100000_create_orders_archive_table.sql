@@ -0,0 +1,13 @@
+-- +goose Up
+-- Orders moved out of the hot tables by the retention job, one JSON snapshot
+-- per order shaped like model.Order.
+CREATE TABLE orders_archive (
+    order_uid   VARCHAR PRIMARY KEY,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    snapshot    JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created ON orders (date_created);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_date_created;
+DROP TABLE IF EXISTS orders_archive;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Multi-tenancy: every row belongs to a shop. Existing rows belong to the
+-- default tenant. order_uid stays globally unique, so child tables keep
+-- joining on it alone.
+ALTER TABLE orders ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+ALTER TABLE deliveries ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+ALTER TABLE payments ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+ALTER TABLE items ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+ALTER TABLE order_revisions ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+ALTER TABLE orders_archive ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+CREATE INDEX idx_orders_tenant_live_date ON orders (tenant_id, date_created DESC, order_uid DESC) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_tenant_live_date;
+ALTER TABLE orders_archive DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE order_revisions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE items DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS tenant_id;
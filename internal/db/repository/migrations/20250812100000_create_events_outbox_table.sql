@@ -0,0 +1,18 @@
+-- +goose Up
+-- Transactional outbox: events are written in the transaction of the change
+-- they describe and published afterwards, so a crash between commit and
+-- publish loses nothing. shardkey routes MarkSent back to the owning database.
+CREATE TABLE events_outbox (
+    id         BIGSERIAL PRIMARY KEY,
+    tenant_id  VARCHAR NOT NULL DEFAULT 'default',
+    shardkey   VARCHAR NOT NULL,
+    order_uid  VARCHAR NOT NULL,
+    event_type VARCHAR NOT NULL,
+    payload    JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at    TIMESTAMPTZ
+);
+CREATE INDEX idx_events_outbox_unsent ON events_outbox (id) WHERE sent_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS events_outbox;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +wbtech additive
+-- Full-text search over the recipient for support lookups (GET /orders/search).
+-- The 'simple' configuration neither stems nor drops stop words, so names and
+-- addresses in any language match as typed.
+ALTER TABLE deliveries ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(city, '') || ' ' || coalesce(address, ''))
+) STORED;
+CREATE INDEX idx_deliveries_search ON deliveries USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_deliveries_search;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +wbtech additive
+-- The payload each order was last stored from, byte for byte as received, so
+-- fields can be re-derived after a mapping bug. Kept out of orders so hot
+-- reads and revision snapshots don't carry it.
+CREATE TABLE orders_raw (
+    order_uid   VARCHAR PRIMARY KEY,
+    tenant_id   VARCHAR NOT NULL DEFAULT 'default',
+    payload     JSONB NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS orders_raw;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +wbtech additive
+-- Items are loaded by order_uid on every read but had no index. Customer and
+-- date listings are filtered by tenant now; support looks orders up by track number.
+CREATE INDEX IF NOT EXISTS idx_items_order_uid ON items (order_uid, id);
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_customer_date ON orders (tenant_id, customer_id, date_created DESC, order_uid DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_track_number ON orders (track_number);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_track_number;
+DROP INDEX IF EXISTS idx_orders_tenant_customer_date;
+DROP INDEX IF EXISTS idx_items_order_uid;
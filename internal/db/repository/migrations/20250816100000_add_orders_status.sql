@@ -0,0 +1,8 @@
+-- +goose Up
+-- Current lifecycle state of the order. Snapshot upserts never change it;
+-- only status updates do.
+ALTER TABLE orders ADD COLUMN status VARCHAR NOT NULL DEFAULT 'created'
+    CONSTRAINT orders_status_check CHECK (status IN ('created', 'paid', 'shipped', 'delivered', 'cancelled'));
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
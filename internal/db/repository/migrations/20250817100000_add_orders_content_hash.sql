@@ -0,0 +1,8 @@
+-- +goose Up
+-- SHA-256 of the order content as last written (see contentHash), so an
+-- upsert that would write the same rows again is skipped. NULL for orders
+-- written before this column existed; their next upsert fills it in.
+ALTER TABLE orders ADD COLUMN content_hash BYTEA;
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Consistency problems found when the order was ingested (payment totals
+-- that don't add up, ...). NULL when there were none or the checks were off.
+ALTER TABLE orders ADD COLUMN warnings TEXT[];
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN IF EXISTS warnings;
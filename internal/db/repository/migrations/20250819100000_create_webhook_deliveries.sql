@@ -0,0 +1,22 @@
+-- +goose Up
+-- +wbtech additive
+-- One row per event and webhook subscriber, updated after every attempt so
+-- operators can see what was delivered and what gave up.
+CREATE TABLE webhook_deliveries (
+    id            BIGSERIAL PRIMARY KEY,
+    url           VARCHAR NOT NULL,
+    event_type    VARCHAR NOT NULL,
+    tenant_id     VARCHAR NOT NULL DEFAULT 'default',
+    order_uid     VARCHAR NOT NULL,
+    status        VARCHAR NOT NULL,
+    attempts      INT NOT NULL DEFAULT 0,
+    response_code INT NOT NULL DEFAULT 0,
+    last_error    TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at  TIMESTAMPTZ
+);
+CREATE INDEX idx_webhook_deliveries_order ON webhook_deliveries (order_uid);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_deliveries;
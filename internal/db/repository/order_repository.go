@@ -1,21 +1,98 @@
 package repository
 
 import (
-	"database/sql"
+	"context"
+	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/merkulovlad/wbtech-go/internal/db/sqlcdb"
 	"github.com/merkulovlad/wbtech-go/internal/logger"
 )
 
+// DB is the part of *pgxpool.Pool the repository needs; tests substitute a
+// recording implementation.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// OrderRepository keeps the fixed-shape reads in sqlc-generated code
+// (queries/*.sql, see sqlc.yaml); statements built at runtime, such as the
+// filtered list, and the writes use db directly.
 type OrderRepository struct {
-	db     *sql.DB
-	logger logger.InterfaceLogger
+	db       DB
+	q        *sqlcdb.Queries
+	logger   logger.InterfaceLogger
+	timeouts Timeouts
 }
 
 var _ Repository = (*OrderRepository)(nil)
 
-func NewOrderRepository(db *sql.DB, log logger.InterfaceLogger) Repository {
-	return &OrderRepository{
-		db:     db,
-		logger: log,
+// Timeouts bound each kind of repository call. They only shorten the
+// caller's context: a caller deadline that is earlier still wins.
+type Timeouts struct {
+	// Read covers single reads, GetOrders, listing, search and history.
+	Read time.Duration
+	// Write covers single-order writes and outbox bookkeeping.
+	Write time.Duration
+	// Batch covers UpsertOrders and ExpireOrders, which backfills and the
+	// retention job run with large batches.
+	Batch time.Duration
+	// Stats covers the aggregate queries.
+	Stats time.Duration
+}
+
+// DefaultTimeouts is used for zero fields of the Timeouts passed to WithTimeouts.
+var DefaultTimeouts = Timeouts{Read: 2 * time.Second, Write: 3 * time.Second, Batch: 10 * time.Second, Stats: 5 * time.Second}
+
+// Option customizes an OrderRepository.
+type Option func(*OrderRepository)
+
+// WithTimeouts replaces DefaultTimeouts; zero fields keep their default.
+func WithTimeouts(t Timeouts) Option {
+	return func(o *OrderRepository) {
+		if t.Read > 0 {
+			o.timeouts.Read = t.Read
+		}
+		if t.Write > 0 {
+			o.timeouts.Write = t.Write
+		}
+		if t.Batch > 0 {
+			o.timeouts.Batch = t.Batch
+		}
+		if t.Stats > 0 {
+			o.timeouts.Stats = t.Stats
+		}
+	}
+}
+
+func NewOrderRepository(db DB, log logger.InterfaceLogger, opts ...Option) Repository {
+	o := &OrderRepository{
+		db:       db,
+		q:        sqlcdb.New(db),
+		logger:   log,
+		timeouts: DefaultTimeouts,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+const qPing = `SELECT 1`
+
+// HealthCheck runs SELECT 1 with a short timeout, so a readiness probe fails
+// fast instead of hanging on a dead database.
+func (o *OrderRepository) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	if _, err := o.db.Exec(ctx, qPing); err != nil {
+		return fmt.Errorf("ping: %w", err)
 	}
+	return nil
 }
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+// deadlineDB records the time left on the context of each transaction.
+type deadlineDB struct {
+	*recordingDB
+	left []time.Duration
+}
+
+func (d *deadlineDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	deadline, _ := ctx.Deadline()
+	d.left = append(d.left, time.Until(deadline))
+	return d.recordingDB.Begin(ctx)
+}
+
+func TestWithTimeouts(t *testing.T) {
+	db := &deadlineDB{recordingDB: openRecorder()}
+	repo := NewOrderRepository(db, nil, WithTimeouts(Timeouts{Batch: time.Minute}))
+	require.Equal(t, Timeouts{Read: 2 * time.Second, Write: 3 * time.Second, Batch: time.Minute, Stats: 5 * time.Second},
+		repo.(*OrderRepository).timeouts)
+
+	ord := &model.Order{OrderUID: "a", DateCreated: time.Unix(0, 0)}
+	_, err := repo.UpsertOrders(context.Background(), []*model.Order{ord})
+	require.NoError(t, err)
+	require.Greater(t, db.left[0], 50*time.Second)
+
+	// a shorter caller deadline wins
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = repo.UpsertOrders(ctx, []*model.Order{ord})
+	require.NoError(t, err)
+	require.LessOrEqual(t, db.left[1], 100*time.Millisecond)
+}
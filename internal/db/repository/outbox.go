@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/db/sqlcdb"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// DefaultOutboxBatch is the FetchUnsentEvents limit used when none is given.
+const DefaultOutboxBatch = 100
+
+const (
+	qInsOutbox = `
+INSERT INTO events_outbox (tenant_id, shardkey, order_uid, event_type, payload)
+VALUES %s`
+
+	qMarkSent = `UPDATE events_outbox SET sent_at = now() WHERE id = ANY($1) AND sent_at IS NULL`
+)
+
+// upsertedEvent describes ord as stored for the tenant tenantID.
+func upsertedEvent(ord *model.Order, tenantID string) ([]any, error) {
+	payload, err := json.Marshal(ord)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s event: %w", model.EventOrderUpserted, err)
+	}
+	return []any{tenantID, ord.ShardKey, ord.OrderUID, model.EventOrderUpserted, payload}, nil
+}
+
+// changeEvent describes storing ord for the tenant tenantID over before, the
+// order as stored so far or nil for a new order. Upserts keep the stored
+// status, so the order after the change has it too.
+func changeEvent(before, ord *model.Order, tenantID string) ([]any, error) {
+	after := ord.Clone()
+	after.TenantID = tenantID
+	after.Status = initialStatus(ord)
+	change := model.OrderChange{After: after}
+	eventType := model.EventOrderCreated
+	if before != nil {
+		eventType = model.EventOrderUpdated
+		after.Status = before.Status
+		changes, err := model.DiffOrders(before, after)
+		if err != nil {
+			return nil, fmt.Errorf("diff %s event: %w", eventType, err)
+		}
+		change.Before, change.Changes = before, changes
+	}
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+	return []any{tenantID, ord.ShardKey, ord.OrderUID, eventType, payload}, nil
+}
+
+// storedOrder loads the live order id of tenantID through q, or nil when
+// there is none.
+func storedOrder(ctx context.Context, q *sqlcdb.Queries, id, tenantID string) (*model.Order, error) {
+	row, err := q.GetOrder(ctx, sqlcdb.GetOrderParams{OrderUID: id, TenantID: tenantID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select stored order: %w", err)
+	}
+	ord := orderFromRow(row)
+	if err := hydrateWith(ctx, q, []*model.Order{ord}); err != nil {
+		return nil, err
+	}
+	return ord, nil
+}
+
+// insertOutboxEvents records events in tx, so they commit or roll back with
+// the change they describe. Each row follows the qInsOutbox column order.
+func insertOutboxEvents(ctx context.Context, tx pgx.Tx, rows [][]any) error {
+	if err := execValues(ctx, tx, qInsOutbox, rows); err != nil {
+		return fmt.Errorf("insert outbox events: %w", err)
+	}
+	return nil
+}
+
+// FetchUnsentEvents returns up to limit events not yet marked sent, oldest
+// first. Rows are not locked: a relay publishes at least once, and a second
+// relay running concurrently publishes the same events again.
+func (o *OrderRepository) FetchUnsentEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	if limit <= 0 {
+		limit = DefaultOutboxBatch
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
+	defer cancel()
+
+	rows, err := o.q.ListUnsentEvents(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("select unsent events: %w", err)
+	}
+	events := make([]model.OutboxEvent, 0, len(rows))
+	for _, r := range rows {
+		events = append(events, model.OutboxEvent{
+			ID:        r.ID,
+			TenantID:  r.TenantID,
+			ShardKey:  r.ShardKey,
+			OrderUID:  r.OrderUID,
+			Type:      r.EventType,
+			Payload:   r.Payload,
+			CreatedAt: r.CreatedAt.Time,
+		})
+	}
+	return events, nil
+}
+
+// MarkSent marks events as published; events already marked are left alone.
+func (o *OrderRepository) MarkSent(ctx context.Context, events []model.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Write)
+	defer cancel()
+
+	ids := make([]int64, len(events))
+	for i, ev := range events {
+		ids[i] = ev.ID
+	}
+	if _, err := o.db.Exec(ctx, qMarkSent, ids); err != nil {
+		return fmt.Errorf("update events_outbox sent_at: %w", err)
+	}
+	return nil
+}
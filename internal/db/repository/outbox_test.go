@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertOrder_WritesOutboxEventInTx(t *testing.T) {
+	ord := &model.Order{OrderUID: "a", ShardKey: "1", DateCreated: time.Unix(0, 0)}
+	calls := recordQueries(t, func(r Repository) error {
+		return r.UpsertOrder(context.Background(), ord)
+	})
+
+	last := calls[len(calls)-1]
+	require.Contains(t, last.query, "INSERT INTO events_outbox")
+	require.Equal(t, []any{"default", "1", "a", model.EventOrderCreated}, last.args[:4])
+	var change model.OrderChange
+	require.NoError(t, json.Unmarshal(last.args[4].([]byte), &change))
+	require.Nil(t, change.Before)
+	require.Equal(t, "a", change.After.OrderUID)
+	require.Equal(t, model.StatusCreated, change.After.Status)
+}
+
+func TestChangeEvent_DiffsUpdates(t *testing.T) {
+	before := &model.Order{OrderUID: "a", ShardKey: "1", TenantID: "default", Status: model.StatusPaid, Version: 1,
+		Payment: model.Payment{Amount: 100}, Items: []model.Item{{ChrtID: 1}}}
+	ord := &model.Order{OrderUID: "a", ShardKey: "1", Version: 2,
+		Payment: model.Payment{Amount: 150}, Items: []model.Item{{ChrtID: 1}}}
+
+	row, err := changeEvent(before, ord, "default")
+	require.NoError(t, err)
+	require.Equal(t, model.EventOrderUpdated, row[3])
+	var change model.OrderChange
+	require.NoError(t, json.Unmarshal(row[4].([]byte), &change))
+	require.Equal(t, before, change.Before)
+	// the stored status is kept, the version is not a change
+	require.Equal(t, model.StatusPaid, change.After.Status)
+	require.Equal(t, []model.FieldChange{{Field: "payment.amount", Before: 100.0, After: 150.0}}, change.Changes)
+}
+
+// returningDB answers every QueryRow in a transaction with row.
+type returningDB struct {
+	*recordingDB
+	row []any
+}
+
+func (d *returningDB) Begin(context.Context) (pgx.Tx, error) {
+	return &returningTx{recordingTx: &recordingTx{d: d.recordingDB}, row: d.row}, nil
+}
+
+type returningTx struct {
+	*recordingTx
+	row []any
+}
+
+func (t *returningTx) QueryRow(_ context.Context, query string, args ...any) pgx.Row {
+	t.d.record(query, args)
+	return &valueRows{rows: [][]any{t.row}, cur: t.row}
+}
+
+func TestDeleteOrder_WritesOutboxEventInTx(t *testing.T) {
+	db := &returningDB{recordingDB: openRecorder(), row: []any{"7"}}
+	require.NoError(t, NewOrderRepository(db, nil).DeleteOrder(context.Background(), "a"))
+
+	calls := db.snapshot()
+	last := calls[len(calls)-1]
+	require.Contains(t, calls[len(calls)-2].query, "DELETE FROM orders")
+	require.Contains(t, last.query, "INSERT INTO events_outbox")
+	require.Equal(t, []any{"default", "7", "a", model.EventOrderDeleted, []byte(`{"order_uid":"a"}`)}, last.args)
+
+	// nothing deleted, nothing to announce
+	calls = recordQueries(t, func(r Repository) error {
+		require.ErrorIs(t, r.DeleteOrder(context.Background(), "a"), ErrNotFound)
+		return nil
+	})
+	require.NotContains(t, calls[len(calls)-1].query, "events_outbox")
+}
+
+func TestArchiveRestore_WriteOutboxEventsInTx(t *testing.T) {
+	db := &returningDB{recordingDB: openRecorder(), row: []any{"7"}}
+	repo := NewOrderRepository(db, nil)
+	require.NoError(t, repo.ArchiveOrder(context.Background(), "a"))
+	require.NoError(t, repo.RestoreOrder(context.Background(), "a"))
+
+	calls := db.snapshot()
+	require.Len(t, calls, 4)
+	require.Contains(t, calls[0].query, "SET deleted_at = now()")
+	require.Equal(t, []any{"default", "7", "a", model.EventOrderArchived, []byte(`{"order_uid":"a"}`)}, calls[1].args)
+	require.Contains(t, calls[2].query, "SET deleted_at = NULL")
+	require.Equal(t, []any{"default", "7", "a", model.EventOrderRestored, []byte(`{"order_uid":"a"}`)}, calls[3].args)
+
+	// already archived: nothing to announce
+	calls = recordQueries(t, func(r Repository) error {
+		require.ErrorIs(t, r.ArchiveOrder(context.Background(), "a"), ErrNotFound)
+		return nil
+	})
+	require.Len(t, calls, 1)
+}
+
+func TestMarkSent_BindsIDs(t *testing.T) {
+	db := openRecorder()
+	repo := NewOrderRepository(db, nil)
+
+	require.NoError(t, repo.MarkSent(context.Background(), nil))
+	require.Empty(t, db.snapshot())
+
+	require.NoError(t, repo.MarkSent(context.Background(), []model.OutboxEvent{{ID: 3}, {ID: 5}}))
+	calls := db.snapshot()
+	require.Len(t, calls, 1)
+	require.Equal(t, qMarkSent, calls[0].query)
+	require.Equal(t, []any{[]int64{3, 5}}, calls[0].args)
+}
+
+func TestFetchUnsentEvents_DefaultLimit(t *testing.T) {
+	calls := recordQueries(t, func(r Repository) error {
+		events, err := r.FetchUnsentEvents(context.Background(), 0)
+		require.Empty(t, events)
+		return err
+	})
+	require.Len(t, calls, 1)
+	require.Contains(t, calls[0].query, "WHERE sent_at IS NULL")
+	require.Equal(t, []any{int64(DefaultOutboxBatch)}, calls[0].args)
+}
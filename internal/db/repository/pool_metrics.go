@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+)
+
+// pools are the connection pools exported at /metrics, by name.
+var (
+	poolsMu sync.Mutex
+	pools   = map[string]*pgxpool.Pool{}
+)
+
+func init() {
+	gauge := func(name, help string, v func(*pgxpool.Stat) float64) {
+		metrics.NewGaugeSet(name, help, "pool", func() map[string]float64 {
+			poolsMu.Lock()
+			defer poolsMu.Unlock()
+			out := make(map[string]float64, len(pools))
+			for p, pool := range pools {
+				out[p] = v(pool.Stat())
+			}
+			return out
+		})
+	}
+	gauge("db_pool_max_conns", "Maximum size of the pool.", func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) })
+	gauge("db_pool_total_conns", "Open connections, idle or in use.", func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) })
+	gauge("db_pool_acquired_conns", "Connections currently in use.", func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) })
+	gauge("db_pool_idle_conns", "Idle connections.", func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) })
+	// cumulative, but exported as gauges since a restarted pool starts over
+	gauge("db_pool_acquire_count", "Connections acquired since the pool was opened.", func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) })
+	gauge("db_pool_empty_acquire_count", "Acquires that had to wait for a connection.", func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) })
+	gauge("db_pool_acquire_seconds", "Total time spent waiting to acquire connections.", func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() })
+}
+
+// PublishPoolStats exports the statistics of pool at /metrics under the
+// label pool="<name>".
+func PublishPoolStats(name string, pool *pgxpool.Pool) {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	pools[name] = pool
+}
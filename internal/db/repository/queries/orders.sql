@@ -0,0 +1,69 @@
+-- name: GetOrder :one
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
+       delivery_service, shardkey, sm_id, date_created, oof_shard, version,
+       tenant_id, status, warnings
+FROM orders WHERE order_uid = $1 AND tenant_id = $2 AND deleted_at IS NULL;
+
+-- name: GetDelivery :one
+SELECT name, phone, zip, city, address, region, email
+FROM deliveries WHERE order_uid = $1;
+
+-- name: GetPayment :one
+SELECT transaction, request_id, currency, provider, amount, payment_dt, bank,
+       delivery_cost, goods_total, custom_fee
+FROM payments WHERE order_uid = $1;
+
+-- name: ListItems :many
+SELECT chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+FROM items WHERE order_uid = $1 ORDER BY id;
+
+-- name: ListOrdersByUIDs :many
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
+       delivery_service, shardkey, sm_id, date_created, oof_shard, version,
+       tenant_id, status, warnings
+FROM orders
+WHERE order_uid = ANY(@order_uids::varchar[]) AND tenant_id = @tenant_id AND deleted_at IS NULL;
+
+-- name: ListDeliveriesByUIDs :many
+SELECT order_uid, name, phone, zip, city, address, region, email
+FROM deliveries WHERE order_uid = ANY(@order_uids::varchar[]);
+
+-- name: ListPaymentsByUIDs :many
+SELECT order_uid, transaction, request_id, currency, provider, amount, payment_dt, bank,
+       delivery_cost, goods_total, custom_fee
+FROM payments WHERE order_uid = ANY(@order_uids::varchar[]);
+
+-- name: ListItemsByUIDs :many
+SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+FROM items WHERE order_uid = ANY(@order_uids::varchar[]) ORDER BY order_uid, id;
+
+-- name: OrderExists :one
+SELECT EXISTS (SELECT 1 FROM orders WHERE order_uid = $1);
+
+-- name: ListRecentOrders :many
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
+       delivery_service, shardkey, sm_id, date_created, oof_shard, version,
+       tenant_id, status, warnings
+FROM orders
+WHERE tenant_id = $1 AND deleted_at IS NULL
+ORDER BY date_created DESC
+LIMIT $2;
+
+-- name: SearchOrders :many
+SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+       o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.version,
+       o.tenant_id, o.status, o.warnings,
+       o.track_number = @query::text AS track_match,
+       ts_rank(d.search_vector, plainto_tsquery('simple', @query::text)) AS rank,
+       ts_headline('simple', d.name, plainto_tsquery('simple', @query::text), @headline_options::text) AS name_headline,
+       ts_headline('simple', d.city, plainto_tsquery('simple', @query::text), @headline_options::text) AS city_headline,
+       ts_headline('simple', d.address, plainto_tsquery('simple', @query::text), @headline_options::text) AS address_headline
+FROM orders o
+JOIN deliveries d ON d.order_uid = o.order_uid
+WHERE (d.search_vector @@ plainto_tsquery('simple', @query::text) OR o.track_number = @query::text)
+  AND o.tenant_id = @tenant_id AND o.deleted_at IS NULL
+ORDER BY track_match DESC, rank DESC, o.date_created DESC, o.order_uid DESC
+LIMIT @max_results OFFSET @skip;
+
+-- name: GetRawPayload :one
+SELECT payload FROM orders_raw WHERE order_uid = $1 AND tenant_id = $2;
@@ -0,0 +1,6 @@
+-- name: ListUnsentEvents :many
+SELECT id, tenant_id, shardkey, order_uid, event_type, payload, created_at
+FROM events_outbox
+WHERE sent_at IS NULL
+ORDER BY id
+LIMIT $1;
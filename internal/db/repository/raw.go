@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/db/sqlcdb"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+// qUpsRaw keeps only the payload of the latest stored version.
+const qUpsRaw = `
+INSERT INTO orders_raw (order_uid, tenant_id, payload)
+VALUES %s
+ON CONFLICT (order_uid) DO UPDATE SET
+  tenant_id = EXCLUDED.tenant_id, payload = EXCLUDED.payload, received_at = now()`
+
+// upsertRaw stores (order_uid, tenant_id, payload) rows in tx.
+func upsertRaw(ctx context.Context, tx pgx.Tx, rows [][]any) error {
+	if err := execValues(ctx, tx, qUpsRaw, rows); err != nil {
+		return fmt.Errorf("upsert orders_raw: %w", err)
+	}
+	return nil
+}
+
+// GetRawPayload returns the payload the order was last stored from, exactly
+// as received. Orders stored without one, such as those created before raw
+// payloads were kept, are ErrNotFound.
+func (o *OrderRepository) GetRawPayload(ctx context.Context, id string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
+	defer cancel()
+
+	payload, err := o.q.GetRawPayload(ctx, sqlcdb.GetRawPayloadParams{OrderUID: id, TenantID: tenant.FromContext(ctx)})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select orders_raw: %w", err)
+	}
+	return payload, nil
+}
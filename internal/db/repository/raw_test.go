@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertOrder_StoresRawPayloadInTx(t *testing.T) {
+	raw := []byte(`{"order_uid":"a","extra":1}`)
+	ord := &model.Order{OrderUID: "a", ShardKey: "1", DateCreated: time.Unix(0, 0), Raw: raw}
+	calls := recordQueries(t, func(r Repository) error {
+		return r.UpsertOrder(context.Background(), ord)
+	})
+
+	var found bool
+	for _, c := range calls {
+		if strings.Contains(c.query, "INSERT INTO orders_raw") {
+			found = true
+			require.Equal(t, []any{"a", "default", raw}, c.args)
+		}
+	}
+	require.True(t, found)
+
+	// orders without a payload, such as restored revisions, keep the stored one
+	ord.Raw = nil
+	calls = recordQueries(t, func(r Repository) error {
+		return r.UpsertOrder(context.Background(), ord)
+	})
+	for _, c := range calls {
+		require.NotContains(t, c.query, "orders_raw")
+	}
+}
+
+func TestGetRawPayload_ScopedToTenant(t *testing.T) {
+	calls := recordQueries(t, func(r Repository) error {
+		_, err := r.GetRawPayload(tenant.WithID(context.Background(), "shop-1"), "a")
+		require.ErrorIs(t, err, ErrNotFound)
+		return nil
+	})
+	require.Len(t, calls, 1)
+	require.Contains(t, calls[0].query, "FROM orders_raw")
+	require.Equal(t, []any{"a", "shop-1"}, calls[0].args)
+}
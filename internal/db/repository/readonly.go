@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// ErrReadOnly is returned by writes while the service runs in degraded mode.
+var ErrReadOnly = errors.New("repository is read-only")
+
+// ReadOnlyRepository serves reads from the wrapped repository and rejects writes.
+// It is used when the schema is behind by additive migrations only, so reads
+// still match the code but writes might not.
+type ReadOnlyRepository struct {
+	Repository
+}
+
+func NewReadOnlyRepository(r Repository) *ReadOnlyRepository {
+	return &ReadOnlyRepository{Repository: r}
+}
+
+func (r *ReadOnlyRepository) UpsertOrder(ctx context.Context, order *model.Order) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) ReplaceOrder(ctx context.Context, order *model.Order, current int64) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) UpsertOrders(ctx context.Context, orders []*model.Order) (int, error) {
+	return 0, ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) DeleteOrder(ctx context.Context, id string) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) ArchiveOrder(ctx context.Context, id string) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) RestoreOrder(ctx context.Context, id string) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) MarkSent(ctx context.Context, events []model.OutboxEvent) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) ExpireOrders(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	return nil, ErrReadOnly
+}
+
+func (r *ReadOnlyRepository) AnonymizeCustomer(ctx context.Context, customerID string) ([]string, error) {
+	return nil, ErrReadOnly
+}
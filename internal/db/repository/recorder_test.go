@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recordingDB is a DB that never talks to a database. It records every
+// statement text and its bound args so tests can assert that user input only
+// ever reaches the database as a parameter.
+type recordingDB struct {
+	mu    sync.Mutex
+	calls []recordedCall
+}
+
+type recordedCall struct {
+	query string
+	args  []any
+}
+
+var _ DB = (*recordingDB)(nil)
+
+// openRecorder returns a fresh recording DB with an empty call log.
+func openRecorder() *recordingDB {
+	return &recordingDB{}
+}
+
+func (d *recordingDB) record(query string, args []any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = append(d.calls, recordedCall{query: query, args: args})
+}
+
+func (d *recordingDB) snapshot() []recordedCall {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]recordedCall(nil), d.calls...)
+}
+
+func (d *recordingDB) Exec(_ context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	d.record(query, args)
+	return pgconn.NewCommandTag("EXEC 1"), nil
+}
+
+func (d *recordingDB) Query(_ context.Context, query string, args ...any) (pgx.Rows, error) {
+	d.record(query, args)
+	return emptyRows{}, nil
+}
+
+func (d *recordingDB) QueryRow(_ context.Context, query string, args ...any) pgx.Row {
+	d.record(query, args)
+	return emptyRow{}
+}
+
+func (d *recordingDB) Begin(context.Context) (pgx.Tx, error) { return &recordingTx{d: d}, nil }
+
+// recordingTx records through its DB; pgx.Tx methods the repository doesn't
+// use are left to the nil embedded interface and panic if called.
+type recordingTx struct {
+	pgx.Tx
+	d *recordingDB
+}
+
+func (t *recordingTx) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	return t.d.Exec(ctx, query, args...)
+}
+
+func (t *recordingTx) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	return t.d.Query(ctx, query, args...)
+}
+
+func (t *recordingTx) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	return t.d.QueryRow(ctx, query, args...)
+}
+
+// CopyFrom records the COPY as one call whose args are all row values in order.
+func (t *recordingTx) CopyFrom(_ context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error) {
+	var args []any
+	var n int64
+	for src.Next() {
+		vals, err := src.Values()
+		if err != nil {
+			return n, err
+		}
+		args = append(args, vals...)
+		n++
+	}
+	t.d.record(fmt.Sprintf("COPY %s (%s) FROM STDIN", table.Sanitize(), strings.Join(columns, ", ")), args)
+	return n, src.Err()
+}
+
+func (t *recordingTx) Commit(context.Context) error   { return nil }
+func (t *recordingTx) Rollback(context.Context) error { return nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Close()                                       {}
+func (emptyRows) Err() error                                   { return nil }
+func (emptyRows) CommandTag() pgconn.CommandTag                { return pgconn.NewCommandTag("SELECT 0") }
+func (emptyRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (emptyRows) Next() bool                                   { return false }
+func (emptyRows) Scan(...any) error                            { return pgx.ErrNoRows }
+func (emptyRows) Values() ([]any, error)                       { return nil, pgx.ErrNoRows }
+func (emptyRows) RawValues() [][]byte                          { return nil }
+func (emptyRows) Conn() *pgx.Conn                              { return nil }
+
+type emptyRow struct{}
+
+func (emptyRow) Scan(...any) error { return pgx.ErrNoRows }
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// DefaultReplicaCooldown is how long a failed replica is skipped before it is tried again.
+const DefaultReplicaCooldown = 30 * time.Second
+
+// ReplicaRepository sends GetOrder(s), GetRecent, search, the list and stats queries to read
+// replicas round-robin, and everything else to the embedded primary.
+// A replica whose query fails is taken out of rotation for the cooldown and
+// the query is retried on the primary, so reads keep working while replicas
+// are down. OrderExists stays on the primary: a lagging replica could report
+// a just-written id as free, and so does GetPrimaryOrder, whose callers write
+// based on what it returns. HealthCheck stays there too: a replica that is down
+// costs latency, not availability. StreamOrders stays there too, since it
+// cannot fail over halfway through without repeating batches, and so does the
+// outbox, which a lagging replica would serve events already marked sent from.
+// Raw payloads are only read while debugging, so they are left there as well.
+type ReplicaRepository struct {
+	Repository // primary
+	replicas   []*replica
+	next       atomic.Uint32
+	cooldown   time.Duration
+	logger     logger.InterfaceLogger
+	now        func() time.Time
+}
+
+type replica struct {
+	Repository
+	index     int
+	downUntil atomic.Int64 // unix nanos; zero while healthy
+}
+
+var _ Repository = (*ReplicaRepository)(nil)
+
+// NewReplicaRepository wraps primary with read replicas. A non-positive
+// cooldown selects DefaultReplicaCooldown.
+func NewReplicaRepository(primary Repository, replicas []Repository, cooldown time.Duration, log logger.InterfaceLogger) *ReplicaRepository {
+	if cooldown <= 0 {
+		cooldown = DefaultReplicaCooldown
+	}
+	r := &ReplicaRepository{
+		Repository: primary,
+		cooldown:   cooldown,
+		logger:     log,
+		now:        time.Now,
+	}
+	for i, rep := range replicas {
+		r.replicas = append(r.replicas, &replica{Repository: rep, index: i})
+	}
+	return r
+}
+
+// pick returns the next healthy replica, or nil when all are cooling down.
+func (r *ReplicaRepository) pick() *replica {
+	now := r.now().UnixNano()
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		rep := r.replicas[(int(start)+i)%len(r.replicas)]
+		if rep.downUntil.Load() <= now {
+			return rep
+		}
+	}
+	return nil
+}
+
+// replicaFailed reports whether err means the replica itself is unhealthy, as
+// opposed to an answer (not found, bad cursor) or the caller giving up.
+func replicaFailed(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrNotFound) &&
+		!errors.Is(err, ErrInvalidCursor) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// read runs fn on a healthy replica and falls back to the primary when there
+// is none or the replica fails.
+func read[T any](ctx context.Context, r *ReplicaRepository, fn func(Repository) (T, error)) (T, error) {
+	rep := r.pick()
+	if rep == nil {
+		return fn(r.Repository)
+	}
+	res, err := fn(rep.Repository)
+	if !replicaFailed(err) || ctx.Err() != nil {
+		return res, err
+	}
+	r.markDown(ctx, rep, err)
+	return fn(r.Repository)
+}
+
+func (r *ReplicaRepository) markDown(ctx context.Context, rep *replica, err error) {
+	rep.downUntil.Store(r.now().Add(r.cooldown).UnixNano())
+	r.logger.WithContext(ctx).Errorf("replica %d failed, reading from primary for %s: %v", rep.index, r.cooldown, err)
+}
+
+// GetOrder falls back to the primary on ErrNotFound as well, since a replica
+// may not have caught up with an order that was just written.
+func (r *ReplicaRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
+	rep := r.pick()
+	if rep == nil {
+		return r.Repository.GetOrder(ctx, id)
+	}
+	o, err := rep.GetOrder(ctx, id)
+	if err == nil || ctx.Err() != nil {
+		return o, err
+	}
+	if replicaFailed(err) {
+		r.markDown(ctx, rep, err)
+	}
+	return r.Repository.GetOrder(ctx, id)
+}
+
+// GetOrders reads from a replica and fetches whatever it is missing from the
+// primary, for the same lag reason as GetOrder.
+func (r *ReplicaRepository) GetOrders(ctx context.Context, ids []string) ([]*model.Order, error) {
+	orders, err := read(ctx, r, func(repo Repository) ([]*model.Order, error) {
+		return repo.GetOrders(ctx, ids)
+	})
+	if err != nil || len(r.replicas) == 0 {
+		return orders, err
+	}
+	found := make(map[string]*model.Order, len(orders))
+	for _, o := range orders {
+		found[o.OrderUID] = o
+	}
+	missing := missingIDs(ids, found)
+	if len(missing) == 0 {
+		return orders, nil
+	}
+	rest, err := r.Repository.GetOrders(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range rest {
+		found[o.OrderUID] = o
+	}
+	return inIDOrder(ids, found), nil
+}
+
+func (r *ReplicaRepository) SearchOrders(ctx context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	return read(ctx, r, func(repo Repository) (*model.SearchPage, error) {
+		return repo.SearchOrders(ctx, query, page)
+	})
+}
+
+func (r *ReplicaRepository) OrderHistory(ctx context.Context, id string) ([]model.OrderRevision, error) {
+	return read(ctx, r, func(repo Repository) ([]model.OrderRevision, error) {
+		return repo.OrderHistory(ctx, id)
+	})
+}
+
+func (r *ReplicaRepository) GetRecent(ctx context.Context, limit int) ([]*model.Order, error) {
+	return read(ctx, r, func(repo Repository) ([]*model.Order, error) {
+		return repo.GetRecent(ctx, limit)
+	})
+}
+
+func (r *ReplicaRepository) ListOrders(ctx context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	return read(ctx, r, func(repo Repository) (*model.OrderPage, error) {
+		return repo.ListOrders(ctx, f, page)
+	})
+}
+
+func (r *ReplicaRepository) GetOrdersByCustomer(ctx context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	return read(ctx, r, func(repo Repository) (*model.OrderPage, error) {
+		return repo.GetOrdersByCustomer(ctx, customerID, page)
+	})
+}
+
+func (r *ReplicaRepository) OrdersPerDay(ctx context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	return read(ctx, r, func(repo Repository) ([]model.DailyCount, error) {
+		return repo.OrdersPerDay(ctx, f)
+	})
+}
+
+func (r *ReplicaRepository) GoodsByDeliveryService(ctx context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	return read(ctx, r, func(repo Repository) ([]model.DeliveryServiceTotal, error) {
+		return repo.GoodsByDeliveryService(ctx, f)
+	})
+}
+
+func (r *ReplicaRepository) TopCustomers(ctx context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	return read(ctx, r, func(repo Repository) ([]model.CustomerTotal, error) {
+		return repo.TopCustomers(ctx, f, limit)
+	})
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaRepository_RoutesReadsAndWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	r1 := mocks.NewMockRepository(ctrl)
+	r2 := mocks.NewMockRepository(ctrl)
+	repo := NewReplicaRepository(primary, []Repository{r1, r2}, time.Minute, mocks.NewMockInterfaceLogger(ctrl))
+
+	o := &model.Order{OrderUID: "a"}
+	r1.EXPECT().GetRecent(gomock.Any(), 5).Return([]*model.Order{o}, nil)
+	r2.EXPECT().GetRecent(gomock.Any(), 5).Return([]*model.Order{o}, nil)
+	primary.EXPECT().UpsertOrder(gomock.Any(), o).Return(nil)
+	primary.EXPECT().OrderExists(gomock.Any(), "a").Return(true, nil)
+	primary.EXPECT().GetPrimaryOrder(gomock.Any(), "a").Return(o, nil)
+
+	// round-robin: each replica serves one read
+	for i := 0; i < 2; i++ {
+		_, err := repo.GetRecent(context.Background(), 5)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.UpsertOrder(context.Background(), o))
+	exists, err := repo.OrderExists(context.Background(), "a")
+	require.NoError(t, err)
+	require.True(t, exists)
+	got, err := repo.GetPrimaryOrder(context.Background(), "a")
+	require.NoError(t, err)
+	require.Same(t, o, got)
+}
+
+func TestReplicaRepository_FailoverAndCooldown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	rep := mocks.NewMockRepository(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().WithContext(gomock.Any()).Return(log).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+
+	now := time.Now()
+	repo := NewReplicaRepository(primary, []Repository{rep}, time.Minute, log)
+	repo.now = func() time.Time { return now }
+
+	want := &model.OrderPage{}
+	rep.EXPECT().ListOrders(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+	primary.EXPECT().ListOrders(gomock.Any(), gomock.Any(), gomock.Any()).Return(want, nil).Times(2)
+
+	got, err := repo.ListOrders(context.Background(), model.OrderFilter{}, model.Page{})
+	require.NoError(t, err)
+	require.Same(t, want, got)
+
+	// still cooling down: the replica is not tried
+	_, err = repo.ListOrders(context.Background(), model.OrderFilter{}, model.Page{})
+	require.NoError(t, err)
+
+	// back in rotation after the cooldown
+	now = now.Add(time.Minute)
+	rep.EXPECT().ListOrders(gomock.Any(), gomock.Any(), gomock.Any()).Return(want, nil)
+	_, err = repo.ListOrders(context.Background(), model.OrderFilter{}, model.Page{})
+	require.NoError(t, err)
+}
+
+func TestReplicaRepository_GetOrderFallsBackOnLag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	rep := mocks.NewMockRepository(ctrl)
+	repo := NewReplicaRepository(primary, []Repository{rep}, time.Minute, mocks.NewMockInterfaceLogger(ctrl))
+
+	want := &model.Order{OrderUID: "fresh"}
+	rep.EXPECT().GetOrder(gomock.Any(), "fresh").Return(nil, ErrNotFound).Times(2)
+	primary.EXPECT().GetOrder(gomock.Any(), "fresh").Return(want, nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		// not found is an answer, not a failure: the replica stays in rotation
+		got, err := repo.GetOrder(context.Background(), "fresh")
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+}
@@ -2,116 +2,151 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
-	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/db/sqlcdb"
 	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
 )
 
 var ErrNotFound = errors.New("order not found")
 
+// ErrStaleVersion is returned when an upsert carries a version that is not
+// newer than the stored one; the stored order is left untouched.
+var ErrStaleVersion = errors.New("stale order version")
+
+// ErrTenantMismatch is returned when an upsert targets an order_uid that is
+// stored for another tenant; the stored order is left untouched.
+var ErrTenantMismatch = errors.New("order belongs to another tenant")
+
+// ErrUnchanged is returned when an upsert carries the content already stored
+// (see contentHash); nothing is written, not even the newer version.
+var ErrUnchanged = errors.New("order unchanged")
+
 const (
-	qSelOrder = `
-SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
-       delivery_service, shardkey, sm_id, date_created, oof_shard
-FROM orders WHERE order_uid = $1`
-
-	qSelDelivery = `
-SELECT name, phone, zip, city, address, region, email
-FROM deliveries WHERE order_uid = $1`
-
-	qSelPayment = `
-SELECT transaction, request_id, currency, provider, amount, payment_dt, bank,
-       delivery_cost, goods_total, custom_fee
-FROM payments WHERE order_uid = $1`
-
-	qSelItems = `
-SELECT chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
-FROM items WHERE order_uid = $1 ORDER BY id`
+	qSelOrderState       = `SELECT tenant_id, version FROM orders WHERE order_uid = $1`
+	qSelVersionForUpdate = `SELECT version FROM orders WHERE order_uid = $1 AND tenant_id = $2 FOR UPDATE`
 )
 
+// tenantOf returns the tenant ord is written for: its own TenantID, or the
+// tenant ctx acts for when it has none.
+func tenantOf(ctx context.Context, ord *model.Order) string {
+	if ord.TenantID != "" {
+		return ord.TenantID
+	}
+	return tenant.FromContext(ctx)
+}
+
 // GetOrder loads an order + delivery + payment + items.
 // Cache can wrap this at a higher layer; repo only talks to DB.
+// Orders of other tenants than the one ctx acts for are ErrNotFound.
 func (o *OrderRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
 	// keep tight timeouts to avoid hanging requests
-	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
 	defer cancel()
 
-	var ord model.Order
-	err := o.db.QueryRowContext(ctx, qSelOrder, id).Scan(
-		&ord.OrderUID, &ord.TrackNumber, &ord.Entry, &ord.Locale, &ord.InternalSignature,
-		&ord.CustomerID, &ord.DeliveryService, &ord.ShardKey, &ord.SmID, &ord.DateCreated, &ord.OofShard,
-	)
-	if errors.Is(err, sql.ErrNoRows) {
+	row, err := o.q.GetOrder(ctx, sqlcdb.GetOrderParams{OrderUID: id, TenantID: tenant.FromContext(ctx)})
+	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("select orders: %w", err)
 	}
+	ord := orderFromRow(row)
 
-	if err := o.db.QueryRowContext(ctx, qSelDelivery, id).Scan(
-		&ord.Delivery.Name, &ord.Delivery.Phone, &ord.Delivery.Zip, &ord.Delivery.City,
-		&ord.Delivery.Address, &ord.Delivery.Region, &ord.Delivery.Email,
-	); err != nil && !errors.Is(err, sql.ErrNoRows) {
+	delivery, err := o.q.GetDelivery(ctx, id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("select deliveries: %w", err)
 	}
+	ord.Delivery = model.Delivery(delivery)
 
-	if err := o.db.QueryRowContext(ctx, qSelPayment, id).Scan(
-		&ord.Payment.Transaction, &ord.Payment.RequestID, &ord.Payment.Currency, &ord.Payment.Provider,
-		&ord.Payment.Amount, &ord.Payment.PaymentDT, &ord.Payment.Bank,
-		&ord.Payment.DeliveryCost, &ord.Payment.GoodsTotal, &ord.Payment.CustomFee,
-	); err != nil && !errors.Is(err, sql.ErrNoRows) {
+	payment, err := o.q.GetPayment(ctx, id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("select payments: %w", err)
 	}
+	ord.Payment = model.Payment(payment)
 
-	rows, err := o.db.QueryContext(ctx, qSelItems, id)
+	items, err := o.q.ListItems(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("select items: %w", err)
 	}
-	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
-			log.Printf("failed to close rows: %v", err)
-		}
-	}(rows)
-
-	items := make([]model.Item, 0, 8)
-	for rows.Next() {
-		var it model.Item
-		if err := rows.Scan(
-			&it.ChrtID, &it.TrackNumber, &it.Price, &it.RID, &it.Name,
-			&it.Sale, &it.Size, &it.TotalPrice, &it.NmID, &it.Brand, &it.Status,
-		); err != nil {
-			return nil, fmt.Errorf("scan item: %w", err)
-		}
-		items = append(items, it)
+	ord.Items = make([]model.Item, 0, len(items))
+	for _, it := range items {
+		ord.Items = append(ord.Items, model.Item(it))
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("items rows: %w", err)
-	}
-	ord.Items = items
 
-	return &ord, nil
+	return ord, nil
+}
+
+// GetPrimaryOrder is GetOrder; this repository is the primary.
+func (o *OrderRepository) GetPrimaryOrder(ctx context.Context, id string) (*model.Order, error) {
+	return o.GetOrder(ctx, id)
 }
 
+// UpsertOrder stores ord with its delivery, payment and items and records a
+// model.EventOrderCreated or, with the fields that changed,
+// model.EventOrderUpdated outbox event in the same transaction. An order
+// whose content is already stored is ErrUnchanged, so redeliveries don't
+// rewrite its items, revisions and outbox.
 func (o *OrderRepository) UpsertOrder(ctx context.Context, ord *model.Order) error {
-	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	return o.upsertOrder(ctx, ord, nil)
+}
+
+// ReplaceOrder is UpsertOrder for a stored order that was read at version
+// current: it locks the row and writes only while the stored version is
+// still current, so a concurrent write in between is ErrStaleVersion instead
+// of being overwritten. An order that is not stored is ErrNotFound.
+func (o *OrderRepository) ReplaceOrder(ctx context.Context, ord *model.Order, current int64) error {
+	return o.upsertOrder(ctx, ord, &current)
+}
+
+// upsertOrder is UpsertOrder, or ReplaceOrder when current is set.
+func (o *OrderRepository) upsertOrder(ctx context.Context, ord *model.Order, current *int64) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Write)
 	defer cancel()
 
-	tx, err := o.db.BeginTx(ctx, &sql.TxOptions{})
+	tx, err := o.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("begin: %w", err)
 	}
-	defer func() { _ = tx.Rollback() }() // no-op if already committed
+	defer func() { _ = tx.Rollback(ctx) }() // no-op if already committed
+
+	tenantID := tenantOf(ctx, ord)
+	hash, err := contentHash(ord)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		var version int64
+		err := tx.QueryRow(ctx, qSelVersionForUpdate, ord.OrderUID, tenantID).Scan(&version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("lock order: %w", err)
+		}
+		if version != *current {
+			return fmt.Errorf("%w: %s changed from version %d", ErrStaleVersion, ord.OrderUID, *current)
+		}
+	}
+	before, err := storedOrder(ctx, o.q.WithTx(tx), ord.OrderUID, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := snapshotRevisions(ctx, tx, []string{ord.OrderUID}); err != nil {
+		return err
+	}
 
-	// orders
-	if _, err := tx.ExecContext(ctx, `
+	// orders; a stored version at least as new or other than current, a row
+	// of another tenant or the same content wins and nothing is written
+	res, err := tx.Exec(ctx, `
 INSERT INTO orders (order_uid, track_number, entry, locale, internal_signature, customer_id,
-                    delivery_service, shardkey, sm_id, date_created, oof_shard)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+                    delivery_service, shardkey, sm_id, date_created, oof_shard, version, tenant_id, status, content_hash,
+                    warnings)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
 ON CONFLICT (order_uid) DO UPDATE SET
   track_number=EXCLUDED.track_number,
   entry=EXCLUDED.entry,
@@ -122,33 +157,57 @@ ON CONFLICT (order_uid) DO UPDATE SET
   shardkey=EXCLUDED.shardkey,
   sm_id=EXCLUDED.sm_id,
   date_created=EXCLUDED.date_created,
-  oof_shard=EXCLUDED.oof_shard
+  oof_shard=EXCLUDED.oof_shard,
+  version=EXCLUDED.version,
+  content_hash=EXCLUDED.content_hash,
+  warnings=EXCLUDED.warnings
+WHERE orders.version < EXCLUDED.version AND orders.tenant_id = EXCLUDED.tenant_id
+  AND orders.content_hash IS DISTINCT FROM EXCLUDED.content_hash
+  AND ($17::bigint IS NULL OR orders.version = $17)
 `,
 		ord.OrderUID, ord.TrackNumber, ord.Entry, ord.Locale, ord.InternalSignature,
-		ord.CustomerID, ord.DeliveryService, ord.ShardKey, ord.SmID, ord.DateCreated, ord.OofShard,
-	); err != nil {
+		ord.CustomerID, ord.DeliveryService, ord.ShardKey, ord.SmID, ord.DateCreated, ord.OofShard, ord.Version,
+		tenantID, initialStatus(ord), hash, ord.Warnings, current,
+	)
+	if err != nil {
 		return fmt.Errorf("upsert orders: %w", err)
 	}
+	if res.RowsAffected() == 0 {
+		var (
+			owner   string
+			version int64
+		)
+		if err := tx.QueryRow(ctx, qSelOrderState, ord.OrderUID).Scan(&owner, &version); err != nil {
+			return fmt.Errorf("select order state: %w", err)
+		}
+		if owner != tenantID {
+			return fmt.Errorf("%w: %s", ErrTenantMismatch, ord.OrderUID)
+		}
+		if version >= ord.Version || current != nil && version != *current {
+			return fmt.Errorf("%w: %s version %d", ErrStaleVersion, ord.OrderUID, ord.Version)
+		}
+		return fmt.Errorf("%w: %s", ErrUnchanged, ord.OrderUID)
+	}
 
 	// deliveries
-	if _, err := tx.ExecContext(ctx, `
-INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	if _, err := tx.Exec(ctx, `
+INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email, tenant_id)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
 ON CONFLICT (order_uid) DO UPDATE SET
   name=EXCLUDED.name, phone=EXCLUDED.phone, zip=EXCLUDED.zip, city=EXCLUDED.city,
   address=EXCLUDED.address, region=EXCLUDED.region, email=EXCLUDED.email
 `,
 		ord.OrderUID, ord.Delivery.Name, ord.Delivery.Phone, ord.Delivery.Zip, ord.Delivery.City,
-		ord.Delivery.Address, ord.Delivery.Region, ord.Delivery.Email,
+		ord.Delivery.Address, ord.Delivery.Region, ord.Delivery.Email, tenantID,
 	); err != nil {
 		return fmt.Errorf("upsert deliveries: %w", err)
 	}
 
 	// payments
-	if _, err := tx.ExecContext(ctx, `
+	if _, err := tx.Exec(ctx, `
 INSERT INTO payments (order_uid, transaction, request_id, currency, provider,
-                      amount, payment_dt, bank, delivery_cost, goods_total, custom_fee)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+                      amount, payment_dt, bank, delivery_cost, goods_total, custom_fee, tenant_id)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
 ON CONFLICT (order_uid) DO UPDATE SET
   transaction=EXCLUDED.transaction, request_id=EXCLUDED.request_id,
   currency=EXCLUDED.currency, provider=EXCLUDED.provider, amount=EXCLUDED.amount,
@@ -157,80 +216,309 @@ ON CONFLICT (order_uid) DO UPDATE SET
 `,
 		ord.OrderUID, ord.Payment.Transaction, ord.Payment.RequestID, ord.Payment.Currency,
 		ord.Payment.Provider, ord.Payment.Amount, ord.Payment.PaymentDT, ord.Payment.Bank,
-		ord.Payment.DeliveryCost, ord.Payment.GoodsTotal, ord.Payment.CustomFee,
+		ord.Payment.DeliveryCost, ord.Payment.GoodsTotal, ord.Payment.CustomFee, tenantID,
 	); err != nil {
 		return fmt.Errorf("upsert payments: %w", err)
 	}
 
 	// items → replace all current items for this order
-	if _, err := tx.ExecContext(ctx, `DELETE FROM items WHERE order_uid = $1`, ord.OrderUID); err != nil {
+	if _, err := tx.Exec(ctx, `DELETE FROM items WHERE order_uid = $1`, ord.OrderUID); err != nil {
 		return fmt.Errorf("delete items: %w", err)
 	}
 	if len(ord.Items) > 0 {
-		stmt, err := tx.PrepareContext(ctx, `
-INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
-`)
-		if err != nil {
-			return fmt.Errorf("prepare items: %w", err)
+		if err := copyItems(ctx, tx, ord, tenantID); err != nil {
+			return err
 		}
-		defer func(stmt *sql.Stmt) {
-			err := stmt.Close()
-			if err != nil {
-				log.Printf("failed to close statement: %v", err)
-			}
-		}(stmt)
-
-		for _, it := range ord.Items {
-			if _, err := stmt.ExecContext(ctx,
-				ord.OrderUID, it.ChrtID, it.TrackNumber, it.Price, it.RID, it.Name,
-				it.Sale, it.Size, it.TotalPrice, it.NmID, it.Brand, it.Status,
-			); err != nil {
-				return fmt.Errorf("insert item: %w", err)
-			}
+	}
+
+	if len(ord.Raw) > 0 {
+		if err := upsertRaw(ctx, tx, [][]any{{ord.OrderUID, tenantID, []byte(ord.Raw)}}); err != nil {
+			return err
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	event, err := changeEvent(before, ord, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := insertOutboxEvents(ctx, tx, [][]any{event}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("commit: %w", err)
 	}
 	return nil
 }
+
+// itemColumns is the column order of the items COPY.
+var itemColumns = []string{
+	"order_uid", "chrt_id", "track_number", "price", "rid", "name",
+	"sale", "size", "total_price", "nm_id", "brand", "status", "tenant_id",
+}
+
+// copyItems streams the order's items with COPY FROM STDIN, which costs one
+// round trip regardless of the item count instead of one INSERT per item.
+func copyItems(ctx context.Context, tx pgx.Tx, ord *model.Order, tenantID string) error {
+	rows := make([][]any, len(ord.Items))
+	for i, it := range ord.Items {
+		rows[i] = []any{
+			ord.OrderUID, it.ChrtID, it.TrackNumber, it.Price, it.RID, it.Name,
+			it.Sale, it.Size, it.TotalPrice, it.NmID, it.Brand, it.Status, tenantID,
+		}
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"items"}, itemColumns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("copy items: %w", err)
+	}
+	return nil
+}
+
 func (o *OrderRepository) GetRecent(ctx context.Context, limit int) ([]*model.Order, error) {
-	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
 	defer cancel()
 
-	rows, err := o.db.QueryContext(ctx, `
-        SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
-               delivery_service, shardkey, sm_id, date_created, oof_shard
-        FROM orders
-        ORDER BY date_created DESC
-        LIMIT $1
-    `, limit)
+	rows, err := o.q.ListRecentOrders(ctx, sqlcdb.ListRecentOrdersParams{TenantID: tenant.FromContext(ctx), Limit: int64(limit)})
 	if err != nil {
 		return nil, fmt.Errorf("select recent orders: %w", err)
 	}
-	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
-			log.Printf("failed to close rows: %v", err)
-		}
-	}(rows)
-
 	var orders []*model.Order
-	for rows.Next() {
-		var ord model.Order
-		if err := rows.Scan(
-			&ord.OrderUID, &ord.TrackNumber, &ord.Entry, &ord.Locale, &ord.InternalSignature,
-			&ord.CustomerID, &ord.DeliveryService, &ord.ShardKey, &ord.SmID, &ord.DateCreated, &ord.OofShard,
-		); err != nil {
-			return nil, fmt.Errorf("scan recent order: %w", err)
+	for _, row := range rows {
+		orders = append(orders, orderFromRow(sqlcdb.GetOrderRow(row)))
+	}
+
+	if err := o.hydrate(ctx, orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetOrders loads several orders with one query per table. The result follows
+// the order of ids; ids that don't exist for the tenant (and repeats) are left out, so callers
+// compare lengths or uids to find misses.
+func (o *OrderRepository) GetOrders(ctx context.Context, ids []string) ([]*model.Order, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
+	defer cancel()
+
+	uids := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			uids = append(uids, id)
 		}
-		orders = append(orders, &ord)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+
+	rows, err := o.q.ListOrdersByUIDs(ctx, sqlcdb.ListOrdersByUIDsParams{OrderUids: uids, TenantID: tenant.FromContext(ctx)})
+	if err != nil {
+		return nil, fmt.Errorf("select orders: %w", err)
+	}
+	byUID := make(map[string]*model.Order, len(rows))
+	for _, row := range rows {
+		byUID[row.OrderUID] = orderFromRow(sqlcdb.GetOrderRow(row))
 	}
 
+	orders := make([]*model.Order, 0, len(byUID))
+	for _, id := range uids {
+		if ord, ok := byUID[id]; ok {
+			orders = append(orders, ord)
+		}
+	}
+	if err := o.hydrate(ctx, orders); err != nil {
+		return nil, err
+	}
 	return orders, nil
 }
+
+// hydrate loads delivery, payment and items for all orders with one query per
+// child table instead of three queries per order.
+func (o *OrderRepository) hydrate(ctx context.Context, orders []*model.Order) error {
+	return hydrateWith(ctx, o.q, orders)
+}
+
+// hydrateWith is hydrate on q, which may be bound to a transaction.
+func hydrateWith(ctx context.Context, q *sqlcdb.Queries, orders []*model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	byUID := make(map[string]*model.Order, len(orders))
+	uids := make([]string, 0, len(orders))
+	for _, ord := range orders {
+		byUID[ord.OrderUID] = ord
+		uids = append(uids, ord.OrderUID)
+		ord.Items = make([]model.Item, 0)
+	}
+
+	deliveries, err := q.ListDeliveriesByUIDs(ctx, uids)
+	if err != nil {
+		return fmt.Errorf("select deliveries: %w", err)
+	}
+	for _, d := range deliveries {
+		if ord, ok := byUID[d.OrderUID]; ok {
+			ord.Delivery = model.Delivery{
+				Name: d.Name, Phone: d.Phone, Zip: d.Zip, City: d.City,
+				Address: d.Address, Region: d.Region, Email: d.Email,
+			}
+		}
+	}
+
+	payments, err := q.ListPaymentsByUIDs(ctx, uids)
+	if err != nil {
+		return fmt.Errorf("select payments: %w", err)
+	}
+	for _, p := range payments {
+		if ord, ok := byUID[p.OrderUID]; ok {
+			ord.Payment = model.Payment{
+				Transaction: p.Transaction, RequestID: p.RequestID, Currency: p.Currency, Provider: p.Provider,
+				Amount: p.Amount, PaymentDT: p.PaymentDT, Bank: p.Bank,
+				DeliveryCost: p.DeliveryCost, GoodsTotal: p.GoodsTotal, CustomFee: p.CustomFee,
+			}
+		}
+	}
+
+	items, err := q.ListItemsByUIDs(ctx, uids)
+	if err != nil {
+		return fmt.Errorf("select items: %w", err)
+	}
+	for _, it := range items {
+		if ord, ok := byUID[it.OrderUID]; ok {
+			ord.Items = append(ord.Items, model.Item{
+				ChrtID: it.ChrtID, TrackNumber: it.TrackNumber, Price: it.Price, RID: it.RID, Name: it.Name,
+				Sale: it.Sale, Size: it.Size, TotalPrice: it.TotalPrice, NmID: it.NmID, Brand: it.Brand, Status: it.Status,
+			})
+		}
+	}
+	return nil
+}
+
+// OrderExists reports whether an order with the given uid is already stored,
+// archived or not, for any tenant. Used to detect collisions of server-generated ids.
+func (o *OrderRepository) OrderExists(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
+	defer cancel()
+
+	exists, err := o.q.OrderExists(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("select order exists: %w", err)
+	}
+	return exists, nil
+}
+
+// DeleteOrder permanently removes the order and its delivery, payment, items
+// and raw payload and records a model.EventOrderDeleted outbox event, all in
+// one transaction; ArchiveOrder is the recoverable alternative. Children are deleted explicitly rather than relying on
+// ON DELETE CASCADE, so the result does not depend on the constraints in place.
+func (o *OrderRepository) DeleteOrder(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Write)
+	defer cancel()
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // no-op if already committed
+
+	tenantID := tenant.FromContext(ctx)
+	for _, table := range []string{"items", "payments", "deliveries", "orders_raw"} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE order_uid = $1 AND tenant_id = $2`, id, tenantID); err != nil {
+			return fmt.Errorf("delete %s: %w", table, err)
+		}
+	}
+	var shardKey string
+	err = tx.QueryRow(ctx, `DELETE FROM orders WHERE order_uid = $1 AND tenant_id = $2 RETURNING shardkey`, id, tenantID).Scan(&shardKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete orders: %w", err)
+	}
+
+	payload, err := json.Marshal(model.OrderDeleted{OrderUID: id})
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", model.EventOrderDeleted, err)
+	}
+	if err := insertOutboxEvents(ctx, tx, [][]any{{tenantID, shardKey, id, model.EventOrderDeleted, payload}}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// ArchiveOrder soft-deletes the order: its rows stay in place but reads no
+// longer return it until RestoreOrder. Archiving an archived order is
+// ErrNotFound. A model.EventOrderArchived outbox event is recorded in the same
+// transaction.
+func (o *OrderRepository) ArchiveOrder(ctx context.Context, id string) error {
+	return o.setDeletedAt(ctx, model.EventOrderArchived,
+		`UPDATE orders SET deleted_at = now() WHERE order_uid = $1 AND tenant_id = $2 AND deleted_at IS NULL RETURNING shardkey`, id)
+}
+
+// RestoreOrder makes an archived order visible again and records a
+// model.EventOrderRestored outbox event; ErrNotFound if it is not archived.
+func (o *OrderRepository) RestoreOrder(ctx context.Context, id string) error {
+	return o.setDeletedAt(ctx, model.EventOrderRestored,
+		`UPDATE orders SET deleted_at = NULL WHERE order_uid = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL RETURNING shardkey`, id)
+}
+
+// setDeletedAt runs query, which returns the shardkey of the order it
+// changed, and records event for it in the same transaction.
+func (o *OrderRepository) setDeletedAt(ctx context.Context, event, query, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Write)
+	defer cancel()
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // no-op if already committed
+
+	tenantID := tenant.FromContext(ctx)
+	var shardKey string
+	err = tx.QueryRow(ctx, query, id, tenantID).Scan(&shardKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("update orders deleted_at: %w", err)
+	}
+
+	payload, err := json.Marshal(model.OrderArchival{OrderUID: id})
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", event, err)
+	}
+	if err := insertOutboxEvents(ctx, tx, [][]any{{tenantID, shardKey, id, event, payload}}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// orderFromRow copies the orders columns; the other row types selecting the
+// same columns convert to sqlcdb.GetOrderRow.
+func orderFromRow(r sqlcdb.GetOrderRow) *model.Order {
+	return &model.Order{
+		OrderUID:          r.OrderUID,
+		TrackNumber:       r.TrackNumber,
+		Entry:             r.Entry,
+		Locale:            r.Locale,
+		InternalSignature: r.InternalSignature,
+		CustomerID:        r.CustomerID,
+		DeliveryService:   r.DeliveryService,
+		ShardKey:          r.ShardKey,
+		SmID:              r.SmID,
+		DateCreated:       r.DateCreated,
+		OofShard:          r.OofShard,
+		Version:           r.Version,
+		TenantID:          r.TenantID,
+		Status:            model.OrderStatus(r.Status),
+		Warnings:          r.Warnings,
+	}
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// qSelExpired picks the oldest orders first and skips rows another
+	// writer holds, so the job never waits on ingestion.
+	qSelExpired = `
+SELECT order_uid FROM orders
+WHERE date_created < $1
+ORDER BY date_created
+LIMIT $2
+FOR UPDATE SKIP LOCKED`
+
+	// qInsArchive copies the orders into orders_archive; an order archived
+	// before, re-ingested and expired again keeps only its latest snapshot.
+	qInsArchive = `
+INSERT INTO orders_archive (order_uid, tenant_id, snapshot)` + orderSnapshot + `
+WHERE o.order_uid = ANY($1)
+ON CONFLICT (order_uid) DO UPDATE SET
+  tenant_id = EXCLUDED.tenant_id, snapshot = EXCLUDED.snapshot, archived_at = now()`
+)
+
+// ExpireOrders moves up to limit orders created before cutoff, archived or
+// not and of every tenant, into orders_archive and deletes them with their
+// delivery, payment, items and raw payload, all in one transaction. It returns
+// the uids it moved; fewer than limit means nothing older is left. Revisions
+// are kept.
+func (o *OrderRepository) ExpireOrders(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Batch)
+	defer cancel()
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // no-op if already committed
+
+	uids, err := expiredUIDs(ctx, tx, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec(ctx, qInsArchive, uids); err != nil {
+		return nil, fmt.Errorf("insert orders archive: %w", err)
+	}
+	for _, table := range []string{"items", "payments", "deliveries", "orders_raw", "orders"} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE order_uid = ANY($1)`, uids); err != nil {
+			return nil, fmt.Errorf("delete expired %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return uids, nil
+}
+
+func expiredUIDs(ctx context.Context, tx pgx.Tx, cutoff time.Time, limit int) ([]string, error) {
+	rows, err := tx.Query(ctx, qSelExpired, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select expired orders: %w", err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, fmt.Errorf("scan expired order: %w", err)
+		}
+		uids = append(uids, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("expired rows: %w", err)
+	}
+	return uids, nil
+}
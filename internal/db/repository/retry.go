@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// RetryPolicy bounds how often and how fast RetryRepository retries.
+type RetryPolicy struct {
+	// Attempts is the total number of tries, including the first.
+	Attempts int
+	// BaseDelay is the backoff ceiling before the first retry; it doubles on
+	// each retry up to MaxDelay. The actual sleep is uniformly jittered in
+	// [0, ceiling) so callers that failed together do not retry together.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy is used for zero fields of the policy passed to NewRetryRepository.
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, BaseDelay: 50 * time.Millisecond, MaxDelay: time.Second}
+
+// retryableStates are SQLSTATEs after which the same statement can succeed:
+// the server has rolled the transaction back, or the connection went away.
+var retryableStates = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// Transient reports whether err is worth retrying: a serialization failure,
+// a deadlock, or a lost/refused connection. Answers such as ErrNotFound and
+// constraint violations, and the caller's own cancellation, are not.
+func Transient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// class 08 is connection_exception
+		return retryableStates[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08")
+	}
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
+
+// RetryRepository retries every call of the wrapped repository that fails
+// with a Transient error. Each attempt is a whole repository call, so writes
+// are retried as a fresh transaction rather than a statement inside an
+// aborted one. StreamOrders is not retried: fn has already seen the batches
+// before the failure.
+//
+// A connection lost while COMMIT is in flight leaves it unknown whether the
+// write landed. Upserts are safe to repeat: a retry of a committed upsert
+// reports ErrStaleVersion. A retried archive or restore may report
+// ErrNotFound for the same reason.
+type RetryRepository struct {
+	Repository
+	policy RetryPolicy
+	logger logger.InterfaceLogger
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+var _ Repository = (*RetryRepository)(nil)
+
+// NewRetryRepository wraps r; zero policy fields fall back to DefaultRetryPolicy.
+func NewRetryRepository(r Repository, policy RetryPolicy, log logger.InterfaceLogger) *RetryRepository {
+	if policy.Attempts <= 0 {
+		policy.Attempts = DefaultRetryPolicy.Attempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return &RetryRepository{Repository: r, policy: policy, logger: log, sleep: sleepCtx}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// backoff returns the jittered delay before retry n (starting at 0).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	ceiling := p.BaseDelay << n
+	if ceiling > p.MaxDelay || ceiling <= 0 {
+		ceiling = p.MaxDelay
+	}
+	return rand.N(ceiling)
+}
+
+func retry[T any](ctx context.Context, r *RetryRepository, op string, fn func() (T, error)) (T, error) {
+	res, err := fn()
+	for n := 0; n < r.policy.Attempts-1 && Transient(err); n++ {
+		d := r.policy.backoff(n)
+		r.logger.WithContext(ctx).Warnf("%s: transient error, retry %d/%d in %s: %v", op, n+1, r.policy.Attempts-1, d, err)
+		if serr := r.sleep(ctx, d); serr != nil {
+			return res, err
+		}
+		res, err = fn()
+	}
+	return res, err
+}
+
+// retryErr is retry for methods that only return an error.
+func retryErr(ctx context.Context, r *RetryRepository, op string, fn func() error) error {
+	_, err := retry(ctx, r, op, func() (struct{}, error) { return struct{}{}, fn() })
+	return err
+}
+
+func (r *RetryRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
+	return retry(ctx, r, "get order", func() (*model.Order, error) {
+		return r.Repository.GetOrder(ctx, id)
+	})
+}
+
+func (r *RetryRepository) GetPrimaryOrder(ctx context.Context, id string) (*model.Order, error) {
+	return retry(ctx, r, "get primary order", func() (*model.Order, error) {
+		return r.Repository.GetPrimaryOrder(ctx, id)
+	})
+}
+
+func (r *RetryRepository) GetRawPayload(ctx context.Context, id string) ([]byte, error) {
+	return retry(ctx, r, "get raw payload", func() ([]byte, error) {
+		return r.Repository.GetRawPayload(ctx, id)
+	})
+}
+
+func (r *RetryRepository) GetOrders(ctx context.Context, ids []string) ([]*model.Order, error) {
+	return retry(ctx, r, "get orders", func() ([]*model.Order, error) {
+		return r.Repository.GetOrders(ctx, ids)
+	})
+}
+
+func (r *RetryRepository) GetRecent(ctx context.Context, limit int) ([]*model.Order, error) {
+	return retry(ctx, r, "get recent", func() ([]*model.Order, error) {
+		return r.Repository.GetRecent(ctx, limit)
+	})
+}
+
+func (r *RetryRepository) UpsertOrder(ctx context.Context, o *model.Order) error {
+	return retryErr(ctx, r, "upsert order", func() error {
+		return r.Repository.UpsertOrder(ctx, o)
+	})
+}
+
+func (r *RetryRepository) ReplaceOrder(ctx context.Context, o *model.Order, current int64) error {
+	return retryErr(ctx, r, "replace order", func() error {
+		return r.Repository.ReplaceOrder(ctx, o, current)
+	})
+}
+
+func (r *RetryRepository) UpsertOrders(ctx context.Context, orders []*model.Order) (int, error) {
+	return retry(ctx, r, "upsert orders", func() (int, error) {
+		return r.Repository.UpsertOrders(ctx, orders)
+	})
+}
+
+func (r *RetryRepository) OrderHistory(ctx context.Context, id string) ([]model.OrderRevision, error) {
+	return retry(ctx, r, "order history", func() ([]model.OrderRevision, error) {
+		return r.Repository.OrderHistory(ctx, id)
+	})
+}
+
+func (r *RetryRepository) OrderExists(ctx context.Context, id string) (bool, error) {
+	return retry(ctx, r, "order exists", func() (bool, error) {
+		return r.Repository.OrderExists(ctx, id)
+	})
+}
+
+func (r *RetryRepository) DeleteOrder(ctx context.Context, id string) error {
+	return retryErr(ctx, r, "delete order", func() error {
+		return r.Repository.DeleteOrder(ctx, id)
+	})
+}
+
+func (r *RetryRepository) UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error {
+	return retryErr(ctx, r, "update status", func() error {
+		return r.Repository.UpdateStatus(ctx, id, status)
+	})
+}
+
+func (r *RetryRepository) ArchiveOrder(ctx context.Context, id string) error {
+	return retryErr(ctx, r, "archive order", func() error {
+		return r.Repository.ArchiveOrder(ctx, id)
+	})
+}
+
+func (r *RetryRepository) RestoreOrder(ctx context.Context, id string) error {
+	return retryErr(ctx, r, "restore order", func() error {
+		return r.Repository.RestoreOrder(ctx, id)
+	})
+}
+
+func (r *RetryRepository) ExpireOrders(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	return retry(ctx, r, "expire orders", func() ([]string, error) {
+		return r.Repository.ExpireOrders(ctx, cutoff, limit)
+	})
+}
+
+func (r *RetryRepository) AnonymizeCustomer(ctx context.Context, customerID string) ([]string, error) {
+	return retry(ctx, r, "anonymize customer", func() ([]string, error) {
+		return r.Repository.AnonymizeCustomer(ctx, customerID)
+	})
+}
+
+func (r *RetryRepository) SearchOrders(ctx context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	return retry(ctx, r, "search orders", func() (*model.SearchPage, error) {
+		return r.Repository.SearchOrders(ctx, query, page)
+	})
+}
+
+func (r *RetryRepository) FetchUnsentEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	return retry(ctx, r, "fetch unsent events", func() ([]model.OutboxEvent, error) {
+		return r.Repository.FetchUnsentEvents(ctx, limit)
+	})
+}
+
+func (r *RetryRepository) MarkSent(ctx context.Context, events []model.OutboxEvent) error {
+	return retryErr(ctx, r, "mark sent", func() error {
+		return r.Repository.MarkSent(ctx, events)
+	})
+}
+
+func (r *RetryRepository) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	return retryErr(ctx, r, "save webhook delivery", func() error {
+		return r.Repository.SaveWebhookDelivery(ctx, d)
+	})
+}
+
+func (r *RetryRepository) ListOrders(ctx context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	return retry(ctx, r, "list orders", func() (*model.OrderPage, error) {
+		return r.Repository.ListOrders(ctx, f, page)
+	})
+}
+
+func (r *RetryRepository) GetOrdersByCustomer(ctx context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	return retry(ctx, r, "orders by customer", func() (*model.OrderPage, error) {
+		return r.Repository.GetOrdersByCustomer(ctx, customerID, page)
+	})
+}
+
+func (r *RetryRepository) OrdersPerDay(ctx context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	return retry(ctx, r, "orders per day", func() ([]model.DailyCount, error) {
+		return r.Repository.OrdersPerDay(ctx, f)
+	})
+}
+
+func (r *RetryRepository) GoodsByDeliveryService(ctx context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	return retry(ctx, r, "goods by delivery service", func() ([]model.DeliveryServiceTotal, error) {
+		return r.Repository.GoodsByDeliveryService(ctx, f)
+	})
+}
+
+func (r *RetryRepository) TopCustomers(ctx context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	return retry(ctx, r, "top customers", func() ([]model.CustomerTotal, error) {
+		return r.Repository.TopCustomers(ctx, f, limit)
+	})
+}
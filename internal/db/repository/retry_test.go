@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransient(t *testing.T) {
+	pg := func(code string) error { return fmt.Errorf("upsert orders: %w", &pgconn.PgError{Code: code}) }
+
+	for _, err := range []error{pg("40001"), pg("40P01"), pg("08006"), pg("57P01"), io.ErrUnexpectedEOF} {
+		require.True(t, Transient(err), "%v", err)
+	}
+	for _, err := range []error{nil, ErrNotFound, ErrStaleVersion, pg("23505"), pg("42P01"), context.Canceled,
+		fmt.Errorf("select orders: %w", context.DeadlineExceeded)} {
+		require.False(t, Transient(err), "%v", err)
+	}
+}
+
+func TestRetryRepository_RetriesTransientErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := mocks.NewMockRepository(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().WithContext(gomock.Any()).Return(log).AnyTimes()
+	log.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	repo := NewRetryRepository(inner, RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond}, log)
+	var slept []time.Duration
+	repo.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	o := &model.Order{OrderUID: "a"}
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	gomock.InOrder(
+		inner.EXPECT().UpsertOrder(gomock.Any(), o).Return(deadlock),
+		inner.EXPECT().UpsertOrder(gomock.Any(), o).Return(nil),
+	)
+	require.NoError(t, repo.UpsertOrder(context.Background(), o))
+	require.Len(t, slept, 1)
+	require.Less(t, slept[0], time.Millisecond)
+
+	// attempts are bounded and the last error is returned
+	inner.EXPECT().GetOrder(gomock.Any(), "a").Return(nil, deadlock).Times(3)
+	_, err := repo.GetOrder(context.Background(), "a")
+	require.ErrorAs(t, err, &deadlock)
+	for _, d := range slept {
+		require.Less(t, d, 4*time.Millisecond)
+	}
+
+	// answers are not retried
+	inner.EXPECT().GetOrder(gomock.Any(), "b").Return(nil, ErrNotFound).Times(1)
+	_, err = repo.GetOrder(context.Background(), "b")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRetryRepository_StopsWhenContextDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := mocks.NewMockRepository(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().WithContext(gomock.Any()).Return(log).AnyTimes()
+	log.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reset := errors.Join(errors.New("write failed"), io.ErrUnexpectedEOF)
+	inner.EXPECT().DeleteOrder(gomock.Any(), "a").Return(reset).Times(1)
+
+	err := NewRetryRepository(inner, RetryPolicy{}, log).DeleteOrder(ctx, "a")
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
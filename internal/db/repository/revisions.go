@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+// orderSnapshot selects (order_uid, tenant_id, snapshot) for the orders aliased
+// o, the snapshot being JSON shaped like model.Order. Column names already
+// match the JSON tags; date_created is converted to timestamptz so it carries
+// an offset.
+// Callers append the WHERE clause.
+const orderSnapshot = `
+SELECT o.order_uid, o.tenant_id,
+       (to_jsonb(o) - 'deleted_at' - 'content_hash') || jsonb_build_object(
+           'date_created', o.date_created AT TIME ZONE 'UTC',
+           'delivery', COALESCE(to_jsonb(d) - 'id' - 'order_uid' - 'tenant_id' - 'search_vector', '{}'::jsonb),
+           'payment', COALESCE(to_jsonb(p) - 'id' - 'order_uid' - 'tenant_id', '{}'::jsonb),
+           'items', COALESCE((
+               SELECT jsonb_agg(to_jsonb(i) - 'id' - 'order_uid' - 'tenant_id' ORDER BY i.id)
+               FROM items i WHERE i.order_uid = o.order_uid
+           ), '[]'::jsonb)
+       )
+FROM orders o
+LEFT JOIN deliveries d ON d.order_uid = o.order_uid
+LEFT JOIN payments p ON p.order_uid = o.order_uid`
+
+const (
+	// qInsRevisions snapshots the stored state of the given orders.
+	// FOR UPDATE serializes concurrent upserts of one order, so every
+	// overwritten version is recorded exactly once.
+	qInsRevisions = `
+INSERT INTO order_revisions (order_uid, tenant_id, snapshot)` + orderSnapshot + `
+WHERE o.order_uid = ANY($1)
+FOR UPDATE OF o`
+
+	qSelRevisions = `
+SELECT id, replaced_at, snapshot
+FROM order_revisions WHERE order_uid = $1 AND tenant_id = $2
+ORDER BY id DESC
+LIMIT $3`
+)
+
+// MaxRevisions caps how many revisions OrderHistory returns.
+const MaxRevisions = 100
+
+// snapshotRevisions records the current state of uids before tx overwrites them.
+// Orders that don't exist yet have nothing to record.
+func snapshotRevisions(ctx context.Context, tx pgx.Tx, uids []string) error {
+	if _, err := tx.Exec(ctx, qInsRevisions, uids); err != nil {
+		return fmt.Errorf("insert order revisions: %w", err)
+	}
+	return nil
+}
+
+// OrderHistory returns the prior versions of an order, most recently replaced
+// first. The current version is not included; load it with GetOrder.
+func (o *OrderRepository) OrderHistory(ctx context.Context, id string) ([]model.OrderRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
+	defer cancel()
+
+	rows, err := o.db.Query(ctx, qSelRevisions, id, tenant.FromContext(ctx), MaxRevisions)
+	if err != nil {
+		return nil, fmt.Errorf("select order revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revs := make([]model.OrderRevision, 0)
+	for rows.Next() {
+		var (
+			rev  model.OrderRevision
+			snap []byte
+		)
+		if err := rows.Scan(&rev.ID, &rev.ReplacedAt, &snap); err != nil {
+			return nil, fmt.Errorf("scan order revision: %w", err)
+		}
+		if err := json.Unmarshal(snap, &rev.Order); err != nil {
+			return nil, fmt.Errorf("decode revision %d: %w", rev.ID, err)
+		}
+		revs = append(revs, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return revs, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/merkulovlad/wbtech-go/internal/db/sqlcdb"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+// MaxSearchDepth caps how far search pages reach: hits are ranked, so deep
+// pages cost the database the whole prefix and are rarely what support wants.
+const MaxSearchDepth = 500
+
+// ts_headline marks matches with control characters, so the recipient data
+// can be HTML-escaped before the markers become <mark> tags.
+const (
+	markStart = "\x01"
+	markStop  = "\x02"
+
+	headlineOptions = `StartSel="` + markStart + `", StopSel="` + markStop + `", HighlightAll=true`
+)
+
+var markReplacer = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// encodeSearchCursor and decodeSearchCursor keep the offset of the next
+// search page opaque, like list cursors.
+func encodeSearchCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("s|" + strconv.Itoa(offset)))
+}
+
+func decodeSearchCursor(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(string(raw), "s|"))
+	if err != nil || !strings.HasPrefix(string(raw), "s|") || n < 0 || n >= MaxSearchDepth {
+		return 0, ErrInvalidCursor
+	}
+	return n, nil
+}
+
+// searchWindow returns the offset and size of the requested search page.
+func searchWindow(page model.Page) (offset, limit int, err error) {
+	if offset, err = decodeSearchCursor(page.Cursor); err != nil {
+		return 0, 0, err
+	}
+	limit = page.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	return offset, min(limit, MaxSearchDepth-offset), nil
+}
+
+// SearchOrders returns one page of the tenant's live orders whose track
+// number is query or whose recipient name, city or address contain every
+// word of it, track number matches first and then best match first, fully
+// hydrated unless page.Shallow. Words are matched as plain terms; tsquery
+// operators in query have no effect. Pages end at MaxSearchDepth hits.
+func (o *OrderRepository) SearchOrders(ctx context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	if strings.TrimSpace(query) == "" {
+		return &model.SearchPage{}, nil
+	}
+	offset, limit, err := searchWindow(page)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Read)
+	defer cancel()
+
+	// fetch one extra row to know whether there is a next page
+	rows, err := o.q.SearchOrders(ctx, sqlcdb.SearchOrdersParams{
+		Query:           query,
+		HeadlineOptions: headlineOptions,
+		TenantID:        tenant.FromContext(ctx),
+		MaxResults:      int64(limit + 1),
+		Skip:            int64(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search orders: %w", err)
+	}
+	res := &model.SearchPage{}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		if offset+limit < MaxSearchDepth {
+			res.NextCursor = encodeSearchCursor(offset + limit)
+		}
+	}
+
+	orders := make([]*model.Order, 0, len(rows))
+	res.Hits = make([]model.SearchHit, 0, len(rows))
+	for _, row := range rows {
+		ord := orderFromRow(sqlcdb.GetOrderRow{
+			OrderUID: row.OrderUID, TrackNumber: row.TrackNumber, Entry: row.Entry, Locale: row.Locale,
+			InternalSignature: row.InternalSignature, CustomerID: row.CustomerID, DeliveryService: row.DeliveryService,
+			ShardKey: row.ShardKey, SmID: row.SmID, DateCreated: row.DateCreated, OofShard: row.OofShard,
+			Version: row.Version, TenantID: row.TenantID, Status: row.Status, Warnings: row.Warnings,
+		})
+		orders = append(orders, ord)
+		res.Hits = append(res.Hits, model.SearchHit{
+			Order:       ord,
+			TrackNumber: row.TrackMatch,
+			Rank:        row.Rank,
+			Highlights:  highlights(map[string]string{"name": row.NameHeadline, "city": row.CityHeadline, "address": row.AddressHeadline}),
+		})
+	}
+	if page.Shallow {
+		return res, nil
+	}
+	if err := o.hydrate(ctx, orders); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// highlights keeps the headlines that contain a match and turns them into
+// escaped HTML.
+func highlights(headlines map[string]string) map[string]string {
+	var res map[string]string
+	for field, h := range headlines {
+		if !strings.Contains(h, markStart) {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]string, len(headlines))
+		}
+		res[field] = markReplacer.Replace(html.EscapeString(h))
+	}
+	return res
+}
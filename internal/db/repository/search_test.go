@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchCursor(t *testing.T) {
+	n, err := decodeSearchCursor(encodeSearchCursor(40))
+	require.NoError(t, err)
+	require.Equal(t, 40, n)
+
+	// list cursors, garbage and offsets past the depth limit are rejected
+	list := encodeCursor(&model.Order{OrderUID: "a"})
+	for _, bad := range []string{"!!!", list, encodeSearchCursor(-1), encodeSearchCursor(MaxSearchDepth)} {
+		_, err := decodeSearchCursor(bad)
+		require.ErrorIs(t, err, ErrInvalidCursor, bad)
+	}
+}
+
+func TestHighlights_EscapeRecipientData(t *testing.T) {
+	got := highlights(map[string]string{
+		"name":    markStart + "Ivan" + markStop + " <script>",
+		"city":    "Moscow",
+		"address": "",
+	})
+	require.Equal(t, map[string]string{"name": "<mark>Ivan</mark> &lt;script&gt;"}, got)
+	require.Nil(t, highlights(map[string]string{"city": "Moscow"}))
+}
+
+func TestSearchOrders_PassesPageWindow(t *testing.T) {
+	calls := recordQueries(t, func(r Repository) error {
+		_, err := r.SearchOrders(context.Background(), "Ivan", model.Page{Limit: 10, Cursor: encodeSearchCursor(MaxSearchDepth - 5)})
+		return err
+	})
+	require.Len(t, calls, 1)
+	// one look-ahead row past the depth limit, starting at the cursor
+	require.Equal(t, []any{"Ivan", headlineOptions, "default", int64(6), int64(MaxSearchDepth - 5)}, calls[0].args)
+}
+
+func TestShardedRepository_SearchMergesByRank(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	hit := func(uid string, rank float32, track bool) model.SearchHit {
+		return model.SearchHit{Order: &model.Order{OrderUID: uid}, Rank: rank, TrackNumber: track}
+	}
+	cur := encodeSearchCursor(1)
+	// each database is asked for everything up to the end of the page
+	primary.EXPECT().SearchOrders(gomock.Any(), "q", model.Page{Limit: 3}).
+		Return(&model.SearchPage{Hits: []model.SearchHit{hit("p1", 0.9, false), hit("p2", 0.1, false)}}, nil)
+	shard1.EXPECT().SearchOrders(gomock.Any(), "q", model.Page{Limit: 3}).
+		Return(&model.SearchPage{Hits: []model.SearchHit{hit("s1", 0, true), hit("s2", 0.5, false), hit("s3", 0.2, false)}, NextCursor: "more"}, nil)
+
+	page, err := repo.SearchOrders(context.Background(), "q", model.Page{Limit: 2, Cursor: cur})
+	require.NoError(t, err)
+	var uids []string
+	for _, h := range page.Hits {
+		uids = append(uids, h.Order.OrderUID)
+	}
+	// s1 (track number) comes first and is on the previous page
+	require.Equal(t, []string{"p1", "s2"}, uids)
+	require.Equal(t, encodeSearchCursor(3), page.NextCursor)
+}
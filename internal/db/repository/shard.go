@@ -0,0 +1,561 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// ShardedRepository routes writes to the database owning an order's shardkey.
+// Reads by order_uid don't know the shardkey up front, so they probe the
+// primary first and then every shard until one has the order. Methods that
+// are not shard-aware yet fall through to the embedded primary.
+type ShardedRepository struct {
+	Repository // primary
+	shards     map[string]Repository
+	keys       []string // sorted, for deterministic probe order
+}
+
+var _ Repository = (*ShardedRepository)(nil)
+
+// NewShardedRepository wraps primary with per-shardkey repositories.
+// Orders whose shardkey has no dedicated shard stay in primary.
+func NewShardedRepository(primary Repository, shards map[string]Repository) *ShardedRepository {
+	keys := make([]string, 0, len(shards))
+	for k := range shards {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return &ShardedRepository{
+		Repository: primary,
+		shards:     shards,
+		keys:       keys,
+	}
+}
+
+// ForShard returns the repository responsible for shardKey.
+func (s *ShardedRepository) ForShard(shardKey string) Repository {
+	if r, ok := s.shards[shardKey]; ok {
+		return r
+	}
+	return s.Repository
+}
+
+// ShardKeys lists the shardkeys that have a dedicated database.
+func (s *ShardedRepository) ShardKeys() []string {
+	return append([]string(nil), s.keys...)
+}
+
+// GetOrderInShard loads an order when the caller already knows its shardkey,
+// avoiding the probe across all shards.
+func (s *ShardedRepository) GetOrderInShard(ctx context.Context, shardKey, id string) (*model.Order, error) {
+	return s.ForShard(shardKey).GetOrder(ctx, id)
+}
+
+func (s *ShardedRepository) all() []Repository {
+	repos := make([]Repository, 0, len(s.keys)+1)
+	repos = append(repos, s.Repository)
+	for _, k := range s.keys {
+		repos = append(repos, s.shards[k])
+	}
+	return repos
+}
+
+func (s *ShardedRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
+	for _, r := range s.all() {
+		ord, err := r.GetOrder(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return ord, err
+	}
+	return nil, ErrNotFound
+}
+
+// GetPrimaryOrder probes like GetOrder.
+func (s *ShardedRepository) GetPrimaryOrder(ctx context.Context, id string) (*model.Order, error) {
+	for _, r := range s.all() {
+		ord, err := r.GetPrimaryOrder(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return ord, err
+	}
+	return nil, ErrNotFound
+}
+
+// GetRawPayload probes like GetOrder.
+func (s *ShardedRepository) GetRawPayload(ctx context.Context, id string) ([]byte, error) {
+	for _, r := range s.all() {
+		payload, err := r.GetRawPayload(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return payload, err
+	}
+	return nil, ErrNotFound
+}
+
+// GetOrders asks each database only for the ids not found so far, in probe order.
+func (s *ShardedRepository) GetOrders(ctx context.Context, ids []string) ([]*model.Order, error) {
+	found := make(map[string]*model.Order, len(ids))
+	missing := ids
+	for _, r := range s.all() {
+		if len(missing) == 0 {
+			break
+		}
+		orders, err := r.GetOrders(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			found[o.OrderUID] = o
+		}
+		missing = missingIDs(missing, found)
+	}
+	return inIDOrder(ids, found), nil
+}
+
+// missingIDs returns the ids without an entry in found.
+func missingIDs(ids []string, found map[string]*model.Order) []string {
+	var out []string
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// inIDOrder lists found orders in the order of ids, each once.
+func inIDOrder(ids []string, found map[string]*model.Order) []*model.Order {
+	out := make([]*model.Order, 0, len(found))
+	for _, id := range ids {
+		if o, ok := found[id]; ok {
+			out = append(out, o)
+			delete(found, id)
+		}
+	}
+	return out
+}
+
+// GetRecent merges the most recent orders of every shard.
+func (s *ShardedRepository) GetRecent(ctx context.Context, limit int) ([]*model.Order, error) {
+	var merged []*model.Order
+	for _, r := range s.all() {
+		orders, err := r.GetRecent(ctx, limit)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, orders...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].DateCreated.After(merged[j].DateCreated)
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+func (s *ShardedRepository) UpsertOrder(ctx context.Context, o *model.Order) error {
+	return s.ForShard(o.ShardKey).UpsertOrder(ctx, o)
+}
+
+func (s *ShardedRepository) ReplaceOrder(ctx context.Context, o *model.Order, current int64) error {
+	return s.ForShard(o.ShardKey).ReplaceOrder(ctx, o, current)
+}
+
+// UpsertOrders groups orders by shard and upserts each group in its shard's
+// transaction. Atomicity holds per shard only, so on error the orders of
+// earlier shards are written and counted.
+func (s *ShardedRepository) UpsertOrders(ctx context.Context, orders []*model.Order) (int, error) {
+	groups := make(map[Repository][]*model.Order)
+	var order []Repository
+	for _, o := range orders {
+		r := s.ForShard(o.ShardKey)
+		if _, ok := groups[r]; !ok {
+			order = append(order, r)
+		}
+		groups[r] = append(groups[r], o)
+	}
+	written := 0
+	for _, r := range order {
+		n, err := r.UpsertOrders(ctx, groups[r])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// OrderHistory collects revisions from every shard, since an order may have
+// moved when its shardkey changed.
+func (s *ShardedRepository) OrderHistory(ctx context.Context, id string) ([]model.OrderRevision, error) {
+	revs := make([]model.OrderRevision, 0)
+	for _, r := range s.all() {
+		part, err := r.OrderHistory(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		revs = append(revs, part...)
+	}
+	sort.SliceStable(revs, func(i, j int) bool { return revs[i].ReplacedAt.After(revs[j].ReplacedAt) })
+	if len(revs) > MaxRevisions {
+		revs = revs[:MaxRevisions]
+	}
+	return revs, nil
+}
+
+// OrderExists checks every shard, since a generated id must be unique globally.
+func (s *ShardedRepository) OrderExists(ctx context.Context, id string) (bool, error) {
+	for _, r := range s.all() {
+		exists, err := r.OrderExists(ctx, id)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+// DeleteOrder removes the order from every shard holding it; the shardkey is
+// not known from the id alone.
+func (s *ShardedRepository) DeleteOrder(ctx context.Context, id string) error {
+	return s.onEveryShard(func(r Repository) error { return r.DeleteOrder(ctx, id) })
+}
+
+func (s *ShardedRepository) UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error {
+	return s.onEveryShard(func(r Repository) error { return r.UpdateStatus(ctx, id, status) })
+}
+
+func (s *ShardedRepository) ArchiveOrder(ctx context.Context, id string) error {
+	return s.onEveryShard(func(r Repository) error { return r.ArchiveOrder(ctx, id) })
+}
+
+func (s *ShardedRepository) RestoreOrder(ctx context.Context, id string) error {
+	return s.onEveryShard(func(r Repository) error { return r.RestoreOrder(ctx, id) })
+}
+
+// ExpireOrders expires up to limit orders in each database, so one call can
+// move more than limit in total.
+func (s *ShardedRepository) ExpireOrders(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	var uids []string
+	for _, r := range s.all() {
+		expired, err := r.ExpireOrders(ctx, cutoff, limit)
+		uids = append(uids, expired...)
+		if err != nil {
+			return uids, err
+		}
+	}
+	return uids, nil
+}
+
+// AnonymizeCustomer erases the customer in every database, since their orders are
+// routed by shardkey and may sit in any of them.
+func (s *ShardedRepository) AnonymizeCustomer(ctx context.Context, customerID string) ([]string, error) {
+	var uids []string
+	for _, r := range s.all() {
+		erased, err := r.AnonymizeCustomer(ctx, customerID)
+		uids = append(uids, erased...)
+		if err != nil {
+			return uids, err
+		}
+	}
+	return uids, nil
+}
+
+// StreamOrders streams the primary and then each shard; batches never span
+// databases.
+func (s *ShardedRepository) StreamOrders(ctx context.Context, fn func(batch []*model.Order) error) error {
+	for _, r := range s.all() {
+		if err := r.StreamOrders(ctx, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchOrders merges the hits of every database by rank. Ranks interleave
+// across databases, so each one is asked for all hits up to the end of the
+// requested page and the page is cut from the merged list.
+func (s *ShardedRepository) SearchOrders(ctx context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	offset, limit, err := searchWindow(page)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		hits []model.SearchHit
+		more bool
+	)
+	for _, r := range s.all() {
+		p, err := r.SearchOrders(ctx, query, model.Page{Limit: offset + limit, Shallow: page.Shallow})
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, p.Hits...)
+		more = more || p.NextCursor != ""
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return betterHit(hits[i], hits[j]) })
+
+	hits = hits[min(offset, len(hits)):]
+	if len(hits) > limit {
+		hits = hits[:limit]
+		more = true
+	}
+	res := &model.SearchPage{Hits: hits}
+	if more && offset+limit < MaxSearchDepth {
+		res.NextCursor = encodeSearchCursor(offset + limit)
+	}
+	return res, nil
+}
+
+// betterHit orders hits the way the search query does.
+func betterHit(a, b model.SearchHit) bool {
+	if a.TrackNumber != b.TrackNumber {
+		return a.TrackNumber
+	}
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+	if !a.Order.DateCreated.Equal(b.Order.DateCreated) {
+		return a.Order.DateCreated.After(b.Order.DateCreated)
+	}
+	return a.Order.OrderUID > b.Order.OrderUID
+}
+
+// FetchUnsentEvents merges up to limit unsent events of every database,
+// oldest first. Ids are per database; MarkSent routes by ShardKey.
+func (s *ShardedRepository) FetchUnsentEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	if limit <= 0 {
+		limit = DefaultOutboxBatch
+	}
+	var merged []model.OutboxEvent
+	for _, r := range s.all() {
+		events, err := r.FetchUnsentEvents(ctx, limit)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, events...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.Before(merged[j].CreatedAt)
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// MarkSent marks each event in the database owning its shardkey.
+func (s *ShardedRepository) MarkSent(ctx context.Context, events []model.OutboxEvent) error {
+	byShard := make(map[string][]model.OutboxEvent)
+	for _, ev := range events {
+		key := ev.ShardKey
+		if _, ok := s.shards[key]; !ok {
+			key = ""
+		}
+		byShard[key] = append(byShard[key], ev)
+	}
+	for key, evs := range byShard {
+		if err := s.ForShard(key).MarkSent(ctx, evs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HealthCheck requires the primary and every shard to answer.
+func (s *ShardedRepository) HealthCheck(ctx context.Context) error {
+	if err := s.Repository.HealthCheck(ctx); err != nil {
+		return err
+	}
+	for _, k := range s.keys {
+		if err := s.shards[k].HealthCheck(ctx); err != nil {
+			return fmt.Errorf("shard %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// onEveryShard runs op on all databases and returns ErrNotFound only if none
+// of them had the order.
+func (s *ShardedRepository) onEveryShard(op func(Repository) error) error {
+	found := false
+	for _, r := range s.all() {
+		err := op(r)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListOrders runs the same keyset page on every shard and merges the results;
+// keyset cursors are position-based, so the merged cursor is valid for all shards.
+func (s *ShardedRepository) ListOrders(ctx context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	return s.listAcross(page, func(r Repository, p model.Page) (*model.OrderPage, error) {
+		return r.ListOrders(ctx, f, p)
+	})
+}
+
+func (s *ShardedRepository) GetOrdersByCustomer(ctx context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	return s.listAcross(page, func(r Repository, p model.Page) (*model.OrderPage, error) {
+		return r.GetOrdersByCustomer(ctx, customerID, p)
+	})
+}
+
+func (s *ShardedRepository) listAcross(page model.Page, list func(Repository, model.Page) (*model.OrderPage, error)) (*model.OrderPage, error) {
+	limit := clampLimit(page.Limit)
+	page.Limit = limit
+	var (
+		merged []*model.Order
+		more   bool
+	)
+	for _, r := range s.all() {
+		p, err := list(r, page)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, p.Orders...)
+		more = more || p.NextCursor != ""
+	}
+	return mergePage(merged, page.Sort, limit, more), nil
+}
+
+// mergePage sorts orders from several shards in order and cuts a page of limit.
+func mergePage(orders []*model.Order, order model.Sort, limit int, more bool) *model.OrderPage {
+	sort.SliceStable(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		if order == model.SortOldest {
+			a, b = b, a
+		}
+		if !a.DateCreated.Equal(b.DateCreated) {
+			return a.DateCreated.After(b.DateCreated)
+		}
+		return a.OrderUID > b.OrderUID
+	})
+	res := &model.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		res.Orders = orders[:limit]
+		more = true
+	}
+	if more && len(res.Orders) > 0 {
+		res.NextCursor = encodeCursor(res.Orders[len(res.Orders)-1])
+	}
+	return res
+}
+
+// OrdersPerDay adds up the per-day counts of every shard.
+func (s *ShardedRepository) OrdersPerDay(ctx context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	byDay := make(map[int64]model.DailyCount) // keyed by unix seconds; time.Time is not a safe map key
+	for _, r := range s.all() {
+		days, err := r.OrdersPerDay(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range days {
+			acc, ok := byDay[d.Day.Unix()]
+			if !ok {
+				acc.Day = d.Day
+			}
+			acc.Orders += d.Orders
+			byDay[d.Day.Unix()] = acc
+		}
+	}
+	out := make([]model.DailyCount, 0, len(byDay))
+	for _, d := range byDay {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day.Before(out[j].Day) })
+	return out, nil
+}
+
+func (s *ShardedRepository) GoodsByDeliveryService(ctx context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	totals := make(map[string]*model.DeliveryServiceTotal)
+	var keys []string
+	for _, r := range s.all() {
+		rows, err := r.GoodsByDeliveryService(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range rows {
+			acc, ok := totals[t.DeliveryService]
+			if !ok {
+				acc = &model.DeliveryServiceTotal{DeliveryService: t.DeliveryService}
+				totals[t.DeliveryService] = acc
+				keys = append(keys, t.DeliveryService)
+			}
+			acc.Orders += t.Orders
+			acc.GoodsTotal += t.GoodsTotal
+		}
+	}
+	out := make([]model.DeliveryServiceTotal, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, *totals[k])
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].GoodsTotal != out[j].GoodsTotal {
+			return out[i].GoodsTotal > out[j].GoodsTotal
+		}
+		return out[i].DeliveryService < out[j].DeliveryService
+	})
+	return out, nil
+}
+
+// TopCustomers merges each shard's top list. A customer whose orders are
+// spread over shards is summed only from the shards where they made the cut,
+// so totals near the bottom of the list may be understated.
+func (s *ShardedRepository) TopCustomers(ctx context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	totals := make(map[string]*model.CustomerTotal)
+	var keys []string
+	for _, r := range s.all() {
+		rows, err := r.TopCustomers(ctx, f, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range rows {
+			acc, ok := totals[c.CustomerID]
+			if !ok {
+				acc = &model.CustomerTotal{CustomerID: c.CustomerID}
+				totals[c.CustomerID] = acc
+				keys = append(keys, c.CustomerID)
+			}
+			acc.Orders += c.Orders
+			acc.GoodsTotal += c.GoodsTotal
+		}
+	}
+	out := make([]model.CustomerTotal, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, *totals[k])
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.GoodsTotal != b.GoodsTotal {
+			return a.GoodsTotal > b.GoodsTotal
+		}
+		if a.Orders != b.Orders {
+			return a.Orders > b.Orders
+		}
+		return a.CustomerID < b.CustomerID
+	})
+	if limit <= 0 {
+		limit = DefaultTopCustomers
+	}
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedRepository_UpsertRoutesByShardKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	onShard := &model.Order{OrderUID: "a", ShardKey: "1"}
+	onPrimary := &model.Order{OrderUID: "b", ShardKey: "9"}
+
+	shard1.EXPECT().UpsertOrder(gomock.Any(), onShard).Return(nil).Times(1)
+	primary.EXPECT().UpsertOrder(gomock.Any(), onPrimary).Return(nil).Times(1)
+
+	require.NoError(t, repo.UpsertOrder(context.Background(), onShard))
+	require.NoError(t, repo.UpsertOrder(context.Background(), onPrimary))
+}
+
+func TestShardedRepository_GetOrderProbesShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	want := &model.Order{OrderUID: "a", ShardKey: "1"}
+	primary.EXPECT().GetOrder(gomock.Any(), "a").Return(nil, ErrNotFound)
+	shard1.EXPECT().GetOrder(gomock.Any(), "a").Return(want, nil)
+
+	got, err := repo.GetOrder(context.Background(), "a")
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestShardedRepository_GetRecentMergesNewestFirst(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	now := time.Now()
+	old := &model.Order{OrderUID: "old", DateCreated: now.Add(-time.Hour)}
+	mid := &model.Order{OrderUID: "mid", DateCreated: now.Add(-time.Minute)}
+	newest := &model.Order{OrderUID: "new", DateCreated: now}
+
+	primary.EXPECT().GetRecent(gomock.Any(), 2).Return([]*model.Order{mid, old}, nil)
+	shard1.EXPECT().GetRecent(gomock.Any(), 2).Return([]*model.Order{newest}, nil)
+
+	got, err := repo.GetRecent(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, []*model.Order{newest, mid}, got)
+}
+
+func TestShardedRepository_DeleteOrderAllShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	primary.EXPECT().DeleteOrder(gomock.Any(), "a").Return(ErrNotFound)
+	shard1.EXPECT().DeleteOrder(gomock.Any(), "a").Return(nil)
+	require.NoError(t, repo.DeleteOrder(context.Background(), "a"))
+
+	primary.EXPECT().DeleteOrder(gomock.Any(), "b").Return(ErrNotFound)
+	shard1.EXPECT().DeleteOrder(gomock.Any(), "b").Return(ErrNotFound)
+	require.ErrorIs(t, repo.DeleteOrder(context.Background(), "b"), ErrNotFound)
+}
+
+func TestShardedRepository_UpsertOrdersGroupsByShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	a := &model.Order{OrderUID: "a", ShardKey: "1"}
+	b := &model.Order{OrderUID: "b", ShardKey: "9"}
+	c := &model.Order{OrderUID: "c", ShardKey: "1"}
+
+	shard1.EXPECT().UpsertOrders(gomock.Any(), []*model.Order{a, c}).Return(2, nil)
+	primary.EXPECT().UpsertOrders(gomock.Any(), []*model.Order{b}).Return(0, nil)
+
+	n, err := repo.UpsertOrders(context.Background(), []*model.Order{a, b, c})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestShardedRepository_GetOrdersAsksOnlyForMisses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	a, b := &model.Order{OrderUID: "a"}, &model.Order{OrderUID: "b", ShardKey: "1"}
+	primary.EXPECT().GetOrders(gomock.Any(), []string{"b", "a", "x"}).Return([]*model.Order{a}, nil)
+	shard1.EXPECT().GetOrders(gomock.Any(), []string{"b", "x"}).Return([]*model.Order{b}, nil)
+
+	got, err := repo.GetOrders(context.Background(), []string{"b", "a", "x"})
+	require.NoError(t, err)
+	require.Equal(t, []*model.Order{b, a}, got)
+}
+
+func TestShardedRepository_HealthCheckNamesFailingShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	down := errors.New("ping: connection refused")
+	primary.EXPECT().HealthCheck(gomock.Any()).Return(nil).Times(2)
+	shard1.EXPECT().HealthCheck(gomock.Any()).Return(nil)
+	require.NoError(t, repo.HealthCheck(context.Background()))
+
+	shard1.EXPECT().HealthCheck(gomock.Any()).Return(down)
+	err := repo.HealthCheck(context.Background())
+	require.ErrorIs(t, err, down)
+	require.ErrorContains(t, err, "shard 1")
+}
+
+func TestOrderRepository_HealthCheckPings(t *testing.T) {
+	db := openRecorder()
+	require.NoError(t, NewOrderRepository(db, nil).HealthCheck(context.Background()))
+	calls := db.snapshot()
+	require.Len(t, calls, 1)
+	require.Equal(t, qPing, calls[0].query)
+}
+
+func TestShardedRepository_StreamOrdersVisitsEveryDatabase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	feed := func(uid string) func(context.Context, func([]*model.Order) error) error {
+		return func(_ context.Context, fn func([]*model.Order) error) error {
+			return fn([]*model.Order{{OrderUID: uid}})
+		}
+	}
+	gomock.InOrder(
+		primary.EXPECT().StreamOrders(gomock.Any(), gomock.Any()).DoAndReturn(feed("a")),
+		shard1.EXPECT().StreamOrders(gomock.Any(), gomock.Any()).DoAndReturn(feed("b")),
+	)
+
+	var seen []string
+	require.NoError(t, repo.StreamOrders(context.Background(), func(batch []*model.Order) error {
+		for _, o := range batch {
+			seen = append(seen, o.OrderUID)
+		}
+		return nil
+	}))
+	require.Equal(t, []string{"a", "b"}, seen)
+}
+
+func TestShardedRepository_OutboxRoutesByShardKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	now := time.Now()
+	onPrimary := model.OutboxEvent{ID: 1, ShardKey: "9", CreatedAt: now}
+	onShard := model.OutboxEvent{ID: 1, ShardKey: "1", CreatedAt: now.Add(-time.Second)}
+	primary.EXPECT().FetchUnsentEvents(gomock.Any(), 10).Return([]model.OutboxEvent{onPrimary}, nil)
+	shard1.EXPECT().FetchUnsentEvents(gomock.Any(), 10).Return([]model.OutboxEvent{onShard}, nil)
+
+	events, err := repo.FetchUnsentEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Equal(t, []model.OutboxEvent{onShard, onPrimary}, events)
+
+	// equal ids of different databases are marked in their own database only
+	primary.EXPECT().MarkSent(gomock.Any(), []model.OutboxEvent{onPrimary}).Return(nil)
+	shard1.EXPECT().MarkSent(gomock.Any(), []model.OutboxEvent{onShard}).Return(nil)
+	require.NoError(t, repo.MarkSent(context.Background(), events))
+}
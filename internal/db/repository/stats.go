@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// DefaultTopCustomers is the TopCustomers limit used when none is given.
+const DefaultTopCustomers = 10
+
+// statsQuery renders an aggregate over orders (aliased o) filtered by f. tmpl
+// has one %s for the WHERE clause followed by one %s per extra value; extra
+// values are bound after the filter's.
+func statsQuery(tmpl string, f model.OrderFilter, extra ...any) (string, []any) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	ph := make([]any, 0, len(extra)+1)
+	ph = append(ph, "WHERE "+strings.Join(filterConditions(f, arg), " AND "))
+	for _, v := range extra {
+		ph = append(ph, arg(v))
+	}
+	return fmt.Sprintf(tmpl, ph...), args
+}
+
+const (
+	qOrdersPerDay = `
+SELECT date_trunc('day', o.date_created) AS day, count(*)
+FROM orders o
+%s
+GROUP BY day
+ORDER BY day`
+
+	qGoodsByDeliveryService = `
+SELECT o.delivery_service, count(*), COALESCE(sum(p.goods_total), 0)
+FROM orders o LEFT JOIN payments p ON p.order_uid = o.order_uid
+%s
+GROUP BY o.delivery_service
+ORDER BY 3 DESC, 1`
+
+	qTopCustomers = `
+SELECT o.customer_id, count(*), COALESCE(sum(p.goods_total), 0)
+FROM orders o LEFT JOIN payments p ON p.order_uid = o.order_uid
+%s
+GROUP BY o.customer_id
+ORDER BY 3 DESC, 2 DESC, 1
+LIMIT %s`
+)
+
+// OrdersPerDay counts orders matching f per day of date_created, oldest first.
+func (o *OrderRepository) OrdersPerDay(ctx context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	query, args := statsQuery(qOrdersPerDay, withTenant(ctx, f))
+	out := make([]model.DailyCount, 0)
+	err := o.aggregate(ctx, query, args, func(rows pgx.Rows) error {
+		var d model.DailyCount
+		if err := rows.Scan(&d.Day, &d.Orders); err != nil {
+			return err
+		}
+		out = append(out, d)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select orders per day: %w", err)
+	}
+	return out, nil
+}
+
+// GoodsByDeliveryService sums payments.goods_total of orders matching f per
+// delivery service, largest first.
+func (o *OrderRepository) GoodsByDeliveryService(ctx context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	query, args := statsQuery(qGoodsByDeliveryService, withTenant(ctx, f))
+	out := make([]model.DeliveryServiceTotal, 0)
+	err := o.aggregate(ctx, query, args, func(rows pgx.Rows) error {
+		var d model.DeliveryServiceTotal
+		if err := rows.Scan(&d.DeliveryService, &d.Orders, &d.GoodsTotal); err != nil {
+			return err
+		}
+		out = append(out, d)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select goods by delivery service: %w", err)
+	}
+	return out, nil
+}
+
+// TopCustomers returns up to limit customers ranked by goods total of their
+// orders matching f.
+func (o *OrderRepository) TopCustomers(ctx context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	if limit <= 0 {
+		limit = DefaultTopCustomers
+	}
+	query, args := statsQuery(qTopCustomers, withTenant(ctx, f), limit)
+	out := make([]model.CustomerTotal, 0)
+	err := o.aggregate(ctx, query, args, func(rows pgx.Rows) error {
+		var c model.CustomerTotal
+		if err := rows.Scan(&c.CustomerID, &c.Orders, &c.GoodsTotal); err != nil {
+			return err
+		}
+		out = append(out, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select top customers: %w", err)
+	}
+	return out, nil
+}
+
+// aggregate runs a stats query; these scan whole tables, so they get a longer
+// budget than point reads.
+func (o *OrderRepository) aggregate(ctx context.Context, query string, args []any, scan func(pgx.Rows) error) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Stats)
+	defer cancel()
+
+	rows, err := o.db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsQuery_BindsFilterThenExtra(t *testing.T) {
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	query, args := statsQuery(qTopCustomers, model.OrderFilter{From: from}, 5)
+
+	require.Contains(t, query, "WHERE deleted_at IS NULL AND date_created >= $1")
+	require.Contains(t, query, "LIMIT $2")
+	require.Equal(t, []any{from, 5}, args)
+
+	query, args = statsQuery(qOrdersPerDay, model.OrderFilter{})
+	require.Contains(t, query, "WHERE deleted_at IS NULL\n")
+	require.Empty(t, args)
+}
+
+func TestTopCustomers_Parameterized(t *testing.T) {
+	for _, s := range injectionSeeds {
+		if s == "" {
+			continue
+		}
+		requireParameterized(t, s, func(r Repository, in string) error {
+			_, err := r.TopCustomers(context.Background(), model.OrderFilter{CustomerID: in}, 0)
+			return err
+		})
+	}
+}
+
+func TestShardedRepository_StatsSumAcrossShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mocks.NewMockRepository(ctrl)
+	shard1 := mocks.NewMockRepository(ctrl)
+	repo := NewShardedRepository(primary, map[string]Repository{"1": shard1})
+
+	d1 := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	d2 := d1.AddDate(0, 0, 1)
+	primary.EXPECT().OrdersPerDay(gomock.Any(), gomock.Any()).Return([]model.DailyCount{{Day: d2, Orders: 1}}, nil)
+	shard1.EXPECT().OrdersPerDay(gomock.Any(), gomock.Any()).Return([]model.DailyCount{{Day: d1, Orders: 2}, {Day: d2, Orders: 3}}, nil)
+
+	days, err := repo.OrdersPerDay(context.Background(), model.OrderFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []model.DailyCount{{Day: d1, Orders: 2}, {Day: d2, Orders: 4}}, days)
+
+	primary.EXPECT().TopCustomers(gomock.Any(), gomock.Any(), 2).Return([]model.CustomerTotal{
+		{CustomerID: "a", Orders: 1, GoodsTotal: 100},
+		{CustomerID: "b", Orders: 1, GoodsTotal: 50},
+	}, nil)
+	shard1.EXPECT().TopCustomers(gomock.Any(), gomock.Any(), 2).Return([]model.CustomerTotal{
+		{CustomerID: "c", Orders: 2, GoodsTotal: 120},
+		{CustomerID: "b", Orders: 1, GoodsTotal: 60},
+	}, nil)
+
+	top, err := repo.TopCustomers(context.Background(), model.OrderFilter{}, 2)
+	require.NoError(t, err)
+	require.Equal(t, []model.CustomerTotal{
+		{CustomerID: "c", Orders: 2, GoodsTotal: 120},
+		{CustomerID: "b", Orders: 2, GoodsTotal: 110},
+	}, top)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+const (
+	qSelStatusForUpdate = `
+SELECT shardkey, status FROM orders
+WHERE order_uid = $1 AND tenant_id = $2 AND deleted_at IS NULL
+FOR UPDATE`
+
+	qUpdStatus = `UPDATE orders SET status = $1 WHERE order_uid = $2`
+)
+
+// StatusTransitionError is returned by UpdateStatus for a status the stored
+// order cannot move to from the one it has (model.OrderStatus.CanBecome).
+type StatusTransitionError struct {
+	OrderUID string
+	From, To model.OrderStatus
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("order %s cannot go from %s to %s", e.OrderUID, e.From, e.To)
+}
+
+// initialStatus is the status a newly inserted order is stored with.
+func initialStatus(ord *model.Order) model.OrderStatus {
+	if ord.Status == "" {
+		return model.StatusCreated
+	}
+	return ord.Status
+}
+
+// UpdateStatus moves the order to status and records an
+// EventOrderStatusChanged event in the same transaction. Setting the status
+// the order already has is a no-op; archived orders and orders of other
+// tenants are ErrNotFound. The transition is checked against the status
+// locked in the transaction, so concurrent updates are checked one after the
+// other; a status the order cannot move to is a *StatusTransitionError.
+func (o *OrderRepository) UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Write)
+	defer cancel()
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tenantID := tenant.FromContext(ctx)
+	var shardKey string
+	var current model.OrderStatus
+	err = tx.QueryRow(ctx, qSelStatusForUpdate, id, tenantID).Scan(&shardKey, &current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("select orders status: %w", err)
+	}
+	// a redelivered status message must not emit a second event
+	if current == status {
+		return nil
+	}
+	if !current.CanBecome(status) {
+		return &StatusTransitionError{OrderUID: id, From: current, To: status}
+	}
+	if _, err := tx.Exec(ctx, qUpdStatus, status, id); err != nil {
+		return fmt.Errorf("update orders status: %w", err)
+	}
+
+	payload, err := json.Marshal(model.StatusUpdate{OrderUID: id, Status: status})
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", model.EventOrderStatusChanged, err)
+	}
+	if err := insertOutboxEvents(ctx, tx, [][]any{{tenantID, shardKey, id, model.EventOrderStatusChanged, payload}}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+// statusDB answers the status lookup of UpdateStatus with a stored order.
+type statusDB struct {
+	*recordingDB
+	current model.OrderStatus
+}
+
+func (d *statusDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &statusTx{recordingTx: &recordingTx{d: d.recordingDB}, current: d.current}, nil
+}
+
+type statusTx struct {
+	*recordingTx
+	current model.OrderStatus
+}
+
+func (t *statusTx) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	t.d.record(query, args)
+	return statusRow{t.current}
+}
+
+type statusRow struct{ current model.OrderStatus }
+
+func (r statusRow) Scan(dest ...any) error {
+	*dest[0].(*string) = "7"
+	*dest[1].(*model.OrderStatus) = r.current
+	return nil
+}
+
+func TestUpdateStatus_WritesEventInTx(t *testing.T) {
+	db := &statusDB{recordingDB: openRecorder(), current: model.StatusCreated}
+	repo := NewOrderRepository(db, nil)
+	ctx := tenant.WithID(context.Background(), "shop-1")
+
+	require.NoError(t, repo.UpdateStatus(ctx, "a", model.StatusPaid))
+	calls := db.snapshot()
+	require.Len(t, calls, 3)
+	require.Equal(t, []any{"a", "shop-1"}, calls[0].args)
+	require.Equal(t, qUpdStatus, calls[1].query)
+	require.Equal(t, []any{model.StatusPaid, "a"}, calls[1].args)
+	require.Contains(t, calls[2].query, "INSERT INTO events_outbox")
+	require.Equal(t, []any{"shop-1", "7", "a", model.EventOrderStatusChanged}, calls[2].args[:4])
+	require.JSONEq(t, `{"order_uid":"a","status":"paid"}`, string(calls[2].args[4].([]byte)))
+
+	// the same status again changes nothing
+	db = &statusDB{recordingDB: openRecorder(), current: model.StatusPaid}
+	require.NoError(t, NewOrderRepository(db, nil).UpdateStatus(ctx, "a", model.StatusPaid))
+	require.Len(t, db.snapshot(), 1)
+}
+
+func TestUpdateStatus_NotFound(t *testing.T) {
+	calls := recordQueries(t, func(r Repository) error {
+		require.ErrorIs(t, r.UpdateStatus(context.Background(), "a", model.StatusPaid), ErrNotFound)
+		return nil
+	})
+	require.Len(t, calls, 1)
+	require.Equal(t, []any{"a", tenant.Default}, calls[0].args)
+}
+
+func TestUpdateStatus_ChecksTheLockedStatus(t *testing.T) {
+	// the order was cancelled after the caller last read it
+	db := &statusDB{recordingDB: openRecorder(), current: model.StatusCancelled}
+	err := NewOrderRepository(db, nil).UpdateStatus(context.Background(), "a", model.StatusShipped)
+
+	var terr *StatusTransitionError
+	require.ErrorAs(t, err, &terr)
+	require.Equal(t, &StatusTransitionError{OrderUID: "a", From: model.StatusCancelled, To: model.StatusShipped}, terr)
+	// nothing is written after the locking read
+	require.Len(t, db.snapshot(), 1)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// StreamBatchSize is the number of orders fetched from the cursor at a time.
+const StreamBatchSize = 500
+
+const (
+	qStreamSnapshot = `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`
+
+	// ordered by the primary key so batches are stable and cheap to resume from
+	qDeclareStream = `
+DECLARE orders_stream NO SCROLL CURSOR FOR
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
+       delivery_service, shardkey, sm_id, date_created, oof_shard, version, tenant_id, status, warnings
+FROM orders WHERE deleted_at IS NULL
+ORDER BY order_uid`
+
+	qFetchStream = `FETCH FORWARD $1 FROM orders_stream`
+)
+
+// StreamOrders calls fn with every live order of every tenant, fully hydrated, in batches of
+// StreamBatchSize ordered by order_uid. The whole stream reads one snapshot
+// through a server-side cursor, so memory stays bounded by one batch and
+// concurrent writes don't shift batch boundaries. An error from fn stops the
+// stream and is returned as is. There is no timeout beyond ctx.
+func (o *OrderRepository) StreamOrders(ctx context.Context, fn func(batch []*model.Order) error) error {
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // closes the cursor as well
+
+	if _, err := tx.Exec(ctx, qStreamSnapshot); err != nil {
+		return fmt.Errorf("set transaction: %w", err)
+	}
+	if _, err := tx.Exec(ctx, qDeclareStream); err != nil {
+		return fmt.Errorf("declare cursor: %w", err)
+	}
+
+	q := o.q.WithTx(tx)
+	for {
+		batch, err := fetchStream(ctx, tx, StreamBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := hydrateWith(ctx, q, batch); err != nil {
+			return err
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < StreamBatchSize {
+			return nil
+		}
+	}
+}
+
+func fetchStream(ctx context.Context, tx pgx.Tx, n int) ([]*model.Order, error) {
+	rows, err := tx.Query(ctx, qFetchStream, n)
+	if err != nil {
+		return nil, fmt.Errorf("fetch orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]*model.Order, 0, n)
+	for rows.Next() {
+		var ord model.Order
+		if err := rows.Scan(
+			&ord.OrderUID, &ord.TrackNumber, &ord.Entry, &ord.Locale, &ord.InternalSignature,
+			&ord.CustomerID, &ord.DeliveryService, &ord.ShardKey, &ord.SmID, &ord.DateCreated, &ord.OofShard, &ord.Version,
+			&ord.TenantID, &ord.Status, &ord.Warnings,
+		); err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		orders = append(orders, &ord)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return orders, nil
+}
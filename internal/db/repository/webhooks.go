@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+const (
+	qInsWebhookDelivery = `
+INSERT INTO webhook_deliveries (url, event_type, tenant_id, order_uid, status, attempts, response_code, last_error, delivered_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id`
+
+	qUpdWebhookDelivery = `
+UPDATE webhook_deliveries
+SET status = $2, attempts = $3, response_code = $4, last_error = $5, delivered_at = $6, updated_at = now()
+WHERE id = $1`
+)
+
+// SaveWebhookDelivery records a new delivery and fills in d.ID, or, once d.ID
+// is set, updates that delivery with the outcome of its latest attempt.
+// Deliveries live in the primary database whatever shard the order is in.
+func (o *OrderRepository) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeouts.Write)
+	defer cancel()
+
+	if d.ID == 0 {
+		err := o.db.QueryRow(ctx, qInsWebhookDelivery,
+			d.URL, d.EventType, d.TenantID, d.OrderUID, string(d.Status), d.Attempts, d.ResponseCode, d.LastError, d.DeliveredAt,
+		).Scan(&d.ID)
+		if err != nil {
+			return fmt.Errorf("insert webhook delivery: %w", err)
+		}
+		return nil
+	}
+	if _, err := o.db.Exec(ctx, qUpdWebhookDelivery,
+		d.ID, string(d.Status), d.Attempts, d.ResponseCode, d.LastError, d.DeliveredAt,
+	); err != nil {
+		return fmt.Errorf("update webhook delivery %d: %w", d.ID, err)
+	}
+	return nil
+}
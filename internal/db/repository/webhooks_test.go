@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveWebhookDelivery_InsertsThenUpdates(t *testing.T) {
+	db := openRecorder()
+	repo := NewOrderRepository(db, nil)
+	d := &model.WebhookDelivery{URL: "https://hooks.example", EventType: model.EventOrderUpserted, TenantID: "default", OrderUID: "a", Status: model.WebhookPending}
+
+	// the recorder returns no row for RETURNING id
+	require.Error(t, repo.SaveWebhookDelivery(context.Background(), d))
+	d.ID, d.Status, d.Attempts, d.ResponseCode = 7, model.WebhookDelivered, 2, 204
+	require.NoError(t, repo.SaveWebhookDelivery(context.Background(), d))
+
+	calls := db.snapshot()
+	require.Len(t, calls, 2)
+	require.Contains(t, calls[0].query, "INSERT INTO webhook_deliveries")
+	require.Equal(t, []any{"https://hooks.example", model.EventOrderUpserted, "default", "a", "pending"}, calls[0].args[:5])
+	require.Contains(t, calls[1].query, "UPDATE webhook_deliveries")
+	require.Equal(t, []any{int64(7), "delivered", 2, 204}, calls[1].args[:4])
+}
@@ -0,0 +1,71 @@
+// Package events carries the changes the order service makes to the side
+// effects that follow them, such as the live order stream, so the service
+// does not need to know who listens.
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/pubsub"
+)
+
+// Event is one change to an order, published after it was stored. Type is one
+// of the model.Event* constants the outbox uses for the same change.
+type Event struct {
+	Type     string
+	TenantID string
+	OrderUID string
+	// Order is the order as stored, for model.EventOrderUpserted.
+	Order *model.Order
+	// Status is the new status, for model.EventOrderStatusChanged.
+	Status model.OrderStatus
+}
+
+// Publisher receives the events of the order service, e.g. a *Bus.
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// Subscriber handles an event. It runs on the goroutine that made the change
+// and must not block; it has no way to fail the change, which is already
+// stored.
+type Subscriber func(ctx context.Context, e Event)
+
+// Bus hands every event to every subscriber, in the order they subscribed.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []Subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds s for all events published from now on.
+func (b *Bus) Subscribe(s Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, s)
+}
+
+// Publish calls every subscriber with e before returning.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+	for _, s := range subs {
+		s(ctx, e)
+	}
+}
+
+// ToHub relays stored orders to hub, for the live order stream. Other events
+// are ignored.
+func ToHub(hub *pubsub.Hub[*model.Order]) Subscriber {
+	return func(_ context.Context, e Event) {
+		if e.Type == model.EventOrderUpserted && e.Order != nil {
+			hub.Publish(e.Order)
+		}
+	}
+}
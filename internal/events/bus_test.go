@@ -0,0 +1,38 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_CallsSubscribersInOrder(t *testing.T) {
+	b := NewBus()
+	var got []string
+	b.Subscribe(func(_ context.Context, e Event) { got = append(got, "a "+e.OrderUID) })
+	b.Subscribe(func(_ context.Context, e Event) { got = append(got, "b "+e.OrderUID) })
+
+	b.Publish(context.Background(), Event{Type: model.EventOrderDeleted, OrderUID: "b1"})
+	require.Equal(t, []string{"a b1", "b b1"}, got)
+}
+
+func TestToHub_RelaysStoredOrdersOnly(t *testing.T) {
+	hub := pubsub.NewHub[*model.Order]()
+	sub := hub.Subscribe(4)
+	b := NewBus()
+	b.Subscribe(ToHub(hub))
+
+	b.Publish(context.Background(), Event{Type: model.EventOrderUpserted, OrderUID: "b1", Order: &model.Order{OrderUID: "b1"}})
+	b.Publish(context.Background(), Event{Type: model.EventOrderStatusChanged, OrderUID: "b1", Status: model.StatusPaid})
+	b.Publish(context.Background(), Event{Type: model.EventOrderDeleted, OrderUID: "b1"})
+	hub.Close()
+
+	var got []string
+	for o := range sub.C {
+		got = append(got, o.OrderUID)
+	}
+	require.Equal(t, []string{"b1"}, got)
+}
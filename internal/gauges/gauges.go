@@ -0,0 +1,46 @@
+// Package gauges publishes the depth of internal queues through expvar, so a
+// single scrape of /debug/vars shows where backpressure accumulates.
+//
+// Every component that buffers work (broker backlog, caches, worker pools,
+// subscriber lists, ...) registers a gauge under the "queues" map. The same
+// gauges are exported at /metrics as queue_depth{queue="<name>"}. Gauges are
+// evaluated on each scrape, so the callback must be cheap and must not block.
+package gauges
+
+import (
+	"expvar"
+	"runtime"
+	"sync"
+
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+)
+
+var (
+	queues = expvar.NewMap("queues")
+	mu     sync.Mutex
+	funcs  = map[string]func() int64{}
+)
+
+func init() {
+	metrics.NewGaugeSet("queue_depth", "Depth of internal queues and buffers.", "queue", snapshot)
+	Register("goroutines", func() int64 { return int64(runtime.NumGoroutine()) })
+}
+
+// Register publishes f under name in the "queues" map, replacing any gauge
+// registered under the same name before.
+func Register(name string, f func() int64) {
+	mu.Lock()
+	funcs[name] = f
+	mu.Unlock()
+	queues.Set(name, expvar.Func(func() any { return f() }))
+}
+
+func snapshot() map[string]float64 {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make(map[string]float64, len(funcs))
+	for name, f := range funcs {
+		out[name] = float64(f())
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package gauges
+
+import (
+	"encoding/json"
+	"expvar"
+	"strings"
+	"testing"
+
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	depth := int64(3)
+	Register("test_queue", func() int64 { return depth })
+	depth = 7
+
+	var got map[string]int64
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("queues").String()), &got))
+	require.Equal(t, int64(7), got["test_queue"])
+	require.Positive(t, got["goroutines"])
+}
+
+func TestRegister_ExportsQueueDepth(t *testing.T) {
+	Register("test_metrics_queue", func() int64 { return 5 })
+
+	var b strings.Builder
+	_, err := metrics.Default.WriteTo(&b)
+	require.NoError(t, err)
+	require.Contains(t, b.String(), `queue_depth{queue="test_metrics_queue"} 5`+"\n")
+}
@@ -0,0 +1,74 @@
+// Package graph serves read-only GraphQL queries over orders and customers.
+//
+// The schema is schema.graphqls; exec.go and models_gen.go are generated from
+// it by gqlgen (see gqlgen.yml) and schema.resolvers.go holds the resolvers.
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+	"github.com/merkulovlad/wbtech-go/internal/service/order"
+)
+
+// MaxComplexity bounds the fields a query may resolve. Listings count once
+// per order they may return, so customer { orders(limit: 100) { orders {
+// customer { orders(limit: 100) { ... } } } } } cannot fan out unbounded.
+const MaxComplexity = 5000
+
+// Resolver answers queries through the order service. The server fills in
+// how client input is checked and how service errors are reported.
+type Resolver struct {
+	Service order.Service
+	// ValidOrderUID and ValidCustomerID report whether an id from the query
+	// may be looked up.
+	ValidOrderUID   func(string) bool
+	ValidCustomerID func(string) bool
+	// MaxBatch bounds the order_uids of orders.
+	MaxBatch int
+	// ServiceError turns an error of Service into the error of the field;
+	// failed is the message for errors it does not recognize.
+	ServiceError func(ctx context.Context, err error, failed i18n.Key) error
+}
+
+// Customer is the source of Customer fields; its orders are listed when
+// selected.
+type Customer struct {
+	ID string `json:"id"`
+}
+
+// NewExecutor returns the executor of r's schema with introspection and the
+// MaxComplexity limit.
+func NewExecutor(r *Resolver) *executor.Executor {
+	cfg := Config{Resolvers: r}
+	cfg.Complexity.Customer.Orders = func(child int, limit *int, _, _ *string) int {
+		if limit != nil {
+			return child * max(*limit, 1)
+		}
+		return child * repository.DefaultPageLimit
+	}
+	cfg.Complexity.Query.Orders = func(child int, orderUids []string) int {
+		return child * max(len(orderUids), 1)
+	}
+	exec := executor.New(NewExecutableSchema(cfg))
+	exec.Use(extension.Introspection{})
+	exec.Use(extension.FixedComplexityLimit(MaxComplexity))
+	return exec
+}
+
+type langKey struct{}
+
+// WithLanguage makes field errors of queries run with ctx use lang.
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// Errorf is a field error localized for the query of ctx.
+func Errorf(ctx context.Context, key i18n.Key, args ...any) error {
+	lang, _ := ctx.Value(langKey{}).(string)
+	return errors.New(i18n.T(lang, key, args...))
+}
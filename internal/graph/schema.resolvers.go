@@ -0,0 +1,105 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.78
+
+import (
+	"context"
+	"errors"
+
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// Orders is the resolver for the orders field.
+func (r *customerResolver) Orders(ctx context.Context, obj *Customer, limit *int, cursor *string, sort *string) (*model.OrderPage, error) {
+	p := model.Page{Limit: repository.DefaultPageLimit}
+	if limit != nil {
+		p.Limit = *limit
+	}
+	if p.Limit <= 0 || p.Limit > repository.MaxPageLimit {
+		return nil, Errorf(ctx, i18n.InvalidLimit)
+	}
+	if cursor != nil {
+		p.Cursor = *cursor
+	}
+	if sort != nil {
+		p.Sort = model.Sort(*sort)
+	}
+	if !p.Sort.Valid() {
+		return nil, Errorf(ctx, i18n.InvalidSort, p.Sort)
+	}
+	res, err := r.Service.ListByCustomer(ctx, obj.ID, p)
+	if err != nil {
+		return nil, r.ServiceError(ctx, err, i18n.ListOrdersFailed)
+	}
+	return res, nil
+}
+
+// Status is the resolver for the status field.
+func (r *orderResolver) Status(ctx context.Context, obj *model.Order) (string, error) {
+	return string(obj.Status), nil
+}
+
+// Customer is the resolver for the customer field.
+func (r *orderResolver) Customer(ctx context.Context, obj *model.Order) (*Customer, error) {
+	if obj.CustomerID == "" {
+		return nil, nil
+	}
+	return &Customer{ID: obj.CustomerID}, nil
+}
+
+// Order is the resolver for the order field.
+func (r *queryResolver) Order(ctx context.Context, orderUID string) (*model.Order, error) {
+	if !r.ValidOrderUID(orderUID) {
+		return nil, Errorf(ctx, i18n.InvalidOrderUID, orderUID)
+	}
+	o, err := r.Service.Get(ctx, orderUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.ServiceError(ctx, err, i18n.GetOrderFailed)
+	}
+	return o, nil
+}
+
+// Orders is the resolver for the orders field.
+func (r *queryResolver) Orders(ctx context.Context, orderUids []string) ([]*model.Order, error) {
+	if len(orderUids) == 0 || len(orderUids) > r.MaxBatch {
+		return nil, Errorf(ctx, i18n.InvalidBatch, r.MaxBatch)
+	}
+	for _, id := range orderUids {
+		if !r.ValidOrderUID(id) {
+			return nil, Errorf(ctx, i18n.InvalidOrderUID, id)
+		}
+	}
+	orders, err := r.Service.GetMany(ctx, orderUids)
+	if err != nil {
+		return nil, r.ServiceError(ctx, err, i18n.BatchGetFailed)
+	}
+	return orders, nil
+}
+
+// Customer is the resolver for the customer field.
+func (r *queryResolver) Customer(ctx context.Context, id string) (*Customer, error) {
+	if !r.ValidCustomerID(id) {
+		return nil, Errorf(ctx, i18n.InvalidCustomerID)
+	}
+	return &Customer{ID: id}, nil
+}
+
+// Customer returns CustomerResolver implementation.
+func (r *Resolver) Customer() CustomerResolver { return &customerResolver{r} }
+
+// Order returns OrderResolver implementation.
+func (r *Resolver) Order() OrderResolver { return &orderResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+type customerResolver struct{ *Resolver }
+type orderResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
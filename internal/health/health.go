@@ -0,0 +1,131 @@
+// Package health runs the named dependency checks behind /readyz and
+// /healthz/details.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// DefaultTimeout bounds a check that sets no Timeout of its own.
+const DefaultTimeout = 2 * time.Second
+
+// Severity says what a failing check means for the instance.
+type Severity int
+
+const (
+	// Critical checks guard something the instance cannot serve without;
+	// one failing makes it unready.
+	Critical Severity = iota
+	// Degraded checks guard something the instance can serve without: a
+	// failure is reported but the instance stays ready.
+	Degraded
+)
+
+func (s Severity) String() string {
+	if s == Degraded {
+		return "degraded"
+	}
+	return "critical"
+}
+
+// Check is a named dependency probe. Check must honor ctx, which carries the
+// request deadline capped by Timeout, or DefaultTimeout if that is zero.
+type Check struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Timeout  time.Duration
+	Severity Severity
+}
+
+// Registry holds the checks components registered. It is safe for
+// concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	checks []Check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds checks, each replacing an earlier check of the same name.
+func (r *Registry) Register(checks ...Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+next:
+	for _, c := range checks {
+		for i := range r.checks {
+			if r.checks[i].Name == c.Name {
+				r.checks[i] = c
+				continue next
+			}
+		}
+		r.checks = append(r.checks, c)
+	}
+}
+
+// Run runs every check concurrently and reports them in registration order.
+// The report is unavailable if a Critical check failed, degraded if only
+// Degraded ones did and ok otherwise.
+func (r *Registry) Run(ctx context.Context) model.HealthReport {
+	r.mu.RLock()
+	checks := append([]Check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]model.CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := model.HealthReport{Status: model.HealthOK, Checks: results}
+	for i, res := range results {
+		switch {
+		case res.Status == model.HealthOK:
+		case checks[i].Severity == Critical:
+			report.Status = model.HealthUnavailable
+		case report.Status == model.HealthOK:
+			report.Status = model.HealthDegraded
+		}
+	}
+	return report
+}
+
+// ErrTimeout is reported for a check that outran its Timeout.
+var ErrTimeout = errors.New("check timed out")
+
+func run(ctx context.Context, c Check) model.CheckResult {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Check(ctx)
+	res := model.CheckResult{
+		Name:       c.Name,
+		Severity:   c.Severity.String(),
+		Status:     model.HealthOK,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = ErrTimeout
+		}
+		res.Status = model.HealthFailed
+		res.Error = err.Error()
+	}
+	return res
+}
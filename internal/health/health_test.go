@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_RunAppliesTimeoutsAndSeverities(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	r := NewRegistry()
+	r.Register(
+		Check{Name: "database", Check: ok},
+		Check{Name: "replica", Check: hang, Timeout: 10 * time.Millisecond, Severity: Degraded},
+	)
+
+	report := r.Run(context.Background())
+	require.Equal(t, model.HealthDegraded, report.Status)
+	require.Len(t, report.Checks, 2)
+	require.Equal(t, model.CheckResult{Name: "database", Severity: "critical", Status: model.HealthOK}, report.Checks[0])
+	require.Equal(t, "replica", report.Checks[1].Name)
+	require.Equal(t, "degraded", report.Checks[1].Severity)
+	require.Equal(t, model.HealthFailed, report.Checks[1].Status)
+	require.Equal(t, ErrTimeout.Error(), report.Checks[1].Error)
+
+	// a later registration under the same name replaces the check in place
+	r.Register(Check{Name: "database", Check: func(context.Context) error { return errors.New("ping: connection refused") }})
+	report = r.Run(context.Background())
+	require.Equal(t, model.HealthUnavailable, report.Status)
+	require.Len(t, report.Checks, 2)
+	require.Equal(t, "ping: connection refused", report.Checks[0].Error)
+}
@@ -0,0 +1,219 @@
+// Package i18n holds the catalog of user-facing API messages.
+//
+// Messages are looked up by Key in the language negotiated from the request's
+// Accept-Language header. A key missing in that language falls back to
+// DefaultLang, and a key missing everywhere is returned as is, so adding a key
+// without translations never produces an empty message.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Key identifies a message in the catalog.
+type Key string
+
+const DefaultLang = "en"
+
+const (
+	InvalidIDLength     Key = "invalid_id_length"
+	InvalidIDCharset    Key = "invalid_id_charset"
+	InvalidIDPattern    Key = "invalid_id_pattern"
+	InvalidCustomerID   Key = "invalid_customer_id"
+	InvalidOrderUID     Key = "invalid_order_uid"
+	InvalidPayload      Key = "invalid_payload"
+	InvalidOrder        Key = "invalid_order"
+	InvalidLimit        Key = "invalid_limit"
+	InvalidCursor       Key = "invalid_cursor"
+	InvalidSort         Key = "invalid_sort"
+	InvalidTimeParam    Key = "invalid_time_param"
+	InvalidTimeRange    Key = "invalid_time_range"
+	InvalidHeader       Key = "invalid_header"
+	InvalidParam        Key = "invalid_param"
+	DeadlineExceeded    Key = "deadline_exceeded"
+	DeadlineAlreadyPast Key = "deadline_already_past"
+	ReadOnly            Key = "read_only"
+	DatabaseUnavailable Key = "database_unavailable"
+	StaleVersion        Key = "stale_version"
+	InvalidTenant       Key = "invalid_tenant"
+	TenantMismatch      Key = "tenant_mismatch"
+	OrderNotFound       Key = "order_not_found"
+	GetOrderFailed      Key = "get_order_failed"
+	NotArchived         Key = "not_archived"
+	CreateOrderFailed   Key = "create_order_failed"
+	ListOrdersFailed    Key = "list_orders_failed"
+	InvalidSearchQuery  Key = "invalid_search_query"
+	InvalidBatch        Key = "invalid_batch"
+	SearchFailed        Key = "search_failed"
+	StatsFailed         Key = "stats_failed"
+	BatchGetFailed      Key = "batch_get_failed"
+	HistoryFailed       Key = "history_failed"
+	RawNotFound         Key = "raw_not_found"
+	RawFailed           Key = "raw_failed"
+	ArchiveFailed       Key = "archive_failed"
+	DeleteFailed        Key = "delete_failed"
+	RestoreFailed       Key = "restore_failed"
+	InvalidStatus       Key = "invalid_status"
+	InvalidTransition   Key = "invalid_transition"
+	StatusUpdateFailed  Key = "status_update_failed"
+	Unauthorized        Key = "unauthorized"
+	ErasureFailed       Key = "erasure_failed"
+	CacheWarmFailed     Key = "cache_warm_failed"
+	InvalidLogLevel     Key = "invalid_log_level"
+	DLQPeekFailed       Key = "dlq_peek_failed"
+	InternalError       Key = "internal_error"
+	RateLimited         Key = "rate_limited"
+	InvalidAPIKey       Key = "invalid_api_key"
+)
+
+var catalog = map[string]map[Key]string{
+	"en": {
+		InvalidIDLength:     "order_uid must be 1 to %d characters long, got %d",
+		InvalidIDCharset:    "order_uid may only contain letters, digits, _ and -",
+		InvalidIDPattern:    "order_uid %q does not match %s",
+		InvalidCustomerID:   "Invalid customer id",
+		InvalidOrderUID:     "Invalid order_uid %q",
+		InvalidPayload:      "Invalid order payload",
+		InvalidOrder:        "Order validation failed: %s",
+		InvalidLimit:        "Invalid limit",
+		InvalidCursor:       "Invalid cursor",
+		InvalidSort:         "Invalid sort %q, expected date_created or -date_created",
+		InvalidTimeParam:    "Invalid %s, expected RFC 3339",
+		InvalidTimeRange:    "from must be before to",
+		InvalidHeader:       "Invalid %s",
+		InvalidParam:        "Invalid %s",
+		DeadlineExceeded:    "Deadline exceeded",
+		DeadlineAlreadyPast: "Deadline already exceeded",
+		ReadOnly:            "Service is read-only, try again later",
+		DatabaseUnavailable: "Database is unavailable, try again later",
+		StaleVersion:        "A newer version of order %q is already stored",
+		InvalidTenant:       "Invalid tenant id %q",
+		TenantMismatch:      "order_uid %q is taken by another tenant",
+		OrderNotFound:       "Order not found",
+		GetOrderFailed:      "Failed to load order",
+		NotArchived:         "Order is not archived",
+		CreateOrderFailed:   "Failed to create order",
+		ListOrdersFailed:    "Failed to list orders",
+		InvalidSearchQuery:  "q must be 1 to %d characters",
+		InvalidBatch:        "order_uids must hold 1 to %d valid ids",
+		SearchFailed:        "Failed to search orders",
+		StatsFailed:         "Failed to compute statistics",
+		BatchGetFailed:      "Failed to load orders",
+		HistoryFailed:       "Failed to load order history",
+		RawNotFound:         "No raw payload stored for this order",
+		RawFailed:           "Failed to load raw payload",
+		ArchiveFailed:       "Failed to archive order",
+		DeleteFailed:        "Failed to delete order",
+		RestoreFailed:       "Failed to restore order",
+		InvalidStatus:       "Invalid status: %s",
+		InvalidTransition:   "Order %q cannot go from %s to %s",
+		StatusUpdateFailed:  "Failed to update order status",
+		Unauthorized:        "Missing or invalid credentials",
+		ErasureFailed:       "Failed to erase personal data",
+		CacheWarmFailed:     "Failed to warm the order cache",
+		InvalidLogLevel:     "Invalid log level %q, expected debug, info, warn or error",
+		DLQPeekFailed:       "Failed to read the dead-letter queue",
+		InternalError:       "Internal server error",
+		RateLimited:         "Too many requests, retry in %d s",
+		InvalidAPIKey:       "Invalid API key",
+	},
+	"ru": {
+		InvalidIDLength:     "order_uid должен содержать от 1 до %d символов, получено %d",
+		InvalidIDCharset:    "order_uid может содержать только латинские буквы, цифры, _ и -",
+		InvalidIDPattern:    "order_uid %q не соответствует шаблону %s",
+		InvalidCustomerID:   "Некорректный идентификатор покупателя",
+		InvalidOrderUID:     "Некорректный order_uid %q",
+		InvalidPayload:      "Некорректные данные заказа",
+		InvalidOrder:        "Заказ не прошёл проверку: %s",
+		InvalidLimit:        "Некорректный limit",
+		InvalidCursor:       "Некорректный курсор",
+		InvalidSort:         "Некорректная сортировка %q, ожидается date_created или -date_created",
+		InvalidTimeParam:    "Некорректный параметр %s, ожидается RFC 3339",
+		InvalidTimeRange:    "from должен быть раньше to",
+		InvalidHeader:       "Некорректный заголовок %s",
+		InvalidParam:        "Некорректный параметр %s",
+		DeadlineExceeded:    "Время ожидания истекло",
+		DeadlineAlreadyPast: "Срок выполнения запроса уже истёк",
+		ReadOnly:            "Сервис доступен только для чтения, повторите позже",
+		DatabaseUnavailable: "База данных недоступна, повторите позже",
+		StaleVersion:        "Уже сохранена более новая версия заказа %q",
+		InvalidTenant:       "Некорректный идентификатор арендатора %q",
+		TenantMismatch:      "order_uid %q занят другим арендатором",
+		OrderNotFound:       "Заказ не найден",
+		GetOrderFailed:      "Не удалось загрузить заказ",
+		NotArchived:         "Заказ не в архиве",
+		CreateOrderFailed:   "Не удалось создать заказ",
+		ListOrdersFailed:    "Не удалось получить список заказов",
+		InvalidSearchQuery:  "q должен содержать от 1 до %d символов",
+		InvalidBatch:        "order_uids должен содержать от 1 до %d корректных идентификаторов",
+		SearchFailed:        "Не удалось выполнить поиск заказов",
+		StatsFailed:         "Не удалось посчитать статистику",
+		BatchGetFailed:      "Не удалось загрузить заказы",
+		HistoryFailed:       "Не удалось загрузить историю заказа",
+		RawNotFound:         "Исходные данные заказа не сохранены",
+		RawFailed:           "Не удалось загрузить исходные данные заказа",
+		ArchiveFailed:       "Не удалось архивировать заказ",
+		DeleteFailed:        "Не удалось удалить заказ",
+		RestoreFailed:       "Не удалось восстановить заказ",
+		InvalidStatus:       "Некорректный статус: %s",
+		InvalidTransition:   "Заказ %q нельзя перевести из статуса %s в %s",
+		StatusUpdateFailed:  "Не удалось обновить статус заказа",
+		Unauthorized:        "Отсутствуют или неверны учётные данные",
+		ErasureFailed:       "Не удалось удалить персональные данные",
+		CacheWarmFailed:     "Не удалось прогреть кэш заказов",
+		InvalidLogLevel:     "Некорректный уровень логирования %q, ожидается debug, info, warn или error",
+		DLQPeekFailed:       "Не удалось прочитать очередь недоставленных сообщений",
+		InternalError:       "Внутренняя ошибка сервера",
+		RateLimited:         "Слишком много запросов, повторите через %d с",
+		InvalidAPIKey:       "Неверный API-ключ",
+	},
+}
+
+// T returns the message for key in lang, formatted with args. Args are ignored
+// by messages without format verbs, so callers may pass context unconditionally.
+func T(lang string, key Key, args ...any) string {
+	msg, ok := catalog[lang][key]
+	if !ok {
+		msg, ok = catalog[DefaultLang][key]
+	}
+	if !ok {
+		return string(key)
+	}
+	if len(args) > 0 && strings.Contains(msg, "%") {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Negotiate picks the best supported language from an Accept-Language header,
+// honoring q-values and matching regional variants by their base ("ru-RU" → "ru").
+func Negotiate(acceptLanguage string) string {
+	type pref struct {
+		lang string
+		q    float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := catalog[base]; ok && q > 0 {
+			prefs = append(prefs, pref{base, q})
+		}
+	}
+	if len(prefs) == 0 {
+		return DefaultLang
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	return prefs[0].lang
+}
@@ -0,0 +1,39 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                          "en",
+		"ru":                        "ru",
+		"ru-RU,ru;q=0.9,en;q=0.8":   "ru",
+		"de-DE, en;q=0.5, ru;q=0.7": "ru",
+		"fr":                        "en",
+		"ru;q=0, en":                "en",
+		"*":                         "en",
+	} {
+		require.Equal(t, want, Negotiate(header), header)
+	}
+}
+
+func TestT_Fallbacks(t *testing.T) {
+	require.Equal(t, "Некорректный курсор", T("ru", InvalidCursor))
+	require.Equal(t, "Invalid from, expected RFC 3339", T("en", InvalidTimeParam, "from"))
+	require.Equal(t, "Invalid cursor", T("de", InvalidCursor))
+	require.Equal(t, "Invalid customer id", T("en", InvalidCustomerID, "customer_id"))
+	require.Equal(t, "no_such_key", T("ru", Key("no_such_key")))
+}
+
+// every message must exist in every language so fallbacks stay the exception
+func TestCatalogComplete(t *testing.T) {
+	for lang, msgs := range catalog {
+		require.Len(t, msgs, len(catalog[DefaultLang]), lang)
+		for key := range catalog[DefaultLang] {
+			require.Contains(t, msgs, key, lang)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package ingest contains the broker-agnostic order ingestion loop.
+// It implements a robust, production-oriented message processing loop with:
+//   - Validation of inbound messages through the order service (order.Validator)
+//   - Dead-Letter Queue (DLQ) publishing for poison/unrecoverable messages
+//   - Clear logging for observability and later diagnostics
+//
+// Design notes (for contributors):
+//   - We prioritize "process what you can, isolate what you can't": invalid or permanently
+//     failing messages are routed to the DLQ instead of blocking the partition/stream.
+//   - Validation rules live in the service layer (order.Validator, run by Create) so the
+//     broker and the HTTP API enforce identical rules. Keep this layer focused on
+//     integrity checks, deserialization and mapping typed errors to DLQ reasons.
+//   - Transport specifics (Kafka, NATS JetStream, ...) live behind the Broker interface;
+//     implementations live in their own packages and must not contain business logic.
+//   - The DLQ preserves the original payload and adds minimal headers for post-mortem analysis.
+//     Do not mutate the original message body when forwarding to DLQ.
+package ingest
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// Message is an inbound order message in a broker-neutral form.
+type Message struct {
+	// Key is the partitioning/routing key, if the broker has one.
+	Key []byte
+	// Value is the raw payload, expected to be a JSON-encoded model.Order.
+	Value []byte
+	// Headers carries transport headers (Kafka headers, NATS headers, ...).
+	Headers map[string]string
+	// Time is when the message was produced, if the broker records it. It
+	// versions orders that don't carry an explicit version.
+	Time time.Time
+	// Origin is the broker-specific message, needed by Ack and DLQ.
+	Origin any
+}
+
+// Broker is the transport the ingestion loop reads orders from.
+// Implementations must be cancellation-aware: Consume returns ctx.Err() once
+// ctx is done.
+type Broker interface {
+	// Consume blocks until the next message is available.
+	Consume(ctx context.Context) (*Message, error)
+	// Ack marks the message as processed so it is not redelivered.
+	Ack(ctx context.Context, m *Message) error
+	// DLQ forwards the original message to the dead-letter destination with
+	// the failure reason attached. It is best-effort: when no DLQ is configured
+	// it is a no-op.
+	DLQ(ctx context.Context, m *Message, reason string, cause error) error
+	// Close releases the underlying connections.
+	Close() error
+}
+
+// Retryable reports whether a message dead-lettered for reason may succeed
+// when handled again later. Brokers with retry destinations send those
+// messages there before the DLQ. Messages that met an open database circuit
+// are never dead-lettered; the Processor holds them until it closes.
+func Retryable(reason string) bool {
+	return reason == "business_error"
+}
+
+// Backlogger is implemented by brokers that can cheaply report how many
+// messages are waiting to be consumed. It is published as a queue gauge.
+type Backlogger interface {
+	Backlog() int64
+}
+
+// Pinger is implemented by brokers that can report whether their server is
+// reachable. It backs the broker check of /readyz.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// DLQPeeker is implemented by brokers that can read back the most recent DLQ
+// messages without consuming them. It backs GET /admin/dlq.
+type DLQPeeker interface {
+	// PeekDLQ returns up to limit messages, newest first.
+	PeekDLQ(ctx context.Context, limit int) ([]DLQMessage, error)
+}
+
+// DLQPreviewSize is how much of a DLQ payload DLQMessage keeps.
+const DLQPreviewSize = 1024
+
+// DLQMessage is a dead-lettered message as shown to operators.
+type DLQMessage struct {
+	Key string `json:"key"`
+	// Error is the reason and cause the message was dead-lettered with.
+	Error string `json:"error"`
+	// OriginPartition and OriginOffset locate the message in the source
+	// topic; they are -1 for messages dead-lettered before they were recorded.
+	OriginPartition int   `json:"origin_partition"`
+	OriginOffset    int64 `json:"origin_offset"`
+	// Time is when the message was dead-lettered.
+	Time time.Time `json:"time"`
+	// Payload is the start of the original payload, at most DLQPreviewSize
+	// bytes; Truncated is set when the payload was longer.
+	Payload   string `json:"payload"`
+	Truncated bool   `json:"truncated"`
+}
+
+// SetPayload stores a preview of payload in m, cut on a UTF-8 boundary.
+func (m *DLQMessage) SetPayload(payload []byte) {
+	if len(payload) <= DLQPreviewSize {
+		m.Payload = string(payload)
+		return
+	}
+	n := DLQPreviewSize
+	for n > 0 && !utf8.RuneStart(payload[n]) {
+		n--
+	}
+	m.Payload = string(payload[:n])
+	m.Truncated = true
+}
@@ -0,0 +1,24 @@
+package ingest
+
+import (
+	"context"
+
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+)
+
+var (
+	consumed   = metrics.NewCounterVec("ingest_messages_total", "Messages consumed from the broker.")
+	dlqed      = metrics.NewCounterVec("ingest_dlq_total", "Messages routed to the DLQ by reason.", "reason")
+	ackErrors  = metrics.NewCounterVec("ingest_ack_errors_total", "Messages whose ack failed.")
+	handleTime = metrics.NewHistogramVec("ingest_message_duration_seconds", "Time from consuming a message to acking it.", nil)
+)
+
+// countingBroker counts DLQ reasons on the way to the wrapped broker.
+type countingBroker struct {
+	Broker
+}
+
+func (b countingBroker) DLQ(ctx context.Context, m *Message, reason string, cause error) error {
+	dlqed.Inc(reason)
+	return b.Broker.DLQ(ctx, m, reason, cause)
+}
@@ -0,0 +1,76 @@
+package ingest
+
+import (
+	"context"
+	"time"
+)
+
+// Status is a snapshot of the ingestion loop for operators.
+type Status struct {
+	// Paused is set between Pause and Resume.
+	Paused bool `json:"paused"`
+	// Consumed counts the messages this processor has consumed since it started.
+	Consumed int64 `json:"consumed"`
+	// LastMessageAt is when the last message was consumed, if any was.
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+	// Backlog is how many messages wait on the broker, for brokers that are
+	// a Backlogger.
+	Backlog *int64 `json:"backlog,omitempty"`
+}
+
+// Pause stops Run from consuming further messages until Resume, e.g. while
+// the database is being maintained. A message already being consumed is
+// still processed. Messages stay on the broker meanwhile.
+func (p *Processor) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed == nil {
+		p.resumed = make(chan struct{})
+		p.log.Info("ingest: paused")
+	}
+}
+
+// Resume undoes Pause.
+func (p *Processor) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed != nil {
+		close(p.resumed)
+		p.resumed = nil
+		p.log.Info("ingest: resumed")
+	}
+}
+
+// Status reports whether the processor is paused and how far it got.
+func (p *Processor) Status() Status {
+	p.mu.Lock()
+	st := Status{Paused: p.resumed != nil}
+	p.mu.Unlock()
+
+	st.Consumed = p.consumed.Load()
+	if ns := p.lastMessage.Load(); ns != 0 {
+		t := time.Unix(0, ns).UTC()
+		st.LastMessageAt = &t
+	}
+	if p.backlog != nil {
+		n := p.backlog.Backlog()
+		st.Backlog = &n
+	}
+	return st
+}
+
+// waitResumed blocks while the processor is paused.
+func (p *Processor) waitResumed(ctx context.Context) error {
+	p.mu.Lock()
+	resumed := p.resumed
+	p.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
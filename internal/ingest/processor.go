@@ -0,0 +1,289 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/service/order"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"golang.org/x/sync/errgroup"
+)
+
+// Processor pulls messages from a Broker and delegates valid orders to the order Service.
+type Processor struct {
+	broker Broker
+	svc    order.Service
+	log    logger.InterfaceLogger
+
+	// canary, when set, is evaluated next to the service's validator for comparison only.
+	canary     order.Validator
+	canaryName string
+
+	// signKey enables HeaderSignature verification; requireSig rejects unsigned messages.
+	signKey    []byte
+	requireSig bool
+
+	// backlog is the broker, when it can report its backlog.
+	backlog Backlogger
+
+	// workers is the number of messages handled at once.
+	workers int
+
+	// circuitBackoff is the first wait before a message is handled again
+	// while the database circuit breaker is open.
+	circuitBackoff time.Duration
+
+	mu sync.Mutex
+	// resumed is closed by Resume; it is nil while the processor is not paused.
+	resumed chan struct{}
+
+	consumed    atomic.Int64
+	lastMessage atomic.Int64 // unix nanoseconds
+}
+
+// Option customizes the Processor.
+type Option func(*Processor)
+
+// WithCanaryRules evaluates a candidate rule set on every message and reports
+// where it disagrees with the service's active rules, without changing DLQ behavior.
+func WithCanaryRules(name string, v order.Validator) Option {
+	return func(p *Processor) {
+		p.canaryName = name
+		p.canary = v
+	}
+}
+
+// WithSignatureKey verifies HeaderSignature with key. When required is set,
+// messages without a signature are routed to the DLQ as well.
+func WithSignatureKey(key []byte, required bool) Option {
+	return func(p *Processor) {
+		p.signKey = key
+		p.requireSig = required
+	}
+}
+
+// WithWorkers handles up to n messages at once, each consumed, handled and
+// acked by its own loop. The broker must allow concurrent Consume and Ack
+// calls and decide what may be handled in parallel; kafka.Consumer keeps the
+// messages of a partition in order.
+func WithWorkers(n int) Option {
+	return func(p *Processor) {
+		p.workers = n
+	}
+}
+
+// WithCircuitBackoff sets the first wait before a message that met an open
+// database circuit breaker is handled again; later waits double up to
+// maxCircuitBackoff. It defaults to repository.DefaultBreakerPolicy.Cooldown,
+// and should match the breaker's cooldown so every retry is its probe.
+func WithCircuitBackoff(d time.Duration) Option {
+	return func(p *Processor) {
+		if d > 0 {
+			p.circuitBackoff = d
+		}
+	}
+}
+
+// maxCircuitBackoff caps the wait between attempts while the circuit is open.
+const maxCircuitBackoff = time.Minute
+
+// NewProcessor constructs a Processor for the given broker.
+func NewProcessor(broker Broker, svc order.Service, log logger.InterfaceLogger, opts ...Option) *Processor {
+	p := &Processor{
+		broker:         countingBroker{broker},
+		svc:            svc,
+		log:            log,
+		circuitBackoff: repository.DefaultBreakerPolicy.Cooldown,
+	}
+	p.backlog, _ = broker.(Backlogger)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Run starts the ingestion loop and blocks until the context is canceled or a fatal error occurs.
+// The loop semantics are:
+//  1. Consume a message, unless paused (see Pause).
+//  2. Verify the optional checksum/signature headers against the raw payload
+//     and take the tenant from the tenant.Header header.
+//  3. Decode JSON into model.Order, or into model.StatusUpdate for messages
+//     whose HeaderMessageType is MessageTypeStatus (see handleStatus).
+//  4. Invoke service.Create, which validates (order.Validator) and stores the order.
+//  5. On unrecoverable failure: forward the original message to the DLQ with reason headers.
+//  6. Ack the message in both cases so a poison message never blocks the stream.
+//
+// While the database circuit breaker is open (repository.ErrCircuitOpen) the
+// message is neither dead-lettered nor acked: the loop backs off (see
+// WithCircuitBackoff) and handles the same message again, so an outage holds
+// the stream instead of draining it into the DLQ.
+//
+// Canceling ctx stops consuming; a message already consumed is still stored
+// and acked, so shutting down drains instead of dead-lettering it. A message
+// waiting for the circuit to close is left unacked and redelivered later. With
+// WithWorkers every worker runs this loop, and an error in one stops all.
+func (p *Processor) Run(ctx context.Context) error {
+	// Ensure resources are closed even on early returns.
+	defer func() {
+		if err := p.broker.Close(); err != nil {
+			p.log.Errorf("ingest: broker close: %v", err)
+		}
+	}()
+
+	if p.workers <= 1 {
+		return p.loop(ctx)
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	for range p.workers {
+		g.Go(func() error { return p.loop(ctx) })
+	}
+	return g.Wait()
+}
+
+func (p *Processor) loop(ctx context.Context) error {
+	work := context.WithoutCancel(ctx)
+	for {
+		if err := p.waitResumed(ctx); err != nil {
+			return err
+		}
+		// Consume blocks until a message arrives or the context is canceled.
+		m, err := p.broker.Consume(ctx)
+		if err != nil {
+			// Returning the error exits the loop. For context cancellation, brokers return ctx.Err().
+			return err
+		}
+
+		consumed.Inc()
+		start := time.Now()
+		p.consumed.Add(1)
+		p.lastMessage.Store(start.UnixNano())
+		backoff := p.circuitBackoff
+		for errors.Is(p.handle(work, m), repository.ErrCircuitOpen) {
+			if err := p.waitCircuit(ctx, backoff); err != nil {
+				return err
+			}
+			backoff = min(2*backoff, maxCircuitBackoff)
+		}
+
+		if err := p.broker.Ack(work, m); err != nil {
+			ackErrors.Inc()
+			p.log.Errorf("ingest: ack failed: %v", err)
+		}
+		handleTime.Observe(time.Since(start).Seconds())
+	}
+}
+
+// waitCircuit sleeps d before a message that met an open circuit is handled
+// again, and then while the processor is paused.
+func (p *Processor) waitCircuit(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.waitResumed(ctx)
+}
+
+// handle stores or dead-letters m. It returns repository.ErrCircuitOpen,
+// and does nothing else, when m must be handled again once the database is
+// reachable.
+func (p *Processor) handle(ctx context.Context, m *Message) error {
+	// Reject tampered or truncated payloads before looking inside them.
+	if err := p.verifyIntegrity(m); err != nil {
+		p.log.Errorf("ingest: integrity check failed: %v", err)
+		_ = p.broker.DLQ(ctx, m, "integrity_check", err)
+		return nil
+	}
+
+	// The tenant comes from the transport; messages without the header
+	// belong to tenant.Default.
+	if id, ok := m.header(tenant.Header); ok {
+		if !tenant.Valid(id) {
+			err := fmt.Errorf("invalid %s header %q", tenant.Header, id)
+			p.log.Errorf("ingest: %v", err)
+			_ = p.broker.DLQ(ctx, m, "invalid_tenant", err)
+			return nil
+		}
+		ctx = tenant.WithID(ctx, id)
+	}
+
+	if t, _ := m.header(HeaderMessageType); t == MessageTypeStatus {
+		return p.handleStatus(ctx, m)
+	}
+
+	// Decode payload into a strongly-typed Order.
+	var o model.Order
+	if err := json.Unmarshal(m.Value, &o); err != nil {
+		p.log.Errorf("ingest: invalid JSON payload: %v", err)
+		_ = p.broker.DLQ(ctx, m, "invalid_json", err)
+		return nil
+	}
+	o.Raw = m.Value
+
+	// Unlike HTTP clients, producers must send order_uid: a generated id would
+	// turn every redelivery into a new order.
+	if o.OrderUID == "" {
+		err := &order.ValidationError{Violations: []order.Violation{
+			{Field: "order_uid", Rule: order.RuleRequired, Message: "order_uid is required"},
+		}}
+		p.log.Errorf("ingest: validation failed: %v", err)
+		_ = p.broker.DLQ(ctx, m, "schema_validation", err)
+		return nil
+	}
+
+	// Redeliveries and concurrent consumers must not let an older payload
+	// overwrite a newer one, so the produce time versions the order.
+	if o.Version == 0 && !m.Time.IsZero() {
+		o.Version = m.Time.UnixNano()
+	}
+
+	// Validation happens in the service so that every ingestion path applies
+	// the same rules; an order.ErrValidation is a schema problem, anything
+	// else a business/storage failure.
+	err := p.svc.Create(ctx, &o)
+	if errors.Is(err, repository.ErrCircuitOpen) {
+		p.log.Errorf("ingest: database unavailable for order=%s, retrying: %v", o.OrderUID, err)
+		return err
+	}
+	if p.canary != nil {
+		var activeErr error
+		if errors.Is(err, order.ErrValidation) {
+			activeErr = err
+		}
+		p.runCanary(&o, activeErr)
+	}
+	if errors.Is(err, order.ErrValidation) {
+		p.log.Errorf("ingest: validation failed: %v", err)
+		_ = p.broker.DLQ(ctx, m, "schema_validation", err)
+		return nil
+	}
+	if errors.Is(err, repository.ErrTenantMismatch) {
+		p.log.Errorf("ingest: order %s belongs to another tenant: %v", o.OrderUID, err)
+		_ = p.broker.DLQ(ctx, m, "tenant_mismatch", err)
+		return nil
+	}
+	if errors.Is(err, repository.ErrStaleVersion) {
+		// a newer version is already stored; this message has nothing to add
+		p.log.Infof("ingest: skipped stale order %s: %v", o.OrderUID, err)
+		return nil
+	}
+	if err != nil {
+		// Consider classifying transient vs permanent errors; for simplicity, DLQ everything here.
+		p.log.Errorf("ingest: service create failed for order=%s: %v", o.OrderUID, err)
+		_ = p.broker.DLQ(ctx, m, "business_error", err)
+		return nil
+	}
+
+	p.log.Infof("ingest: created order %s", o.OrderUID)
+	return nil
+}
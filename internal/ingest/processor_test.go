@@ -0,0 +1,447 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/service/order"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker replays a fixed list of messages and records acks and DLQ reasons.
+type fakeBroker struct {
+	msgs   []*Message
+	acked  int
+	dlq    []string
+	closed bool
+}
+
+func (b *fakeBroker) Consume(ctx context.Context) (*Message, error) {
+	if len(b.msgs) == 0 {
+		return nil, context.Canceled
+	}
+	m := b.msgs[0]
+	b.msgs = b.msgs[1:]
+	return m, nil
+}
+
+func (b *fakeBroker) Ack(ctx context.Context, m *Message) error {
+	b.acked++
+	return nil
+}
+
+func (b *fakeBroker) DLQ(ctx context.Context, m *Message, reason string, cause error) error {
+	b.dlq = append(b.dlq, reason)
+	return nil
+}
+
+func (b *fakeBroker) Close() error {
+	b.closed = true
+	return nil
+}
+
+func validOrder(uid string) *model.Order {
+	return &model.Order{
+		OrderUID:        uid,
+		TrackNumber:     "TRK",
+		Entry:           "WBIL",
+		CustomerID:      "c1",
+		DeliveryService: "meest",
+		ShardKey:        "9",
+		OofShard:        "1",
+		DateCreated:     time.Now(),
+		Items:           []model.Item{{ChrtID: 1}},
+	}
+}
+
+func encode(t *testing.T, o *model.Order) *Message {
+	t.Helper()
+	b, err := json.Marshal(o)
+	require.NoError(t, err)
+	return &Message{Value: b}
+}
+
+func TestProcessor_Run_RoutesFailuresToDLQ(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	invalid := validOrder("bad")
+	invalid.Items = nil
+
+	broker := &fakeBroker{msgs: []*Message{
+		{Value: []byte("{not json")},
+		encode(t, invalid),
+		encode(t, validOrder("")),
+		encode(t, validOrder("fails")),
+		encode(t, validOrder("ok")),
+	}}
+
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+		switch o.OrderUID {
+		case "bad":
+			return order.ValidateOrder(o)
+		case "fails":
+			return errors.New("db down")
+		}
+		return nil
+	}).Times(3)
+
+	err := NewProcessor(broker, svc, log).Run(context.Background())
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []string{"invalid_json", "schema_validation", "schema_validation", "business_error"}, broker.dlq)
+	require.Equal(t, 5, broker.acked)
+	require.True(t, broker.closed)
+}
+
+func TestProcessor_VersionsByMessageTimeAndSkipsStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	produced := time.Date(2025, 8, 9, 10, 0, 0, 0, time.UTC)
+	older := encode(t, validOrder("o-1"))
+	older.Time = produced
+	explicit := validOrder("o-1")
+	explicit.Version = 7
+	broker := &fakeBroker{msgs: []*Message{older, encode(t, explicit)}}
+
+	gomock.InOrder(
+		svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+			require.Equal(t, produced.UnixNano(), o.Version)
+			require.Equal(t, older.Value, []byte(o.Raw))
+			return nil
+		}),
+		svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+			require.EqualValues(t, 7, o.Version)
+			return fmt.Errorf("%w: o-1", repository.ErrStaleVersion)
+		}),
+	)
+
+	err := NewProcessor(broker, svc, log).Run(context.Background())
+	require.ErrorIs(t, err, context.Canceled)
+	// a stale message is acked without a DLQ entry
+	require.Empty(t, broker.dlq)
+	require.Equal(t, 2, broker.acked)
+}
+
+func TestProcessor_TenantHeader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	shop := encode(t, validOrder("shop"))
+	shop.Headers = map[string]string{"x-tenant-id": "shop-1"}
+	bad := encode(t, validOrder("bad"))
+	bad.Headers = map[string]string{tenant.Header: "../etc"}
+	foreign := encode(t, validOrder("foreign"))
+
+	broker := &fakeBroker{msgs: []*Message{shop, bad, foreign}}
+	gomock.InOrder(
+		svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, o *model.Order) error {
+			require.Equal(t, "shop-1", tenant.FromContext(ctx))
+			return nil
+		}),
+		svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, o *model.Order) error {
+			require.Equal(t, tenant.Default, tenant.FromContext(ctx))
+			return fmt.Errorf("%w: foreign", repository.ErrTenantMismatch)
+		}),
+	)
+
+	_ = NewProcessor(broker, svc, log).Run(context.Background())
+	require.Equal(t, []string{"invalid_tenant", "tenant_mismatch"}, broker.dlq)
+	require.Equal(t, 3, broker.acked)
+}
+
+func TestProcessor_CanaryDoesNotChangeDLQ(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Warnf(gomock.Any(), gomock.Any()).Times(1)
+
+	// valid for the default rules, but the strict canary wants delivery/payment data
+	broker := &fakeBroker{msgs: []*Message{encode(t, validOrder("ok"))}}
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	rejects := func() int64 {
+		if v, ok := canaryStats.Get(canaryCandidateRejects).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := rejects()
+	_ = NewProcessor(broker, svc, log, WithCanaryRules(order.ValidatorStrict, order.Validators[order.ValidatorStrict])).Run(context.Background())
+
+	require.Empty(t, broker.dlq)
+	require.Equal(t, before+1, rejects())
+}
+
+func TestProcessor_IntegrityHeaders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	key := []byte("secret")
+	signed := encode(t, validOrder("signed"))
+	signed.Headers = map[string]string{HeaderSignature: SignPayload(key, signed.Value)}
+
+	tampered := encode(t, validOrder("tampered"))
+	tampered.Headers = map[string]string{HeaderSignature: SignPayload(key, []byte("other"))}
+
+	truncated := encode(t, validOrder("truncated"))
+	sum := sha256.Sum256(truncated.Value)
+	truncated.Headers = map[string]string{"x-payload-checksum": fmt.Sprintf("sha256=%x", sum)}
+	truncated.Value = truncated.Value[:len(truncated.Value)/2]
+
+	unsigned := encode(t, validOrder("unsigned"))
+
+	broker := &fakeBroker{msgs: []*Message{signed, tampered, truncated, unsigned}}
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+		require.Equal(t, "signed", o.OrderUID)
+		return nil
+	}).Times(1)
+
+	_ = NewProcessor(broker, svc, log, WithSignatureKey(key, true)).Run(context.Background())
+	require.Equal(t, []string{"integrity_check", "integrity_check", "integrity_check"}, broker.dlq)
+	require.Equal(t, 4, broker.acked)
+}
+
+func TestProcessor_StatusMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	status := func(body string) *Message {
+		return &Message{Value: []byte(body), Headers: map[string]string{"x-message-type": MessageTypeStatus}}
+	}
+	broker := &fakeBroker{msgs: []*Message{
+		status(`{"order_uid":"a","status":"paid"}`),
+		status(`{"order_uid":"a","status":"lost"}`),
+		status(`{"order_uid":"a","status":"created"}`),
+		status(`{"order_uid":"gone","status":"paid"}`),
+		status(`{broken`),
+	}}
+	gomock.InOrder(
+		svc.EXPECT().UpdateStatus(gomock.Any(), "a", model.StatusPaid).Return(nil),
+		svc.EXPECT().UpdateStatus(gomock.Any(), "a", model.OrderStatus("lost")).Return(order.StatusViolation()),
+		svc.EXPECT().UpdateStatus(gomock.Any(), "a", model.StatusCreated).
+			Return(&order.TransitionError{OrderUID: "a", From: model.StatusPaid, To: model.StatusCreated}),
+		svc.EXPECT().UpdateStatus(gomock.Any(), "gone", model.StatusPaid).Return(repository.ErrNotFound),
+	)
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	_ = NewProcessor(broker, svc, log).Run(context.Background())
+	require.Equal(t, []string{"schema_validation", "invalid_transition", "unknown_order", "invalid_json"}, broker.dlq)
+	require.Equal(t, 5, broker.acked)
+}
+
+func TestProcessor_PauseHoldsMessagesOnTheBroker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Info(gomock.Any()).AnyTimes()
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	broker := &fakeBroker{msgs: []*Message{encode(t, validOrder("b1"))}}
+	p := NewProcessor(broker, svc, log)
+	p.Pause()
+	require.Equal(t, Status{Paused: true}, p.Status())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, p.Run(ctx), context.DeadlineExceeded)
+	require.Len(t, broker.msgs, 1)
+
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	p.Resume()
+	require.ErrorIs(t, p.Run(context.Background()), context.Canceled)
+	st := p.Status()
+	require.False(t, st.Paused)
+	require.Equal(t, int64(1), st.Consumed)
+	require.NotNil(t, st.LastMessageAt)
+}
+
+func TestProcessor_HoldsMessagesWhileCircuitIsOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	broker := &fakeBroker{msgs: []*Message{encode(t, validOrder("o-1"))}}
+	gomock.InOrder(
+		svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(repository.ErrCircuitOpen).Times(2),
+		svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+			require.Equal(t, "o-1", o.OrderUID)
+			return nil
+		}),
+	)
+	err := NewProcessor(broker, svc, log, WithCircuitBackoff(time.Millisecond)).Run(context.Background())
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, broker.dlq)
+	require.Equal(t, 1, broker.acked)
+
+	// shutting down while the circuit is open leaves the message unacked
+	broker = &fakeBroker{msgs: []*Message{encode(t, validOrder("o-2"))}}
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(fmt.Errorf("upsert: %w", repository.ErrCircuitOpen)).MinTimes(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, NewProcessor(broker, svc, log, WithCircuitBackoff(5*time.Millisecond)).Run(ctx), context.DeadlineExceeded)
+	require.Empty(t, broker.dlq)
+	require.Zero(t, broker.acked)
+}
+
+func TestDLQMessage_SetPayloadTruncatesOnRuneBoundary(t *testing.T) {
+	var m DLQMessage
+	m.SetPayload([]byte(`{"order_uid":"b1"}`))
+	require.Equal(t, `{"order_uid":"b1"}`, m.Payload)
+	require.False(t, m.Truncated)
+
+	long := []byte(strings.Repeat("a", DLQPreviewSize-1) + "ж" + "tail")
+	m.SetPayload(long)
+	require.True(t, m.Truncated)
+	require.Equal(t, strings.Repeat("a", DLQPreviewSize-1), m.Payload)
+}
+
+// cancelingBroker cancels the processor's context as it hands out its message,
+// like a shutdown signal arriving mid-message.
+type cancelingBroker struct {
+	fakeBroker
+	cancel context.CancelFunc
+}
+
+func (b *cancelingBroker) Consume(ctx context.Context) (*Message, error) {
+	m, err := b.fakeBroker.Consume(ctx)
+	b.cancel()
+	if err != nil {
+		return nil, ctx.Err()
+	}
+	return m, nil
+}
+
+func TestProcessor_DrainsTheMessageInHandOnCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	broker := &cancelingBroker{fakeBroker: fakeBroker{msgs: []*Message{encode(t, validOrder("b1"))}}, cancel: cancel}
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ *model.Order) error {
+		return ctx.Err()
+	})
+
+	require.ErrorIs(t, NewProcessor(broker, svc, log).Run(ctx), context.Canceled)
+	require.Empty(t, broker.dlq)
+	require.Equal(t, 1, broker.acked)
+}
+
+// chanBroker hands out the messages of a channel to concurrent workers.
+type chanBroker struct {
+	msgs  chan *Message
+	acked atomic.Int32
+	dlq   atomic.Int32
+}
+
+func (b *chanBroker) Consume(ctx context.Context) (*Message, error) {
+	select {
+	case m, ok := <-b.msgs:
+		if !ok {
+			return nil, context.Canceled
+		}
+		return m, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *chanBroker) Ack(context.Context, *Message) error {
+	b.acked.Add(1)
+	return nil
+}
+
+func (b *chanBroker) DLQ(context.Context, *Message, string, error) error {
+	b.dlq.Add(1)
+	return nil
+}
+
+func (b *chanBroker) Close() error { return nil }
+
+func TestProcessor_WorkersHandleMessagesConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	const workers = 3
+	broker := &chanBroker{msgs: make(chan *Message, workers)}
+	for i := range workers {
+		broker.msgs <- encode(t, validOrder(fmt.Sprintf("o-%d", i)))
+	}
+	close(broker.msgs)
+
+	// every Create waits for the others, so the messages must be in hand at once
+	var arrived sync.WaitGroup
+	arrived.Add(workers)
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *model.Order) error {
+		arrived.Done()
+		done := make(chan struct{})
+		go func() { arrived.Wait(); close(done) }()
+		select {
+		case <-done:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("messages were handled one at a time")
+		}
+	}).Times(workers)
+
+	err := NewProcessor(broker, svc, log, WithWorkers(workers)).Run(context.Background())
+	require.ErrorIs(t, err, context.Canceled)
+	require.EqualValues(t, workers, broker.acked.Load())
+	require.Zero(t, broker.dlq.Load())
+}
@@ -0,0 +1,33 @@
+package ingest
+
+import (
+	"expvar"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// canaryStats counts how the candidate rule set compares to the active one.
+// Published at /debug/vars under "ingest_canary".
+var canaryStats = expvar.NewMap("ingest_canary")
+
+const (
+	canaryAgree            = "agree"
+	canaryCandidateRejects = "candidate_rejects" // active accepts, candidate would DLQ
+	canaryCandidateAccepts = "candidate_accepts" // active DLQs, candidate would accept
+)
+
+// runCanary evaluates the candidate rules and records disagreement with the
+// active verdict. It never affects what happens to the message.
+func (p *Processor) runCanary(o *model.Order, activeErr error) {
+	candErr := p.canary.Validate(o)
+	switch {
+	case (activeErr == nil) == (candErr == nil):
+		canaryStats.Add(canaryAgree, 1)
+	case candErr != nil:
+		canaryStats.Add(canaryCandidateRejects, 1)
+		p.log.Warnf("ingest: canary rules %q would reject order=%s: %v", p.canaryName, o.OrderUID, candErr)
+	default:
+		canaryStats.Add(canaryCandidateAccepts, 1)
+		p.log.Warnf("ingest: canary rules %q would accept order=%s rejected by active rules: %v", p.canaryName, o.OrderUID, activeErr)
+	}
+}
@@ -0,0 +1,52 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/service/order"
+)
+
+const (
+	// HeaderMessageType selects what a message carries: MessageTypeStatus
+	// messages hold a model.StatusUpdate, any other value or no header an order.
+	HeaderMessageType = "X-Message-Type"
+	MessageTypeStatus = "status"
+)
+
+// handleStatus applies a status-change message. An unknown order is DLQed
+// rather than dropped: the status may have overtaken the order itself. Like
+// handle, it returns repository.ErrCircuitOpen for a message to redeliver.
+func (p *Processor) handleStatus(ctx context.Context, m *Message) error {
+	var u model.StatusUpdate
+	if err := json.Unmarshal(m.Value, &u); err != nil {
+		p.log.Errorf("ingest: invalid JSON status payload: %v", err)
+		_ = p.broker.DLQ(ctx, m, "invalid_json", err)
+		return nil
+	}
+
+	err := p.svc.UpdateStatus(ctx, u.OrderUID, u.Status)
+	switch {
+	case errors.Is(err, order.ErrValidation), errors.Is(err, order.ErrInvalidOrderUID):
+		p.log.Errorf("ingest: status validation failed: %v", err)
+		_ = p.broker.DLQ(ctx, m, "schema_validation", err)
+	case errors.Is(err, order.ErrInvalidTransition):
+		p.log.Errorf("ingest: rejected status update: %v", err)
+		_ = p.broker.DLQ(ctx, m, "invalid_transition", err)
+	case errors.Is(err, repository.ErrCircuitOpen):
+		p.log.Errorf("ingest: database unavailable for status of order=%s, retrying: %v", u.OrderUID, err)
+		return err
+	case errors.Is(err, repository.ErrNotFound):
+		p.log.Errorf("ingest: status %s for unknown order %s", u.Status, u.OrderUID)
+		_ = p.broker.DLQ(ctx, m, "unknown_order", err)
+	case err != nil:
+		p.log.Errorf("ingest: status update failed for order=%s: %v", u.OrderUID, err)
+		_ = p.broker.DLQ(ctx, m, "business_error", err)
+	default:
+		p.log.Infof("ingest: order %s is %s", u.OrderUID, u.Status)
+	}
+	return nil
+}
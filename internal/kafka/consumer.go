@@ -1,222 +1,563 @@
-// Package kafka contains Kafka consumer logic for the Orders domain.
-// It implements a robust, production-oriented message processing loop with:
-//   - Structured validation of inbound messages (basic schema/business sanity checks)
-//   - Dead-Letter Queue (DLQ) publishing for poison/unrecoverable messages
-//   - Clear logging for observability and later diagnostics
+// Package kafka implements ingest.Broker on top of kafka-go.
 //
 // Design notes (for contributors):
-//   - We prioritize "process what you can, isolate what you can't": invalid or permanently
-//     failing messages are routed to the DLQ instead of blocking the partition.
-//   - Validation here is intentionally lightweight. Deeper domain validation happens in
-//     the service layer and at the DB via constraints. Keep this layer focused on
-//     deserialization and basic structural checks.
-//   - The consumer is cancellation-aware: context cancellation propagates to the underlying
-//     Kafka reader, exiting cleanly.
+//   - Offsets are committed explicitly on Ack (FetchMessage + CommitMessages), so a crash
+//     between fetch and processing redelivers the message instead of losing it.
+//   - Messages are fetched ahead by one goroutine per topic and handed out by Consume with at
+//     most one message per partition in flight, so several workers (ingest.WithWorkers) keep
+//     each partition in order and commit its offsets in order.
+//   - The consumer is cancellation-aware: Consume returns once its context is canceled, and
+//     Close stops the fetching.
 //   - The DLQ preserves the original payload and adds minimal headers for post-mortem analysis.
-//     Do not mutate the original message body when forwarding to DLQ.
+//     Do not mutate the original message body when forwarding to DLQ. Retry topics get the
+//     same copy; the origin headers always name where the message was first consumed.
 package kafka
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	"strings"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/ingest"
 	"github.com/merkulovlad/wbtech-go/internal/logger"
-	"github.com/merkulovlad/wbtech-go/internal/model"
-	"github.com/merkulovlad/wbtech-go/internal/service/order"
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
 )
 
-// Consumer wraps a kafka-go Reader and an optional DLQ Writer and orchestrates
-// message decoding, validation, and delegation to the order Service.
+// Header keys of forwarded messages.
+const (
+	headerError     = "error"
+	headerTimestamp = "timestamp"
+	// headerRetryAt is when a message in a retry topic may be handled again.
+	headerRetryAt = "retry-at"
+)
+
+// Consumer reads the source topic and the retry topics, and forwards failed
+// messages to the next retry topic or the DLQ.
 type Consumer struct {
-	// reader consumes messages from the primary topic.
-	reader *kafka.Reader
-	// dlqWriter is an optional producer used to forward irrecoverable messages.
-	dlqWriter *kafka.Writer
+	// readers consume the source topic and the retry topics, by topic.
+	readers map[string]*kafka.Reader
+	// writer sends to the DLQ and the retry topics; nil when there are none.
+	writer *kafka.Writer
+	// dialer opens the connections outside the readers and the writer, with
+	// the same authentication.
+	dialer  *kafka.Dialer
+	brokers []string
 
-	// svc is the domain service used to persist/process orders.
-	svc order.Service
 	// log is the project-wide logger interface.
 	log logger.InterfaceLogger
 
 	// topic is the source topic name (for reference in DLQ headers).
 	topic string
 	// dlqTopic is the DLQ topic name (empty means DLQ disabled).
-	dlqTopic string
+	dlqTopic    string
+	retryTopics []string
+	retryDelay  time.Duration
+
+	limiter *limiter
+	// prefetch bounds the fetched messages waiting for their partition.
+	prefetch int
+	now      func() time.Time
+
+	// fetched carries what the per-topic fetch loops read, until stop.
+	fetched chan fetchResult
+	stop    context.CancelFunc
+	loops   sync.WaitGroup
+
+	mu sync.Mutex
+	// queued are fetched messages, oldest first, whose partition was busy.
+	queued []kafka.Message
+	// busy holds the partitions with a message between Consume and Ack.
+	busy map[partition]bool
+	// changed is closed and replaced whenever a partition stops being busy.
+	changed chan struct{}
 }
 
-// NewConsumer constructs a new Consumer.
-//
-// Parameters:
-//   - brokers: Kafka bootstrap addresses.
-//   - topic: source topic to consume from.
-//   - groupID: consumer group identifier.
-//   - dlqTopic: DLQ topic; if empty, DLQ publishing is disabled.
-//   - svc: domain service to handle valid orders.
-//   - log: logger implementation.
-//
-// Note: DLQ usage is recommended in production to avoid partition halts caused by poison messages.
-func NewConsumer(brokers []string, topic, groupID, dlqTopic string, svc order.Service, log logger.InterfaceLogger) *Consumer {
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: brokers,
-		Topic:   topic,
-		GroupID: groupID,
-	})
-	var w *kafka.Writer
-	if dlqTopic != "" {
-		w = &kafka.Writer{
-			Addr:     kafka.TCP(brokers...),
-			Topic:    dlqTopic,
-			Balancer: &kafka.LeastBytes{},
-		}
-	}
-	return &Consumer{
-		reader:    r,
-		dlqWriter: w,
-		svc:       svc,
-		log:       log,
-		topic:     topic,
-		dlqTopic:  dlqTopic,
-	}
-}
-
-// Run starts the consumer loop and blocks until the context is canceled or a fatal error occurs.
-// The loop semantics are:
-//  1. Read a message.
-//  2. Decode JSON into model.Order.
-//  3. Validate minimally (required fields, sensible ranges, non-empty items).
-//  4. Invoke service.Create to perform domain processing/storage.
-//  5. On unrecoverable failure: forward the original message to the DLQ with reason headers.
-//     On success: ack via offset commit (handled internally by kafka-go Reader).
-func (c *Consumer) Run(ctx context.Context) error {
-	// Ensure resources are closed even on early returns.
-	defer func() {
-		if err := c.reader.Close(); err != nil {
-			c.log.Errorf("kafka: reader close: %v", err)
-		}
-		if c.dlqWriter != nil {
-			if err := c.dlqWriter.Close(); err != nil {
-				c.log.Errorf("kafka: dlq writer close: %v", err)
-			}
+type partition struct {
+	topic string
+	id    int
+}
+
+type fetchResult struct {
+	msg kafka.Message
+	err error
+}
+
+var (
+	_ ingest.Broker     = (*Consumer)(nil)
+	_ ingest.Backlogger = (*Consumer)(nil)
+	_ ingest.Pinger     = (*Consumer)(nil)
+	_ ingest.DLQPeeker  = (*Consumer)(nil)
+)
+
+// peekTimeout bounds PeekDLQ when ctx has no deadline of its own.
+const peekTimeout = 5 * time.Second
+
+// NewConsumer connects to the brokers of cfg and starts fetching from its
+// topic and retry topics. It fails only on unusable security settings.
+func NewConsumer(cfg *config.KafkaConfig, log logger.InterfaceLogger) (*Consumer, error) {
+	mechanism, err := Mechanism(cfg.SASLMechanism, cfg.SASLUsername, cfg.SASLPassword)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	d := &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		SASLMechanism: mechanism,
+		TLS:           tlsConfig,
+	}
+	start := kafka.FirstOffset
+	if cfg.StartOffset == config.KafkaStartLatest {
+		start = kafka.LastOffset
+	}
+	newReader := func(topic, group string) *kafka.Reader {
+		return kafka.NewReader(kafka.ReaderConfig{
+			Brokers:        cfg.Brokers,
+			Topic:          topic,
+			GroupID:        group,
+			Dialer:         d,
+			QueueCapacity:  cfg.BatchSize,
+			CommitInterval: cfg.CommitInterval,
+			StartOffset:    start,
+		})
+	}
+
+	c := &Consumer{
+		readers:     map[string]*kafka.Reader{cfg.Topic: newReader(cfg.Topic, cfg.Group)},
+		dialer:      d,
+		brokers:     cfg.Brokers,
+		log:         log,
+		topic:       cfg.Topic,
+		dlqTopic:    cfg.DLQTopic,
+		retryTopics: cfg.RetryTopics,
+		retryDelay:  cfg.RetryDelay,
+		limiter:     newLimiter(cfg.RateLimit),
+		prefetch:    max(cfg.BatchSize, 1),
+		now:         time.Now,
+		fetched:     make(chan fetchResult),
+		busy:        map[partition]bool{},
+		changed:     make(chan struct{}),
+	}
+	for _, t := range cfg.RetryTopics {
+		c.readers[t] = newReader(t, cfg.Group+"."+t)
+	}
+	if cfg.DLQTopic != "" || len(cfg.RetryTopics) > 0 {
+		// the topic is set per message
+		c.writer = &kafka.Writer{
+			Addr:      kafka.TCP(cfg.Brokers...),
+			Balancer:  &kafka.LeastBytes{},
+			Transport: &kafka.Transport{SASL: mechanism, TLS: tlsConfig},
 		}
-	}()
+	}
 
-	for {
-		// ReadMessage blocks until a message arrives or the context is canceled.
-		m, err := c.reader.ReadMessage(ctx)
+	ctx, stop := context.WithCancel(context.Background())
+	c.stop = stop
+	for topic, r := range c.readers {
+		c.loops.Add(1)
+		go c.fetch(ctx, r, topic != c.topic)
+	}
+	return c, nil
+}
+
+// Mechanism returns the SASL mechanism called name (config.KafkaSASLPlain,
+// KafkaSASLSCRAMSHA256 or KafkaSASLSCRAMSHA512) with the given credentials,
+// or nil for an empty name.
+func Mechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case config.KafkaSASLPlain:
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case config.KafkaSASLSCRAMSHA256:
+		return scram.Mechanism(scram.SHA256, username, password)
+	case config.KafkaSASLSCRAMSHA512:
+		return scram.Mechanism(scram.SHA512, username, password)
+	}
+	return nil, fmt.Errorf("kafka: unsupported SASL mechanism %q", name)
+}
+
+// newTLSConfig returns the TLS settings of cfg, nil for plain connections.
+func newTLSConfig(cfg *config.KafkaConfig) (*tls.Config, error) {
+	if !cfg.TLS {
+		return nil, nil
+	}
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
 		if err != nil {
-			// Returning the error exits the loop. For context cancellation, kafka-go returns ctx.Err().
-			return err
+			return nil, fmt.Errorf("kafka: TLS CA: %w", err)
 		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("kafka: TLS CA: no certificate in %s", cfg.TLSCAFile)
+		}
+	}
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: TLS client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
 
-		// Decode payload into a strongly-typed Order.
-		var o model.Order
-		if err := json.Unmarshal(m.Value, &o); err != nil {
-			c.log.Errorf("kafka: invalid JSON payload: %v", err)
-			_ = c.sendToDLQ(ctx, m, "invalid_json", err)
-			continue
+// fetch passes the messages of r to Consume until ctx is done or r fails.
+// Messages of a retry topic are passed on once their headerRetryAt is due.
+func (c *Consumer) fetch(ctx context.Context, r *kafka.Reader, retry bool) {
+	defer c.loops.Done()
+	for {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				select {
+				case c.fetched <- fetchResult{err: err}:
+				case <-ctx.Done():
+				}
+			}
+			return
+		}
+		if retry {
+			if at, err := time.Parse(time.RFC3339Nano, header(m, headerRetryAt)); err == nil {
+				if sleepCtx(ctx, at.Sub(c.now())) != nil {
+					return
+				}
+			}
 		}
+		select {
+		case c.fetched <- fetchResult{msg: m}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
 
-		// Minimal, defensive validation before entering domain logic.
-		if err := validateOrder(&o); err != nil {
-			c.log.Errorf("kafka: validation failed: %v", err)
-			_ = c.sendToDLQ(ctx, m, "schema_validation", err)
-			continue
+// Consume returns the next message whose partition has no other message
+// being handled, without committing its offset.
+func (c *Consumer) Consume(ctx context.Context) (*ingest.Message, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	m, err := c.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return &ingest.Message{
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+		Time:    m.Time,
+		Origin:  m,
+	}, nil
+}
+
+func (c *Consumer) next(ctx context.Context) (kafka.Message, error) {
+	for {
+		c.mu.Lock()
+		for i, m := range c.queued {
+			p := partition{m.Topic, m.Partition}
+			if !c.busy[p] {
+				c.busy[p] = true
+				c.queued = slices.Delete(c.queued, i, i+1)
+				c.mu.Unlock()
+				return m, nil
+			}
+		}
+		changed := c.changed
+		var fetched <-chan fetchResult
+		if len(c.queued) < c.prefetch {
+			fetched = c.fetched
 		}
+		c.mu.Unlock()
 
-		// Delegate to domain service (idempotency and deeper validation happen there).
-		if err := c.svc.Create(ctx, &o); err != nil {
-			// Consider classifying transient vs permanent errors; for simplicity, DLQ everything here.
-			c.log.Errorf("kafka: service create failed for order=%s: %v", o.OrderUID, err)
-			_ = c.sendToDLQ(ctx, m, "business_error", err)
-			continue
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-changed:
+		case r := <-fetched:
+			if r.err != nil {
+				return kafka.Message{}, r.err
+			}
+			c.mu.Lock()
+			c.queued = append(c.queued, r.msg)
+			c.mu.Unlock()
 		}
+	}
+}
 
-		c.log.Infof("kafka: created order %s", o.OrderUID)
+// Ack commits the offset of the message and lets the next message of its
+// partition be consumed.
+func (c *Consumer) Ack(ctx context.Context, m *ingest.Message) error {
+	src, ok := m.Origin.(kafka.Message)
+	if !ok {
+		return fmt.Errorf("kafka: ack: unexpected origin %T", m.Origin)
+	}
+	defer c.release(partition{src.Topic, src.Partition})
+	r, ok := c.readers[src.Topic]
+	if !ok {
+		return fmt.Errorf("kafka: ack: message of unknown topic %s", src.Topic)
 	}
+	return r.CommitMessages(ctx, src)
+}
+
+func (c *Consumer) release(p partition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.busy, p)
+	close(c.changed)
+	c.changed = make(chan struct{})
 }
 
-// sendToDLQ forwards the original message to the DLQ topic, augmenting headers with diagnostics.
-// If DLQ is disabled or the write fails, the error is logged and suppressed (best-effort policy).
-func (c *Consumer) sendToDLQ(ctx context.Context, src kafka.Message, reason string, cause error) error {
-	if c.dlqWriter == nil {
+// DLQ forwards the original message, augmenting headers with diagnostics: to
+// the next retry topic when reason is ingest.Retryable and one is left,
+// otherwise to the DLQ topic. If the destination is disabled or the write
+// fails, the error is logged (best-effort policy).
+func (c *Consumer) DLQ(ctx context.Context, m *ingest.Message, reason string, cause error) error {
+	if c.writer == nil {
 		// DLQ is optional; silently ignore if not configured.
 		return nil
 	}
+	src, ok := m.Origin.(kafka.Message)
+	if !ok {
+		return fmt.Errorf("kafka: dlq: unexpected origin %T", m.Origin)
+	}
 	errText := reason
 	if cause != nil {
 		errText = fmt.Sprintf("%s: %v", reason, cause)
 	}
+	now := c.now().UTC()
+	stamp := kafka.Header{Key: headerTimestamp, Value: []byte(now.Format(time.RFC3339Nano))}
+
+	if topic, delay, ok := c.retryTopic(src.Topic, reason); ok {
+		retryMsg := kafka.Message{
+			Topic: topic,
+			Key:   src.Key,
+			Value: src.Value,
+			// the produce time keeps versioning the order
+			Time: src.Time,
+			Headers: c.forwardHeaders(src,
+				kafka.Header{Key: headerError, Value: []byte(errText)}, stamp,
+				kafka.Header{Key: headerRetryAt, Value: []byte(now.Add(delay).Format(time.RFC3339Nano))}),
+		}
+		if err := c.writer.WriteMessages(ctx, retryMsg); err != nil {
+			c.log.Errorf("kafka: retry write failed (topic=%s): %v", topic, err)
+			return err
+		}
+		c.log.Warnf("kafka: message retried via %s in %s: %s", topic, delay, errText)
+		return nil
+	}
+
+	if c.dlqTopic == "" {
+		return nil
+	}
 	dlqMsg := kafka.Message{
-		Key:   src.Key,   // preserve key for potential replay/partitioning affinity
-		Value: src.Value, // preserve exact original payload
-		Headers: append(src.Headers, []kafka.Header{
-			{Key: "error", Value: []byte(errText)},
-			{Key: "origin-topic", Value: []byte(c.topic)},
-			{Key: "timestamp", Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
-		}...),
-	}
-	if err := c.dlqWriter.WriteMessages(ctx, dlqMsg); err != nil {
+		Topic:   c.dlqTopic,
+		Key:     src.Key,   // preserve key for potential replay/partitioning affinity
+		Value:   src.Value, // preserve exact original payload
+		Headers: c.forwardHeaders(src, kafka.Header{Key: headerError, Value: []byte(errText)}, stamp),
+	}
+	if err := c.writer.WriteMessages(ctx, dlqMsg); err != nil {
 		c.log.Errorf("kafka: DLQ write failed (topic=%s): %v", c.dlqTopic, err)
 		return err
 	}
 	return nil
 }
 
-// validateOrder performs minimal structural validation of an Order.
-// Keep this function free of business/stateful checks—only validate what is intrinsic
-// to the single message (e.g., required fields, non-zero timestamps, basic ranges).
-// Domain-level rules (e.g., state transitions, uniqueness) should live in the service layer.
-func validateOrder(o *model.Order) error {
-	if o == nil {
-		return fmt.Errorf("order is nil")
+// retryTopic returns the retry topic after topic for a failure for reason,
+// and how long the message waits there.
+func (c *Consumer) retryTopic(topic, reason string) (string, time.Duration, bool) {
+	if !ingest.Retryable(reason) {
+		return "", 0, false
+	}
+	hop := 0
+	if topic != c.topic {
+		hop = slices.Index(c.retryTopics, topic) + 1
+	}
+	if hop >= len(c.retryTopics) {
+		return "", 0, false
+	}
+	return c.retryTopics[hop], c.retryDelay << hop, true
+}
+
+// forwardHeaders returns the headers of src with extra replacing those of
+// the same key, and the origin of src unless it was forwarded already.
+func (c *Consumer) forwardHeaders(src kafka.Message, extra ...kafka.Header) []kafka.Header {
+	out := make([]kafka.Header, 0, len(src.Headers)+len(extra)+3)
+	forwarded := false
+	for _, h := range src.Headers {
+		if h.Key == "origin-topic" {
+			forwarded = true
+		}
+		if !slices.ContainsFunc(extra, func(e kafka.Header) bool { return e.Key == h.Key }) {
+			out = append(out, h)
+		}
+	}
+	if !forwarded {
+		out = append(out,
+			kafka.Header{Key: "origin-topic", Value: []byte(src.Topic)},
+			kafka.Header{Key: "origin-partition", Value: []byte(strconv.Itoa(src.Partition))},
+			kafka.Header{Key: "origin-offset", Value: []byte(strconv.FormatInt(src.Offset, 10))},
+		)
 	}
+	return append(out, extra...)
+}
 
-	var errs []string
+func header(m kafka.Message, key string) string {
+	for _, h := range m.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
 
-	// Required non-empty identifiers/strings.
-	if o.OrderUID == "" {
-		errs = append(errs, "order_uid is required")
+// PeekDLQ reads the last limit messages of every DLQ partition over plain
+// connections, outside the consumer group, so nothing is committed, and
+// returns the newest limit of them.
+func (c *Consumer) PeekDLQ(ctx context.Context, limit int) ([]ingest.DLQMessage, error) {
+	if c.dlqTopic == "" || limit <= 0 {
+		return nil, nil
 	}
-	if o.TrackNumber == "" {
-		errs = append(errs, "track_number is required")
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, peekTimeout)
+		defer cancel()
 	}
-	if o.Entry == "" {
-		errs = append(errs, "entry is required")
+	addr := c.brokers[0]
+	partitions, err := c.dialer.LookupPartitions(ctx, "tcp", addr, c.dlqTopic)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: dlq partitions: %w", err)
+	}
+
+	var out []ingest.DLQMessage
+	for _, p := range partitions {
+		msgs, err := c.peekPartition(ctx, c.dialer, addr, p.ID, limit)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: dlq partition %d: %w", p.ID, err)
+		}
+		out = append(out, msgs...)
 	}
-	if o.CustomerID == "" {
-		errs = append(errs, "customer_id is required")
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.After(out[j].Time) })
+	if len(out) > limit {
+		out = out[:limit]
 	}
-	if o.DeliveryService == "" {
-		errs = append(errs, "delivery_service is required")
+	return out, nil
+}
+
+func (c *Consumer) peekPartition(ctx context.Context, d *kafka.Dialer, addr string, partition, limit int) ([]ingest.DLQMessage, error) {
+	conn, err := d.DialLeader(ctx, "tcp", addr, c.dlqTopic, partition)
+	if err != nil {
+		return nil, err
 	}
-	if o.ShardKey == "" {
-		errs = append(errs, "shardkey is required")
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, err
 	}
-	if o.OofShard == "" {
-		errs = append(errs, "oof_shard is required")
+	offset := max(first, last-int64(limit))
+	if _, err := conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+		return nil, err
 	}
+	var out []ingest.DLQMessage
+	for offset < last {
+		batch := conn.ReadBatch(1, 10<<20)
+		read := 0
+		for offset < last {
+			m, err := batch.ReadMessage()
+			if err != nil {
+				break
+			}
+			offset = m.Offset + 1
+			read++
+			out = append(out, dlqMessage(m))
+		}
+		if err := batch.Close(); err != nil && !errors.Is(err, io.EOF) {
+			return out, err
+		}
+		if read == 0 {
+			break
+		}
+	}
+	return out, nil
+}
 
-	// Items must be present (empty order is not actionable).
-	if len(o.Items) == 0 {
-		errs = append(errs, "items must be non-empty")
+// dlqMessage maps a message written by DLQ to its operator view.
+func dlqMessage(m kafka.Message) ingest.DLQMessage {
+	dm := ingest.DLQMessage{Key: string(m.Key), OriginPartition: -1, OriginOffset: -1, Time: m.Time}
+	for _, h := range m.Headers {
+		switch h.Key {
+		case "error":
+			dm.Error = string(h.Value)
+		case "origin-partition":
+			if p, err := strconv.Atoi(string(h.Value)); err == nil {
+				dm.OriginPartition = p
+			}
+		case "origin-offset":
+			if o, err := strconv.ParseInt(string(h.Value), 10, 64); err == nil {
+				dm.OriginOffset = o
+			}
+		}
 	}
+	dm.SetPayload(m.Value)
+	return dm
+}
 
-	// Timestamp should be set (zero time usually indicates producer bug).
-	if o.DateCreated.IsZero() {
-		errs = append(errs, "date_created must be set")
+// Close stops fetching and closes the readers and the writer.
+func (c *Consumer) Close() error {
+	c.stop()
+	c.loops.Wait()
+	var errs []error
+	for _, r := range c.readers {
+		errs = append(errs, r.Close())
 	}
+	if c.writer != nil {
+		if werr := c.writer.Close(); werr != nil {
+			c.log.Errorf("kafka: dlq writer close: %v", werr)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	// Optional sanity: SmID should not be negative.
-	if o.SmID < 0 {
-		errs = append(errs, "sm_id must be >= 0")
+// Ping succeeds once any of the bootstrap brokers accepts a connection.
+func (c *Consumer) Ping(ctx context.Context) error {
+	var err error
+	for _, addr := range c.brokers {
+		var conn *kafka.Conn
+		if conn, err = c.dialer.DialContext(ctx, "tcp", addr); err == nil {
+			return conn.Close()
+		}
 	}
+	return fmt.Errorf("kafka: no broker reachable: %w", err)
+}
 
-	if len(errs) > 0 {
-		return fmt.Errorf("validation failed: %s", strings.Join(errs, "; "))
+// Backlog returns the consumer group lag last reported by the readers.
+func (c *Consumer) Backlog() int64 {
+	var n int64
+	for _, r := range c.readers {
+		n += r.Lag()
 	}
-	return nil
+	return n
 }
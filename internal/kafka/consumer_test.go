@@ -0,0 +1,111 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConsumer() *Consumer {
+	return &Consumer{
+		topic:       "orders",
+		dlqTopic:    "orders.dlq",
+		retryTopics: []string{"orders.retry.1", "orders.retry.2"},
+		retryDelay:  time.Second,
+		prefetch:    10,
+		now:         time.Now,
+		fetched:     make(chan fetchResult),
+		busy:        map[partition]bool{},
+		changed:     make(chan struct{}),
+	}
+}
+
+func TestConsumer_OneMessagePerPartitionInFlight(t *testing.T) {
+	c := newTestConsumer()
+	go func() {
+		for _, m := range []kafka.Message{
+			{Topic: "orders", Partition: 0, Offset: 1},
+			{Topic: "orders", Partition: 0, Offset: 2},
+			{Topic: "orders", Partition: 1, Offset: 1},
+		} {
+			c.fetched <- fetchResult{msg: m}
+		}
+	}()
+
+	m, err := c.next(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), m.Offset)
+	require.Equal(t, 0, m.Partition)
+
+	// partition 0 is busy, so its second message waits behind partition 1
+	m, err = c.next(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, m.Partition)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.next(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	c.release(partition{"orders", 0})
+	m, err = c.next(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, m.Partition)
+	require.Equal(t, int64(2), m.Offset)
+}
+
+func TestConsumer_RetryTopic(t *testing.T) {
+	c := newTestConsumer()
+	for _, tc := range []struct {
+		topic, reason string
+		next          string
+		delay         time.Duration
+	}{
+		{"orders", "business_error", "orders.retry.1", time.Second},
+		{"orders.retry.1", "business_error", "orders.retry.2", 2 * time.Second},
+		{"orders.retry.2", "business_error", "", 0},
+		{"orders", "schema_validation", "", 0},
+		{"orders", "invalid_json", "", 0},
+	} {
+		next, delay, ok := c.retryTopic(tc.topic, tc.reason)
+		require.Equal(t, tc.next != "", ok, tc)
+		require.Equal(t, tc.next, next, tc)
+		require.Equal(t, tc.delay, delay, tc)
+	}
+}
+
+func TestConsumer_ForwardHeadersKeepTheFirstOrigin(t *testing.T) {
+	c := newTestConsumer()
+	errHeader := kafka.Header{Key: headerError, Value: []byte("business_error: db down")}
+
+	first := c.forwardHeaders(kafka.Message{
+		Topic: "orders", Partition: 3, Offset: 42,
+		Headers: []kafka.Header{{Key: "X-Tenant-ID", Value: []byte("shop")}},
+	}, errHeader)
+	require.Equal(t, []kafka.Header{
+		{Key: "X-Tenant-ID", Value: []byte("shop")},
+		{Key: "origin-topic", Value: []byte("orders")},
+		{Key: "origin-partition", Value: []byte("3")},
+		{Key: "origin-offset", Value: []byte("42")},
+		errHeader,
+	}, first)
+
+	again := kafka.Header{Key: headerError, Value: []byte("business_error: still down")}
+	second := c.forwardHeaders(kafka.Message{Topic: "orders.retry.1", Partition: 0, Offset: 7, Headers: first}, again)
+	require.Equal(t, append(first[:len(first)-1:len(first)-1], again), second)
+	require.Equal(t, 42, int(dlqMessage(kafka.Message{Headers: second}).OriginOffset))
+}
+
+func TestLimiter_SpacesWaits(t *testing.T) {
+	l := newLimiter(100)
+	start := time.Now()
+	for range 3 {
+		require.NoError(t, l.wait(context.Background()))
+	}
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	require.Nil(t, newLimiter(0))
+	require.NoError(t, newLimiter(0).wait(context.Background()))
+}
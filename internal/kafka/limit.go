@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter spaces out waits to at most a rate per second; a nil limiter
+// never waits.
+type limiter struct {
+	mu    sync.Mutex
+	every time.Duration
+	next  time.Time
+}
+
+func newLimiter(perSecond int) *limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &limiter{every: time.Second / time.Duration(perSecond)}
+}
+
+// wait blocks until the next slot or until ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.every)
+	l.mu.Unlock()
+	return sleepCtx(ctx, at.Sub(now))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
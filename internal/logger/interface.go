@@ -1,5 +1,7 @@
 package logger
 
+import "context"
+
 type InterfaceLogger interface {
 	Info(args ...interface{})
 	Infof(template string, args ...interface{})
@@ -11,5 +13,9 @@ type InterfaceLogger interface {
 	Debugf(template string, args ...interface{})
 	Warn(args ...interface{})
 	Warnf(template string, args ...interface{})
+	// With returns a logger adding the key/value pairs to every entry.
+	With(keysAndValues ...interface{}) InterfaceLogger
+	// WithContext returns a logger adding the fields stored by ContextWith.
+	WithContext(ctx context.Context) InterfaceLogger
 	Sync() error
 }
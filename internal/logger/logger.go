@@ -1,7 +1,11 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"syscall"
 
 	"github.com/merkulovlad/wbtech-go/internal/config/config"
 
@@ -12,12 +16,34 @@ import (
 type Logger struct {
 	sugar  *zap.SugaredLogger
 	logger *zap.Logger
+	// level gates the file and console cores; nil for NewFromCore loggers.
+	level *zap.AtomicLevel
 }
 
 var _ InterfaceLogger = (*Logger)(nil)
 
-func NewLogger(cfg *config.LogConfig) (*Logger, error) {
-	if err := os.MkdirAll("logs", 0755); err != nil {
+// Option customizes the cores NewLogger builds.
+type Option func(*options)
+
+type options struct {
+	cores []zapcore.Core
+}
+
+// WithCore tees every entry into core as well, e.g. a zaptest/observer core
+// in tests or an extra sink in production. The core applies its own level.
+func WithCore(core zapcore.Core) Option {
+	return func(o *options) {
+		o.cores = append(o.cores, core)
+	}
+}
+
+func NewLogger(cfg *config.LogConfig, opts ...Option) (*Logger, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Filename), 0755); err != nil {
 		return nil, err
 	}
 
@@ -26,10 +52,11 @@ func NewLogger(cfg *config.LogConfig) (*Logger, error) {
 		return nil, err
 	}
 
-	level, err := zapcore.ParseLevel(cfg.Level)
+	parsed, err := zapcore.ParseLevel(cfg.Level)
 	if err != nil {
 		return nil, err
 	}
+	level := zap.NewAtomicLevelAt(parsed)
 
 	encoderCfg := zap.NewProductionEncoderConfig()
 	encoderCfg.TimeKey = "timestamp"
@@ -44,12 +71,65 @@ func NewLogger(cfg *config.LogConfig) (*Logger, error) {
 	if cfg.ToConsole {
 		cores = append(cores, zapcore.NewCore(jsonEncoder, zapcore.AddSync(os.Stdout), level))
 	}
+	cores = append(cores, o.cores...)
+
+	l := NewFromCore(zapcore.NewTee(cores...))
+	l.level = &level
+	return l, nil
+}
 
-	core := zapcore.NewTee(cores...)
+// NewFromCore builds a Logger writing only to core.
+func NewFromCore(core zapcore.Core) *Logger {
 	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
-	sugar := logger.Sugar()
+	return &Logger{sugar: logger.Sugar(), logger: logger}
+}
+
+type fieldsKey struct{}
+
+// ContextWith returns a copy of ctx carrying the key/value pairs; loggers
+// obtained via WithContext add them to every entry. Pairs accumulate across calls.
+func ContextWith(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	prev, _ := ctx.Value(fieldsKey{}).([]interface{})
+	fields := make([]interface{}, 0, len(prev)+len(keysAndValues))
+	fields = append(append(fields, prev...), keysAndValues...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// With returns a child logger that adds the key/value pairs to every entry.
+func (l *Logger) With(keysAndValues ...interface{}) InterfaceLogger {
+	sugar := l.sugar.With(keysAndValues...)
+	return &Logger{sugar: sugar, logger: sugar.Desugar(), level: l.level}
+}
 
-	return &Logger{sugar: sugar, logger: logger}, nil
+// Level returns the level of the file and console output.
+func (l *Logger) Level() string {
+	if l.level == nil {
+		return ""
+	}
+	return l.level.String()
+}
+
+// SetLevel changes the level of the file and console output, for this logger
+// and every logger derived from it. Cores added with WithCore keep their own.
+func (l *Logger) SetLevel(level string) error {
+	if l.level == nil {
+		return errors.New("logger: level is fixed by its core")
+	}
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(parsed)
+	return nil
+}
+
+// WithContext returns a child logger with the fields stored in ctx by ContextWith.
+func (l *Logger) WithContext(ctx context.Context) InterfaceLogger {
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
 }
 
 func (l *Logger) Info(args ...interface{}) {
@@ -92,6 +172,12 @@ func (l *Logger) Warnf(template string, args ...interface{}) {
 	l.sugar.Warnf(template, args...)
 }
 
+// Sync flushes buffered entries. EINVAL and ENOTTY are ignored: they are what
+// fsync returns for stdout attached to a terminal or pipe, not a lost write.
 func (l *Logger) Sync() error {
-	return l.sugar.Sync()
+	err := l.sugar.Sync()
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		return nil
+	}
+	return err
 }
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObserved(level zapcore.Level) (*Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+	return NewFromCore(core), logs
+}
+
+func TestLogger_LevelFiltering(t *testing.T) {
+	l, logs := newObserved(zapcore.WarnLevel)
+
+	l.Debugf("debug %d", 1)
+	l.Info("info")
+	l.Warnf("warn %d", 2)
+	l.Errorf("error %d", 3)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	require.Equal(t, "warn 2", entries[0].Message)
+	require.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+}
+
+func TestNewLogger_WithCoreAndFile(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	cfg := &config.LogConfig{Filename: filepath.Join(t.TempDir(), "nested", "app.log"), Level: "error"}
+
+	l, err := NewLogger(cfg, WithCore(core))
+	require.NoError(t, err)
+	l.Info("kept by observer only")
+	require.Equal(t, 1, logs.FilterMessage("kept by observer only").Len())
+	require.NoError(t, l.Sync())
+}
+
+func TestNewLogger_InvalidLevel(t *testing.T) {
+	_, err := NewLogger(&config.LogConfig{Filename: filepath.Join(t.TempDir(), "app.log"), Level: "loud"})
+	require.Error(t, err)
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	cfg := &config.LogConfig{Filename: filepath.Join(t.TempDir(), "app.log"), Level: "error"}
+	l, err := NewLogger(cfg)
+	require.NoError(t, err)
+	child := l.With("component", "ingest")
+
+	child.Info("dropped")
+	require.NoError(t, l.SetLevel("info"))
+	require.Equal(t, "info", l.Level())
+	child.Info("written")
+	require.Error(t, l.SetLevel("loud"))
+	require.NoError(t, l.Sync())
+
+	b, err := os.ReadFile(cfg.Filename)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "dropped")
+	require.Contains(t, string(b), "written")
+
+	fixed, _ := newObserved(zapcore.InfoLevel)
+	require.Error(t, fixed.SetLevel("debug"))
+}
+
+func TestLogger_ContextFields(t *testing.T) {
+	l, logs := newObserved(zapcore.InfoLevel)
+
+	ctx := ContextWith(context.Background(), "request_id", "r1")
+	ctx = ContextWith(ctx, "order_uid", "b1")
+	l.WithContext(ctx).Infof("handled")
+	l.WithContext(context.Background()).Info("plain")
+	l.With("component", "ingest").Info("child")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	require.Equal(t, map[string]interface{}{"request_id": "r1", "order_uid": "b1"}, entries[0].ContextMap())
+	require.Empty(t, entries[1].Context)
+	require.Equal(t, map[string]interface{}{"component": "ingest"}, entries[2].ContextMap())
+}
+
+// failingSyncer fails Sync with err.
+type failingSyncer struct{ err error }
+
+func (f failingSyncer) Write(p []byte) (int, error) { return len(p), nil }
+func (f failingSyncer) Sync() error                 { return f.err }
+
+func TestLogger_SyncErrors(t *testing.T) {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	newWith := func(err error) *Logger {
+		return NewFromCore(zapcore.NewCore(enc, failingSyncer{err}, zapcore.InfoLevel))
+	}
+
+	diskFull := errors.New("disk full")
+	require.ErrorIs(t, newWith(diskFull).Sync(), diskFull)
+	require.NoError(t, newWith(syscall.EINVAL).Sync())
+	require.NoError(t, newWith(syscall.ENOTTY).Sync())
+	require.NoError(t, newWith(nil).Sync())
+}
@@ -0,0 +1,316 @@
+// Package metrics renders counters, histograms and gauges in the Prometheus
+// text exposition format served at /metrics.
+//
+// Metrics are registered once, usually in a package-level var, and live for
+// the life of the process. Label values must come from a small fixed set
+// (route templates, outcome names, ...): every distinct combination is kept
+// and exported forever.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the output of WriteTo.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are latency buckets in seconds, from 5ms to 10s.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families by name.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+	help     map[string]string
+	kinds    map[string]string
+}
+
+// Default is the registry the New* functions register on.
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]family{}, help: map[string]string{}, kinds: map[string]string{}}
+}
+
+// register panics on a duplicate name, like a duplicate expvar.Publish.
+func (r *Registry) register(name, help, kind string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.families[name]; dup {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.families[name] = f
+	r.help[name] = help
+	r.kinds[name] = kind
+}
+
+// WriteTo writes every family in name order.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	families := make([]family, len(names))
+	help := make([]string, len(names))
+	kinds := make([]string, len(names))
+	for i, name := range names {
+		families[i], help[i], kinds[i] = r.families[name], r.help[name], r.kinds[name]
+	}
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for i, name := range names {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help[i]), name, kinds[i])
+		families[i].write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// series is the label set of one time series, keyed by its joined values.
+type series struct {
+	names  []string
+	values []string
+}
+
+func (s series) key() string { return strings.Join(s.values, "\xff") }
+
+// labels renders {a="x",b="y"} with extra appended, or "" when there are none.
+func (s series) labels(extra ...string) string {
+	if len(s.names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	pairs := make([]string, 0, len(s.names)+len(extra)/2)
+	for i, n := range s.names {
+		pairs = append(pairs, n+`="`+escapeLabel(s.values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	b.WriteString(strings.Join(pairs, ","))
+	b.WriteByte('}')
+	return b.String()
+}
+
+func newSeries(names, values []string) series {
+	if len(values) != len(names) {
+		panic(fmt.Sprintf("metrics: got %d label values for %d labels %v", len(values), len(names), names))
+	}
+	return series{names: names, values: append([]string(nil), values...)}
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+func escapeHelp(v string) string  { return helpEscaper.Replace(v) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m in order so scrapes are stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a monotonically increasing counter per label set.
+type CounterVec struct {
+	name   string
+	labels []string
+	mu     sync.Mutex
+	series map[string]*counter
+}
+
+type counter struct {
+	series
+	n uint64
+}
+
+// NewCounterVec registers a counter family on Default.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{name: name, labels: labels, series: map[string]*counter{}}
+	Default.register(name, help, "counter", v)
+	return v
+}
+
+// Inc adds one to the series for values, given in label order.
+func (v *CounterVec) Inc(values ...string) { v.Add(1, values...) }
+
+// Add adds n to the series for values.
+func (v *CounterVec) Add(n uint64, values ...string) {
+	s := newSeries(v.labels, values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.series[s.key()]
+	if !ok {
+		c = &counter{series: s}
+		v.series[s.key()] = c
+	}
+	c.n += n
+}
+
+// Value returns the current count for values.
+func (v *CounterVec) Value(values ...string) uint64 {
+	s := newSeries(v.labels, values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.series[s.key()]; ok {
+		return c.n
+	}
+	return 0
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, k := range sortedKeys(v.series) {
+		c := v.series[k]
+		fmt.Fprintf(w, "%s%s %d\n", v.name, c.labels(), c.n)
+	}
+}
+
+// HistogramVec counts observations into cumulative buckets per label set.
+type HistogramVec struct {
+	name    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+type histogram struct {
+	series
+	counts []uint64 // per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec registers a histogram family on Default; buckets are upper
+// bounds in increasing order, nil selects DefBuckets.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	v := &HistogramVec{name: name, labels: labels, buckets: buckets, series: map[string]*histogram{}}
+	Default.register(name, help, "histogram", v)
+	return v
+}
+
+// Observe records x in the series for values.
+func (v *HistogramVec) Observe(x float64, values ...string) {
+	s := newSeries(v.labels, values)
+	i := sort.SearchFloat64s(v.buckets, x) // first bucket with bound >= x
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	h, ok := v.series[s.key()]
+	if !ok {
+		h = &histogram{series: s, counts: make([]uint64, len(v.buckets)+1)}
+		v.series[s.key()] = h
+	}
+	h.counts[i]++
+	h.sum += x
+	h.count++
+}
+
+// Count returns the number of observations for values.
+func (v *HistogramVec) Count(values ...string) uint64 {
+	s := newSeries(v.labels, values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if h, ok := v.series[s.key()]; ok {
+		return h.count
+	}
+	return 0
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, k := range sortedKeys(v.series) {
+		h := v.series[k]
+		var cum uint64
+		for i, n := range h.counts {
+			cum += n
+			le := math.Inf(1)
+			if i < len(v.buckets) {
+				le = v.buckets[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, h.labels("le", formatFloat(le)), cum)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, h.labels(), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, h.labels(), h.count)
+	}
+}
+
+// gaugeSet evaluates its callback on every scrape.
+type gaugeSet struct {
+	name  string
+	label string
+	f     func() map[string]float64
+}
+
+// NewGaugeFunc registers a gauge whose value is f() at scrape time. f must be
+// cheap and must not block.
+func NewGaugeFunc(name, help string, f func() float64) {
+	Default.register(name, help, "gauge", &gaugeSet{name: name, f: func() map[string]float64 {
+		return map[string]float64{"": f()}
+	}})
+}
+
+// NewGaugeSet registers a gauge family whose series are the entries of f(),
+// keyed by the value of label.
+func NewGaugeSet(name, help, label string, f func() map[string]float64) {
+	Default.register(name, help, "gauge", &gaugeSet{name: name, label: label, f: f})
+}
+
+func (g *gaugeSet) write(w *bufio.Writer) {
+	values := g.f()
+	for _, k := range sortedKeys(values) {
+		s := series{}
+		if g.label != "" {
+			s = series{names: []string{g.label}, values: []string{k}}
+		}
+		fmt.Fprintf(w, "%s%s %s\n", g.name, s.labels(), formatFloat(values[k]))
+	}
+}
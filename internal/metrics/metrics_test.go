@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	var b strings.Builder
+	_, err := Default.WriteTo(&b)
+	require.NoError(t, err)
+	return b.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_events_total", "Events by kind.", "kind")
+	c.Inc("a")
+	c.Add(2, `q"uote`)
+	c.Inc("a")
+
+	out := scrape(t)
+	require.Contains(t, out, "# HELP test_events_total Events by kind.\n# TYPE test_events_total counter\n")
+	require.Contains(t, out, `test_events_total{kind="a"} 2`+"\n")
+	require.Contains(t, out, `test_events_total{kind="q\"uote"} 2`+"\n")
+	require.EqualValues(t, 2, c.Value("a"))
+	require.Panics(t, func() { c.Inc() })
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+	h.Observe(0.05, "get")
+	h.Observe(0.1, "get")
+	h.Observe(3, "get")
+
+	out := scrape(t)
+	for _, line := range []string{
+		`test_latency_seconds_bucket{op="get",le="0.1"} 2`,
+		`test_latency_seconds_bucket{op="get",le="1"} 2`,
+		`test_latency_seconds_bucket{op="get",le="+Inf"} 3`,
+		`test_latency_seconds_sum{op="get"} 3.15`,
+		`test_latency_seconds_count{op="get"} 3`,
+	} {
+		require.Contains(t, out, line+"\n")
+	}
+	require.EqualValues(t, 3, h.Count("get"))
+}
+
+func TestGauges(t *testing.T) {
+	depth := 1.0
+	NewGaugeFunc("test_depth", "Depth.", func() float64 { return depth })
+	NewGaugeSet("test_pool_conns", "Conns.", "pool", func() map[string]float64 {
+		return map[string]float64{"b": 2, "a": 1}
+	})
+	depth = 4
+
+	out := scrape(t)
+	require.Contains(t, out, "test_depth 4\n")
+	require.Contains(t, out, "test_pool_conns{pool=\"a\"} 1\ntest_pool_conns{pool=\"b\"} 2\n")
+	require.Panics(t, func() { NewGaugeFunc("test_depth", "again", nil) })
+}
@@ -6,6 +6,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/merkulovlad/wbtech-go/internal/model"
@@ -34,6 +35,32 @@ func (m *MockInterfaceCache) EXPECT() *MockInterfaceCacheMockRecorder {
 	return m.recorder
 }
 
+// Clear mocks base method.
+func (m *MockInterfaceCache) Clear() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockInterfaceCacheMockRecorder) Clear() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockInterfaceCache)(nil).Clear))
+}
+
+// Delete mocks base method.
+func (m *MockInterfaceCache) Delete(key string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", key)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockInterfaceCacheMockRecorder) Delete(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockInterfaceCache)(nil).Delete), key)
+}
+
 // Get mocks base method.
 func (m *MockInterfaceCache) Get(key string) (*model.Order, bool) {
 	m.ctrl.T.Helper()
@@ -49,6 +76,36 @@ func (mr *MockInterfaceCacheMockRecorder) Get(key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockInterfaceCache)(nil).Get), key)
 }
 
+// Lookup mocks base method.
+func (m *MockInterfaceCache) Lookup(key string) (*model.Order, time.Time, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", key)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(bool)
+	return ret0, ret1, ret2
+}
+
+// Lookup indicates an expected call of Lookup.
+func (mr *MockInterfaceCacheMockRecorder) Lookup(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockInterfaceCache)(nil).Lookup), key)
+}
+
+// Popular mocks base method.
+func (m *MockInterfaceCache) Popular(n int) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Popular", n)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Popular indicates an expected call of Popular.
+func (mr *MockInterfaceCacheMockRecorder) Popular(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Popular", reflect.TypeOf((*MockInterfaceCache)(nil).Popular), n)
+}
+
 // Set mocks base method.
 func (m *MockInterfaceCache) Set(key string, value *model.Order) error {
 	m.ctrl.T.Helper()
@@ -62,3 +119,87 @@ func (mr *MockInterfaceCacheMockRecorder) Set(key, value interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockInterfaceCache)(nil).Set), key, value)
 }
+
+// MockSized is a mock of Sized interface.
+type MockSized struct {
+	ctrl     *gomock.Controller
+	recorder *MockSizedMockRecorder
+}
+
+// MockSizedMockRecorder is the mock recorder for MockSized.
+type MockSizedMockRecorder struct {
+	mock *MockSized
+}
+
+// NewMockSized creates a new mock instance.
+func NewMockSized(ctrl *gomock.Controller) *MockSized {
+	mock := &MockSized{ctrl: ctrl}
+	mock.recorder = &MockSizedMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSized) EXPECT() *MockSizedMockRecorder {
+	return m.recorder
+}
+
+// Len mocks base method.
+func (m *MockSized) Len() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Len")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Len indicates an expected call of Len.
+func (mr *MockSizedMockRecorder) Len() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Len", reflect.TypeOf((*MockSized)(nil).Len))
+}
+
+// SetLimit mocks base method.
+func (m *MockSized) SetLimit(n int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLimit", n)
+}
+
+// SetLimit indicates an expected call of SetLimit.
+func (mr *MockSizedMockRecorder) SetLimit(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLimit", reflect.TypeOf((*MockSized)(nil).SetLimit), n)
+}
+
+// MockExpiring is a mock of Expiring interface.
+type MockExpiring struct {
+	ctrl     *gomock.Controller
+	recorder *MockExpiringMockRecorder
+}
+
+// MockExpiringMockRecorder is the mock recorder for MockExpiring.
+type MockExpiringMockRecorder struct {
+	mock *MockExpiring
+}
+
+// NewMockExpiring creates a new mock instance.
+func NewMockExpiring(ctrl *gomock.Controller) *MockExpiring {
+	mock := &MockExpiring{ctrl: ctrl}
+	mock.recorder = &MockExpiringMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExpiring) EXPECT() *MockExpiringMockRecorder {
+	return m.recorder
+}
+
+// SetTTL mocks base method.
+func (m *MockExpiring) SetTTL(d time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTTL", d)
+}
+
+// SetTTL indicates an expected call of SetTTL.
+func (mr *MockExpiringMockRecorder) SetTTL(d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTTL", reflect.TypeOf((*MockExpiring)(nil).SetTTL), d)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/merkulovlad/wbtech-go/internal/model"
@@ -35,6 +36,79 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AnonymizeCustomer mocks base method.
+func (m *MockRepository) AnonymizeCustomer(ctx context.Context, customerID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeCustomer", ctx, customerID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymizeCustomer indicates an expected call of AnonymizeCustomer.
+func (mr *MockRepositoryMockRecorder) AnonymizeCustomer(ctx, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeCustomer", reflect.TypeOf((*MockRepository)(nil).AnonymizeCustomer), ctx, customerID)
+}
+
+// ArchiveOrder mocks base method.
+func (m *MockRepository) ArchiveOrder(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveOrder", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchiveOrder indicates an expected call of ArchiveOrder.
+func (mr *MockRepositoryMockRecorder) ArchiveOrder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveOrder", reflect.TypeOf((*MockRepository)(nil).ArchiveOrder), ctx, id)
+}
+
+// DeleteOrder mocks base method.
+func (m *MockRepository) DeleteOrder(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrder", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrder indicates an expected call of DeleteOrder.
+func (mr *MockRepositoryMockRecorder) DeleteOrder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrder", reflect.TypeOf((*MockRepository)(nil).DeleteOrder), ctx, id)
+}
+
+// ExpireOrders mocks base method.
+func (m *MockRepository) ExpireOrders(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireOrders", ctx, cutoff, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireOrders indicates an expected call of ExpireOrders.
+func (mr *MockRepositoryMockRecorder) ExpireOrders(ctx, cutoff, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireOrders", reflect.TypeOf((*MockRepository)(nil).ExpireOrders), ctx, cutoff, limit)
+}
+
+// FetchUnsentEvents mocks base method.
+func (m *MockRepository) FetchUnsentEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchUnsentEvents", ctx, limit)
+	ret0, _ := ret[0].([]model.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchUnsentEvents indicates an expected call of FetchUnsentEvents.
+func (mr *MockRepositoryMockRecorder) FetchUnsentEvents(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchUnsentEvents", reflect.TypeOf((*MockRepository)(nil).FetchUnsentEvents), ctx, limit)
+}
+
 // GetOrder mocks base method.
 func (m *MockRepository) GetOrder(ctx context.Context, id string) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +124,66 @@ func (mr *MockRepositoryMockRecorder) GetOrder(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockRepository)(nil).GetOrder), ctx, id)
 }
 
+// GetOrders mocks base method.
+func (m *MockRepository) GetOrders(ctx context.Context, ids []string) ([]*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrders", ctx, ids)
+	ret0, _ := ret[0].([]*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrders indicates an expected call of GetOrders.
+func (mr *MockRepositoryMockRecorder) GetOrders(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockRepository)(nil).GetOrders), ctx, ids)
+}
+
+// GetOrdersByCustomer mocks base method.
+func (m *MockRepository) GetOrdersByCustomer(ctx context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersByCustomer", ctx, customerID, page)
+	ret0, _ := ret[0].(*model.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersByCustomer indicates an expected call of GetOrdersByCustomer.
+func (mr *MockRepositoryMockRecorder) GetOrdersByCustomer(ctx, customerID, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByCustomer", reflect.TypeOf((*MockRepository)(nil).GetOrdersByCustomer), ctx, customerID, page)
+}
+
+// GetPrimaryOrder mocks base method.
+func (m *MockRepository) GetPrimaryOrder(ctx context.Context, id string) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrimaryOrder", ctx, id)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPrimaryOrder indicates an expected call of GetPrimaryOrder.
+func (mr *MockRepositoryMockRecorder) GetPrimaryOrder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrimaryOrder", reflect.TypeOf((*MockRepository)(nil).GetPrimaryOrder), ctx, id)
+}
+
+// GetRawPayload mocks base method.
+func (m *MockRepository) GetRawPayload(ctx context.Context, id string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRawPayload", ctx, id)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRawPayload indicates an expected call of GetRawPayload.
+func (mr *MockRepositoryMockRecorder) GetRawPayload(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRawPayload", reflect.TypeOf((*MockRepository)(nil).GetRawPayload), ctx, id)
+}
+
 // GetRecent mocks base method.
 func (m *MockRepository) GetRecent(ctx context.Context, limit int) ([]*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +199,209 @@ func (mr *MockRepositoryMockRecorder) GetRecent(ctx, limit interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecent", reflect.TypeOf((*MockRepository)(nil).GetRecent), ctx, limit)
 }
 
+// GoodsByDeliveryService mocks base method.
+func (m *MockRepository) GoodsByDeliveryService(ctx context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GoodsByDeliveryService", ctx, f)
+	ret0, _ := ret[0].([]model.DeliveryServiceTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GoodsByDeliveryService indicates an expected call of GoodsByDeliveryService.
+func (mr *MockRepositoryMockRecorder) GoodsByDeliveryService(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GoodsByDeliveryService", reflect.TypeOf((*MockRepository)(nil).GoodsByDeliveryService), ctx, f)
+}
+
+// HealthCheck mocks base method.
+func (m *MockRepository) HealthCheck(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthCheck indicates an expected call of HealthCheck.
+func (mr *MockRepositoryMockRecorder) HealthCheck(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockRepository)(nil).HealthCheck), ctx)
+}
+
+// ListOrders mocks base method.
+func (m *MockRepository) ListOrders(ctx context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, f, page)
+	ret0, _ := ret[0].(*model.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockRepositoryMockRecorder) ListOrders(ctx, f, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockRepository)(nil).ListOrders), ctx, f, page)
+}
+
+// MarkSent mocks base method.
+func (m *MockRepository) MarkSent(ctx context.Context, events []model.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSent", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSent indicates an expected call of MarkSent.
+func (mr *MockRepositoryMockRecorder) MarkSent(ctx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSent", reflect.TypeOf((*MockRepository)(nil).MarkSent), ctx, events)
+}
+
+// OrderExists mocks base method.
+func (m *MockRepository) OrderExists(ctx context.Context, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrderExists", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrderExists indicates an expected call of OrderExists.
+func (mr *MockRepositoryMockRecorder) OrderExists(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrderExists", reflect.TypeOf((*MockRepository)(nil).OrderExists), ctx, id)
+}
+
+// OrderHistory mocks base method.
+func (m *MockRepository) OrderHistory(ctx context.Context, id string) ([]model.OrderRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrderHistory", ctx, id)
+	ret0, _ := ret[0].([]model.OrderRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrderHistory indicates an expected call of OrderHistory.
+func (mr *MockRepositoryMockRecorder) OrderHistory(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrderHistory", reflect.TypeOf((*MockRepository)(nil).OrderHistory), ctx, id)
+}
+
+// OrdersPerDay mocks base method.
+func (m *MockRepository) OrdersPerDay(ctx context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrdersPerDay", ctx, f)
+	ret0, _ := ret[0].([]model.DailyCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrdersPerDay indicates an expected call of OrdersPerDay.
+func (mr *MockRepositoryMockRecorder) OrdersPerDay(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrdersPerDay", reflect.TypeOf((*MockRepository)(nil).OrdersPerDay), ctx, f)
+}
+
+// ReplaceOrder mocks base method.
+func (m *MockRepository) ReplaceOrder(ctx context.Context, o *model.Order, current int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceOrder", ctx, o, current)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceOrder indicates an expected call of ReplaceOrder.
+func (mr *MockRepositoryMockRecorder) ReplaceOrder(ctx, o, current interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceOrder", reflect.TypeOf((*MockRepository)(nil).ReplaceOrder), ctx, o, current)
+}
+
+// RestoreOrder mocks base method.
+func (m *MockRepository) RestoreOrder(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreOrder", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreOrder indicates an expected call of RestoreOrder.
+func (mr *MockRepositoryMockRecorder) RestoreOrder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreOrder", reflect.TypeOf((*MockRepository)(nil).RestoreOrder), ctx, id)
+}
+
+// SaveWebhookDelivery mocks base method.
+func (m *MockRepository) SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebhookDelivery", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWebhookDelivery indicates an expected call of SaveWebhookDelivery.
+func (mr *MockRepositoryMockRecorder) SaveWebhookDelivery(ctx, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebhookDelivery", reflect.TypeOf((*MockRepository)(nil).SaveWebhookDelivery), ctx, d)
+}
+
+// SearchOrders mocks base method.
+func (m *MockRepository) SearchOrders(ctx context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchOrders", ctx, query, page)
+	ret0, _ := ret[0].(*model.SearchPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchOrders indicates an expected call of SearchOrders.
+func (mr *MockRepositoryMockRecorder) SearchOrders(ctx, query, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchOrders", reflect.TypeOf((*MockRepository)(nil).SearchOrders), ctx, query, page)
+}
+
+// StreamOrders mocks base method.
+func (m *MockRepository) StreamOrders(ctx context.Context, fn func([]*model.Order) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamOrders", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamOrders indicates an expected call of StreamOrders.
+func (mr *MockRepositoryMockRecorder) StreamOrders(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamOrders", reflect.TypeOf((*MockRepository)(nil).StreamOrders), ctx, fn)
+}
+
+// TopCustomers mocks base method.
+func (m *MockRepository) TopCustomers(ctx context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopCustomers", ctx, f, limit)
+	ret0, _ := ret[0].([]model.CustomerTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopCustomers indicates an expected call of TopCustomers.
+func (mr *MockRepositoryMockRecorder) TopCustomers(ctx, f, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopCustomers", reflect.TypeOf((*MockRepository)(nil).TopCustomers), ctx, f, limit)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockRepository) UpdateStatus(ctx context.Context, id string, status model.OrderStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockRepositoryMockRecorder) UpdateStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockRepository)(nil).UpdateStatus), ctx, id, status)
+}
+
 // UpsertOrder mocks base method.
 func (m *MockRepository) UpsertOrder(ctx context.Context, o *model.Order) error {
 	m.ctrl.T.Helper()
@@ -78,3 +415,18 @@ func (mr *MockRepositoryMockRecorder) UpsertOrder(ctx, o interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertOrder", reflect.TypeOf((*MockRepository)(nil).UpsertOrder), ctx, o)
 }
+
+// UpsertOrders mocks base method.
+func (m *MockRepository) UpsertOrders(ctx context.Context, orders []*model.Order) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertOrders", ctx, orders)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertOrders indicates an expected call of UpsertOrders.
+func (mr *MockRepositoryMockRecorder) UpsertOrders(ctx, orders interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertOrders", reflect.TypeOf((*MockRepository)(nil).UpsertOrders), ctx, orders)
+}
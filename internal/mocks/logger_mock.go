@@ -5,9 +5,11 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	logger "github.com/merkulovlad/wbtech-go/internal/logger"
 )
 
 // MockInterfaceLogger is a mock of InterfaceLogger interface.
@@ -211,3 +213,35 @@ func (mr *MockInterfaceLoggerMockRecorder) Warnf(template interface{}, args ...i
 	varargs := append([]interface{}{template}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnf", reflect.TypeOf((*MockInterfaceLogger)(nil).Warnf), varargs...)
 }
+
+// With mocks base method.
+func (m *MockInterfaceLogger) With(keysAndValues ...interface{}) logger.InterfaceLogger {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range keysAndValues {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "With", varargs...)
+	ret0, _ := ret[0].(logger.InterfaceLogger)
+	return ret0
+}
+
+// With indicates an expected call of With.
+func (mr *MockInterfaceLoggerMockRecorder) With(keysAndValues ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "With", reflect.TypeOf((*MockInterfaceLogger)(nil).With), keysAndValues...)
+}
+
+// WithContext mocks base method.
+func (m *MockInterfaceLogger) WithContext(ctx context.Context) logger.InterfaceLogger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithContext", ctx)
+	ret0, _ := ret[0].(logger.InterfaceLogger)
+	return ret0
+}
+
+// WithContext indicates an expected call of WithContext.
+func (mr *MockInterfaceLoggerMockRecorder) WithContext(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithContext", reflect.TypeOf((*MockInterfaceLogger)(nil).WithContext), ctx)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/merkulovlad/wbtech-go/internal/model"
@@ -35,6 +36,49 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// AnonymizeCustomer mocks base method.
+func (m *MockService) AnonymizeCustomer(c context.Context, customerID string) (*model.ErasureResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeCustomer", c, customerID)
+	ret0, _ := ret[0].(*model.ErasureResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymizeCustomer indicates an expected call of AnonymizeCustomer.
+func (mr *MockServiceMockRecorder) AnonymizeCustomer(c, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeCustomer", reflect.TypeOf((*MockService)(nil).AnonymizeCustomer), c, customerID)
+}
+
+// Archive mocks base method.
+func (m *MockService) Archive(c context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Archive", c, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Archive indicates an expected call of Archive.
+func (mr *MockServiceMockRecorder) Archive(c, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Archive", reflect.TypeOf((*MockService)(nil).Archive), c, id)
+}
+
+// CacheWarm mocks base method.
+func (m *MockService) CacheWarm() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheWarm")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// CacheWarm indicates an expected call of CacheWarm.
+func (mr *MockServiceMockRecorder) CacheWarm() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheWarm", reflect.TypeOf((*MockService)(nil).CacheWarm))
+}
+
 // Create mocks base method.
 func (m *MockService) Create(c context.Context, order *model.Order) error {
 	m.ctrl.T.Helper()
@@ -49,6 +93,34 @@ func (mr *MockServiceMockRecorder) Create(c, order interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockService)(nil).Create), c, order)
 }
 
+// Delete mocks base method.
+func (m *MockService) Delete(c context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", c, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockServiceMockRecorder) Delete(c, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), c, id)
+}
+
+// FlushCache mocks base method.
+func (m *MockService) FlushCache() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushCache")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// FlushCache indicates an expected call of FlushCache.
+func (mr *MockServiceMockRecorder) FlushCache() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushCache", reflect.TypeOf((*MockService)(nil).FlushCache))
+}
+
 // Get mocks base method.
 func (m *MockService) Get(c context.Context, id string) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +136,196 @@ func (mr *MockServiceMockRecorder) Get(c, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockService)(nil).Get), c, id)
 }
 
+// GetMany mocks base method.
+func (m *MockService) GetMany(c context.Context, ids []string) ([]*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMany", c, ids)
+	ret0, _ := ret[0].([]*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMany indicates an expected call of GetMany.
+func (mr *MockServiceMockRecorder) GetMany(c, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*MockService)(nil).GetMany), c, ids)
+}
+
+// GoodsByDeliveryService mocks base method.
+func (m *MockService) GoodsByDeliveryService(c context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GoodsByDeliveryService", c, f)
+	ret0, _ := ret[0].([]model.DeliveryServiceTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GoodsByDeliveryService indicates an expected call of GoodsByDeliveryService.
+func (mr *MockServiceMockRecorder) GoodsByDeliveryService(c, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GoodsByDeliveryService", reflect.TypeOf((*MockService)(nil).GoodsByDeliveryService), c, f)
+}
+
+// HealthCheck mocks base method.
+func (m *MockService) HealthCheck(c context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthCheck indicates an expected call of HealthCheck.
+func (mr *MockServiceMockRecorder) HealthCheck(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockService)(nil).HealthCheck), c)
+}
+
+// History mocks base method.
+func (m *MockService) History(c context.Context, id string) ([]model.OrderRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", c, id)
+	ret0, _ := ret[0].([]model.OrderRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockServiceMockRecorder) History(c, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockService)(nil).History), c, id)
+}
+
+// List mocks base method.
+func (m *MockService) List(c context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", c, f, page)
+	ret0, _ := ret[0].(*model.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockServiceMockRecorder) List(c, f, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockService)(nil).List), c, f, page)
+}
+
+// ListByCustomer mocks base method.
+func (m *MockService) ListByCustomer(c context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByCustomer", c, customerID, page)
+	ret0, _ := ret[0].(*model.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByCustomer indicates an expected call of ListByCustomer.
+func (mr *MockServiceMockRecorder) ListByCustomer(c, customerID, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCustomer", reflect.TypeOf((*MockService)(nil).ListByCustomer), c, customerID, page)
+}
+
+// OrdersPerDay mocks base method.
+func (m *MockService) OrdersPerDay(c context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrdersPerDay", c, f)
+	ret0, _ := ret[0].([]model.DailyCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrdersPerDay indicates an expected call of OrdersPerDay.
+func (mr *MockServiceMockRecorder) OrdersPerDay(c, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrdersPerDay", reflect.TypeOf((*MockService)(nil).OrdersPerDay), c, f)
+}
+
+// Patch mocks base method.
+func (m *MockService) Patch(c context.Context, id string, patch *model.OrderPatch) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", c, id, patch)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockServiceMockRecorder) Patch(c, id, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockService)(nil).Patch), c, id, patch)
+}
+
+// RawPayload mocks base method.
+func (m *MockService) RawPayload(c context.Context, id string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RawPayload", c, id)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RawPayload indicates an expected call of RawPayload.
+func (mr *MockServiceMockRecorder) RawPayload(c, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RawPayload", reflect.TypeOf((*MockService)(nil).RawPayload), c, id)
+}
+
+// Restore mocks base method.
+func (m *MockService) Restore(c context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", c, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockServiceMockRecorder) Restore(c, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockService)(nil).Restore), c, id)
+}
+
+// Search mocks base method.
+func (m *MockService) Search(c context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", c, query, page)
+	ret0, _ := ret[0].(*model.SearchPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockServiceMockRecorder) Search(c, query, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockService)(nil).Search), c, query, page)
+}
+
+// SetRefreshAfter mocks base method.
+func (m *MockService) SetRefreshAfter(d time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRefreshAfter", d)
+}
+
+// SetRefreshAfter indicates an expected call of SetRefreshAfter.
+func (mr *MockServiceMockRecorder) SetRefreshAfter(d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRefreshAfter", reflect.TypeOf((*MockService)(nil).SetRefreshAfter), d)
+}
+
+// TopCustomers mocks base method.
+func (m *MockService) TopCustomers(c context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopCustomers", c, f, limit)
+	ret0, _ := ret[0].([]model.CustomerTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopCustomers indicates an expected call of TopCustomers.
+func (mr *MockServiceMockRecorder) TopCustomers(c, f, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopCustomers", reflect.TypeOf((*MockService)(nil).TopCustomers), c, f, limit)
+}
+
 // UpdateCache mocks base method.
 func (m *MockService) UpdateCache(c context.Context) error {
 	m.ctrl.T.Helper()
@@ -77,3 +339,32 @@ func (mr *MockServiceMockRecorder) UpdateCache(c interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCache", reflect.TypeOf((*MockService)(nil).UpdateCache), c)
 }
+
+// UpdateStatus mocks base method.
+func (m *MockService) UpdateStatus(c context.Context, id string, status model.OrderStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", c, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockServiceMockRecorder) UpdateStatus(c, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockService)(nil).UpdateStatus), c, id, status)
+}
+
+// Warm mocks base method.
+func (m *MockService) Warm(c context.Context, strategy model.WarmStrategy, n int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Warm", c, strategy, n)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Warm indicates an expected call of Warm.
+func (mr *MockServiceMockRecorder) Warm(c, strategy, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warm", reflect.TypeOf((*MockService)(nil).Warm), c, strategy, n)
+}
@@ -0,0 +1,11 @@
+package model
+
+// CacheFlushResult reports how many orders a cache flush dropped.
+type CacheFlushResult struct {
+	Flushed int `json:"flushed"`
+}
+
+// LogLevel is the body of the admin log level endpoints, e.g. "debug".
+type LogLevel struct {
+	Level string `json:"level"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// BatchGetRequest names the orders to load in one call.
+type BatchGetRequest struct {
+	OrderUIDs []string `json:"order_uids"`
+}
+
+// BatchGetResponse holds the orders found, in request order, and the
+// requested uids that don't exist for the tenant.
+type BatchGetResponse struct {
+	Orders  []*Order `json:"orders"`
+	Missing []string `json:"missing"`
+}
@@ -9,3 +9,13 @@ type Delivery struct {
 	Region  string `json:"region"`
 	Email   string `json:"email"`
 }
+
+// Erased replaces the recipient's name, phone, email and address once their
+// personal data has been erased. City, region and zip stay for the stats.
+const Erased = "[erased]"
+
+// ErasureResult reports how many orders a data deletion request scrubbed.
+type ErasureResult struct {
+	CustomerID string `json:"customer_id"`
+	Orders     int    `json:"orders"`
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// FieldChange is one field that differs between two versions of an order,
+// named by its JSON path, e.g. "payment.amount".
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// OrderChange is the payload of EventOrderCreated and EventOrderUpdated:
+// the order as stored, and for updates what was stored before and which
+// fields differ.
+type OrderChange struct {
+	Before  *Order        `json:"before,omitempty"`
+	After   *Order        `json:"after"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// DiffOrders lists the fields that differ between before and after, ordered
+// by path. Lists such as items are compared as a whole. version is left out:
+// every update changes it.
+func DiffOrders(before, after *Order) ([]FieldChange, error) {
+	b, err := fieldMap(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fieldMap(after)
+	if err != nil {
+		return nil, err
+	}
+	delete(b, "version")
+	delete(a, "version")
+	var changes []FieldChange
+	diffFields("", b, a, &changes)
+	return changes, nil
+}
+
+// fieldMap decodes the JSON form of o, keeping numbers exact.
+func fieldMap(o *Order) (map[string]any, error) {
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return nil, fmt.Errorf("encode order %s: %w", o.OrderUID, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode order %s: %w", o.OrderUID, err)
+	}
+	return m, nil
+}
+
+func diffFields(prefix string, before, after map[string]any, changes *[]FieldChange) {
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b, a := before[k], after[k]
+		bm, bok := b.(map[string]any)
+		am, aok := a.(map[string]any)
+		if bok && aok {
+			diffFields(prefix+k+".", bm, am, changes)
+			continue
+		}
+		if !reflect.DeepEqual(b, a) {
+			*changes = append(*changes, FieldChange{Field: prefix + k, Before: b, After: a})
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+// Envelope is the body of every JSON response under /api/v2. Successful
+// responses carry Data, failed ones Error; Meta is set on both.
+type Envelope struct {
+	Data  any       `json:"data,omitempty"`
+	Error *APIError `json:"error,omitempty"`
+	Meta  Meta      `json:"meta"`
+}
+
+// APIError describes a failed request. Code is stable and meant for
+// programs, Msg is localized for people and may change.
+type APIError struct {
+	Code    string `json:"code"`
+	Msg     string `json:"msg"`
+	Details any    `json:"details,omitempty"`
+}
+
+// Meta carries what is about the response rather than part of it.
+type Meta struct {
+	RequestID string `json:"request_id,omitempty"`
+	// NextCursor continues a paginated listing, whose Data is the page's items.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,32 @@
+package model
+
+// Readiness is the body of /readyz: Status is HealthOK, HealthDegraded or
+// HealthUnavailable, Checks maps every check name to "ok" or the reason it
+// failed.
+type Readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+	HealthFailed      = "failed"
+)
+
+// HealthReport is the body of /healthz/details.
+type HealthReport struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult is one check of a HealthReport. Status is HealthOK or
+// HealthFailed with Error saying why.
+type CheckResult struct {
+	Name       string `json:"name"`
+	Severity   string `json:"severity"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
@@ -1,6 +1,11 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+)
 
 type Order struct {
 	OrderUID          string    `json:"order_uid"`
@@ -17,4 +22,35 @@ type Order struct {
 	SmID              int       `json:"sm_id"`
 	DateCreated       time.Time `json:"date_created"`
 	OofShard          string    `json:"oof_shard"`
+	// Status is the current lifecycle state. Orders are stored as created
+	// unless the payload says otherwise; afterwards only status updates
+	// change it.
+	Status OrderStatus `json:"status,omitempty"`
+	// Version orders concurrent writes of the same order: an upsert whose
+	// version is not greater than the stored one is skipped. Create fills in
+	// the broker timestamp or the request time when it is zero.
+	Version int64 `json:"version,omitempty"`
+	// Warnings lists the consistency problems found when the order was
+	// stored, such as payment totals that don't add up. It is set by the
+	// order service; values in the payload are dropped.
+	Warnings []string `json:"warnings,omitempty"`
+	// Meta holds what the order service's enrichers add on read, such as
+	// days_in_transit. It is never stored; values in the payload are dropped.
+	Meta map[string]any `json:"meta,omitempty"`
+	// TenantID is the shop the order belongs to. It is taken from the request
+	// path or broker header, never from the payload.
+	TenantID string `json:"tenant_id,omitempty"`
+	// Raw is the payload the order was decoded from. When set, the repository
+	// keeps it byte for byte next to the normalized rows.
+	Raw json.RawMessage `json:"-"`
+}
+
+// Clone returns a copy of o that can be changed without affecting o, such as
+// a cached order. Raw is shared.
+func (o *Order) Clone() *Order {
+	c := *o
+	c.Items = slices.Clone(o.Items)
+	c.Warnings = slices.Clone(o.Warnings)
+	c.Meta = maps.Clone(o.Meta)
+	return &c
 }
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventOrderUpserted is written whenever a bulk upsert (backfill, seed)
+// stores an order; the payload is the order as stored. It is also the event
+// the order service publishes in process for every stored order.
+const EventOrderUpserted = "order.upserted"
+
+// EventOrderCreated and EventOrderUpdated are written when a single upsert,
+// such as order.Service.Create, stores a new order or changes a stored one;
+// the payload is an OrderChange.
+const (
+	EventOrderCreated = "order.created"
+	EventOrderUpdated = "order.updated"
+)
+
+// EventOrderDeleted is written whenever an order is deleted for good; the
+// payload is an OrderDeleted.
+const EventOrderDeleted = "order.deleted"
+
+// OrderDeleted is the payload of EventOrderDeleted.
+type OrderDeleted struct {
+	OrderUID string `json:"order_uid"`
+}
+
+// EventOrderArchived and EventOrderRestored are written when an order is
+// archived (soft-deleted) or restored; the payload is an OrderArchival.
+const (
+	EventOrderArchived = "order.archived"
+	EventOrderRestored = "order.restored"
+)
+
+// OrderArchival is the payload of EventOrderArchived and EventOrderRestored.
+type OrderArchival struct {
+	OrderUID string `json:"order_uid"`
+}
+
+// OutboxEvent is a change recorded in the same transaction as the change
+// itself, waiting to be published.
+type OutboxEvent struct {
+	ID       int64  `json:"id"`
+	TenantID string `json:"tenant_id"`
+	// ShardKey is the shardkey of the order, which locates the database
+	// holding the event.
+	ShardKey  string          `json:"shardkey"`
+	OrderUID  string          `json:"order_uid"`
+	Type      string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// OrderFilter narrows order listings; zero fields don't filter.
+type OrderFilter struct {
+	CustomerID string
+	Status     OrderStatus
+	// From is the inclusive lower bound on date_created.
+	From time.Time
+	// To is the exclusive upper bound on date_created.
+	To time.Time
+	// TenantID is set by the repository from the context; callers leave it empty.
+	TenantID string
+}
+
+// Page selects a slice of a keyset-paginated collection.
+type Page struct {
+	// Limit is the maximum number of items to return.
+	Limit int
+	// Cursor is the opaque NextCursor of the previous page; empty starts from
+	// the first order in Sort order.
+	Cursor string
+	// Sort orders the collection by date_created; empty is SortNewest.
+	Sort Sort
+	// Shallow leaves the delivery, payment and items of listed orders
+	// empty, for callers that fill them in from elsewhere, like the order
+	// cache.
+	Shallow bool
+}
+
+// Sort is the order of a listing, named like the ?sort= values that select it.
+type Sort string
+
+const (
+	SortNewest Sort = "-date_created"
+	SortOldest Sort = "date_created"
+)
+
+// Valid reports whether s is empty or one of the known orders.
+func (s Sort) Valid() bool {
+	return s == "" || s == SortNewest || s == SortOldest
+}
+
+// OrderPage is one page of orders in the order the Page asked for.
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,78 @@
+package model
+
+// OrderPatch corrects some fields of a stored order, such as a mistyped
+// delivery address, without resending the whole order. Fields left nil keep
+// their stored value.
+type OrderPatch struct {
+	TrackNumber     *string        `json:"track_number,omitempty"`
+	Entry           *string        `json:"entry,omitempty"`
+	Locale          *string        `json:"locale,omitempty"`
+	DeliveryService *string        `json:"delivery_service,omitempty"`
+	SmID            *int           `json:"sm_id,omitempty"`
+	Delivery        *DeliveryPatch `json:"delivery,omitempty"`
+}
+
+// DeliveryPatch corrects some fields of an order's delivery.
+type DeliveryPatch struct {
+	Name    *string `json:"name,omitempty"`
+	Phone   *string `json:"phone,omitempty"`
+	Zip     *string `json:"zip,omitempty"`
+	City    *string `json:"city,omitempty"`
+	Address *string `json:"address,omitempty"`
+	Region  *string `json:"region,omitempty"`
+	Email   *string `json:"email,omitempty"`
+}
+
+// Strings returns the string fields p sets, keyed by JSON path.
+func (p *OrderPatch) Strings() map[string]*string {
+	fields := map[string]*string{
+		"track_number":     p.TrackNumber,
+		"entry":            p.Entry,
+		"locale":           p.Locale,
+		"delivery_service": p.DeliveryService,
+	}
+	if d := p.Delivery; d != nil {
+		fields["delivery.name"] = d.Name
+		fields["delivery.phone"] = d.Phone
+		fields["delivery.zip"] = d.Zip
+		fields["delivery.city"] = d.City
+		fields["delivery.address"] = d.Address
+		fields["delivery.region"] = d.Region
+		fields["delivery.email"] = d.Email
+	}
+	for path, v := range fields {
+		if v == nil {
+			delete(fields, path)
+		}
+	}
+	return fields
+}
+
+// Empty reports whether p sets no field.
+func (p *OrderPatch) Empty() bool {
+	return p.SmID == nil && len(p.Strings()) == 0
+}
+
+// Apply sets the fields p sets on o.
+func (p *OrderPatch) Apply(o *Order) {
+	set(&o.TrackNumber, p.TrackNumber)
+	set(&o.Entry, p.Entry)
+	set(&o.Locale, p.Locale)
+	set(&o.DeliveryService, p.DeliveryService)
+	set(&o.SmID, p.SmID)
+	if d := p.Delivery; d != nil {
+		set(&o.Delivery.Name, d.Name)
+		set(&o.Delivery.Phone, d.Phone)
+		set(&o.Delivery.Zip, d.Zip)
+		set(&o.Delivery.City, d.City)
+		set(&o.Delivery.Address, d.Address)
+		set(&o.Delivery.Region, d.Region)
+		set(&o.Delivery.Email, d.Email)
+	}
+}
+
+func set[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
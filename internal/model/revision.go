@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// OrderRevision is the state an order had before an upsert replaced it.
+type OrderRevision struct {
+	ID         int64     `json:"id"`
+	ReplacedAt time.Time `json:"replaced_at"`
+	Order      Order     `json:"order"`
+}
@@ -0,0 +1,20 @@
+package model
+
+// SearchHit is one order found by a search, with what matched it.
+type SearchHit struct {
+	Order *Order `json:"order"`
+	// TrackNumber is set when the query was the order's track number; such
+	// hits come before recipient matches.
+	TrackNumber bool `json:"track_number,omitempty"`
+	// Rank is the relevance of the recipient match, higher is better.
+	Rank float32 `json:"rank"`
+	// Highlights holds the recipient fields (name, city, address) that
+	// matched, HTML-escaped, with the matching words wrapped in <mark></mark>.
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchPage is one page of search hits, best first.
+type SearchPage struct {
+	Hits       []SearchHit `json:"hits"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// DailyCount is the number of orders created on one UTC day.
+type DailyCount struct {
+	Day    time.Time `json:"day"`
+	Orders int64     `json:"orders"`
+}
+
+// DeliveryServiceTotal aggregates orders shipped by one delivery service.
+type DeliveryServiceTotal struct {
+	DeliveryService string `json:"delivery_service"`
+	Orders          int64  `json:"orders"`
+	GoodsTotal      int64  `json:"goods_total"`
+}
+
+// CustomerTotal aggregates one customer's orders.
+type CustomerTotal struct {
+	CustomerID string `json:"customer_id"`
+	Orders     int64  `json:"orders"`
+	GoodsTotal int64  `json:"goods_total"`
+}
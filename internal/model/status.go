@@ -0,0 +1,56 @@
+package model
+
+// OrderStatus is the lifecycle state of an order.
+type OrderStatus string
+
+const (
+	StatusCreated   OrderStatus = "created"
+	StatusPaid      OrderStatus = "paid"
+	StatusShipped   OrderStatus = "shipped"
+	StatusDelivered OrderStatus = "delivered"
+	StatusCancelled OrderStatus = "cancelled"
+)
+
+// Statuses lists every OrderStatus in lifecycle order; it matches the
+// orders_status_check constraint.
+var Statuses = []OrderStatus{StatusCreated, StatusPaid, StatusShipped, StatusDelivered, StatusCancelled}
+
+// Valid reports whether s is one of Statuses.
+func (s OrderStatus) Valid() bool {
+	for _, v := range Statuses {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// next is the status each status moves on to; statuses missing from it are
+// final.
+var next = map[OrderStatus]OrderStatus{
+	StatusCreated: StatusPaid,
+	StatusPaid:    StatusShipped,
+	StatusShipped: StatusDelivered,
+}
+
+// CanBecome reports whether an order in status s may move to to: one step
+// along created → paid → shipped → delivered, or to cancelled from anywhere.
+// Staying in s is allowed too, so a redelivered update is not an error.
+func (s OrderStatus) CanBecome(to OrderStatus) bool {
+	if to == s || to == StatusCancelled {
+		return true
+	}
+	n, ok := next[s]
+	return ok && n == to
+}
+
+// StatusUpdate moves an order to a new status. It is the body of
+// PUT /order/{order_uid}/status and of status-change broker messages.
+type StatusUpdate struct {
+	OrderUID string      `json:"order_uid"`
+	Status   OrderStatus `json:"status"`
+}
+
+// EventOrderStatusChanged is written whenever UpdateStatus changes an order;
+// the payload is the StatusUpdate.
+const EventOrderStatusChanged = "order.status_changed"
@@ -0,0 +1,23 @@
+package model
+
+import "fmt"
+
+// WarmStrategy picks the orders a cache warm loads.
+type WarmStrategy string
+
+const (
+	// WarmRecent loads the most recently created orders.
+	WarmRecent WarmStrategy = "recent"
+	// WarmPopular loads the orders looked up most often, as counted by the
+	// cache, whether they are cached now or not.
+	WarmPopular WarmStrategy = "popular"
+)
+
+// ParseWarmStrategy accepts the names of the WarmStrategy constants.
+func ParseWarmStrategy(name string) (WarmStrategy, error) {
+	switch s := WarmStrategy(name); s {
+	case WarmRecent, WarmPopular:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown warm strategy %q, want %q or %q", name, WarmRecent, WarmPopular)
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// WebhookStatus is where a webhook delivery stands.
+type WebhookStatus string
+
+const (
+	// WebhookPending deliveries are queued or between retries.
+	WebhookPending WebhookStatus = "pending"
+	// WebhookDelivered deliveries were answered with a 2xx status.
+	WebhookDelivered WebhookStatus = "delivered"
+	// WebhookFailed deliveries ran out of attempts.
+	WebhookFailed WebhookStatus = "failed"
+)
+
+// WebhookDelivery is one event sent to one webhook subscriber, with the
+// outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID        int64         `json:"id"`
+	URL       string        `json:"url"`
+	EventType string        `json:"event_type"`
+	TenantID  string        `json:"tenant_id"`
+	OrderUID  string        `json:"order_uid"`
+	Status    WebhookStatus `json:"status"`
+	Attempts  int           `json:"attempts"`
+	// ResponseCode is the HTTP status of the latest attempt, 0 when it got
+	// no response.
+	ResponseCode int        `json:"response_code,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,176 @@
+// Package nats implements ingest.Broker on top of NATS JetStream, for deployments
+// that don't run Kafka.
+//
+// Design notes (for contributors):
+//   - A durable pull consumer with explicit acks is used, so unacked messages are
+//     redelivered after a restart, just like uncommitted Kafka offsets.
+//   - The DLQ is a plain JetStream subject; the original payload and headers are kept
+//     and diagnostics are added as extra headers, mirroring the Kafka DLQ format.
+package nats
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/ingest"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Consumer reads orders from a JetStream stream through a durable pull consumer.
+type Consumer struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+	iter jetstream.MessagesContext
+
+	log logger.InterfaceLogger
+
+	// subject is the source subject (for reference in DLQ headers).
+	subject string
+	// dlqSubject is the DLQ subject (empty means DLQ disabled).
+	dlqSubject string
+
+	// pending is the consumer's NumPending as of the last delivered message.
+	pending atomic.Int64
+}
+
+var (
+	_ ingest.Broker     = (*Consumer)(nil)
+	_ ingest.Backlogger = (*Consumer)(nil)
+	_ ingest.Pinger     = (*Consumer)(nil)
+)
+
+// NewConsumer connects to NATS and binds a durable consumer on stream filtered by subject.
+// The stream is created if it doesn't exist yet.
+func NewConsumer(ctx context.Context, url, stream, subject, durable, dlqSubject string, log logger.InterfaceLogger) (*Consumer, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("nats: connect: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("nats: jetstream: %w", err)
+	}
+
+	subjects := []string{subject}
+	if dlqSubject != "" {
+		subjects = append(subjects, dlqSubject)
+	}
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: subjects,
+	}); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("nats: stream %s: %w", stream, err)
+	}
+
+	cons, err := js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("nats: consumer %s: %w", durable, err)
+	}
+	iter, err := cons.Messages()
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("nats: messages: %w", err)
+	}
+
+	return &Consumer{
+		conn:       nc,
+		js:         js,
+		iter:       iter,
+		log:        log,
+		subject:    subject,
+		dlqSubject: dlqSubject,
+	}, nil
+}
+
+// Consume blocks until the next message is delivered or ctx is canceled.
+func (c *Consumer) Consume(ctx context.Context) (*ingest.Message, error) {
+	msg, err := c.iter.Next(jetstream.NextContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	var published time.Time
+	if meta, err := msg.Metadata(); err == nil {
+		c.pending.Store(int64(meta.NumPending))
+		published = meta.Timestamp
+	}
+	headers := make(map[string]string, len(msg.Headers()))
+	for k := range msg.Headers() {
+		headers[k] = msg.Headers().Get(k)
+	}
+	return &ingest.Message{
+		Value:   msg.Data(),
+		Headers: headers,
+		Time:    published,
+		Origin:  msg,
+	}, nil
+}
+
+// Ack acknowledges the message to JetStream.
+func (c *Consumer) Ack(ctx context.Context, m *ingest.Message) error {
+	msg, ok := m.Origin.(jetstream.Msg)
+	if !ok {
+		return fmt.Errorf("nats: ack: unexpected origin %T", m.Origin)
+	}
+	return msg.DoubleAck(ctx)
+}
+
+// DLQ publishes the original payload to the DLQ subject with diagnostic headers.
+func (c *Consumer) DLQ(ctx context.Context, m *ingest.Message, reason string, cause error) error {
+	if c.dlqSubject == "" {
+		// DLQ is optional; silently ignore if not configured.
+		return nil
+	}
+	errText := reason
+	if cause != nil {
+		errText = fmt.Sprintf("%s: %v", reason, cause)
+	}
+	out := nats.NewMsg(c.dlqSubject)
+	out.Data = m.Value
+	for k, v := range m.Headers {
+		out.Header.Set(k, v)
+	}
+	out.Header.Set("error", errText)
+	out.Header.Set("origin-subject", c.subject)
+	out.Header.Set("timestamp", time.Now().UTC().Format(time.RFC3339Nano))
+
+	if _, err := c.js.PublishMsg(ctx, out); err != nil {
+		c.log.Errorf("nats: DLQ publish failed (subject=%s): %v", c.dlqSubject, err)
+		return err
+	}
+	return nil
+}
+
+// Close stops the message iterator and drains the connection.
+func (c *Consumer) Close() error {
+	c.iter.Stop()
+	return c.conn.Drain()
+}
+
+// Ping reports whether the connection is up; nats.go reconnects on its own,
+// so a reconnecting connection is reported as down until it is back.
+func (c *Consumer) Ping(ctx context.Context) error {
+	if s := c.conn.Status(); s != nats.CONNECTED {
+		return fmt.Errorf("nats: connection %s", s)
+	}
+	return nil
+}
+
+// Backlog returns the number of messages pending for the durable consumer,
+// as reported with the last delivered message.
+func (c *Consumer) Backlog() int64 {
+	return c.pending.Load()
+}
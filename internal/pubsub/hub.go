@@ -0,0 +1,84 @@
+// Package pubsub fans values out to in-process subscribers, such as clients
+// of the live order stream.
+package pubsub
+
+import "sync"
+
+// Hub delivers every published value to every current subscriber. Publish
+// never blocks: a subscriber whose buffer is full misses the value, so one
+// slow client cannot hold up ingestion. Values are shared between
+// subscribers and must not be modified.
+type Hub[T any] struct {
+	mu     sync.Mutex
+	subs   map[*Subscription[T]]struct{}
+	closed bool
+}
+
+func NewHub[T any]() *Hub[T] {
+	return &Hub[T]{subs: map[*Subscription[T]]struct{}{}}
+}
+
+// Subscription receives published values on C until it or its hub is closed,
+// at which point C is closed.
+type Subscription[T any] struct {
+	C    <-chan T
+	c    chan T
+	hub  *Hub[T]
+	once sync.Once
+}
+
+// Subscribe buffers up to buffer values for the new subscriber. On a closed
+// hub the subscription starts closed.
+func (h *Hub[T]) Subscribe(buffer int) *Subscription[T] {
+	c := make(chan T, buffer)
+	s := &Subscription[T]{C: c, c: c, hub: h}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		s.once.Do(func() { close(c) })
+		return s
+	}
+	h.subs[s] = struct{}{}
+	return s
+}
+
+// Publish hands v to every subscriber with room for it and reports how many
+// missed it.
+func (h *Hub[T]) Publish(v T) (dropped int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		select {
+		case s.c <- v:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Len returns the number of subscribers.
+func (h *Hub[T]) Len() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return int64(len(h.subs))
+}
+
+// Close ends every subscription, e.g. so open streams let the server shut down.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		delete(h.subs, s)
+		s.once.Do(func() { close(s.c) })
+	}
+}
+
+// Close unsubscribes; it may be called more than once.
+func (s *Subscription[T]) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	delete(s.hub.subs, s)
+	s.once.Do(func() { close(s.c) })
+}
@@ -0,0 +1,33 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_FansOutAndDropsForFullSubscribers(t *testing.T) {
+	h := NewHub[int]()
+	fast := h.Subscribe(2)
+	slow := h.Subscribe(1)
+	require.EqualValues(t, 2, h.Len())
+
+	require.Zero(t, h.Publish(1))
+	require.Equal(t, 1, h.Publish(2)) // slow is full
+	require.Equal(t, 1, <-fast.C)
+	require.Equal(t, 2, <-fast.C)
+	require.Equal(t, 1, <-slow.C)
+
+	slow.Close()
+	slow.Close()
+	require.EqualValues(t, 1, h.Len())
+	_, open := <-slow.C
+	require.False(t, open)
+
+	h.Close()
+	_, open = <-fast.C
+	require.False(t, open)
+	_, open = <-h.Subscribe(1).C
+	require.False(t, open, "subscriptions to a closed hub start closed")
+	fast.Close()
+}
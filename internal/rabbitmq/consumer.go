@@ -0,0 +1,201 @@
+// Package rabbitmq implements ingest.Broker on top of AMQP 0-9-1, so the order
+// service can be dropped into RabbitMQ-based stacks.
+//
+// Design notes (for contributors):
+//   - Deliveries are consumed with manual acks; nothing is acked before the
+//     ingestion loop has finished with the message.
+//   - The source queue is declared with a dead-letter exchange (DLX). DLQ publishes the
+//     original payload to the DLX with diagnostic headers, mirroring the Kafka DLQ format;
+//     if that publish fails the delivery is nacked without requeue so the broker
+//     dead-letters it instead.
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/ingest"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Consumer reads orders from a RabbitMQ queue bound to an exchange.
+type Consumer struct {
+	conn       *amqp.Connection
+	ch         *amqp.Channel
+	deliveries <-chan amqp.Delivery
+
+	log logger.InterfaceLogger
+
+	// queue is the source queue (for reference in DLQ headers).
+	queue string
+	// dlx is the dead-letter exchange (empty means DLQ disabled).
+	dlx string
+}
+
+// delivery tracks whether a message was already settled so Ack after a
+// fallback nack doesn't settle the same delivery tag twice.
+type delivery struct {
+	amqp.Delivery
+	settled bool
+}
+
+var (
+	_ ingest.Broker = (*Consumer)(nil)
+	_ ingest.Pinger = (*Consumer)(nil)
+)
+
+// NewConsumer connects to RabbitMQ and declares the topology:
+// exchange -> queue (bound by routingKey, dead-lettering to dlx) and dlx -> dlq.
+func NewConsumer(url, exchange, queue, routingKey, dlx, dlq string, prefetch int, log logger.InterfaceLogger) (*Consumer, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("rabbitmq: dial: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("rabbitmq: channel: %w", err)
+	}
+
+	fail := func(step string, err error) (*Consumer, error) {
+		_ = ch.Close()
+		_ = conn.Close()
+		return nil, fmt.Errorf("rabbitmq: %s: %w", step, err)
+	}
+
+	var queueArgs amqp.Table
+	if dlx != "" {
+		if err := ch.ExchangeDeclare(dlx, amqp.ExchangeFanout, true, false, false, false, nil); err != nil {
+			return fail("declare dlx", err)
+		}
+		if _, err := ch.QueueDeclare(dlq, true, false, false, false, nil); err != nil {
+			return fail("declare dlq", err)
+		}
+		if err := ch.QueueBind(dlq, "", dlx, false, nil); err != nil {
+			return fail("bind dlq", err)
+		}
+		queueArgs = amqp.Table{"x-dead-letter-exchange": dlx}
+	}
+	if err := ch.ExchangeDeclare(exchange, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fail("declare exchange", err)
+	}
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, queueArgs); err != nil {
+		return fail("declare queue", err)
+	}
+	if err := ch.QueueBind(queue, routingKey, exchange, false, nil); err != nil {
+		return fail("bind queue", err)
+	}
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		return fail("qos", err)
+	}
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fail("consume", err)
+	}
+
+	return &Consumer{
+		conn:       conn,
+		ch:         ch,
+		deliveries: deliveries,
+		log:        log,
+		queue:      queue,
+		dlx:        dlx,
+	}, nil
+}
+
+// Consume blocks until the next delivery arrives or ctx is canceled.
+func (c *Consumer) Consume(ctx context.Context) (*ingest.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case d, ok := <-c.deliveries:
+		if !ok {
+			return nil, fmt.Errorf("rabbitmq: delivery channel closed")
+		}
+		headers := make(map[string]string, len(d.Headers))
+		for k, v := range d.Headers {
+			headers[k] = fmt.Sprint(v)
+		}
+		return &ingest.Message{
+			Key:     []byte(d.RoutingKey),
+			Value:   d.Body,
+			Headers: headers,
+			Time:    d.Timestamp,
+			Origin:  &delivery{Delivery: d},
+		}, nil
+	}
+}
+
+// Ack acknowledges the delivery unless it was already dead-lettered by DLQ.
+func (c *Consumer) Ack(ctx context.Context, m *ingest.Message) error {
+	d, ok := m.Origin.(*delivery)
+	if !ok {
+		return fmt.Errorf("rabbitmq: ack: unexpected origin %T", m.Origin)
+	}
+	if d.settled {
+		return nil
+	}
+	d.settled = true
+	return d.Ack(false)
+}
+
+// DLQ publishes the original payload to the dead-letter exchange with diagnostic headers.
+func (c *Consumer) DLQ(ctx context.Context, m *ingest.Message, reason string, cause error) error {
+	if c.dlx == "" {
+		// DLQ is optional; silently ignore if not configured.
+		return nil
+	}
+	d, ok := m.Origin.(*delivery)
+	if !ok {
+		return fmt.Errorf("rabbitmq: dlq: unexpected origin %T", m.Origin)
+	}
+	errText := reason
+	if cause != nil {
+		errText = fmt.Sprintf("%s: %v", reason, cause)
+	}
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers["error"] = errText
+	headers["origin-queue"] = c.queue
+	headers["timestamp"] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	err := c.ch.PublishWithContext(ctx, c.dlx, d.RoutingKey, false, false, amqp.Publishing{
+		Headers:     headers,
+		ContentType: d.ContentType,
+		Body:        d.Body, // preserve exact original payload
+	})
+	if err != nil {
+		c.log.Errorf("rabbitmq: DLQ publish failed (dlx=%s): %v, dead-lettering via nack", c.dlx, err)
+		d.settled = true
+		if nerr := d.Nack(false, false); nerr != nil {
+			c.log.Errorf("rabbitmq: nack: %v", nerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Ping reports whether the connection and the consuming channel are open.
+// The consumer does not reconnect, so once closed it stays down.
+func (c *Consumer) Ping(ctx context.Context) error {
+	if c.conn.IsClosed() {
+		return errors.New("rabbitmq: connection closed")
+	}
+	if c.ch.IsClosed() {
+		return errors.New("rabbitmq: channel closed")
+	}
+	return nil
+}
+
+// Close closes the channel and the connection.
+func (c *Consumer) Close() error {
+	if err := c.ch.Close(); err != nil {
+		c.log.Errorf("rabbitmq: channel close: %v", err)
+	}
+	return c.conn.Close()
+}
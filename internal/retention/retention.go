@@ -0,0 +1,85 @@
+// Package retention keeps the hot order tables small by periodically moving
+// orders older than a configured age into orders_archive.
+//
+// Each pass expires orders in batches (one transaction each) until a batch
+// comes back short, and evicts the expired orders from the cache so they stop
+// being served. A failed pass is logged and retried on the next tick.
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/service/cache"
+)
+
+// Job expires old orders on a timer.
+type Job struct {
+	repo     repository.Repository
+	cache    cache.InterfaceCache
+	log      logger.InterfaceLogger
+	maxAge   time.Duration
+	interval time.Duration
+	batch    int
+	now      func() time.Time
+}
+
+func NewJob(repo repository.Repository, c cache.InterfaceCache, cfg *config.RetentionConfig, log logger.InterfaceLogger) *Job {
+	j := &Job{
+		repo:     repo,
+		cache:    c,
+		log:      log,
+		maxAge:   time.Duration(cfg.Days) * 24 * time.Hour,
+		interval: cfg.Interval,
+		batch:    cfg.BatchSize,
+		now:      time.Now,
+	}
+	if j.interval <= 0 {
+		j.interval = time.Hour
+	}
+	if j.batch <= 0 {
+		j.batch = 500
+	}
+	return j
+}
+
+// Run expires orders right away and then every interval until ctx is done.
+func (j *Job) Run(ctx context.Context) error {
+	t := time.NewTicker(j.interval)
+	defer t.Stop()
+	for {
+		if n, err := j.RunOnce(ctx); err != nil {
+			j.log.Errorf("retention: stopped after %d orders: %v", n, err)
+		} else if n > 0 {
+			j.log.Infof("retention: archived %d orders older than %s", n, j.maxAge)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce expires every order older than the cutoff and returns how many it moved.
+func (j *Job) RunOnce(ctx context.Context) (int, error) {
+	cutoff := j.now().Add(-j.maxAge)
+	total := 0
+	for ctx.Err() == nil {
+		uids, err := j.repo.ExpireOrders(ctx, cutoff, j.batch)
+		for _, uid := range uids {
+			j.cache.Delete(uid)
+		}
+		total += len(uids)
+		if err != nil {
+			return total, err
+		}
+		if len(uids) < j.batch {
+			return total, nil
+		}
+	}
+	return total, ctx.Err()
+}
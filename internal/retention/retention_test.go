@@ -0,0 +1,52 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob_RunOnceExpiresInBatchesAndEvicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockRepository(ctrl)
+	c := mocks.NewMockInterfaceCache(ctrl)
+	job := NewJob(repo, c, &config.RetentionConfig{Days: 30, BatchSize: 2}, mocks.NewMockInterfaceLogger(ctrl))
+	now := time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC)
+	job.now = func() time.Time { return now }
+	cutoff := now.AddDate(0, 0, -30)
+
+	gomock.InOrder(
+		repo.EXPECT().ExpireOrders(gomock.Any(), cutoff, 2).Return([]string{"a", "b"}, nil),
+		repo.EXPECT().ExpireOrders(gomock.Any(), cutoff, 2).Return([]string{"c"}, nil),
+	)
+	for _, uid := range []string{"a", "b", "c"} {
+		c.EXPECT().Delete(uid)
+	}
+
+	n, err := job.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
+
+func TestJob_RunOnceStopsOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockRepository(ctrl)
+	c := mocks.NewMockInterfaceCache(ctrl)
+	job := NewJob(repo, c, &config.RetentionConfig{Days: 1, BatchSize: 2}, mocks.NewMockInterfaceLogger(ctrl))
+
+	dbErr := errors.New("db down")
+	repo.EXPECT().ExpireOrders(gomock.Any(), gomock.Any(), 2).Return(nil, dbErr)
+	n, err := job.RunOnce(context.Background())
+	require.ErrorIs(t, err, dbErr)
+	require.Zero(t, n)
+}
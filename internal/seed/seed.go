@@ -0,0 +1,204 @@
+// Package seed fills local and demo databases with generated orders.
+//
+// Orders look like the ones producers send (hex order_uids, WBILMT track
+// numbers, +7 phone numbers, one to five items with consistent totals) and
+// pass the strict validation rules. They are generated from a seed, so the
+// same options produce the same orders: re-running a seed is a no-op, and a
+// different seed adds new orders.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+// Options controls a seed run.
+type Options struct {
+	// Count is the number of orders to insert.
+	Count int
+	// BatchSize is the number of orders upserted per transaction.
+	BatchSize int
+	// Seed selects the generated data set.
+	Seed uint64
+	// Days spreads date_created over this many days before Now; 0 means 30.
+	Days int
+	// Customers is the size of the customer pool orders are drawn from, so
+	// that customers have several orders; 0 means Count/3 + 1.
+	Customers int
+	// Tenant owns the orders; empty means tenant.Default.
+	Tenant string
+	// Now anchors date_created; zero means time.Now().
+	Now time.Time
+}
+
+var (
+	names = []string{
+		"Иван Иванов", "Мария Петрова", "Алексей Сидоров", "Елена Козлова",
+		"Дмитрий Волков", "Анна Морозова", "Сергей Соколов", "Ольга Лебедева",
+		"Николай Козлов", "Татьяна Новикова", "Михаил Зайцев", "Ирина Семенова",
+	}
+	cities = []struct{ city, region string }{
+		{"Москва", "Московская область"}, {"Санкт-Петербург", "Ленинградская область"},
+		{"Екатеринбург", "Свердловская область"}, {"Казань", "Татарстан"},
+		{"Ростов-на-Дону", "Ростовская область"}, {"Краснодар", "Краснодарский край"},
+		{"Уфа", "Башкортостан"}, {"Самара", "Самарская область"},
+	}
+	streets          = []string{"Ленина", "Тверская", "Садовая", "Мира", "Гагарина", "Пушкина", "Лесная"}
+	domains          = []string{"gmail.com", "yandex.ru", "mail.ru", "outlook.com"}
+	deliveryServices = []string{"meest", "СДЭК", "Boxberry", "Почта России", "DHL"}
+	banks            = []string{"alpha", "sber", "tinkoff", "vtb"}
+	locales          = []string{"ru", "ru", "ru", "en"}
+	goods            = []struct{ name, brand string }{
+		{"Смартфон iPhone 15 Pro", "Apple"}, {"Наушники AirPods Pro", "Apple"},
+		{"Телевизор QLED", "Samsung"}, {"Игровая консоль PlayStation 5", "Sony"},
+		{"Беспроводная колонка", "JBL"}, {"Клавиатура MX Keys", "Logitech"},
+		{"Мышь MX Master", "Logitech"}, {"Монитор UltraSharp", "Dell"},
+		{"SSD накопитель", "Samsung"}, {"Принтер LaserJet", "HP"},
+	}
+)
+
+// Generator produces the orders of one seed; it is not safe for concurrent use.
+type Generator struct {
+	rnd  *rand.Rand
+	opts Options
+}
+
+func NewGenerator(opts Options) *Generator {
+	if opts.Days <= 0 {
+		opts.Days = 30
+	}
+	if opts.Customers <= 0 {
+		opts.Customers = opts.Count/3 + 1
+	}
+	if opts.Tenant == "" {
+		opts.Tenant = tenant.Default
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	return &Generator{rnd: rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15)), opts: opts}
+}
+
+func pick[T any](r *rand.Rand, s []T) T { return s[r.IntN(len(s))] }
+
+// token returns n random characters of alphabet.
+func (g *Generator) token(n int, alphabet string) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[g.rnd.IntN(len(alphabet))]
+	}
+	return string(b)
+}
+
+const (
+	hexDigits  = "0123456789abcdef"
+	lowerAlnum = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// Next returns the next order of the seed.
+func (g *Generator) Next() *model.Order {
+	r := g.rnd
+	track := "WBILMT" + strconv.Itoa(100000+r.IntN(900000))
+	created := g.opts.Now.Add(-time.Duration(r.Int64N(int64(g.opts.Days) * int64(24*time.Hour)))).Truncate(time.Second).UTC()
+	place := pick(r, cities)
+
+	items := make([]model.Item, 1+r.IntN(5))
+	goodsTotal := 0
+	for i := range items {
+		price := (1 + r.IntN(110)) * 100
+		sale := r.IntN(31)
+		good := pick(r, goods)
+		items[i] = model.Item{
+			ChrtID:      1000000 + r.IntN(9000000),
+			TrackNumber: track,
+			Price:       price,
+			RID:         g.token(17, hexDigits) + "test",
+			Name:        good.name,
+			Sale:        sale,
+			Size:        strconv.Itoa(r.IntN(5)),
+			TotalPrice:  price - price*sale/100,
+			NmID:        1000000 + r.IntN(9000000),
+			Brand:       good.brand,
+			Status:      202,
+		}
+		goodsTotal += items[i].TotalPrice
+	}
+	deliveryCost := 100 * (5 + r.IntN(30))
+	customFee := 0
+	if r.IntN(10) == 0 {
+		customFee = 100 * (1 + r.IntN(5))
+	}
+
+	uid := g.token(32, hexDigits)
+	return &model.Order{
+		OrderUID:    uid,
+		TrackNumber: track,
+		Entry:       "WBIL",
+		Delivery: model.Delivery{
+			Name:    pick(r, names),
+			Phone:   "+79" + g.token(9, "0123456789"),
+			Zip:     strconv.Itoa(100000 + r.IntN(900000)),
+			City:    place.city,
+			Address: fmt.Sprintf("ул. %s, д. %d, кв. %d", pick(r, streets), 1+r.IntN(150), 1+r.IntN(300)),
+			Region:  place.region,
+			Email:   g.token(8, lowerAlnum) + "@" + pick(r, domains),
+		},
+		Payment: model.Payment{
+			Transaction:  uid,
+			Currency:     "RUB",
+			Provider:     "wbpay",
+			Amount:       goodsTotal + deliveryCost + customFee,
+			PaymentDT:    created.Unix(),
+			Bank:         pick(r, banks),
+			DeliveryCost: deliveryCost,
+			GoodsTotal:   goodsTotal,
+			CustomFee:    customFee,
+		},
+		Items:           items,
+		Locale:          pick(r, locales),
+		CustomerID:      "customer_" + strconv.Itoa(1+r.IntN(g.opts.Customers)),
+		DeliveryService: pick(r, deliveryServices),
+		ShardKey:        strconv.Itoa(r.IntN(10)),
+		SmID:            r.IntN(100),
+		DateCreated:     created,
+		OofShard:        strconv.Itoa(1 + r.IntN(2)),
+		// a fixed version makes re-running the same seed skip every order
+		Version:  created.UnixNano(),
+		TenantID: g.opts.Tenant,
+	}
+}
+
+// Run inserts opts.Count generated orders through repo in batches and returns
+// the number written. Orders already stored by an earlier run of the same
+// seed are skipped by the repository as stale and not counted.
+func Run(ctx context.Context, repo repository.Repository, log logger.InterfaceLogger, opts Options) (int, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	gen := NewGenerator(opts)
+	ctx = tenant.WithID(ctx, gen.opts.Tenant)
+
+	done, written := 0, 0
+	for done < opts.Count {
+		batch := make([]*model.Order, 0, min(opts.BatchSize, opts.Count-done))
+		for len(batch) < cap(batch) {
+			batch = append(batch, gen.Next())
+		}
+		n, err := repo.UpsertOrders(ctx, batch)
+		written += n
+		if err != nil {
+			return written, fmt.Errorf("upsert batch after %d orders: %w", done, err)
+		}
+		done += len(batch)
+		log.Infof("seed: %d/%d orders", done, opts.Count)
+	}
+	return written, nil
+}
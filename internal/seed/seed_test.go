@@ -0,0 +1,64 @@
+package seed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/service/order"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_ValidAndDeterministic(t *testing.T) {
+	now := time.Date(2025, 8, 15, 12, 0, 0, 0, time.UTC)
+	a := NewGenerator(Options{Count: 50, Seed: 7, Now: now})
+	b := NewGenerator(Options{Count: 50, Seed: 7, Now: now})
+
+	uids := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		o := a.Next()
+		require.Equal(t, o, b.Next())
+		require.NoError(t, order.ValidateOrderStrict(o))
+		require.True(t, order.UUIDv7Generator{}.Valid(o.OrderUID))
+		require.Regexp(t, `^\+79\d{9}$`, o.Delivery.Phone)
+		require.Regexp(t, `^[a-z0-9]+@[a-z.]+$`, o.Delivery.Email)
+		require.WithinRange(t, o.DateCreated, now.AddDate(0, 0, -30), now)
+
+		sum := 0
+		for _, it := range o.Items {
+			sum += it.TotalPrice
+		}
+		require.Equal(t, sum, o.Payment.GoodsTotal)
+		require.Equal(t, sum+o.Payment.DeliveryCost+o.Payment.CustomFee, o.Payment.Amount)
+		uids[o.OrderUID] = true
+	}
+	require.Len(t, uids, 50)
+
+	require.NotEqual(t, NewGenerator(Options{Seed: 8, Now: now}).Next().OrderUID,
+		NewGenerator(Options{Seed: 7, Now: now}).Next().OrderUID)
+}
+
+func TestRun_UpsertsInBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockRepository(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	var sizes []int
+	repo.EXPECT().UpsertOrders(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, orders []*model.Order) (int, error) {
+		require.Equal(t, "demo", tenant.FromContext(ctx))
+		sizes = append(sizes, len(orders))
+		return len(orders), nil
+	}).Times(3)
+
+	n, err := Run(context.Background(), repo, log, Options{Count: 25, BatchSize: 10, Tenant: "demo"})
+	require.NoError(t, err)
+	require.Equal(t, 25, n)
+	require.Equal(t, []int{10, 10, 5}, sizes)
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/api/adminapi"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+	"github.com/merkulovlad/wbtech-go/internal/ingest"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// Consumer is the ingestion loop as the admin endpoints control it, i.e.
+// an *ingest.Processor.
+type Consumer interface {
+	Pause()
+	Resume()
+	Status() ingest.Status
+}
+
+// LevelController changes the log level at runtime, like *logger.Logger.
+type LevelController interface {
+	Level() string
+	SetLevel(level string) error
+}
+
+// WithConsumer serves the /admin/consumer endpoints, which pause, resume and
+// report on c.
+func WithConsumer(c Consumer) Option {
+	return func(h *Handler) {
+		h.consumer = c
+	}
+}
+
+// WithLogLevel serves /admin/log-level, which reads and changes the level of l.
+func WithLogLevel(l LevelController) Option {
+	return func(h *Handler) {
+		h.levels = l
+	}
+}
+
+// WithDLQ serves GET /admin/dlq, which shows the newest messages p holds in
+// its dead-letter queue.
+func WithDLQ(p ingest.DLQPeeker) Option {
+	return func(h *Handler) {
+		h.dlq = p
+	}
+}
+
+const (
+	defaultDLQPeek = 20
+	maxDLQPeek     = 100
+)
+
+// The operational controls below act on the whole process, so they have no
+// tenant scope. Those whose Option was not given answer like unmounted paths.
+
+// WarmCache answers POST /admin/cache/warm.
+func (h *Handler) WarmCache(c *fiber.Ctx) error {
+	if err := h.Order.UpdateCache(c.UserContext()); err != nil {
+		return h.respondError(c, err, i18n.CacheWarmFailed)
+	}
+	h.log(c).Info("admin: order cache re-warmed")
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// FlushCache answers POST /admin/cache/flush.
+func (h *Handler) FlushCache(c *fiber.Ctx) error {
+	n := h.Order.FlushCache()
+	h.log(c).Infof("admin: flushed %d orders from the cache", n)
+	return respondJSON(c, fiber.StatusOK, &model.CacheFlushResult{Flushed: n})
+}
+
+// GetConsumerStatus answers GET /admin/consumer.
+func (h *Handler) GetConsumerStatus(c *fiber.Ctx) error {
+	if h.consumer == nil {
+		return notMounted(c)
+	}
+	return respondJSON(c, fiber.StatusOK, h.consumer.Status())
+}
+
+// PauseConsumer answers POST /admin/consumer/pause.
+func (h *Handler) PauseConsumer(c *fiber.Ctx) error {
+	if h.consumer == nil {
+		return notMounted(c)
+	}
+	h.consumer.Pause()
+	h.log(c).Warn("admin: consumer paused")
+	return respondJSON(c, fiber.StatusOK, h.consumer.Status())
+}
+
+// ResumeConsumer answers POST /admin/consumer/resume.
+func (h *Handler) ResumeConsumer(c *fiber.Ctx) error {
+	if h.consumer == nil {
+		return notMounted(c)
+	}
+	h.consumer.Resume()
+	h.log(c).Info("admin: consumer resumed")
+	return respondJSON(c, fiber.StatusOK, h.consumer.Status())
+}
+
+// PeekDLQ answers GET /admin/dlq.
+func (h *Handler) PeekDLQ(c *fiber.Ctx, params adminapi.PeekDLQParams) error {
+	if h.dlq == nil {
+		return notMounted(c)
+	}
+	limit := defaultDLQPeek
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if limit <= 0 || limit > maxDLQPeek {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidLimit)
+	}
+	msgs, err := h.dlq.PeekDLQ(c.UserContext(), limit)
+	if err != nil {
+		return h.respondError(c, err, i18n.DLQPeekFailed)
+	}
+	if msgs == nil {
+		msgs = []ingest.DLQMessage{}
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return respondJSON(c, fiber.StatusOK, msgs)
+}
+
+// GetLogLevel answers GET /admin/log-level.
+func (h *Handler) GetLogLevel(c *fiber.Ctx) error {
+	if h.levels == nil {
+		return notMounted(c)
+	}
+	return respondJSON(c, fiber.StatusOK, &model.LogLevel{Level: h.levels.Level()})
+}
+
+// SetLogLevel answers PUT /admin/log-level.
+func (h *Handler) SetLogLevel(c *fiber.Ctx) error {
+	if h.levels == nil {
+		return notMounted(c)
+	}
+	var req adminapi.SetLogLevelJSONRequestBody
+	if err := c.BodyParser(&req); err != nil {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidPayload)
+	}
+	prev := h.levels.Level()
+	if err := h.levels.SetLevel(req.Level); err != nil {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidLogLevel, req.Level)
+	}
+	h.log(c).Warnf("admin: log level changed from %s to %s", prev, h.levels.Level())
+	return respondJSON(c, fiber.StatusOK, &model.LogLevel{Level: h.levels.Level()})
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// compressor gzips or deflates response bodies of at least minSize bytes for
+// clients that accept it. Unlike fiber's compress middleware it lets the
+// threshold be configured: small error and status bodies are not worth it,
+// multi-item orders and order lists are.
+type compressor struct {
+	level   int
+	minSize int
+	gzip    sync.Pool
+	deflate sync.Pool
+}
+
+// newCompressor takes a flate level from flate.BestSpeed to flate.BestCompression.
+func newCompressor(level, minSize int) *compressor {
+	c := &compressor{level: level, minSize: minSize}
+	c.gzip.New = func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, level)
+		return w
+	}
+	c.deflate.New = func() any {
+		w, _ := flate.NewWriter(io.Discard, level)
+		return w
+	}
+	return c
+}
+
+// compressible reports whether a response of contentType is text worth compressing.
+func compressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json") ||
+		strings.HasPrefix(contentType, "application/x-ndjson") ||
+		strings.HasPrefix(contentType, "text/")
+}
+
+// acceptedEncoding picks gzip over deflate from an Accept-Encoding header,
+// ignoring q-values other than an explicit q=0.
+func acceptedEncoding(header string) string {
+	var deflate bool
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(params, " ", "") == "q=0" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip":
+			return "gzip"
+		case "deflate":
+			deflate = true
+		}
+	}
+	if deflate {
+		return "deflate"
+	}
+	return ""
+}
+
+func (z *compressor) middleware(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		return err
+	}
+	c.Vary(fiber.HeaderAcceptEncoding)
+	resp := c.Response()
+	encoding := acceptedEncoding(c.Get(fiber.HeaderAcceptEncoding))
+	if encoding == "" || c.Method() == fiber.MethodHead || resp.IsBodyStream() ||
+		len(resp.Body()) < z.minSize ||
+		len(resp.Header.Peek(fiber.HeaderContentEncoding)) > 0 ||
+		!compressible(string(resp.Header.ContentType())) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(resp.Body()) / 4)
+	if encoding == "gzip" {
+		w := z.gzip.Get().(*gzip.Writer)
+		defer z.gzip.Put(w)
+		w.Reset(&buf)
+		if err := writeAll(w, resp.Body()); err != nil {
+			return err
+		}
+	} else {
+		w := z.deflate.Get().(*flate.Writer)
+		defer z.deflate.Put(w)
+		w.Reset(&buf)
+		if err := writeAll(w, resp.Body()); err != nil {
+			return err
+		}
+	}
+	resp.SetBodyRaw(buf.Bytes())
+	resp.Header.Set(fiber.HeaderContentEncoding, encoding)
+	return nil
+}
+
+func writeAll(w io.WriteCloser, p []byte) error {
+	if _, err := w.Write(p); err != nil {
+		return err
+	}
+	return w.Close()
+}
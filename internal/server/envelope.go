@@ -0,0 +1,59 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// APIPrefixV2 serves the same API as APIPrefix with every JSON body wrapped
+// in a model.Envelope and every error identified by its i18n.Key as code.
+const APIPrefixV2 = "/api/v2"
+
+// enveloped reports whether c is answered with a model.Envelope.
+func enveloped(c *fiber.Ctx) bool {
+	return strings.HasPrefix(c.Path(), APIPrefixV2)
+}
+
+func envelopeMeta(c *fiber.Ctx) model.Meta {
+	return model.Meta{RequestID: string(c.Response().Header.Peek(fiber.HeaderXRequestID))}
+}
+
+// respondJSON writes v with status; under APIPrefixV2 it goes into Data,
+// and a page's items into Data with its cursor in Meta.
+func respondJSON(c *fiber.Ctx, status int, v any) error {
+	if !enveloped(c) {
+		return c.Status(status).JSON(v)
+	}
+	env := model.Envelope{Data: v, Meta: envelopeMeta(c)}
+	switch p := v.(type) {
+	case *model.OrderPage:
+		env.Data, env.Meta.NextCursor = p.Orders, p.NextCursor
+	case *model.SearchPage:
+		env.Data, env.Meta.NextCursor = p.Hits, p.NextCursor
+	}
+	return c.Status(status).JSON(&env)
+}
+
+// errorJSON writes an ErrorResponse whose message is localized for the
+// caller's Accept-Language; args fill the message's format verbs.
+func errorJSON(c *fiber.Ctx, status int, key i18n.Key, args ...any) error {
+	return errorDetailsJSON(c, status, key, nil, args...)
+}
+
+// errorDetailsJSON is errorJSON with details for the envelope's error, e.g.
+// the individual validation problems. ErrorResponse has no room for them.
+func errorDetailsJSON(c *fiber.Ctx, status int, key i18n.Key, details any, args ...any) error {
+	c.Vary(fiber.HeaderAcceptLanguage)
+	lang := i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+	msg := i18n.T(lang, key, args...)
+	if !enveloped(c) {
+		return c.Status(status).JSON(&model.ErrorResponse{Status: status, Msg: msg})
+	}
+	return c.Status(status).JSON(&model.Envelope{
+		Error: &model.APIError{Code: string(key), Msg: msg, Details: details},
+		Meta:  envelopeMeta(c),
+	})
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+)
+
+// errorResponses maps errors the service layer passes through from the
+// repository to the response every handler gives for them. Handlers that need
+// a more specific message check for the error before calling respondError.
+var errorResponses = []struct {
+	err    error
+	status int
+	key    i18n.Key
+}{
+	{repository.ErrNotFound, fiber.StatusNotFound, i18n.OrderNotFound},
+	{repository.ErrInvalidCursor, fiber.StatusBadRequest, i18n.InvalidCursor},
+	{repository.ErrStaleVersion, fiber.StatusConflict, i18n.StaleVersion},
+	{repository.ErrTenantMismatch, fiber.StatusConflict, i18n.TenantMismatch},
+	{repository.ErrReadOnly, fiber.StatusServiceUnavailable, i18n.ReadOnly},
+	{repository.ErrCircuitOpen, fiber.StatusServiceUnavailable, i18n.DatabaseUnavailable},
+	{context.DeadlineExceeded, fiber.StatusGatewayTimeout, i18n.DeadlineExceeded},
+}
+
+// respondError answers err with its entry in errorResponses, or logs it and
+// answers 500 with the failed message. args fill the message's format verbs.
+func (h *Handler) respondError(c *fiber.Ctx, err error, failed i18n.Key, args ...any) error {
+	for _, r := range errorResponses {
+		if errors.Is(err, r.err) {
+			return errorJSON(c, r.status, r.key, args...)
+		}
+	}
+	h.log(c).Errorf("%s %s: %s", c.Method(), c.Path(), err.Error())
+	return errorJSON(c, fiber.StatusInternalServerError, failed, args...)
+}
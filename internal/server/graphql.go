@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/api/orderapi"
+	"github.com/merkulovlad/wbtech-go/internal/graph"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+)
+
+// newGraphQL wires the schema of internal/graph to the order service, with
+// the same input checks and error messages as the JSON API.
+func (h *Handler) newGraphQL() *executor.Executor {
+	return graph.NewExecutor(&graph.Resolver{
+		Service:         h.Order,
+		ValidOrderUID:   func(id string) bool { return h.uids.valid(id) },
+		ValidCustomerID: orderUIDPattern.MatchString,
+		MaxBatch:        maxBatchGet,
+		ServiceError:    h.gqlServiceError,
+	})
+}
+
+// gqlServiceError is respondError for field errors.
+func (h *Handler) gqlServiceError(ctx context.Context, err error, failed i18n.Key) error {
+	for _, r := range errorResponses {
+		if errors.Is(err, r.err) {
+			return graph.Errorf(ctx, r.key)
+		}
+	}
+	h.Logger.WithContext(ctx).Errorf("graphql: %s", err.Error())
+	return graph.Errorf(ctx, failed)
+}
+
+// QueryGraphQL answers GET /graphql, with the request in the query string.
+func (h *Handler) QueryGraphQL(c *fiber.Ctx, params orderapi.QueryGraphQLParams) error {
+	start := graphql.Now()
+	req := orderapi.GraphQLRequest{Query: optional(params.Query), OperationName: optional(params.OperationName)}
+	if v := optional(params.Variables); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+			return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidPayload)
+		}
+	}
+	return h.runGraphQL(c, req, start)
+}
+
+// PostGraphQL answers POST /graphql.
+func (h *Handler) PostGraphQL(c *fiber.Ctx) error {
+	start := graphql.Now()
+	var req orderapi.PostGraphQLJSONRequestBody
+	if err := c.BodyParser(&req); err != nil {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidPayload)
+	}
+	return h.runGraphQL(c, req, start)
+}
+
+// runGraphQL executes req, which was read since start. Syntax, validation and
+// complexity errors answer 400 without data.
+func (h *Handler) runGraphQL(c *fiber.Ctx, req orderapi.GraphQLRequest, start time.Time) error {
+	params := &graphql.RawParams{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+		ReadTime:      graphql.TraceTiming{Start: start, End: graphql.Now()},
+	}
+
+	c.Vary(fiber.HeaderAcceptLanguage)
+	ctx := graph.WithLanguage(c.UserContext(), i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage)))
+	ctx = graphql.StartOperationTrace(ctx)
+	op, errs := h.gql.CreateOperationContext(ctx, params)
+	if errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(h.gql.DispatchError(graphql.WithOperationContext(ctx, op), errs))
+	}
+	responses, ctx := h.gql.DispatchOperation(ctx, op)
+	return c.JSON(responses(ctx))
+}
@@ -1,44 +1,359 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/99designs/gqlgen/graphql/executor"
 	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/api/orderapi"
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/health"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+	"github.com/merkulovlad/wbtech-go/internal/ingest"
 	"github.com/merkulovlad/wbtech-go/internal/logger"
 	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/pubsub"
 	ordr "github.com/merkulovlad/wbtech-go/internal/service/order"
 )
 
 type Handler struct {
 	Order  ordr.Service
 	Logger logger.InterfaceLogger
+	// admin guards the destructive endpoints; they are not mounted while it is nil.
+	admin fiber.Handler
+	// hardDelete makes DELETE remove orders instead of archiving them.
+	hardDelete bool
+	// checks are what /readyz and /healthz/details run: the database and
+	// cache, then whatever WithReadyChecks registered.
+	checks *health.Registry
+	// apiKeys identifies callers by X-API-Key; nil when no keys are configured.
+	apiKeys fiber.Handler
+	// limit rate-limits the API routes; nil leaves them unlimited.
+	limit fiber.Handler
+	// reloader changes the rate limits at runtime, if set.
+	reloader Reloader
+	// gql runs the queries of /graphql.
+	gql *executor.Executor
+	// feed is relayed by /orders/stream, which answers 404 while it is nil.
+	feed *pubsub.Hub[*model.Order]
+	// orderCacheControl is the Cache-Control of GET /order/:order_uid; empty sends none.
+	orderCacheControl string
+	// uids validates order_uid values from clients.
+	uids uidRules
+	// webUI is the lookup page served at /, if any.
+	webUI fs.FS
+	// consumer, levels and dlq back the /admin operational controls; each
+	// group answers 404 while its field is nil.
+	consumer Consumer
+	levels   LevelController
+	dlq      ingest.DLQPeeker
 }
 
 func NewHandler(order ordr.Service, logger logger.InterfaceLogger) *Handler {
-	return &Handler{
+	h := &Handler{
 		Order:  order,
 		Logger: logger,
+		uids:   uidRules{maxLen: defaultOrderUIDMaxLength},
+		checks: health.NewRegistry(),
+	}
+	h.registerHealthChecks()
+	h.gql = h.newGraphQL()
+	return h
+}
+
+// log returns h.Logger tagged with the request id of c.
+func (h *Handler) log(c *fiber.Ctx) logger.InterfaceLogger {
+	return h.Logger.WithContext(c.UserContext())
+}
+
+// GetOrder answers GET /order/{order_uid}.
+func (h *Handler) GetOrder(c *fiber.Ctx, orderUID string) error {
+	order, err := h.Order.Get(c.UserContext(), orderUID)
+	if err != nil {
+		return h.respondError(c, err, i18n.GetOrderFailed)
+	}
+	if h.orderCacheControl != "" {
+		c.Set(fiber.HeaderCacheControl, h.orderCacheControl)
+	}
+	return respondJSON(c, fiber.StatusOK, order)
+}
+
+// GetOrderHistory answers GET /order/{order_uid}/history.
+func (h *Handler) GetOrderHistory(c *fiber.Ctx, orderUID string) error {
+	revs, err := h.Order.History(c.UserContext(), orderUID)
+	if err != nil {
+		return h.respondError(c, err, i18n.HistoryFailed)
+	}
+	return respondJSON(c, fiber.StatusOK, revs)
+}
+
+// GetRawOrder answers GET /order/{order_uid}/raw with the stored bytes.
+func (h *Handler) GetRawOrder(c *fiber.Ctx, orderUID string) error {
+	payload, err := h.Order.RawPayload(c.UserContext(), orderUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return errorJSON(c, fiber.StatusNotFound, i18n.RawNotFound)
+	}
+	if err != nil {
+		return h.respondError(c, err, i18n.RawFailed)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Status(fiber.StatusOK).Send(payload)
+}
+
+// DeleteOrder answers DELETE /order/{order_uid}, behind h.admin.
+func (h *Handler) DeleteOrder(c *fiber.Ctx, orderUID string) error {
+	if h.admin == nil {
+		// the path is served for GET, so DELETE alone is not allowed
+		return fiber.ErrMethodNotAllowed
+	}
+	if h.hardDelete {
+		return h.changeArchived(c, orderUID, h.Order.Delete, i18n.OrderNotFound, i18n.DeleteFailed)
+	}
+	return h.changeArchived(c, orderUID, h.Order.Archive, i18n.OrderNotFound, i18n.ArchiveFailed)
+}
+
+// ArchiveOrder answers POST /order/{order_uid}/archive.
+func (h *Handler) ArchiveOrder(c *fiber.Ctx, orderUID string) error {
+	return h.changeArchived(c, orderUID, h.Order.Archive, i18n.OrderNotFound, i18n.ArchiveFailed)
+}
+
+// RestoreOrder answers POST /order/{order_uid}/restore.
+func (h *Handler) RestoreOrder(c *fiber.Ctx, orderUID string) error {
+	return h.changeArchived(c, orderUID, h.Order.Restore, i18n.NotArchived, i18n.RestoreFailed)
+}
+
+func (h *Handler) changeArchived(c *fiber.Ctx, id string, op func(context.Context, string) error, notFound, failed i18n.Key) error {
+	if err := op(c.UserContext(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return errorJSON(c, fiber.StatusNotFound, notFound)
+		}
+		return h.respondError(c, err, failed)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// UpdateOrderStatus answers PUT /order/{order_uid}/status.
+func (h *Handler) UpdateOrderStatus(c *fiber.Ctx, orderUID string) error {
+	var body orderapi.UpdateOrderStatusJSONRequestBody
+	if err := c.BodyParser(&body); err != nil {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidPayload)
+	}
+	if err := h.Order.UpdateStatus(c.UserContext(), orderUID, body.Status); err != nil {
+		var verr *ordr.ValidationError
+		if errors.As(err, &verr) {
+			return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidStatus, strings.Join(verr.Messages(), "; "))
+		}
+		var terr *ordr.TransitionError
+		if errors.As(err, &terr) {
+			return errorJSON(c, fiber.StatusConflict, i18n.InvalidTransition, terr.OrderUID, terr.From, terr.To)
+		}
+		return h.respondError(c, err, i18n.StatusUpdateFailed)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateOrder answers POST /order, keeping the body as the order's raw payload.
+func (h *Handler) CreateOrder(c *fiber.Ctx) error {
+	var order orderapi.CreateOrderJSONRequestBody
+	if err := c.BodyParser(&order); err != nil {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidPayload)
+	}
+	// fiber reuses the body buffer once the handler returns
+	if body := c.Body(); json.Valid(body) {
+		order.Raw = append(json.RawMessage(nil), body...)
+	}
+	if err := h.Order.Create(c.UserContext(), &order); err != nil {
+		if errors.Is(err, ordr.ErrInvalidOrderUID) {
+			return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidOrderUID, order.OrderUID)
+		}
+		var verr *ordr.ValidationError
+		if errors.As(err, &verr) {
+			return errorDetailsJSON(c, fiber.StatusBadRequest, i18n.InvalidOrder, verr.Violations, strings.Join(verr.Messages(), "; "))
+		}
+		return h.respondError(c, err, i18n.CreateOrderFailed, order.OrderUID)
+	}
+	h.log(c).Infof("Created order %s", order.OrderUID)
+	return respondJSON(c, fiber.StatusCreated, &orderapi.CreateOrderResponse{OrderUid: order.OrderUID})
+}
+
+// ListOrders answers GET /orders.
+func (h *Handler) ListOrders(c *fiber.Ctx, params orderapi.ListOrdersParams) error {
+	page, key := pageParams(params.Limit, params.Cursor, params.Sort)
+	if key != "" {
+		return errorJSON(c, fiber.StatusBadRequest, key, optional(params.Sort))
+	}
+	filter, key, param := orderFilterParams(params.CustomerId, params.Status, params.From, params.To)
+	if key != "" {
+		return errorJSON(c, fiber.StatusBadRequest, key, param)
+	}
+	res, err := h.Order.List(c.UserContext(), filter, page)
+	return h.respondPage(c, res, err)
+}
+
+// maxSearchQuery bounds the length of ?q= in characters.
+const maxSearchQuery = 200
+
+// SearchOrders answers GET /orders/search.
+func (h *Handler) SearchOrders(c *fiber.Ctx, params orderapi.SearchOrdersParams) error {
+	q := strings.TrimSpace(optional(params.Q))
+	if q == "" || utf8.RuneCountInString(q) > maxSearchQuery {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidSearchQuery, maxSearchQuery)
+	}
+	page, key := pageParams(params.Limit, params.Cursor, nil)
+	if key != "" {
+		return errorJSON(c, fiber.StatusBadRequest, key)
+	}
+	res, err := h.Order.Search(c.UserContext(), q, page)
+	if err != nil {
+		return h.respondError(c, err, i18n.SearchFailed)
+	}
+	if res.Hits == nil {
+		res.Hits = []model.SearchHit{}
+	}
+	return respondJSON(c, fiber.StatusOK, res)
+}
+
+// maxBatchGet bounds the number of uids one batch-get may ask for.
+const maxBatchGet = 100
+
+// BatchGetOrders answers POST /orders/batch-get.
+func (h *Handler) BatchGetOrders(c *fiber.Ctx) error {
+	var req orderapi.BatchGetOrdersJSONRequestBody
+	if err := c.BodyParser(&req); err != nil {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidPayload)
+	}
+	if len(req.OrderUIDs) == 0 || len(req.OrderUIDs) > maxBatchGet {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidBatch, maxBatchGet)
+	}
+	for _, id := range req.OrderUIDs {
+		if !h.uids.valid(id) {
+			return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidBatch, maxBatchGet)
+		}
+	}
+	orders, err := h.Order.GetMany(c.UserContext(), req.OrderUIDs)
+	if err != nil {
+		return h.respondError(c, err, i18n.BatchGetFailed)
+	}
+
+	found := make(map[string]bool, len(orders))
+	for _, o := range orders {
+		found[o.OrderUID] = true
+	}
+	res := model.BatchGetResponse{Orders: orders, Missing: []string{}}
+	if res.Orders == nil {
+		res.Orders = []*model.Order{}
+	}
+	for _, id := range req.OrderUIDs {
+		if !found[id] {
+			found[id] = true // list repeats once
+			res.Missing = append(res.Missing, id)
+		}
+	}
+	return respondJSON(c, fiber.StatusOK, res)
+}
+
+// orderFilterParams checks ?customer_id=&status=&from=&to=; a non-empty key
+// describes the invalid parameter and param fills in its message.
+func orderFilterParams(customerID *string, status *model.OrderStatus, from, to *string) (model.OrderFilter, i18n.Key, string) {
+	var f model.OrderFilter
+	if v := optional(customerID); v != "" {
+		if !orderUIDPattern.MatchString(v) {
+			return f, i18n.InvalidCustomerID, "customer_id"
+		}
+		f.CustomerID = v
+	}
+	if v := optional(status); v != "" {
+		if !v.Valid() {
+			return f, i18n.InvalidStatus, string(v)
+		}
+		f.Status = v
+	}
+	for _, p := range []struct {
+		name string
+		val  *string
+		dst  *time.Time
+	}{{"from", from, &f.From}, {"to", to, &f.To}} {
+		if v := optional(p.val); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return f, i18n.InvalidTimeParam, p.name
+			}
+			*p.dst = t
+		}
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return f, i18n.InvalidTimeRange, "to"
+	}
+	return f, "", ""
+}
+
+// ListCustomerOrders answers GET /customers/{customer_id}/orders.
+func (h *Handler) ListCustomerOrders(c *fiber.Ctx, customerID string, params orderapi.ListCustomerOrdersParams) error {
+	if !orderUIDPattern.MatchString(customerID) {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidCustomerID)
+	}
+	page, key := pageParams(params.Limit, params.Cursor, params.Sort)
+	if key != "" {
+		return errorJSON(c, fiber.StatusBadRequest, key, optional(params.Sort))
+	}
+	res, err := h.Order.ListByCustomer(c.UserContext(), customerID, page)
+	return h.respondPage(c, res, err)
+}
+
+// EraseCustomer answers POST /admin/customers/{customer_id}/erase.
+func (h *Handler) EraseCustomer(c *fiber.Ctx, customerID string) error {
+	if !orderUIDPattern.MatchString(customerID) {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidCustomerID)
+	}
+	res, err := h.Order.AnonymizeCustomer(c.UserContext(), customerID)
+	if err != nil {
+		return h.respondError(c, err, i18n.ErasureFailed)
+	}
+	h.log(c).Infof("erased personal data of customer %s from %d orders", customerID, res.Orders)
+	return respondJSON(c, fiber.StatusOK, res)
+}
+
+// EraseTenantCustomer answers POST /admin/tenants/{tenant_id}/customers/{customer_id}/erase;
+// tenantMiddleware has already scoped the request to the tenant.
+func (h *Handler) EraseTenantCustomer(c *fiber.Ctx, _ string, customerID string) error {
+	return h.EraseCustomer(c, customerID)
+}
+
+// pageParams checks ?limit=&cursor=&sort=; a non-empty key describes the
+// invalid parameter.
+func pageParams(limit *int, cursor *string, sort *model.Sort) (model.Page, i18n.Key) {
+	n := repository.DefaultPageLimit
+	if limit != nil {
+		n = *limit
+	}
+	if n <= 0 || n > repository.MaxPageLimit {
+		return model.Page{}, i18n.InvalidLimit
+	}
+	if !optional(sort).Valid() {
+		return model.Page{}, i18n.InvalidSort
+	}
+	return model.Page{Limit: n, Cursor: optional(cursor), Sort: optional(sort)}, ""
+}
+
+// optional returns the value of an optional parameter, or the zero value
+// when the request left it out.
+func optional[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
 	}
+	return v
 }
 
-// getOrderHandler
-// @Summary      Get order by ID
-// @Description  Retrieves order details by order_uid
-// @Tags         order
-// @Produce      json
-// @Param        order_uid  path      string  true  "Order UID"
-// @Success      200  {object}  model.Order
-// @Failure      400  {object}  model.ErrorResponse
-// @Failure      404  {object}  model.ErrorResponse
-// @Router       /order/{order_uid} [get]
-func (h *Handler) getOrderHandler(c *fiber.Ctx) error {
-	id := c.Params("order_uid")
-	h.Logger.Infof("Getting order %s", id)
-	if id == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(&model.ErrorResponse{Status: fiber.StatusBadRequest, Msg: "Invalid id"})
-	}
-	order, err := h.Order.Get(c.Context(), id)
+func (h *Handler) respondPage(c *fiber.Ctx, page *model.OrderPage, err error) error {
 	if err != nil {
-		h.Logger.Errorf("Get order error: %s", err.Error())
+		return h.respondError(c, err, i18n.ListOrdersFailed)
 	}
-	h.Logger.Infof("Get order %v", order)
-	return c.Status(fiber.StatusOK).JSON(&order)
+	return respondJSON(c, fiber.StatusOK, page)
 }
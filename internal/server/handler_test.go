@@ -0,0 +1,1330 @@
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/merkulovlad/wbtech-go/internal/api"
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/health"
+	"github.com/merkulovlad/wbtech-go/internal/ingest"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/pubsub"
+	ordr "github.com/merkulovlad/wbtech-go/internal/service/order"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newMockLogger accepts the access log and any handler log line.
+func newMockLogger(ctrl *gomock.Controller) *mocks.MockInterfaceLogger {
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().WithContext(gomock.Any()).Return(log).AnyTimes()
+	log.EXPECT().With(gomock.Any()).Return(log).AnyTimes()
+	log.EXPECT().Info(gomock.Any()).AnyTimes()
+	log.EXPECT().Error(gomock.Any()).AnyTimes()
+	log.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+	return log
+}
+
+func newTestApp(t *testing.T) (*fiber.App, *mocks.MockService) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	log := newMockLogger(ctrl)
+	return NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second}), svc
+}
+
+func TestHealthz_DoesNotTouchDependencies(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().HealthCheck(gomock.Any()).Times(0)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/healthz", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestReadyz_ReportsEveryCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	log := newMockLogger(ctrl)
+	brokerErr := errors.New("kafka: no broker reachable")
+	broker := ReadyCheck{Name: "kafka", Check: func(context.Context) error { return brokerErr }}
+	app := NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second}, WithReadyChecks(broker))
+
+	ready := func() (int, model.Readiness) {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/readyz", nil))
+		require.NoError(t, err)
+		var body model.Readiness
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	svc.EXPECT().HealthCheck(gomock.Any()).Return(errors.New("ping: connection refused"))
+	svc.EXPECT().CacheWarm().Return(false)
+	status, body := ready()
+	require.Equal(t, fiber.StatusServiceUnavailable, status)
+	require.Equal(t, "unavailable", body.Status)
+	require.Equal(t, map[string]string{
+		"database": "ping: connection refused",
+		"cache":    errCacheCold.Error(),
+		"kafka":    brokerErr.Error(),
+	}, body.Checks)
+
+	brokerErr = nil
+	svc.EXPECT().HealthCheck(gomock.Any()).Return(nil)
+	svc.EXPECT().CacheWarm().Return(true)
+	status, body = ready()
+	require.Equal(t, fiber.StatusOK, status)
+	require.Equal(t, model.Readiness{Status: "ok", Checks: map[string]string{"database": "ok", "cache": "ok", "kafka": "ok"}}, body)
+}
+
+func TestHealthDetails_ReportsDegradedChecksWithoutFailing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	log := newMockLogger(ctrl)
+	replica := ReadyCheck{Name: "replica", Severity: health.Degraded, Check: func(context.Context) error {
+		return errors.New("replica lagging")
+	}}
+	app := NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second}, WithReadyChecks(replica))
+
+	svc.EXPECT().HealthCheck(gomock.Any()).Return(nil).Times(2)
+	svc.EXPECT().CacheWarm().Return(true).Times(2)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/healthz/details", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var report model.HealthReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	for i := range report.Checks {
+		report.Checks[i].DurationMS = 0
+	}
+	require.Equal(t, model.HealthReport{Status: model.HealthDegraded, Checks: []model.CheckResult{
+		{Name: "database", Severity: "critical", Status: model.HealthOK},
+		{Name: "cache", Severity: "critical", Status: model.HealthOK},
+		{Name: "replica", Severity: "degraded", Status: model.HealthFailed, Error: "replica lagging"},
+	}}, report)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/readyz", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestGetOrderHandler_RejectsInjection(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+
+	for _, id := range []string{
+		"' OR '1'='1",
+		"1; DROP TABLE orders; --",
+		"1 UNION SELECT * FROM payments",
+		"abc\x00def",
+		"%27%20OR%201=1",
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, "/order/"+url.PathEscape(id), nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "id %q", id)
+	}
+}
+
+func TestGetOrderHandler_AcceptsValidUID(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), "b563feb7b2b84b6test").Return(&model.Order{OrderUID: "b563feb7b2b84b6test"}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/order/b563feb7b2b84b6test", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestGetOrderHandler_MapsErrors(t *testing.T) {
+	app, svc := newTestApp(t)
+	gomock.InOrder(
+		svc.EXPECT().Get(gomock.Any(), "gone").Return(nil, fmt.Errorf("get order: %w", repository.ErrNotFound)),
+		svc.EXPECT().Get(gomock.Any(), "b1").Return(nil, errors.New("connection refused")),
+		svc.EXPECT().Get(gomock.Any(), "b1").Return(nil, context.DeadlineExceeded),
+	)
+
+	for _, tc := range []struct {
+		id     string
+		status int
+		msg    string
+	}{
+		{"gone", fiber.StatusNotFound, "Order not found"},
+		{"b1", fiber.StatusInternalServerError, "Failed to load order"},
+		{"b1", fiber.StatusGatewayTimeout, "Deadline exceeded"},
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/order/"+tc.id, nil))
+		require.NoError(t, err)
+		require.Equal(t, tc.status, resp.StatusCode)
+		var body model.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Equal(t, model.ErrorResponse{Status: tc.status, Msg: tc.msg}, body)
+	}
+}
+
+func TestOrderHistoryHandler(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().History(gomock.Any(), "b1").Return([]model.OrderRevision{
+		{ID: 2, Order: model.Order{OrderUID: "b1", TrackNumber: "TRK2"}},
+		{ID: 1, Order: model.Order{OrderUID: "b1", TrackNumber: "TRK1"}},
+	}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/order/b1/history", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var revs []model.OrderRevision
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&revs))
+	require.Len(t, revs, 2)
+	require.Equal(t, "TRK2", revs[0].Order.TrackNumber)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/order/"+url.PathEscape("' OR 1")+"/history", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestArchiveRestoreHandlers(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Archive(gomock.Any(), "b1").Return(nil)
+	svc.EXPECT().Restore(gomock.Any(), "b1").Return(repository.ErrNotFound)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/order/b1/archive", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodPost, "/order/b1/restore", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	var body model.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "Order is not archived", body.Msg)
+}
+
+func TestCreateOrderHandler_ReturnsGeneratedUID(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+		require.Empty(t, o.OrderUID)
+		require.JSONEq(t, `{"track_number":"TRK"}`, string(o.Raw))
+		o.OrderUID = "generated"
+		return nil
+	})
+
+	req := httptest.NewRequest(fiber.MethodPost, "/order", strings.NewReader(`{"track_number":"TRK"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "generated", body["order_uid"])
+}
+
+func TestCreateOrderHandler_ReadOnly(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(repository.ErrReadOnly)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/order", strings.NewReader(`{"order_uid":"b1"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestCreateOrderHandler_StaleVersion(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(repository.ErrStaleVersion)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/order", strings.NewReader(`{"order_uid":"b1","version":1}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+func TestTenantRoutes_ScopeContext(t *testing.T) {
+	app, svc := newTestApp(t)
+	gomock.InOrder(
+		svc.EXPECT().Get(gomock.Any(), "b1").DoAndReturn(func(ctx context.Context, _ string) (*model.Order, error) {
+			require.Equal(t, "shop-1", tenant.FromContext(ctx))
+			return &model.Order{OrderUID: "b1"}, nil
+		}),
+		svc.EXPECT().Get(gomock.Any(), "b1").DoAndReturn(func(ctx context.Context, _ string) (*model.Order, error) {
+			require.Equal(t, tenant.Default, tenant.FromContext(ctx))
+			return &model.Order{OrderUID: "b1"}, nil
+		}),
+	)
+
+	for _, path := range []string{"/tenants/shop-1/order/b1", "/order/b1"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode, path)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/tenants/shop%201/order/b1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreateOrderHandler_TenantMismatch(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(repository.ErrTenantMismatch)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/tenants/shop-1/order", strings.NewReader(`{"order_uid":"b1"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+func TestSearchOrdersHandler(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Search(gomock.Any(), "Test Testov", model.Page{Limit: 20}).Return(&model.SearchPage{}, nil)
+	svc.EXPECT().Search(gomock.Any(), "WBILMTESTTRACK", model.Page{Limit: 5, Cursor: "abc"}).Return(&model.SearchPage{
+		Hits:       []model.SearchHit{{Order: &model.Order{OrderUID: "b1"}, TrackNumber: true}},
+		NextCursor: "next",
+	}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/orders/search?q="+url.QueryEscape(" Test Testov "), nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var body model.SearchPage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.NotNil(t, body.Hits)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/orders/search?q=WBILMTESTTRACK&limit=5&cursor=abc", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "next", body.NextCursor)
+	require.True(t, body.Hits[0].TrackNumber)
+
+	for _, q := range []string{"", "%20%20", strings.Repeat("a", maxSearchQuery+1), "TRK&limit=0"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/orders/search?q="+q, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode, q)
+	}
+}
+
+func TestCreateOrderHandler_ValidationError(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&ordr.ValidationError{Violations: []ordr.Violation{{Field: "items", Rule: ordr.RuleNonEmpty, Message: "items must be non-empty"}}})
+
+	req := httptest.NewRequest(fiber.MethodPost, "/order", strings.NewReader(`{"order_uid":"b1"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var body model.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "Order validation failed: items must be non-empty", body.Msg)
+}
+
+func TestDeadlineMiddleware_PropagatesShorterBudget(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), "b1").DoAndReturn(func(ctx context.Context, _ string) (*model.Order, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		require.WithinDuration(t, time.Now().Add(100*time.Millisecond), deadline, 50*time.Millisecond)
+		return &model.Order{OrderUID: "b1"}, nil
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/order/b1", nil)
+	req.Header.Set(HeaderGrpcTimeout, "100m")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestDeadlineMiddleware_ExpiredOrMalformed(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+
+	expired := httptest.NewRequest(fiber.MethodGet, "/order/b1", nil)
+	expired.Header.Set(HeaderDeadline, time.Now().Add(-time.Second).Format(time.RFC3339Nano))
+	resp, err := app.Test(expired)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+
+	malformed := httptest.NewRequest(fiber.MethodGet, "/order/b1", nil)
+	malformed.Header.Set(HeaderGrpcTimeout, "soon")
+	resp, err = app.Test(malformed)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestListCustomerOrdersHandler(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().ListByCustomer(gomock.Any(), "c1", model.Page{Limit: 5, Cursor: "abc"}).
+		Return(&model.OrderPage{Orders: []*model.Order{{OrderUID: "o1"}}, NextCursor: "next"}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/customers/c1/orders?limit=5&cursor=abc", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var page model.OrderPage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	require.Equal(t, "next", page.NextCursor)
+	require.Len(t, page.Orders, 1)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/customers/c1/orders?limit=1000", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestListOrdersHandler_DateRange(t *testing.T) {
+	app, svc := newTestApp(t)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	svc.EXPECT().List(gomock.Any(), model.OrderFilter{CustomerID: "c1", From: from, To: to}, model.Page{Limit: 20}).
+		Return(&model.OrderPage{}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet,
+		"/orders?customer_id=c1&from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	for _, q := range []string{"from=yesterday", "from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/orders?"+q, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode, q)
+	}
+}
+
+func TestListOrdersHandler_SortAndStatus(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().List(gomock.Any(), model.OrderFilter{Status: model.StatusPaid}, model.Page{Limit: 20, Sort: model.SortOldest}).
+		Return(&model.OrderPage{}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/orders?status=paid&sort=date_created", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	for _, q := range []string{"status=lost", "sort=price", "sort=DATE_CREATED"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/orders?"+q, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode, q)
+	}
+}
+
+func TestDebugVars(t *testing.T) {
+	app, _ := newTestApp(t)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/debug/vars", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var vars map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&vars))
+	require.Contains(t, vars, "memstats")
+}
+
+func TestErrorResponse_Localized(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	for lang, want := range map[string]string{
+		"ru-RU,ru;q=0.9": "Некорректный limit",
+		"en":             "Invalid limit",
+		"":               "Invalid limit",
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, "/orders?limit=0", nil)
+		req.Header.Set(fiber.HeaderAcceptLanguage, lang)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, resp.Header.Get(fiber.HeaderVary), fiber.HeaderAcceptLanguage)
+
+		var body model.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Equal(t, want, body.Msg, lang)
+	}
+}
+
+// parameters the generated wrappers cannot bind are answered like the
+// handlers answer theirs, in both API versions
+func TestParamErrors_LocalizedAndEnveloped(t *testing.T) {
+	app, _ := newTestApp(t)
+	get := func(path, lang string) (int, map[string]any) {
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		req.Header.Set(fiber.HeaderAcceptLanguage, lang)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var got map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		return resp.StatusCode, got
+	}
+
+	status, got := get(APIPrefix+"/orders?limit=ten", "en")
+	require.Equal(t, fiber.StatusBadRequest, status)
+	require.Equal(t, map[string]any{"status": 400.0, "msg": "Invalid limit"}, got)
+
+	status, got = get(APIPrefixV2+"/stats/top-customers?limit=1.5", "ru")
+	require.Equal(t, fiber.StatusBadRequest, status)
+	require.Equal(t, map[string]any{"code": "invalid_param", "msg": "Некорректный параметр limit"}, got["error"])
+}
+
+func TestStatsHandlers(t *testing.T) {
+	app, svc := newTestApp(t)
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	svc.EXPECT().TopCustomers(gomock.Any(), model.OrderFilter{From: from}, 3).
+		Return([]model.CustomerTotal{{CustomerID: "c1", Orders: 2, GoodsTotal: 500}}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/stats/top-customers?limit=3&from=2025-08-01T00:00:00Z", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var top []model.CustomerTotal
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&top))
+	require.Equal(t, "c1", top[0].CustomerID)
+
+	for _, target := range []string{
+		"/stats/top-customers?limit=1000",
+		"/stats/orders-per-day?from=yesterday",
+		"/stats/delivery-services?customer_id=%27%20OR",
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode, target)
+	}
+}
+
+func TestRawOrderHandler(t *testing.T) {
+	app, svc := newTestApp(t)
+	raw := `{"order_uid":"b1",  "unknown_field":true}`
+	svc.EXPECT().RawPayload(gomock.Any(), "b1").Return([]byte(raw), nil)
+	svc.EXPECT().RawPayload(gomock.Any(), "b2").Return(nil, repository.ErrNotFound)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/order/b1/raw", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get(fiber.HeaderContentType))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, raw, string(body))
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/order/b2/raw", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestUpdateStatusHandler(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().UpdateStatus(gomock.Any(), "b1", model.StatusShipped).Return(nil)
+	svc.EXPECT().UpdateStatus(gomock.Any(), "b1", model.OrderStatus("lost")).
+		Return(&ordr.ValidationError{Violations: []ordr.Violation{{Field: "status", Rule: ordr.RuleOneOf, Message: "status must be one of created, paid"}}})
+	svc.EXPECT().UpdateStatus(gomock.Any(), "b2", model.StatusPaid).Return(repository.ErrNotFound)
+	svc.EXPECT().UpdateStatus(gomock.Any(), "b1", model.StatusCreated).
+		Return(&ordr.TransitionError{OrderUID: "b1", From: model.StatusShipped, To: model.StatusCreated})
+
+	put := func(id, body string) int {
+		req := httptest.NewRequest(fiber.MethodPut, "/order/"+id+"/status", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	require.Equal(t, fiber.StatusNoContent, put("b1", `{"status":"shipped"}`))
+	require.Equal(t, fiber.StatusBadRequest, put("b1", `{"status":"lost"}`))
+	require.Equal(t, fiber.StatusNotFound, put("b2", `{"status":"paid"}`))
+	require.Equal(t, fiber.StatusConflict, put("b1", `{"status":"created"}`))
+	require.Equal(t, fiber.StatusBadRequest, put("b1", `{not json`))
+}
+
+type fakeConsumer struct{ paused bool }
+
+func (f *fakeConsumer) Pause()                { f.paused = true }
+func (f *fakeConsumer) Resume()               { f.paused = false }
+func (f *fakeConsumer) Status() ingest.Status { return ingest.Status{Paused: f.paused, Consumed: 7} }
+
+type fakeLevels struct{ level string }
+
+func (f *fakeLevels) Level() string { return f.level }
+func (f *fakeLevels) SetLevel(level string) error {
+	if level != "debug" && level != "info" {
+		return errors.New("unknown level")
+	}
+	f.level = level
+	return nil
+}
+
+func TestAdminOps_ControlCacheConsumerAndLogLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	log := newMockLogger(ctrl)
+	log.EXPECT().Warn(gomock.Any()).AnyTimes()
+	log.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+	consumer, levels := &fakeConsumer{}, &fakeLevels{level: "info"}
+	app := NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second, AdminToken: "s3cret"},
+		WithConsumer(consumer), WithLogLevel(levels))
+
+	call := func(method, path, body string, auth bool) (int, string) {
+		req := httptest.NewRequest(method, APIPrefix+"/admin"+path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if auth {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer s3cret")
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	for _, path := range []string{"/cache/warm", "/cache/flush", "/consumer/pause"} {
+		status, _ := call(fiber.MethodPost, path, "", false)
+		require.Equal(t, fiber.StatusUnauthorized, status, path)
+	}
+	require.False(t, consumer.paused)
+
+	svc.EXPECT().UpdateCache(gomock.Any()).Return(nil)
+	status, _ := call(fiber.MethodPost, "/cache/warm", "", true)
+	require.Equal(t, fiber.StatusNoContent, status)
+	svc.EXPECT().UpdateCache(gomock.Any()).Return(errors.New("db down"))
+	status, _ = call(fiber.MethodPost, "/cache/warm", "", true)
+	require.Equal(t, fiber.StatusInternalServerError, status)
+
+	svc.EXPECT().FlushCache().Return(4)
+	status, body := call(fiber.MethodPost, "/cache/flush", "", true)
+	require.Equal(t, fiber.StatusOK, status)
+	require.JSONEq(t, `{"flushed":4}`, body)
+
+	status, body = call(fiber.MethodPost, "/consumer/pause", "", true)
+	require.Equal(t, fiber.StatusOK, status)
+	require.JSONEq(t, `{"paused":true,"consumed":7}`, body)
+	_, body = call(fiber.MethodGet, "/consumer", "", true)
+	require.JSONEq(t, `{"paused":true,"consumed":7}`, body)
+	_, body = call(fiber.MethodPost, "/consumer/resume", "", true)
+	require.JSONEq(t, `{"paused":false,"consumed":7}`, body)
+
+	status, body = call(fiber.MethodPut, "/log-level", `{"level":"debug"}`, true)
+	require.Equal(t, fiber.StatusOK, status)
+	require.JSONEq(t, `{"level":"debug"}`, body)
+	status, _ = call(fiber.MethodPut, "/log-level", `{"level":"loud"}`, true)
+	require.Equal(t, fiber.StatusBadRequest, status)
+	_, body = call(fiber.MethodGet, "/log-level", "", true)
+	require.JSONEq(t, `{"level":"debug"}`, body)
+
+	// without a consumer, e.g. in a prefork child, its endpoints do not exist
+	app = NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second, AdminToken: "s3cret"})
+	status, _ = call(fiber.MethodGet, "/consumer", "", true)
+	require.Equal(t, fiber.StatusNotFound, status)
+}
+
+type fakeDLQ struct {
+	msgs  []ingest.DLQMessage
+	limit int
+}
+
+func (f *fakeDLQ) PeekDLQ(_ context.Context, limit int) ([]ingest.DLQMessage, error) {
+	f.limit = limit
+	return f.msgs, nil
+}
+
+func TestPeekDLQ_ListsMessagesBehindAdminToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	dlq := &fakeDLQ{msgs: []ingest.DLQMessage{{
+		Key: "b1", Error: "schema_validation: items: required", OriginPartition: 2, OriginOffset: 41,
+		Time: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), Payload: `{"order_uid":`, Truncated: true,
+	}}}
+	app := NewServer(svc, newMockLogger(ctrl), &config.ServerConfig{MaxRequestTimeout: time.Second, AdminToken: "s3cret"}, WithDLQ(dlq))
+
+	peek := func(query, auth string) *http.Response {
+		req := httptest.NewRequest(fiber.MethodGet, APIPrefix+"/admin/dlq"+query, nil)
+		req.Header.Set(fiber.HeaderAuthorization, auth)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	require.Equal(t, fiber.StatusUnauthorized, peek("", "Bearer wrong").StatusCode)
+	require.Equal(t, fiber.StatusBadRequest, peek("?limit=1000", "Bearer s3cret").StatusCode)
+
+	resp := peek("", "Bearer s3cret")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, defaultDLQPeek, dlq.limit)
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"key":"b1","error":"schema_validation: items: required","origin_partition":2,"origin_offset":41,`+
+		`"time":"2026-10-01T12:00:00Z","payload":"{\"order_uid\":","truncated":true}]`, string(b))
+
+	dlq.msgs = nil
+	resp = peek("?limit=5", "Bearer s3cret")
+	require.Equal(t, 5, dlq.limit)
+	b, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(b))
+}
+
+// The routes are generated from internal/api/openapi.yaml; this keeps the
+// spec valid, the guards registered around the generated routes on documented
+// paths, and the schemas bound to Go types in line with their json tags.
+func TestOpenAPI_MatchesServerAndModels(t *testing.T) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(api.Spec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(loader.Context))
+
+	documented := map[string]bool{}
+	for path, item := range doc.Paths.Map() {
+		prefix := APIPrefix
+		if len(item.Servers) > 0 { // the probes, served from the root
+			prefix = ""
+		}
+		for method := range item.Operations() {
+			documented[method+" "+prefix+path] = true
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	cfg := &config.ServerConfig{MaxRequestTimeout: time.Second, AdminToken: "s3cret"}
+	app := NewServer(mocks.NewMockService(ctrl), newMockLogger(ctrl), cfg,
+		WithOrderFeed(pubsub.NewHub[*model.Order]()), WithConsumer(&fakeConsumer{}), WithLogLevel(&fakeLevels{}), WithDLQ(&fakeDLQ{}))
+	param := regexp.MustCompile(`:(\w+)`)
+	served := map[string]bool{}
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		op := r.Method + " " + param.ReplaceAllString(r.Path, "{$1}")
+		served[op] = true
+		// tenant scopes and /api/v2 repeat the documented /api/v1 paths
+		if strings.HasPrefix(r.Path, APIPrefix) && !strings.HasPrefix(r.Path, APIPrefix+"/tenants/") {
+			require.True(t, documented[op], "%s is served but not documented", op)
+		}
+	}
+	for op := range documented {
+		require.True(t, served[op], "%s is documented but not served", op)
+	}
+
+	goTypes := map[string]any{
+		"Order": model.Order{}, "Delivery": model.Delivery{}, "Payment": model.Payment{}, "Item": model.Item{},
+		"OrderStatus": model.StatusCreated, "Sort": model.SortNewest,
+		"OrderPage": model.OrderPage{}, "OrderRevision": model.OrderRevision{}, "SearchPage": model.SearchPage{},
+		"SearchHit": model.SearchHit{}, "StatusUpdate": model.StatusUpdate{},
+		"BatchGetRequest": model.BatchGetRequest{}, "BatchGetResponse": model.BatchGetResponse{},
+		"DailyCount": model.DailyCount{}, "DeliveryServiceTotal": model.DeliveryServiceTotal{}, "CustomerTotal": model.CustomerTotal{},
+		"ErasureResult": model.ErasureResult{}, "CacheFlushResult": model.CacheFlushResult{}, "LogLevel": model.LogLevel{},
+		"ConsumerStatus": ingest.Status{}, "DLQMessage": ingest.DLQMessage{}, "ErrorResponse": model.ErrorResponse{},
+		"Readiness": model.Readiness{}, "HealthReport": model.HealthReport{}, "CheckResult": model.CheckResult{},
+	}
+	for name, ref := range doc.Components.Schemas {
+		if _, bound := ref.Value.Extensions["x-go-type"]; !bound {
+			continue
+		}
+		v, ok := goTypes[name]
+		require.True(t, ok, "schema %s is bound to a Go type missing here", name)
+		typ := reflect.TypeOf(v)
+		if typ.Kind() != reflect.Struct {
+			continue
+		}
+		var fields []string
+		for i := range typ.NumField() {
+			f := typ.Field(i)
+			if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); f.IsExported() && name != "" && name != "-" {
+				fields = append(fields, name)
+			}
+		}
+		require.ElementsMatch(t, fields, slices.Collect(maps.Keys(ref.Value.Properties)), "schema %s", name)
+	}
+}
+
+func TestEraseCustomerHandler_RequiresAdminToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	log := newMockLogger(ctrl)
+	app := NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second, AdminToken: "s3cret"})
+
+	svc.EXPECT().AnonymizeCustomer(gomock.Any(), "c1").DoAndReturn(func(ctx context.Context, id string) (*model.ErasureResult, error) {
+		require.Equal(t, "shop-1", tenant.FromContext(ctx))
+		return &model.ErasureResult{CustomerID: id, Orders: 3}, nil
+	})
+
+	erase := func(path, auth string) *http.Response {
+		req := httptest.NewRequest(fiber.MethodPost, path, nil)
+		if auth != "" {
+			req.Header.Set(fiber.HeaderAuthorization, auth)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	require.Equal(t, fiber.StatusUnauthorized, erase("/admin/customers/c1/erase", "").StatusCode)
+	require.Equal(t, fiber.StatusUnauthorized, erase("/admin/customers/c1/erase", "Bearer wrong").StatusCode)
+	require.Equal(t, fiber.StatusBadRequest, erase("/admin/customers/c%201/erase", "Bearer s3cret").StatusCode)
+
+	resp := erase("/admin/tenants/shop-1/customers/c1/erase", "Bearer s3cret")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var res model.ErasureResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	require.Equal(t, model.ErasureResult{CustomerID: "c1", Orders: 3}, res)
+
+	// without a configured token the admin routes do not exist
+	app, _ = newTestApp(t)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/admin/customers/c1/erase", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestDeleteOrderHandler(t *testing.T) {
+	newApp := func(hard bool) (*fiber.App, *mocks.MockService) {
+		ctrl := gomock.NewController(t)
+		svc := mocks.NewMockService(ctrl)
+		log := newMockLogger(ctrl)
+		cfg := &config.ServerConfig{MaxRequestTimeout: time.Second, AdminToken: "s3cret", HardDelete: hard}
+		return NewServer(svc, log, cfg), svc
+	}
+	del := func(app *fiber.App, path, auth string) int {
+		req := httptest.NewRequest(fiber.MethodDelete, path, nil)
+		req.Header.Set(fiber.HeaderAuthorization, auth)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	soft, svc := newApp(false)
+	svc.EXPECT().Archive(gomock.Any(), "b1").Return(nil)
+	svc.EXPECT().Archive(gomock.Any(), "b2").Return(repository.ErrNotFound)
+	svc.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+	require.Equal(t, fiber.StatusUnauthorized, del(soft, "/order/b1", ""))
+	require.Equal(t, fiber.StatusNoContent, del(soft, "/order/b1", "Bearer s3cret"))
+	require.Equal(t, fiber.StatusNotFound, del(soft, "/order/b2", "Bearer s3cret"))
+
+	hard, svc := newApp(true)
+	svc.EXPECT().Delete(gomock.Any(), "b1").DoAndReturn(func(ctx context.Context, _ string) error {
+		require.Equal(t, "shop-1", tenant.FromContext(ctx))
+		return nil
+	})
+	require.Equal(t, fiber.StatusNoContent, del(hard, "/tenants/shop-1/order/b1", "Bearer s3cret"))
+
+	// without an admin token orders cannot be deleted over HTTP
+	app, _ := newTestApp(t)
+	require.Equal(t, fiber.StatusMethodNotAllowed, del(app, "/order/b1", ""))
+}
+
+func TestBatchGetOrdersHandler(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().GetMany(gomock.Any(), []string{"b1", "gone", "b2", "gone"}).
+		Return([]*model.Order{{OrderUID: "b1"}, {OrderUID: "b2"}}, nil)
+
+	post := func(body string) *http.Response {
+		req := httptest.NewRequest(fiber.MethodPost, "/orders/batch-get", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	resp := post(`{"order_uids":["b1","gone","b2","gone"]}`)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var body model.BatchGetResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Orders, 2)
+	require.Equal(t, []string{"gone"}, body.Missing)
+
+	tooMany, _ := json.Marshal(model.BatchGetRequest{OrderUIDs: make([]string, maxBatchGet+1)})
+	for _, bad := range []string{`{"order_uids":[]}`, `{"order_uids":["ok","' OR 1=1"]}`, string(tooMany), `{not json`} {
+		require.Equal(t, fiber.StatusBadRequest, post(bad).StatusCode, bad)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), "m-1").Return(&model.Order{OrderUID: "m-1"}, nil)
+
+	before := httpDuration.Count(fiber.MethodGet, "/order/:order_uid", "200", "anonymous")
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/order/m-1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/no/such/route", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	require.Equal(t, before+1, httpDuration.Count(fiber.MethodGet, "/order/:order_uid", "200", "anonymous"))
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, metrics.ContentType, resp.Header.Get(fiber.HeaderContentType))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `http_request_duration_seconds_count{method="GET",route="unmatched",status="404",caller="anonymous"}`)
+	require.Contains(t, string(body), "# TYPE db_pool_total_conns gauge")
+}
+
+func TestPprof_RequiresConfigAndAdminToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	log := newMockLogger(ctrl)
+	log.EXPECT().Warn(gomock.Any()).Times(1)
+
+	get := func(app *fiber.App, auth string) int {
+		req := httptest.NewRequest(fiber.MethodGet, "/debug/pprof/cmdline", nil)
+		if auth != "" {
+			req.Header.Set(fiber.HeaderAuthorization, auth)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	off := NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second, AdminToken: "s3cret"})
+	require.Equal(t, fiber.StatusNotFound, get(off, "Bearer s3cret"))
+
+	unguarded := NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second, Pprof: true})
+	require.Equal(t, fiber.StatusNotFound, get(unguarded, ""))
+
+	on := NewServer(svc, log, &config.ServerConfig{MaxRequestTimeout: time.Second, AdminToken: "s3cret", Pprof: true})
+	require.Equal(t, fiber.StatusUnauthorized, get(on, ""))
+	require.Equal(t, fiber.StatusOK, get(on, "Bearer s3cret"))
+}
+
+func TestRequestID_PropagatedToResponseAndLogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	core, logs := observer.New(zapcore.InfoLevel)
+	app := NewServer(svc, logger.NewFromCore(core), &config.ServerConfig{MaxRequestTimeout: time.Second})
+	svc.EXPECT().Get(gomock.Any(), "r-1").Return(&model.Order{OrderUID: "r-1"}, nil).Times(2)
+
+	get := func(id string) *http.Response {
+		req := httptest.NewRequest(fiber.MethodGet, "/order/r-1", nil)
+		if id != "" {
+			req.Header.Set(fiber.HeaderXRequestID, id)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := get("trace-42")
+	require.Equal(t, "trace-42", resp.Header.Get(fiber.HeaderXRequestID))
+	entries := logs.TakeAll()
+	require.NotEmpty(t, entries)
+	for _, e := range entries {
+		require.Equal(t, "trace-42", e.ContextMap()["request_id"], e.Message)
+	}
+
+	resp = get("bad id\twith spaces")
+	generated := resp.Header.Get(fiber.HeaderXRequestID)
+	require.NoError(t, uuid.Validate(generated))
+	require.Equal(t, generated, logs.All()[0].ContextMap()["request_id"])
+}
+
+func TestAccessLog_StructuredFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	core, logs := observer.New(zapcore.InfoLevel)
+	app := NewServer(svc, logger.NewFromCore(core), &config.ServerConfig{MaxRequestTimeout: time.Second})
+	svc.EXPECT().Get(gomock.Any(), "a-1").Return(nil, errors.New("db down"))
+
+	req := httptest.NewRequest(fiber.MethodGet, "/order/a-1", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "req-7")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	access := logs.FilterMessage("request").All()
+	require.Len(t, access, 1)
+	require.Equal(t, zapcore.ErrorLevel, access[0].Level)
+	fields := access[0].ContextMap()
+	require.Equal(t, "GET", fields["method"])
+	require.Equal(t, "/order/a-1", fields["path"])
+	require.EqualValues(t, fiber.StatusInternalServerError, fields["status"])
+	require.Equal(t, "req-7", fields["request_id"])
+	require.Equal(t, "0.0.0.0", fields["remote_ip"])
+	require.Positive(t, fields["bytes"])
+	require.Contains(t, fields, "latency")
+}
+
+func TestRecover_AnswersPanicsWith500(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	core, logs := observer.New(zapcore.InfoLevel)
+	app := NewServer(svc, logger.NewFromCore(core), &config.ServerConfig{MaxRequestTimeout: time.Second})
+	svc.EXPECT().Get(gomock.Any(), "p-1").DoAndReturn(func(context.Context, string) (*model.Order, error) {
+		var o *model.Order
+		return o, errors.New(o.OrderUID) // nil dereference
+	})
+
+	before := httpPanics.Value("/order/:order_uid")
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/order/p-1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	var body model.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "Internal server error", body.Msg)
+	require.Equal(t, before+1, httpPanics.Value("/order/:order_uid"))
+
+	panics := logs.FilterLevelExact(zapcore.ErrorLevel).All()
+	require.Len(t, panics, 2) // the panic and its access log entry
+	require.Contains(t, panics[0].Message, "nil pointer dereference")
+	require.Contains(t, panics[0].ContextMap()["stack"], "runtime/debug.Stack")
+	require.EqualValues(t, fiber.StatusInternalServerError, panics[1].ContextMap()["status"])
+}
+
+func TestAPIKey_IdentifiesCallers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	core, logs := observer.New(zapcore.InfoLevel)
+	sum := sha256.Sum256([]byte("billing-key"))
+	cfg := &config.ServerConfig{
+		MaxRequestTimeout: time.Second,
+		APIKeys:           map[string]string{"billing": hex.EncodeToString(sum[:])},
+		RequireAPIKey:     true,
+		APIKeyRateLimits:  map[string]int{"billing": 1},
+	}
+	app := NewServer(svc, logger.NewFromCore(core), cfg)
+	svc.EXPECT().Get(gomock.Any(), "k-1").Return(&model.Order{OrderUID: "k-1"}, nil).Times(1)
+
+	get := func(key string) int {
+		req := httptest.NewRequest(fiber.MethodGet, "/order/k-1", nil)
+		if key != "" {
+			req.Header.Set(HeaderAPIKey, key)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	before := httpDuration.Count(fiber.MethodGet, "/order/:order_uid", "200", "billing")
+	require.Equal(t, fiber.StatusUnauthorized, get(""))
+	require.Equal(t, fiber.StatusUnauthorized, get("guess"))
+	require.Equal(t, fiber.StatusOK, get("billing-key"))
+	require.Equal(t, fiber.StatusTooManyRequests, get("billing-key"), "per-key limit")
+	require.Equal(t, before+1, httpDuration.Count(fiber.MethodGet, "/order/:order_uid", "200", "billing"))
+
+	access := logs.FilterMessage("request").FilterField(zap.String("caller", "billing")).All()
+	require.Len(t, access, 2)
+}
+
+func TestNewServer_AppliesBodyLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	app := NewServer(svc, newMockLogger(ctrl), &config.ServerConfig{MaxRequestTimeout: time.Second, BodyLimit: 64})
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/order", strings.NewReader(`{"track_number":"`+strings.Repeat("x", 100)+`"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	// fasthttp refuses to read the body and drops the connection
+	_, err := app.Test(req)
+	require.ErrorContains(t, err, "body size exceeds the given limit")
+}
+
+func TestListen_LoadsConfiguredCertificate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cfg := &config.ServerConfig{
+		Host:              "127.0.0.1",
+		MaxRequestTimeout: time.Second,
+		TLSCertFile:       filepath.Join(t.TempDir(), "missing.crt"),
+		TLSKeyFile:        filepath.Join(t.TempDir(), "missing.key"),
+	}
+	app := NewServer(mocks.NewMockService(ctrl), newMockLogger(ctrl), cfg)
+
+	// fails before binding, so no port is taken
+	err := Listen(app, cfg)
+	require.ErrorContains(t, err, "cannot load TLS key pair")
+
+	cfg.TLSClientCAFile = filepath.Join(t.TempDir(), "missing-ca.crt")
+	err = Listen(app, cfg)
+	require.ErrorContains(t, err, "cannot load TLS key pair")
+}
+
+func TestCompress_GzipsLargeResponsesOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	app := NewServer(svc, newMockLogger(ctrl), &config.ServerConfig{MaxRequestTimeout: time.Second, CompressLevel: 5, CompressMinSize: 1024})
+	big := &model.Order{OrderUID: "big-1"}
+	for i := 0; i < 50; i++ {
+		big.Items = append(big.Items, model.Item{ChrtID: i, Name: "Mascaras", Brand: "Vivienne Sabo"})
+	}
+	svc.EXPECT().Get(gomock.Any(), "big-1").Return(big, nil)
+	svc.EXPECT().Get(gomock.Any(), "small-1").Return(&model.Order{OrderUID: "small-1"}, nil)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/order/big-1", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "br;q=1.0, gzip;q=0.8")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, "gzip", resp.Header.Get(fiber.HeaderContentEncoding))
+	require.Contains(t, resp.Header.Get(fiber.HeaderVary), fiber.HeaderAcceptEncoding)
+	zr, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	var got model.Order
+	require.NoError(t, json.NewDecoder(zr).Decode(&got))
+	require.Len(t, got.Items, 50)
+
+	req = httptest.NewRequest(fiber.MethodGet, "/order/small-1", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	require.Empty(t, resp.Header.Get(fiber.HeaderContentEncoding))
+
+	req = httptest.NewRequest(fiber.MethodGet, "/order/big-1", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip;q=0, identity")
+	svc.EXPECT().Get(gomock.Any(), "big-1").Return(big, nil)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	require.Empty(t, resp.Header.Get(fiber.HeaderContentEncoding))
+}
+
+func TestRoutes_VersionedAndDeprecatedAliases(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), "b1").Return(&model.Order{OrderUID: "b1"}, nil).Times(2)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+"/order/b1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Deprecation"))
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/order/b1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get("Deprecation"))
+	require.Equal(t, `</api/v1/order/b1>; rel="successor-version"`, resp.Header.Get(fiber.HeaderLink))
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/healthz", nil))
+	require.NoError(t, err)
+	require.Empty(t, resp.Header.Get("Deprecation"))
+}
+
+func TestGetOrderHandler_CacheControl(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	cfg := &config.ServerConfig{MaxRequestTimeout: time.Second, OrderMaxAge: 30 * time.Second, OrderStaleWhileRevalidate: 5 * time.Minute}
+	app := NewServer(svc, newMockLogger(ctrl), cfg)
+	svc.EXPECT().Get(gomock.Any(), "b1").Return(&model.Order{OrderUID: "b1"}, nil)
+	svc.EXPECT().Get(gomock.Any(), "b2").Return(nil, repository.ErrNotFound)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+"/order/b1", nil))
+	require.NoError(t, err)
+	require.Equal(t, "private, max-age=30, stale-while-revalidate=300", resp.Header.Get(fiber.HeaderCacheControl))
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+"/order/b2", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	require.Empty(t, resp.Header.Get(fiber.HeaderCacheControl))
+}
+
+func TestOrderUIDValidation_RejectsBeforeTheService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	cfg := &config.ServerConfig{MaxRequestTimeout: time.Second, OrderUIDMaxLength: 12, OrderUIDPattern: `^b[0-9]+$`}
+	app := NewServer(svc, newMockLogger(ctrl), cfg)
+	svc.EXPECT().Get(gomock.Any(), "b42").Return(&model.Order{OrderUID: "b42"}, nil)
+
+	for path, msg := range map[string]string{
+		"/order/b4200000000000":   "order_uid must be 1 to 12 characters long, got 14",
+		"/order/b1;drop":          "order_uid may only contain letters, digits, _ and -",
+		"/order/x42/history":      `order_uid "x42" does not match ^b[0-9]+$`,
+		"/order/x%27%20or%201=1":  "order_uid may only contain letters, digits, _ and -",
+		"/order/%D0%B1%D0%B1/raw": "order_uid may only contain letters, digits, _ and -",
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+path, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode, path)
+		var body model.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Equal(t, msg, body.Msg, path)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+"/order/b42", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestEnvelope_WrapsV2Responses(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), "b1").Return(&model.Order{OrderUID: "b1"}, nil).Times(2)
+	svc.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&model.OrderPage{Orders: []*model.Order{{OrderUID: "b1"}}, NextCursor: "next"}, nil)
+	svc.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&ordr.ValidationError{Violations: []ordr.Violation{
+		{Field: "items", Rule: ordr.RuleNonEmpty, Message: "items must be non-empty"},
+		{Field: "track_number", Rule: ordr.RuleRequired, Message: "track_number is required"},
+	}})
+
+	get := func(method, path, body string) (int, map[string]any) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(fiber.HeaderXRequestID, "req-1")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var got map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		return resp.StatusCode, got
+	}
+
+	status, got := get(fiber.MethodGet, APIPrefixV2+"/order/b1", "")
+	require.Equal(t, fiber.StatusOK, status)
+	require.Equal(t, "b1", got["data"].(map[string]any)["order_uid"])
+	require.Equal(t, map[string]any{"request_id": "req-1"}, got["meta"])
+	require.NotContains(t, got, "error")
+
+	_, got = get(fiber.MethodGet, APIPrefixV2+"/orders", "")
+	require.Len(t, got["data"], 1)
+	require.Equal(t, "next", got["meta"].(map[string]any)["next_cursor"])
+
+	status, got = get(fiber.MethodGet, APIPrefixV2+"/order/b%20'1", "")
+	require.Equal(t, fiber.StatusBadRequest, status)
+	require.Equal(t, map[string]any{"code": "invalid_id_charset", "msg": "order_uid may only contain letters, digits, _ and -"}, got["error"])
+	require.NotContains(t, got, "data")
+
+	status, got = get(fiber.MethodPost, APIPrefixV2+"/order", `{"order_uid":"b2"}`)
+	require.Equal(t, fiber.StatusBadRequest, status)
+	require.Equal(t, "invalid_order", got["error"].(map[string]any)["code"])
+	require.Equal(t, []any{
+		map[string]any{"field": "items", "rule": "non_empty", "message": "items must be non-empty"},
+		map[string]any{"field": "track_number", "rule": "required", "message": "track_number is required"},
+	}, got["error"].(map[string]any)["details"])
+
+	// v1 keeps its shapes
+	_, got = get(fiber.MethodGet, APIPrefix+"/order/b1", "")
+	require.Equal(t, "b1", got["order_uid"])
+	_, got = get(fiber.MethodGet, APIPrefix+"/order/b%20'1", "")
+	require.Equal(t, map[string]any{"status": 400.0, "msg": "order_uid may only contain letters, digits, _ and -"}, got)
+}
+
+func TestGraphQL_SelectsFieldsAndTraversesCustomers(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), "b1").Return(&model.Order{
+		OrderUID: "b1", CustomerID: "c1",
+		Delivery: model.Delivery{City: "Kiryat Mozkin"},
+		Items:    []model.Item{{Name: "Mascaras", Price: 453}},
+	}, nil)
+	svc.EXPECT().ListByCustomer(gomock.Any(), "c1", model.Page{Limit: 2}).
+		Return(&model.OrderPage{Orders: []*model.Order{{OrderUID: "b1"}, {OrderUID: "b0"}}, NextCursor: "next"}, nil)
+	svc.EXPECT().Get(gomock.Any(), "gone").Return(nil, repository.ErrNotFound)
+
+	body := `{"query":"query($id: String!) { order(order_uid: $id) { order_uid delivery { city } items { name } customer { orders(limit: 2) { orders { order_uid } next_cursor } } } gone: order(order_uid: \"gone\") { order_uid } }","variables":{"id":"b1"}}`
+	req := httptest.NewRequest(fiber.MethodPost, APIPrefix+"/graphql", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	got, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"data":{"order":{"order_uid":"b1","delivery":{"city":"Kiryat Mozkin"},"items":[{"name":"Mascaras"}],`+
+		`"customer":{"orders":{"orders":[{"order_uid":"b1"},{"order_uid":"b0"}],"next_cursor":"next"}}},"gone":null}}`, string(got))
+}
+
+func TestGraphQL_RejectsInvalidQueries(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+
+	q := url.QueryEscape(`{ order(order_uid: "b1") { password } }`)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+"/graphql?query="+q, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	var out struct {
+		Data   any              `json:"data"`
+		Errors []map[string]any `json:"errors"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Nil(t, out.Data)
+	require.Equal(t, `Cannot query field "password" on type "Order".`, out.Errors[0]["message"])
+
+	req := httptest.NewRequest(fiber.MethodGet, APIPrefix+"/graphql?query="+url.QueryEscape(`{ order(order_uid: "b 1") { order_uid } }`), nil)
+	req.Header.Set(fiber.HeaderAcceptLanguage, "ru")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Equal(t, `Некорректный order_uid "b 1"`, out.Errors[0]["message"])
+}
+
+func TestGraphQL_IntrospectsAndLimitsComplexity(t *testing.T) {
+	app, svc := newTestApp(t)
+	svc.EXPECT().ListByCustomer(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	q := url.QueryEscape(`{ __type(name: "Customer") { fields { name } } }`)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+"/graphql?query="+q, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	got, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"data":{"__type":{"fields":[{"name":"id"},{"name":"orders"}]}}}`, string(got))
+
+	q = url.QueryEscape(`{ customer(id: "c1") { orders(limit: 100) { orders { customer { orders(limit: 100) { orders { order_uid } } } } } } }`)
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+"/graphql?query="+q, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	var out struct {
+		Errors []map[string]any `json:"errors"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Contains(t, out.Errors[0]["message"], "operation has complexity")
+}
+
+func TestStreamOrders_RelaysFeedPerTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	feed := pubsub.NewHub[*model.Order]()
+	app := NewServer(mocks.NewMockService(ctrl), newMockLogger(ctrl), &config.ServerConfig{MaxRequestTimeout: time.Second}, WithOrderFeed(feed))
+
+	type result struct {
+		resp *http.Response
+		body string
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, APIPrefix+"/orders/stream", nil), -1)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		b, err := io.ReadAll(resp.Body)
+		done <- result{resp, string(b), err}
+	}()
+	require.Eventually(t, func() bool { return feed.Len() == 1 }, time.Second, time.Millisecond)
+	feed.Publish(&model.Order{OrderUID: "b1", TenantID: tenant.Default})
+	feed.Publish(&model.Order{OrderUID: "other", TenantID: "shop-1"})
+	feed.Close()
+
+	res := <-done
+	require.NoError(t, res.err)
+	require.Equal(t, "text/event-stream", res.resp.Header.Get(fiber.HeaderContentType))
+	require.Contains(t, res.body, "id: b1\nevent: order\ndata: {\"order_uid\":\"b1\"")
+	require.NotContains(t, res.body, "other")
+	require.Zero(t, feed.Len())
+}
+
+func TestWebUI_ServesIndexAtRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ui := fstest.MapFS{
+		"index.html": {Data: []byte("<h1>lookup</h1>")},
+		"script.js":  {Data: []byte("fetch()")},
+	}
+	app := NewServer(mocks.NewMockService(ctrl), newMockLogger(ctrl), &config.ServerConfig{MaxRequestTimeout: time.Second}, WithWebUI(ui))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get(fiber.HeaderContentType), "text/html")
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, "<h1>lookup</h1>", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/script.js", nil))
+	require.NoError(t, err)
+	require.Contains(t, resp.Header.Get(fiber.HeaderContentType), "javascript")
+}
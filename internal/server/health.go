@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/api/healthapi"
+	"github.com/merkulovlad/wbtech-go/internal/health"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// ReadyCheck is a dependency /readyz probes; see health.Check.
+type ReadyCheck = health.Check
+
+var errCacheCold = errors.New("cache not warmed yet")
+
+// registerHealthChecks registers the built-in database and cache checks.
+// Both are critical: without the database nothing is served, and a cold
+// cache would send every lookup to it.
+func (h *Handler) registerHealthChecks() {
+	h.checks.Register(
+		health.Check{Name: "database", Check: h.Order.HealthCheck},
+		health.Check{Name: "cache", Check: func(context.Context) error {
+			if !h.Order.CacheWarm() {
+				return errCacheCold
+			}
+			return nil
+		}},
+	)
+}
+
+// Liveness answers GET /healthz.
+func (h *Handler) Liveness(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(&healthapi.Liveness{Status: "ok"})
+}
+
+// Readiness answers GET /readyz.
+func (h *Handler) Readiness(c *fiber.Ctx) error {
+	report := h.runChecks(c)
+	res := model.Readiness{Status: report.Status, Checks: make(map[string]string, len(report.Checks))}
+	for _, r := range report.Checks {
+		res.Checks[r.Name] = model.HealthOK
+		if r.Error != "" {
+			res.Checks[r.Name] = r.Error
+		}
+	}
+	return c.Status(readyStatus(report)).JSON(res)
+}
+
+// HealthDetails answers GET /healthz/details.
+func (h *Handler) HealthDetails(c *fiber.Ctx) error {
+	report := h.runChecks(c)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(readyStatus(report)).JSON(report)
+}
+
+// runChecks runs the registered checks and logs the failing ones.
+func (h *Handler) runChecks(c *fiber.Ctx) model.HealthReport {
+	report := h.checks.Run(c.UserContext())
+	for _, r := range report.Checks {
+		if r.Status != model.HealthOK {
+			h.log(c).Errorf("health check %s (%s) failed: %s", r.Name, r.Severity, r.Error)
+		}
+	}
+	return report
+}
+
+func readyStatus(report model.HealthReport) int {
+	if report.Status == model.HealthUnavailable {
+		return fiber.StatusServiceUnavailable
+	}
+	return fiber.StatusOK
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+)
+
+var httpDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+	"Latency of HTTP requests by method, route template, status and API key caller.", nil, "method", "route", "status", "caller")
+
+// metricsMiddleware records every request in httpDuration. The route label is
+// the matched template, not the path, so ids do not blow up cardinality;
+// requests no route matched share "unmatched". Requests without an API key
+// are counted for caller "anonymous".
+func metricsMiddleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	status := responseStatus(c, err)
+	route := c.Route().Path
+	var fe *fiber.Error
+	if errors.As(err, &fe) && fe.Code == fiber.StatusNotFound {
+		route = "unmatched"
+	}
+	caller := callerName(c)
+	if caller == "" {
+		caller = "anonymous"
+	}
+	httpDuration.Observe(time.Since(start).Seconds(), c.Method(), route, strconv.Itoa(status), caller)
+	return err
+}
+
+// Metrics answers GET /metrics.
+func (h *Handler) Metrics(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, metrics.ContentType)
+	_, err := metrics.Default.WriteTo(c.Response().BodyWriter())
+	return err
+}
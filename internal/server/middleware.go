@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// HeaderAPIKey identifies a service caller, see apiKeyMiddleware.
+	HeaderAPIKey = "X-API-Key"
+	// HeaderDeadline carries an absolute RFC 3339 deadline set by the caller.
+	HeaderDeadline = "X-Deadline"
+	// HeaderGrpcTimeout carries a relative timeout in gRPC wire format, e.g. "250m" or "2S".
+	HeaderGrpcTimeout = "Grpc-Timeout"
+)
+
+var errBadTimeout = errors.New("malformed timeout")
+
+// requestIDPattern is what an incoming X-Request-ID must look like to be
+// kept; anything else is replaced, since the id ends up in every log line.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestIDMiddleware keeps the caller's X-Request-ID or generates one, echoes
+// it in the response and stores it in the request context, so loggers obtained
+// through logger.WithContext tag every entry of the request with request_id.
+func requestIDMiddleware(c *fiber.Ctx) error {
+	id := c.Get(fiber.HeaderXRequestID)
+	if !requestIDPattern.MatchString(id) {
+		id = uuid.NewString()
+	}
+	c.Set(fiber.HeaderXRequestID, id)
+	c.SetUserContext(logger.ContextWith(c.UserContext(), "request_id", id))
+	return c.Next()
+}
+
+// deadlineMiddleware turns the caller's latency budget into the request context
+// deadline, bounded by max, so service and repository calls fail fast once the
+// caller has given up. Handlers must use c.UserContext() to inherit it.
+func deadlineMiddleware(max time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		now := time.Now()
+		deadline := now.Add(max)
+
+		if v := c.Get(HeaderDeadline); v != "" {
+			d, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidHeader, HeaderDeadline)
+			}
+			if d.Before(deadline) {
+				deadline = d
+			}
+		} else if v := c.Get(HeaderGrpcTimeout); v != "" {
+			d, err := parseGrpcTimeout(v)
+			if err != nil {
+				return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidHeader, HeaderGrpcTimeout)
+			}
+			if now.Add(d).Before(deadline) {
+				deadline = now.Add(d)
+			}
+		}
+
+		if !deadline.After(now) {
+			return errorJSON(c, fiber.StatusGatewayTimeout, i18n.DeadlineAlreadyPast)
+		}
+
+		ctx, cancel := context.WithDeadline(c.UserContext(), deadline)
+		defer cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// accessLogMiddleware logs one structured entry per request once it is
+// answered; 5xx responses are logged at error level.
+func accessLogMiddleware(log logger.InterfaceLogger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		entry := log.WithContext(c.UserContext()).With(
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"latency", time.Since(start),
+			"bytes", responseSize(c.Response()),
+			"remote_ip", c.IP(),
+		)
+		if status >= fiber.StatusInternalServerError {
+			entry.Error("request")
+		} else {
+			entry.Info("request")
+		}
+		return err
+	}
+}
+
+// responseSize is the body length, or the Content-Length (-1 when unknown)
+// of a streamed body: reading a stream's Body() would drain it.
+func responseSize(resp *fasthttp.Response) int {
+	if resp.IsBodyStream() {
+		return resp.Header.ContentLength()
+	}
+	return len(resp.Body())
+}
+
+var httpPanics = metrics.NewCounterVec("http_panics_total",
+	"Handler panics recovered by route template; any increase deserves an alert.", "route")
+
+// recoverMiddleware turns a handler panic into a 500 ErrorResponse, logs it
+// with the stack and counts it in httpPanics. It runs inside the access log,
+// so the recovered request is logged with its 500.
+func recoverMiddleware(log logger.InterfaceLogger) fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			httpPanics.Inc(c.Route().Path)
+			log.WithContext(c.UserContext()).With("stack", string(debug.Stack())).
+				Error(fmt.Sprintf("panic in %s %s: %v", c.Method(), c.Path(), v))
+			err = errorJSON(c, fiber.StatusInternalServerError, i18n.InternalError)
+		}()
+		return c.Next()
+	}
+}
+
+// responseStatus is the status c is answered with. When a handler returned
+// err, the app error handler has not written the response yet.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// tenantMiddleware scopes the request to the tenant in the :tenant_id path
+// parameter.
+func tenantMiddleware(c *fiber.Ctx) error {
+	id := c.Params("tenant_id")
+	if !tenant.Valid(id) {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidTenant, id)
+	}
+	c.SetUserContext(tenant.WithID(c.UserContext(), id))
+	return c.Next()
+}
+
+// parseGrpcTimeout parses the gRPC "TimeoutValue TimeoutUnit" format:
+// up to 8 digits followed by one of H, M, S, m (milli), u (micro), n (nano).
+func parseGrpcTimeout(v string) (time.Duration, error) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, errBadTimeout
+	}
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, errBadTimeout
+	}
+	var unit time.Duration
+	switch v[len(v)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, errBadTimeout
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// localCaller is the c.Locals key of the caller name set by apiKeyMiddleware.
+const localCaller = "caller"
+
+// callerName returns the API key owner of c, or "" for anonymous requests.
+func callerName(c *fiber.Ctx) string {
+	name, _ := c.Locals(localCaller).(string)
+	return name
+}
+
+// apiKeyMiddleware identifies callers by X-API-Key. keys maps caller names to
+// the hex SHA-256 of their key (validated by config). Keys are looked up by
+// hash, so the lookup time reveals nothing about the key itself. A known key
+// tags the request logs with caller=<name>; an unknown one is rejected, and
+// so is a missing one when required is set.
+func apiKeyMiddleware(keys map[string]string, required bool) fiber.Handler {
+	byHash := make(map[[sha256.Size]byte]string, len(keys))
+	for name, sum := range keys {
+		var h [sha256.Size]byte
+		_, _ = hex.Decode(h[:], []byte(sum))
+		byHash[h] = name
+	}
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderAPIKey)
+		if key == "" {
+			if required {
+				return errorJSON(c, fiber.StatusUnauthorized, i18n.Unauthorized)
+			}
+			return c.Next()
+		}
+		name, ok := byHash[sha256.Sum256([]byte(key))]
+		if !ok {
+			return errorJSON(c, fiber.StatusUnauthorized, i18n.InvalidAPIKey)
+		}
+		c.Locals(localCaller, name)
+		c.SetUserContext(logger.ContextWith(c.UserContext(), "caller", name))
+		return c.Next()
+	}
+}
+
+// adminMiddleware lets through requests carrying "Authorization: Bearer <token>".
+func adminMiddleware(token string) fiber.Handler {
+	want := []byte("Bearer " + token)
+	return func(c *fiber.Ctx) error {
+		if subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), want) != 1 {
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return errorJSON(c, fiber.StatusUnauthorized, i18n.Unauthorized)
+		}
+		return c.Next()
+	}
+}
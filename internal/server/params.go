@@ -0,0 +1,52 @@
+package server
+
+import (
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+)
+
+// orderUIDPattern restricts ids to the charset producers actually use, so
+// quotes, spaces and other injection payloads never reach the service layer.
+var orderUIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+var idCharset = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// defaultOrderUIDMaxLength applies when the config sets no maximum.
+const defaultOrderUIDMaxLength = 64
+
+// uidRules is what an order_uid from a client must look like:
+// ServerConfig.OrderUIDMaxLength and OrderUIDPattern on top of idCharset.
+type uidRules struct {
+	maxLen  int
+	pattern *regexp.Regexp // nil when not configured
+}
+
+// problem returns the message describing why id is invalid, or "" if it is
+// valid, with the args filling it.
+func (r uidRules) problem(id string) (i18n.Key, []any) {
+	switch {
+	case !idCharset.MatchString(id):
+		return i18n.InvalidIDCharset, nil
+	case id == "" || len(id) > r.maxLen:
+		return i18n.InvalidIDLength, []any{r.maxLen, len(id)}
+	case r.pattern != nil && !r.pattern.MatchString(id):
+		return i18n.InvalidIDPattern, []any{id, r.pattern.String()}
+	}
+	return "", nil
+}
+
+func (r uidRules) valid(id string) bool {
+	key, _ := r.problem(id)
+	return key == ""
+}
+
+// validOrderUID answers 400 before the route's handler runs when the
+// :order_uid path parameter breaks h.uids.
+func (h *Handler) validOrderUID(c *fiber.Ctx) error {
+	if key, args := h.uids.problem(c.Params("order_uid")); key != "" {
+		return errorJSON(c, fiber.StatusBadRequest, key, args...)
+	}
+	return c.Next()
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+)
+
+// rateLimiter keeps one token bucket per client: it holds up to burst tokens,
+// refills at rate tokens per second, and every request takes one.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// sweepEvery is how often buckets that have refilled completely are dropped;
+// a full bucket is what a new client gets anyway.
+const sweepEvery = time.Minute
+
+// newRateLimiter returns a limiter allowing rate requests per second with the
+// given burst; a non-positive burst is set to rate.
+func newRateLimiter(rate, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = rate
+	}
+	return &rateLimiter{
+		rate:    float64(rate),
+		burst:   float64(burst),
+		now:     time.Now,
+		clients: make(map[string]*bucket),
+	}
+}
+
+// allow takes a token from the bucket of key. When there is none it returns
+// false and how long until the next one.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= sweepEvery {
+		l.sweep(now)
+	}
+	b, ok := l.clients[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.clients, key)
+		}
+	}
+	l.swept = now
+}
+
+// rateLimits picks the limiter of a request: callers with their own limit
+// use it, other callers share def by name and anonymous clients by IP. The
+// limits can change while requests are served, see update.
+type rateLimits struct {
+	set atomic.Pointer[limiterSet]
+}
+
+type limiterSet struct {
+	def    *rateLimiter // nil when only perKey callers are limited
+	perKey map[string]*rateLimiter
+}
+
+func newRateLimits(rate, burst int, perKey map[string]int) *rateLimits {
+	r := &rateLimits{}
+	r.update(rate, burst, perKey)
+	return r
+}
+
+// update replaces the limits; every client starts over with a full bucket.
+// A rate of 0 and no perKey limits leave requests unlimited.
+func (r *rateLimits) update(rate, burst int, perKey map[string]int) {
+	set := &limiterSet{perKey: make(map[string]*rateLimiter, len(perKey))}
+	if rate > 0 {
+		set.def = newRateLimiter(rate, burst)
+	}
+	for name, rate := range perKey {
+		set.perKey[name] = newRateLimiter(rate, rate)
+	}
+	r.set.Store(set)
+}
+
+// middleware answers 429 with Retry-After once the client's bucket is empty.
+func (r *rateLimits) middleware(c *fiber.Ctx) error {
+	set := r.set.Load()
+	l, key := set.def, "ip:"+c.IP()
+	if name := callerName(c); name != "" {
+		key = "key:" + name
+		if pk, ok := set.perKey[name]; ok {
+			l = pk
+		}
+	}
+	if l == nil {
+		return c.Next()
+	}
+	ok, wait := l.allow(key)
+	if ok {
+		return c.Next()
+	}
+	secs := int(math.Ceil(wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(secs))
+	return errorJSON(c, fiber.StatusTooManyRequests, i18n.RateLimited, secs)
+}
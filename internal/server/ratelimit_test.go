@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/config/reload"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2, 3)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.allow("a")
+		require.True(t, ok, "burst request %d", i)
+	}
+	ok, wait := l.allow("a")
+	require.False(t, ok)
+	require.Equal(t, 500*time.Millisecond, wait)
+	ok, _ = l.allow("b")
+	require.True(t, ok, "clients have their own buckets")
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.allow("a")
+	require.True(t, ok)
+
+	// idle clients are forgotten once their bucket is full again
+	now = now.Add(sweepEvery)
+	_, _ = l.allow("c")
+	require.Len(t, l.clients, 1)
+}
+
+func TestRateLimit_Answers429WithRetryAfter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	app := NewServer(svc, newMockLogger(ctrl), &config.ServerConfig{MaxRequestTimeout: time.Second, RateLimit: 1})
+	svc.EXPECT().Get(gomock.Any(), "l-1").Return(&model.Order{OrderUID: "l-1"}, nil).Times(1)
+
+	get := func(path string) int {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		require.NoError(t, err)
+		if resp.StatusCode == fiber.StatusTooManyRequests {
+			require.Equal(t, "1", resp.Header.Get(fiber.HeaderRetryAfter))
+		}
+		return resp.StatusCode
+	}
+	require.Equal(t, fiber.StatusOK, get("/order/l-1"))
+	require.Equal(t, fiber.StatusTooManyRequests, get("/order/l-1"))
+	require.Equal(t, fiber.StatusOK, get("/healthz"))
+}
+
+// reloader hands its subscribers straight to the test.
+type reloader struct{ subs []reload.Subscriber }
+
+func (r *reloader) Subscribe(s reload.Subscriber) { r.subs = append(r.subs, s) }
+
+func TestRateLimit_FollowsReloads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockService(ctrl)
+	svc.EXPECT().Get(gomock.Any(), "l-1").Return(&model.Order{OrderUID: "l-1"}, nil).AnyTimes()
+	r := &reloader{}
+	// unlimited at startup
+	app := NewServer(svc, newMockLogger(ctrl), &config.ServerConfig{MaxRequestTimeout: time.Second}, WithReloader(r))
+	require.Len(t, r.subs, 1)
+
+	get := func() int {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/order/l-1", nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	require.Equal(t, fiber.StatusOK, get())
+	require.Equal(t, fiber.StatusOK, get())
+
+	r.subs[0](config.Tunables{}, config.Tunables{RateLimit: 1})
+	require.Equal(t, fiber.StatusOK, get())
+	require.Equal(t, fiber.StatusTooManyRequests, get())
+
+	r.subs[0](config.Tunables{RateLimit: 1}, config.Tunables{})
+	require.Equal(t, fiber.StatusOK, get())
+	require.Equal(t, fiber.StatusOK, get())
+}
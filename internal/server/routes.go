@@ -1,21 +1,105 @@
 package server
 
-import "github.com/gofiber/fiber/v2"
-
-// Health check endpoint
-// @Summary      Health check
-// @Description  Returns service health status
-// @Tags         health
-// @Produce      json
-// @Success      200  {object}  map[string]string
-// @Router       /healthz [get]
+import (
+	"errors"
+	"html"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/api/adminapi"
+	"github.com/merkulovlad/wbtech-go/internal/api/healthapi"
+	"github.com/merkulovlad/wbtech-go/internal/api/orderapi"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+)
+
+// APIPrefix versions the order and admin API. A breaking change to a response
+// ships under a new prefix while this one keeps answering as before.
+const APIPrefix = "/api/v1"
+
+// legacyPrefixes are the unversioned paths the API was served from before
+// APIPrefix. They stay mounted as deprecated aliases until consumers move.
+var legacyPrefixes = []string{"/order", "/orders", "/customers", "/stats", "/graphql", "/tenants", "/admin"}
+
 func (h *Handler) registerRoutes(app *fiber.App) {
+	if h.webUI != nil {
+		if err := h.registerWebUI(app); err != nil {
+			h.Logger.Errorf("web UI not mounted: %v", err)
+		}
+	}
+	// probes and the page above need no key and stay unlimited
+	if h.apiKeys != nil {
+		app.Use(h.apiKeys)
+	}
+	if h.limit != nil {
+		app.Use(h.limit)
+	}
+	app.Use(paramErrorMiddleware)
+
+	h.registerAPI(app.Group(APIPrefix))
+	h.registerAPI(app.Group(APIPrefixV2))
+	app.Use(legacyPrefixes, deprecatedMiddleware)
+	h.registerAPI(app)
+}
+
+// registerAPI mounts everything that is versioned under APIPrefix and
+// APIPrefixV2 on r.
+func (h *Handler) registerAPI(r fiber.Router) {
+	// unscoped paths act for tenant.Default
+	h.registerOrderRoutes(r)
+	h.registerOrderRoutes(r.Group("/tenants/:tenant_id", tenantMiddleware))
+	if h.admin != nil {
+		r.Use("/admin", h.admin)
+		r.Use("/admin/tenants/:tenant_id", tenantMiddleware)
+		adminapi.RegisterHandlers(r, h)
+	}
+}
 
-	app.Get("/healthz", func(c *fiber.Ctx) error {
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"status": "ok",
-		})
-	})
+// deprecatedMiddleware marks a legacy path as deprecated and points at its
+// APIPrefix successor.
+func deprecatedMiddleware(c *fiber.Ctx) error {
+	c.Set("Deprecation", "true")
+	c.Set(fiber.HeaderLink, "<"+APIPrefix+c.OriginalURL()+`>; rel="successor-version"`)
+	return c.Next()
+}
+
+// registerOrderRoutes mounts the order API on r; it is mounted once per
+// scope. The guards run ahead of the generated routes they share a path with.
+func (h *Handler) registerOrderRoutes(r fiber.Router) {
+	if h.admin != nil {
+		r.Delete("/order/:order_uid", h.admin)
+	}
+	r.Use("/order/:order_uid", h.validOrderUID)
+	orderapi.RegisterHandlers(r, h)
+}
+
+var (
+	_ orderapi.ServerInterface  = (*Handler)(nil)
+	_ adminapi.ServerInterface  = (*Handler)(nil)
+	_ healthapi.ServerInterface = (*Handler)(nil)
+)
+
+// paramPattern finds the parameter in the messages of the generated wrappers,
+// like "Invalid format for parameter limit: ...".
+var paramPattern = regexp.MustCompile(`(?:parameter|argument) (\w+)`)
+
+// paramErrorMiddleware answers the 400s of the generated wrappers, which
+// reject parameters that don't parse as their spec type, like the handlers
+// answer theirs.
+func paramErrorMiddleware(c *fiber.Ctx) error {
+	err := c.Next()
+	var fe *fiber.Error
+	if !errors.As(err, &fe) || fe.Code != fiber.StatusBadRequest {
+		return err
+	}
+	name := "query string"
+	if m := paramPattern.FindStringSubmatch(fe.Message); m != nil {
+		name = m[1]
+	}
+	return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidParam, name)
+}
 
-	app.Get("/order/:order_uid", h.getOrderHandler)
+// notMounted answers like a path no route matches, for operations whose
+// dependency the server was built without.
+func notMounted(c *fiber.Ctx) error {
+	return fiber.NewError(fiber.StatusNotFound, "Cannot "+c.Method()+" "+html.EscapeString(c.OriginalURL()))
 }
@@ -1,22 +1,156 @@
 package server
 
 import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"regexp"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/expvar"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+	"github.com/merkulovlad/wbtech-go/internal/api/healthapi"
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/config/reload"
 	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/pubsub"
 	"github.com/merkulovlad/wbtech-go/internal/service/order"
 )
 
-func NewServer(orderSvc order.Service, log logger.InterfaceLogger) *fiber.App {
-	app := fiber.New()
+// Option adds an optional part of the API.
+type Option func(*Handler)
+
+// WithReadyChecks makes /readyz and /healthz/details run checks next to the
+// database and the order cache.
+func WithReadyChecks(checks ...ReadyCheck) Option {
+	return func(h *Handler) {
+		h.checks.Register(checks...)
+	}
+}
+
+// WithOrderFeed mounts GET /orders/stream, which relays the orders published
+// on feed to clients as Server-Sent Events.
+func WithOrderFeed(feed *pubsub.Hub[*model.Order]) Option {
+	return func(h *Handler) {
+		h.feed = feed
+	}
+}
+
+// Reloader notifies of changes to the tunable settings, like *reload.Reloader.
+type Reloader interface {
+	Subscribe(s reload.Subscriber)
+}
+
+// WithReloader applies changed rate limits from r to the requests that
+// follow, including limits set after starting without any.
+func WithReloader(r Reloader) Option {
+	return func(h *Handler) {
+		h.reloader = r
+	}
+}
+
+// WithWebUI serves the order lookup page in fsys at /.
+func WithWebUI(fsys fs.FS) Option {
+	return func(h *Handler) {
+		h.webUI = fsys
+	}
+}
+
+// NewServer builds the HTTP API.
+func NewServer(orderSvc order.Service, log logger.InterfaceLogger, cfg *config.ServerConfig, opts ...Option) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		BodyLimit:    cfg.BodyLimit,
+		Prefork:      cfg.Prefork,
+	})
+	app.Use(metricsMiddleware)
+	app.Use(requestIDMiddleware)
+	app.Use(accessLogMiddleware(log))
+	app.Use(recoverMiddleware(log))
+	if cfg.CompressLevel > 0 {
+		// inside the access log, so its bytes are what went over the wire
+		app.Use(newCompressor(cfg.CompressLevel, cfg.CompressMinSize).middleware)
+	}
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3001",
-		AllowMethods:     "GET,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:     "Origin, Content-Type, Accept, " + HeaderDeadline + ", " + HeaderGrpcTimeout + ", " + fiber.HeaderXRequestID + ", " + HeaderAPIKey,
+		ExposeHeaders:    fiber.HeaderXRequestID,
 		AllowCredentials: false,
 	}))
+	// /debug/vars: queue gauges, canary stats and the Go runtime memstats
+	app.Use(expvar.New())
 	h := NewHandler(orderSvc, log)
+	// probes and /metrics answer without deadline, key or rate limit
+	healthapi.RegisterHandlers(app, h)
+	if cfg.AdminToken != "" {
+		h.admin = adminMiddleware(cfg.AdminToken)
+	}
+	if cfg.Pprof {
+		if h.admin == nil {
+			log.Warn("BACKEND_PPROF is set without BACKEND_ADMIN_TOKEN; /debug/pprof stays unmounted")
+		} else {
+			// ahead of deadlineMiddleware: /debug/pprof/profile runs for ?seconds=
+			app.Use("/debug/pprof", h.admin, pprof.New())
+		}
+	}
+	app.Use(deadlineMiddleware(cfg.MaxRequestTimeout))
+	h.hardDelete = cfg.HardDelete
+	h.orderCacheControl = orderCacheControl(cfg)
+	if cfg.OrderUIDMaxLength > 0 {
+		h.uids.maxLen = cfg.OrderUIDMaxLength
+	}
+	if cfg.OrderUIDPattern != "" {
+		h.uids.pattern = regexp.MustCompile(cfg.OrderUIDPattern) // checked by config.MustLoad
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if len(cfg.APIKeys) > 0 {
+		h.apiKeys = apiKeyMiddleware(cfg.APIKeys, cfg.RequireAPIKey)
+	}
+	if cfg.RateLimit > 0 || len(cfg.APIKeyRateLimits) > 0 || h.reloader != nil {
+		limits := newRateLimits(cfg.RateLimit, cfg.RateBurst, cfg.APIKeyRateLimits)
+		h.limit = limits.middleware
+		if h.reloader != nil {
+			h.reloader.Subscribe(func(old, new config.Tunables) {
+				if old.RateLimit != new.RateLimit || old.RateBurst != new.RateBurst || !maps.Equal(old.APIKeyRateLimits, new.APIKeyRateLimits) {
+					limits.update(new.RateLimit, new.RateBurst, new.APIKeyRateLimits)
+					log.Infof("rate limits changed to %d/s (burst %d), per key %v", new.RateLimit, new.RateBurst, new.APIKeyRateLimits)
+				}
+			})
+		}
+	}
 	h.registerRoutes(app)
 
 	return app
 }
+
+// orderCacheControl is private: responses depend on the tenant and API key.
+func orderCacheControl(cfg *config.ServerConfig) string {
+	if cfg.OrderMaxAge <= 0 {
+		return ""
+	}
+	v := fmt.Sprintf("private, max-age=%d", int(cfg.OrderMaxAge.Seconds()))
+	if cfg.OrderStaleWhileRevalidate > 0 {
+		v += fmt.Sprintf(", stale-while-revalidate=%d", int(cfg.OrderStaleWhileRevalidate.Seconds()))
+	}
+	return v
+}
+
+// Listen serves app on cfg.Addr(): plain HTTP, HTTPS when a certificate is
+// configured, and mutual TLS when a client CA is configured as well.
+func Listen(app *fiber.App, cfg *config.ServerConfig) error {
+	switch {
+	case !cfg.TLS():
+		return app.Listen(cfg.Addr())
+	case cfg.TLSClientCAFile == "":
+		return app.ListenTLS(cfg.Addr(), cfg.TLSCertFile, cfg.TLSKeyFile)
+	default:
+		return app.ListenMutualTLS(cfg.Addr(), cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile)
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/api/orderapi"
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/i18n"
+)
+
+// maxTopCustomers bounds ?limit= of /stats/top-customers.
+const maxTopCustomers = 100
+
+// GetOrdersPerDay answers GET /stats/orders-per-day.
+func (h *Handler) GetOrdersPerDay(c *fiber.Ctx, params orderapi.GetOrdersPerDayParams) error {
+	filter, key, param := orderFilterParams(params.CustomerId, params.Status, params.From, params.To)
+	if key != "" {
+		return errorJSON(c, fiber.StatusBadRequest, key, param)
+	}
+	res, err := h.Order.OrdersPerDay(c.UserContext(), filter)
+	return h.respondStats(c, res, err)
+}
+
+// GetDeliveryServices answers GET /stats/delivery-services.
+func (h *Handler) GetDeliveryServices(c *fiber.Ctx, params orderapi.GetDeliveryServicesParams) error {
+	filter, key, param := orderFilterParams(params.CustomerId, params.Status, params.From, params.To)
+	if key != "" {
+		return errorJSON(c, fiber.StatusBadRequest, key, param)
+	}
+	res, err := h.Order.GoodsByDeliveryService(c.UserContext(), filter)
+	return h.respondStats(c, res, err)
+}
+
+// GetTopCustomers answers GET /stats/top-customers; it filters by date only.
+func (h *Handler) GetTopCustomers(c *fiber.Ctx, params orderapi.GetTopCustomersParams) error {
+	limit := repository.DefaultTopCustomers
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if limit <= 0 || limit > maxTopCustomers {
+		return errorJSON(c, fiber.StatusBadRequest, i18n.InvalidLimit)
+	}
+	filter, key, param := orderFilterParams(nil, nil, params.From, params.To)
+	if key != "" {
+		return errorJSON(c, fiber.StatusBadRequest, key, param)
+	}
+	res, err := h.Order.TopCustomers(c.UserContext(), filter, limit)
+	return h.respondStats(c, res, err)
+}
+
+func (h *Handler) respondStats(c *fiber.Ctx, res any, err error) error {
+	if err != nil {
+		return h.respondError(c, err, i18n.StatsFailed)
+	}
+	return respondJSON(c, fiber.StatusOK, res)
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+const (
+	// streamBuffer is how many orders a client may fall behind by before it
+	// misses some.
+	streamBuffer = 64
+	// streamHeartbeat keeps idle streams from being cut by proxies.
+	streamHeartbeat = 15 * time.Second
+)
+
+// StreamOrders answers GET /orders/stream while h.feed is set.
+func (h *Handler) StreamOrders(c *fiber.Ctx) error {
+	if h.feed == nil {
+		return notMounted(c)
+	}
+	tenantID := tenant.FromContext(c.UserContext())
+	sub := h.feed.Subscribe(streamBuffer)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no") // nginx would hold events back otherwise
+	// the request context ends with the handler; the stream ends when the
+	// client goes away or the feed is closed on shutdown
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		w.WriteString("retry: 3000\n\n")
+		for w.Flush() == nil {
+			select {
+			case o, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				owner := o.TenantID
+				if owner == "" {
+					owner = tenant.Default
+				}
+				if owner != tenantID {
+					continue
+				}
+				b, err := json.Marshal(o)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "id: %s\nevent: order\ndata: %s\n\n", o.OrderUID, b)
+			case <-heartbeat.C:
+				w.WriteString(": ping\n\n")
+			}
+		}
+	})
+	return nil
+}
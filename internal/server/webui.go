@@ -0,0 +1,41 @@
+package server
+
+import (
+	"io/fs"
+	"mime"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerWebUI serves the top-level files of fsys by name and index.html at /.
+// They are read once, at startup.
+func (h *Handler) registerWebUI(app *fiber.App) error {
+	entries, err := fs.ReadDir(h.webUI, ".")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		body, err := fs.ReadFile(h.webUI, e.Name())
+		if err != nil {
+			return err
+		}
+		contentType := mime.TypeByExtension(path.Ext(e.Name()))
+		if contentType == "" {
+			contentType = fiber.MIMEOctetStream
+		}
+		serve := func(c *fiber.Ctx) error {
+			c.Set(fiber.HeaderContentType, contentType)
+			c.Set(fiber.HeaderCacheControl, "no-cache")
+			return c.Send(body)
+		}
+		app.Get("/"+e.Name(), serve)
+		if e.Name() == "index.html" {
+			app.Get("/", serve)
+		}
+	}
+	return nil
+}
@@ -3,25 +3,38 @@ package cache
 import (
 	"container/list"
 	"sync"
+	"time"
 
 	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
 	"github.com/merkulovlad/wbtech-go/internal/model"
 )
 
+var (
+	lookups   = metrics.NewCounterVec("order_cache_lookups_total", "Order cache lookups by result (hit, miss).", "result")
+	evictions = metrics.NewCounterVec("order_cache_evictions_total", "Orders dropped from the cache to make room.")
+)
+
 type Cache struct {
 	mu    sync.RWMutex
 	data  map[string]*list.Element
 	order *list.List // keep insertion order (FIFO)
 	limit int
+	ttl   time.Duration
 	log   logger.InterfaceLogger
+	stats *accessStats
 }
 
 type entry struct {
-	key   string
-	value *model.Order
+	key    string
+	value  *model.Order
+	stored time.Time
 }
 
-var _ InterfaceCache = (*Cache)(nil)
+var (
+	_ InterfaceCache = (*Cache)(nil)
+	_ Sized          = (*Cache)(nil)
+)
 
 func NewCache(log logger.InterfaceLogger) *Cache {
 	return &Cache{
@@ -29,50 +42,131 @@ func NewCache(log logger.InterfaceLogger) *Cache {
 		order: list.New(),
 		limit: 10,
 		log:   log,
+		stats: newAccessStats(),
 	}
 }
 
 func (c *Cache) Get(key string) (*model.Order, bool) {
+	value, _, ok := c.Lookup(key)
+	return value, ok
+}
+
+func (c *Cache) Lookup(key string) (*model.Order, time.Time, bool) {
+	c.stats.record(key)
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	elem, ok := c.data[key]
 	if !ok {
+		lookups.Inc("miss")
 		c.log.Infof("Key not found: %s", key)
-		return nil, false
+		return nil, time.Time{}, false
 	}
 
 	ent := elem.Value.(*entry)
+	if c.ttl > 0 && time.Since(ent.stored) > c.ttl {
+		// removed by the next Set that needs room
+		lookups.Inc("miss")
+		c.log.Infof("Key expired: %s", key)
+		return nil, time.Time{}, false
+	}
+
+	lookups.Inc("hit")
 	c.log.Infof("Get from cache: %s", key)
-	return ent.value, true
+	return ent.value, ent.stored, true
 }
 
 func (c *Cache) Set(key string, value *model.Order) error {
+	c.store(key, value, time.Now())
+	return nil
+}
+
+// store is Set with the time Lookup reports, for orders that were stored
+// elsewhere first.
+func (c *Cache) store(key string, value *model.Order, stored time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	// if already exists, update
 	if elem, ok := c.data[key]; ok {
 		c.log.Infof("Update in cache: %s", key)
-		elem.Value.(*entry).value = value
-		return nil
+		ent := elem.Value.(*entry)
+		ent.value, ent.stored = value, stored
+		return
 	}
 
 	// check limit
-	if c.order.Len() >= c.limit {
-		// remove oldest
-		oldest := c.order.Front()
-		if oldest != nil {
-			ent := oldest.Value.(*entry)
-			delete(c.data, ent.key)
-			c.order.Remove(oldest)
-			c.log.Infof("Removed oldest from cache: %s", ent.key)
-		}
-	}
+	c.evictTo(c.limit - 1)
 
-	ent := &entry{key, value}
+	ent := &entry{key, value, stored}
 	elem := c.order.PushBack(ent)
 	c.data[key] = elem
 	c.log.Infof("Set to cache: %s", key)
-	return nil
+}
+
+// SetLimit changes how many orders the cache holds, dropping the oldest
+// beyond n at once. n below 1 is taken as 1.
+func (c *Cache) SetLimit(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = max(n, 1)
+	c.evictTo(c.limit)
+}
+
+// SetTTL makes orders stored longer than d ago misses; 0 keeps them until
+// they are evicted.
+func (c *Cache) SetTTL(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = d
+}
+
+// evictTo removes the oldest entries until at most n remain.
+func (c *Cache) evictTo(n int) {
+	for c.order.Len() > n {
+		// remove oldest
+		oldest := c.order.Front()
+		ent := oldest.Value.(*entry)
+		delete(c.data, ent.key)
+		c.order.Remove(oldest)
+		evictions.Inc()
+		c.log.Infof("Removed oldest from cache: %s", ent.key)
+	}
+}
+
+// Popular returns up to n keys, most looked up first, whether or not they
+// are cached.
+func (c *Cache) Popular(n int) []string {
+	return c.stats.top(n)
+}
+
+// Len returns the number of cached orders.
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.order.Len()
+}
+
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.data[key]
+	if !ok {
+		return
+	}
+	delete(c.data, key)
+	c.order.Remove(elem)
+	c.log.Infof("Deleted from cache: %s", key)
+}
+
+func (c *Cache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.order.Len()
+	c.data = make(map[string]*list.Element)
+	c.order.Init()
+	c.log.Infof("Cleared cache: %d entries", n)
+	return n
 }
@@ -1,8 +1,10 @@
 package cache
 
 import (
+	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/merkulovlad/wbtech-go/internal/mocks"
@@ -85,6 +87,75 @@ func TestCache_UpdateExisting_DoesNotGrow(t *testing.T) {
 	}
 }
 
+func TestCache_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	c := NewCache(mockLog)
+	_ = c.Set("k1", &model.Order{OrderUID: "k1"})
+	_ = c.Set("k2", &model.Order{OrderUID: "k2"})
+
+	c.Delete("k1")
+	c.Delete("missing")
+
+	if _, ok := c.Get("k1"); ok {
+		t.Fatalf("expected k1 to be deleted")
+	}
+	if _, ok := c.Get("k2"); !ok {
+		t.Fatalf("expected k2 to stay")
+	}
+	if len(c.data) != 1 || c.order.Len() != 1 {
+		t.Fatalf("sizes: data=%d order=%d", len(c.data), c.order.Len())
+	}
+}
+
+func TestCache_Lookup_ReportsWhenStored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	c := NewCache(mockLog)
+	before := time.Now()
+	_ = c.Set("k1", &model.Order{OrderUID: "k1"})
+	_, first, ok := c.Lookup("k1")
+	if !ok || first.Before(before) {
+		t.Fatalf("lookup: ok=%t stored=%v, want >= %v", ok, first, before)
+	}
+	_ = c.Set("k1", &model.Order{OrderUID: "k1", TrackNumber: "T2"})
+	v, second, _ := c.Lookup("k1")
+	if v.TrackNumber != "T2" || second.Before(first) {
+		t.Fatalf("update did not refresh the entry: %+v at %v", v, second)
+	}
+}
+
+func TestCache_Clear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	c := NewCache(mockLog)
+	_ = c.Set("k1", &model.Order{OrderUID: "k1"})
+	_ = c.Set("k2", &model.Order{OrderUID: "k2"})
+
+	if n := c.Clear(); n != 2 {
+		t.Fatalf("cleared %d, want 2", n)
+	}
+	if _, ok := c.Get("k1"); ok {
+		t.Fatalf("expected k1 to be cleared")
+	}
+	_ = c.Set("k3", &model.Order{OrderUID: "k3"})
+	if len(c.data) != 1 || c.order.Len() != 1 {
+		t.Fatalf("sizes: data=%d order=%d", len(c.data), c.order.Len())
+	}
+}
+
 func TestCache_Eviction_FIFO(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -127,6 +198,35 @@ func TestCache_Eviction_FIFO(t *testing.T) {
 	}
 }
 
+func TestCache_SetLimit_EvictsOldest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	c := NewCache(mockLog)
+	for _, k := range []string{"A", "B", "C"} {
+		if err := c.Set(k, &model.Order{OrderUID: k}); err != nil {
+			t.Fatalf("Set %s: %v", k, err)
+		}
+	}
+
+	c.SetLimit(1)
+	if c.Len() != 1 {
+		t.Fatalf("expected 1 entry after shrinking, got %d", c.Len())
+	}
+	if _, ok := c.Get("C"); !ok {
+		t.Fatalf("expected the newest entry C to stay")
+	}
+
+	c.SetLimit(3)
+	_ = c.Set("D", &model.Order{OrderUID: "D"})
+	if c.Len() != 2 {
+		t.Fatalf("expected room for D after growing, got %d entries", c.Len())
+	}
+}
+
 func TestCache_Concurrent_SetGet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -162,3 +262,104 @@ func TestCache_Concurrent_SetGet(t *testing.T) {
 		t.Fatalf("x3 should be present")
 	}
 }
+
+func TestCache_Popular_RanksLookupsAndForgetsColdKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	c := NewCache(mockLog)
+	_ = c.Set("k1", &model.Order{OrderUID: "k1"})
+	for i := 0; i < 4; i++ {
+		c.Get("k2") // misses count too
+	}
+	c.Get("k1")
+	c.Get("k1")
+	c.Get("k3")
+
+	if got := c.Popular(2); len(got) != 2 || got[0] != "k2" || got[1] != "k1" {
+		t.Fatalf("Popular(2) = %v", got)
+	}
+
+	for i := 0; len(c.stats.counts) < maxTracked; i++ {
+		c.Get(fmt.Sprintf("cold-%d", i))
+	}
+	c.Get("new")
+	// the single lookups halve to zero; k2 and k1 keep theirs
+	if got := c.Popular(10); len(got) != 3 || got[0] != "k2" || got[1] != "k1" || got[2] != "new" {
+		t.Fatalf("after decay Popular(10) = %v", got)
+	}
+}
+
+func TestCache_TTL_ExpiresEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	c := NewCache(mockLog)
+	c.SetTTL(time.Minute)
+	c.store("old", &model.Order{OrderUID: "old"}, time.Now().Add(-2*time.Minute))
+	_ = c.Set("new", &model.Order{OrderUID: "new"})
+
+	if _, ok := c.Get("old"); ok {
+		t.Fatalf("old should have expired")
+	}
+	if _, ok := c.Get("new"); !ok {
+		t.Fatalf("new should be present")
+	}
+}
+
+func TestSharded_SetTTL_AppliesToStoredOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	s := NewSharded(4, 10, 0, mockLog)
+	s.shard("old").store("old", &model.Order{OrderUID: "old"}, time.Now().Add(-2*time.Minute))
+	if _, ok := s.Get("old"); !ok {
+		t.Fatalf("old should be present without a TTL")
+	}
+	s.SetTTL(time.Minute)
+	if _, ok := s.Get("old"); ok {
+		t.Fatalf("old should have expired")
+	}
+}
+
+func TestSharded_SplitsCapacityAndSharesStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	s := NewSharded(4, 10, 0, mockLog)
+	for _, c := range s.shards {
+		if c.limit != 3 {
+			t.Fatalf("shard limit = %d, want 3", c.limit)
+		}
+	}
+	for i := range 100 {
+		k := fmt.Sprintf("k%d", i)
+		_ = s.Set(k, &model.Order{OrderUID: k})
+	}
+	if n := s.Len(); n != 12 {
+		t.Fatalf("Len = %d, want 12", n)
+	}
+	if _, ok := s.Get("k99"); !ok {
+		t.Fatalf("the newest order should be present")
+	}
+	s.Get("k98")
+	s.Get("k98")
+	if got := s.Popular(1); len(got) != 1 || got[0] != "k98" {
+		t.Fatalf("Popular(1) = %v", got)
+	}
+	if n := s.Clear(); n != 12 || s.Len() != 0 {
+		t.Fatalf("Clear = %d, left %d entries", n, s.Len())
+	}
+}
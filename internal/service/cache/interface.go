@@ -1,8 +1,33 @@
 package cache
 
-import model "github.com/merkulovlad/wbtech-go/internal/model"
+import (
+	"time"
+
+	model "github.com/merkulovlad/wbtech-go/internal/model"
+)
 
 type InterfaceCache interface {
 	Get(key string) (*model.Order, bool)
+	// Lookup is Get that also returns when the value was last Set.
+	Lookup(key string) (*model.Order, time.Time, bool)
 	Set(key string, value *model.Order) error
+	Delete(key string)
+	// Popular returns up to n keys, most looked up first, e.g. to warm the
+	// cache with.
+	Popular(n int) []string
+	// Clear drops every entry and returns how many there were.
+	Clear() int
+}
+
+// Sized is a cache holding a bounded number of orders in process, which
+// Len reports and SetLimit changes.
+type Sized interface {
+	Len() int
+	SetLimit(n int)
+}
+
+// Expiring is a cache whose orders expire once they were stored longer ago
+// than a TTL, which SetTTL changes; 0 keeps them until they are evicted.
+type Expiring interface {
+	SetTTL(d time.Duration)
 }
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// Layered puts an in-process cache in front of a shared one, usually Redis:
+// reads try near first and copy what they find in far into it, writes and
+// deletes go to both. Changes made through other instances reach near only
+// once its copy expires, so near should have a short TTL.
+type Layered struct {
+	near *Sharded
+	far  InterfaceCache
+}
+
+var (
+	_ InterfaceCache = (*Layered)(nil)
+	_ Sized          = (*Layered)(nil)
+	_ Expiring       = (*Layered)(nil)
+)
+
+func NewLayered(near *Sharded, far InterfaceCache) *Layered {
+	return &Layered{near: near, far: far}
+}
+
+func (l *Layered) Get(key string) (*model.Order, bool) {
+	value, _, ok := l.Lookup(key)
+	return value, ok
+}
+
+func (l *Layered) Lookup(key string) (*model.Order, time.Time, bool) {
+	if value, stored, ok := l.near.Lookup(key); ok {
+		return value, stored, true
+	}
+	value, stored, ok := l.far.Lookup(key)
+	if ok {
+		// keeps the age, so RefreshAfter still counts from the write
+		l.near.shard(key).store(key, value, stored)
+	}
+	return value, stored, ok
+}
+
+// Set stores value in far, then near; a far failure is returned, but near
+// serves the order regardless.
+func (l *Layered) Set(key string, value *model.Order) error {
+	err := l.far.Set(key, value)
+	_ = l.near.Set(key, value)
+	return err
+}
+
+func (l *Layered) Delete(key string) {
+	l.far.Delete(key)
+	l.near.Delete(key)
+}
+
+// Popular returns up to n keys, most looked up through this process first.
+func (l *Layered) Popular(n int) []string { return l.near.Popular(n) }
+
+// Clear empties both layers and returns how many orders far held.
+func (l *Layered) Clear() int {
+	l.near.Clear()
+	return l.far.Clear()
+}
+
+// Len returns the number of orders held in process.
+func (l *Layered) Len() int { return l.near.Len() }
+
+// SetLimit changes the capacity of near.
+func (l *Layered) SetLimit(n int) { l.near.SetLimit(n) }
+
+// SetTTL changes the TTL of both layers.
+func (l *Layered) SetTTL(d time.Duration) {
+	l.near.SetTTL(d)
+	if far, ok := l.far.(Expiring); ok {
+		far.SetTTL(d)
+	}
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisOptions configure a Redis cache.
+type RedisOptions struct {
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	// TLS connects with TLS, verifying the server against the system roots.
+	TLS bool
+	// KeyPrefix is put before every order UID; Clear deletes the keys
+	// starting with it and nothing else.
+	KeyPrefix string
+	// TTL expires orders in Redis; 0 keeps them until Redis evicts them.
+	TTL time.Duration
+	// Timeout bounds every command, dial included.
+	Timeout time.Duration
+	// PoolSize is the most connections kept open.
+	PoolSize int
+}
+
+// Redis keeps orders in Redis, shared by every instance of the service and
+// kept across restarts. A failing Redis is logged and read as a miss, so the
+// service falls back to the database. Orders are stored as JSON without
+// model.Order.Raw.
+type Redis struct {
+	client *redis.Client
+	prefix string
+	ttl    atomic.Int64 // time.Duration
+	log    logger.InterfaceLogger
+	stats  *accessStats
+}
+
+var (
+	_ InterfaceCache = (*Redis)(nil)
+	_ Expiring       = (*Redis)(nil)
+)
+
+// redisEntry is the value stored per order.
+type redisEntry struct {
+	Stored time.Time    `json:"stored"`
+	Order  *model.Order `json:"order"`
+}
+
+// clearBatch is how many keys Clear asks SCAN for at a time.
+const clearBatch = 500
+
+func NewRedis(opts RedisOptions, log logger.InterfaceLogger) *Redis {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	o := &redis.Options{
+		Addr:         opts.Addr,
+		Username:     opts.Username,
+		Password:     opts.Password,
+		DB:           opts.DB,
+		DialTimeout:  opts.Timeout,
+		ReadTimeout:  opts.Timeout,
+		WriteTimeout: opts.Timeout,
+		PoolSize:     opts.PoolSize,
+		MaxIdleConns: opts.PoolSize,
+		// the cache falls back to the database instead of retrying
+		MaxRetries: -1,
+	}
+	if opts.TLS {
+		o.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	r := &Redis{client: redis.NewClient(o), prefix: opts.KeyPrefix, log: log, stats: newAccessStats()}
+	r.SetTTL(opts.TTL)
+	return r
+}
+
+// SetTTL changes the expiry of orders Set from now on; orders already in
+// Redis keep theirs.
+func (r *Redis) SetTTL(d time.Duration) {
+	r.ttl.Store(int64(d))
+}
+
+func (r *Redis) Get(key string) (*model.Order, bool) {
+	value, _, ok := r.Lookup(key)
+	return value, ok
+}
+
+func (r *Redis) Lookup(key string) (*model.Order, time.Time, bool) {
+	r.stats.record(key)
+	b, err := r.client.Get(context.Background(), r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		lookups.Inc("miss")
+		r.log.Infof("Key not found: %s", key)
+		return nil, time.Time{}, false
+	}
+	if err != nil {
+		lookups.Inc("miss")
+		r.log.Warnf("redis cache: get %s: %v", key, err)
+		return nil, time.Time{}, false
+	}
+	var ent redisEntry
+	if err := json.Unmarshal(b, &ent); err != nil || ent.Order == nil {
+		lookups.Inc("miss")
+		r.log.Warnf("redis cache: decode %s: %v", key, err)
+		return nil, time.Time{}, false
+	}
+	lookups.Inc("hit")
+	r.log.Infof("Get from cache: %s", key)
+	return ent.Order, ent.Stored, true
+}
+
+func (r *Redis) Set(key string, value *model.Order) error {
+	b, err := json.Marshal(redisEntry{Stored: time.Now(), Order: value})
+	if err != nil {
+		return err
+	}
+	// a zero TTL stores the key without expiry
+	if err := r.client.Set(context.Background(), r.prefix+key, b, time.Duration(r.ttl.Load())).Err(); err != nil {
+		return err
+	}
+	r.log.Infof("Set to cache: %s", key)
+	return nil
+}
+
+func (r *Redis) Delete(key string) {
+	if err := r.client.Del(context.Background(), r.prefix+key).Err(); err != nil {
+		r.log.Warnf("redis cache: delete %s: %v", key, err)
+		return
+	}
+	r.log.Infof("Deleted from cache: %s", key)
+}
+
+// Popular returns up to n keys, most looked up through this process first.
+func (r *Redis) Popular(n int) []string {
+	return r.stats.top(n)
+}
+
+// Clear deletes every key under the prefix, scanning rather than flushing so
+// other data in the database is left alone.
+func (r *Redis) Clear() int {
+	ctx := context.Background()
+	n := 0
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, r.prefix+"*", clearBatch).Result()
+		if err != nil {
+			r.log.Warnf("redis cache: clear: %v", err)
+			return n
+		}
+		if len(keys) > 0 {
+			deleted, err := r.client.Del(ctx, keys...).Result()
+			if err != nil {
+				r.log.Warnf("redis cache: clear: %v", err)
+				return n
+			}
+			n += int(deleted)
+		}
+		if cursor = next; cursor == 0 {
+			r.log.Infof("Cleared cache: %d entries", n)
+			return n
+		}
+	}
+}
+
+// Ping checks that Redis answers.
+func (r *Redis) Ping() error {
+	return r.client.Ping(context.Background()).Err()
+}
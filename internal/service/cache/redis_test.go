@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// newTestRedis returns a Redis cache logging in with password to an
+// in-memory server whose password is "pw".
+func newTestRedis(t *testing.T, password string) (*Redis, *miniredis.Miniredis) {
+	ctrl := gomock.NewController(t)
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLog.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+	m := miniredis.RunT(t)
+	m.RequireAuth("pw")
+	return NewRedis(RedisOptions{Addr: m.Addr(), Password: password, KeyPrefix: "c:", TTL: time.Minute}, mockLog), m
+}
+
+func TestRedis_SetLookupDeleteClear(t *testing.T) {
+	r, m := newTestRedis(t, "pw")
+	if err := r.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if _, ok := r.Get("k1"); ok {
+		t.Fatalf("expected miss")
+	}
+	before := time.Now()
+	for _, k := range []string{"k1", "k2"} {
+		if err := r.Set(k, &model.Order{OrderUID: k, TenantID: "shop"}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if ttl := m.TTL("c:k1"); ttl != time.Minute {
+		t.Fatalf("ttl = %s, want 1m", ttl)
+	}
+	got, stored, ok := r.Lookup("k1")
+	if !ok || got.OrderUID != "k1" || got.TenantID != "shop" || stored.Before(before) {
+		t.Fatalf("Lookup = %+v, %v, %t", got, stored, ok)
+	}
+
+	r.Delete("k1")
+	if _, ok := r.Get("k1"); ok {
+		t.Fatalf("k1 should be deleted")
+	}
+	_ = m.Set("other", "kept")
+	if n := r.Clear(); n != 1 {
+		t.Fatalf("Clear = %d, want 1", n)
+	}
+	if v, _ := m.Get("other"); v != "kept" {
+		t.Fatalf("Clear deleted a key outside the prefix")
+	}
+	if n := m.TotalConnectionCount(); n != 1 {
+		t.Fatalf("connections should be reused, opened %d", n)
+	}
+
+	// a changed TTL applies to the next Set
+	r.SetTTL(0)
+	_ = r.Set("k3", &model.Order{OrderUID: "k3"})
+	if ttl := m.TTL("c:k3"); ttl != 0 {
+		t.Fatalf("ttl = %s, want none", ttl)
+	}
+}
+
+func TestRedis_FailureIsAMiss(t *testing.T) {
+	r, _ := newTestRedis(t, "wrong")
+	if err := r.Ping(); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Fatalf("Ping = %v, want WRONGPASS", err)
+	}
+	if err := r.Set("k1", &model.Order{OrderUID: "k1"}); err == nil {
+		t.Fatalf("Set should fail")
+	}
+	if _, ok := r.Get("k1"); ok {
+		t.Fatalf("expected miss")
+	}
+}
+
+func TestLayered_FillsNearFromFar(t *testing.T) {
+	far, m := newTestRedis(t, "pw")
+	ctrl := gomock.NewController(t)
+	mockLog := mocks.NewMockInterfaceLogger(ctrl)
+	mockLog.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	near := NewSharded(2, 10, time.Minute, mockLog)
+	l := NewLayered(near, far)
+
+	// written through another instance
+	other := NewRedis(RedisOptions{Addr: m.Addr(), Password: "pw", KeyPrefix: "c:"}, mockLog)
+	if err := other.Set("k1", &model.Order{OrderUID: "k1"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	_, farStored, _ := far.Lookup("k1")
+
+	if _, stored, ok := l.Lookup("k1"); !ok || !stored.Equal(farStored) {
+		t.Fatalf("Lookup = %v, %t; want the far store time %v", stored, ok, farStored)
+	}
+	if _, stored, ok := near.Lookup("k1"); !ok || !stored.Equal(farStored) {
+		t.Fatalf("near should hold k1 with the far store time")
+	}
+	commands := m.CommandCount()
+	l.Get("k1")
+	if m.CommandCount() != commands {
+		t.Fatalf("a near hit should not reach far")
+	}
+
+	l.Delete("k1")
+	if _, ok := l.Get("k1"); ok {
+		t.Fatalf("k1 should be deleted from both layers")
+	}
+}
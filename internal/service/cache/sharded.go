@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// Sharded spreads orders over several Caches by key, so concurrent requests
+// for different orders rarely wait on the same lock. The capacity is split
+// evenly, so each shard evicts its own oldest order; lookups are counted
+// across all of them for Popular.
+type Sharded struct {
+	shards []*Cache
+}
+
+var (
+	_ InterfaceCache = (*Sharded)(nil)
+	_ Sized          = (*Sharded)(nil)
+	_ Expiring       = (*Sharded)(nil)
+)
+
+// NewSharded returns a cache of n shards (at least 1) holding limit orders
+// in all, expiring them after ttl unless it is 0.
+func NewSharded(n, limit int, ttl time.Duration, log logger.InterfaceLogger) *Sharded {
+	n = max(n, 1)
+	s := &Sharded{shards: make([]*Cache, n)}
+	stats := newAccessStats()
+	for i := range s.shards {
+		c := NewCache(log)
+		c.stats = stats
+		c.SetTTL(ttl)
+		s.shards[i] = c
+	}
+	s.SetLimit(limit)
+	return s
+}
+
+func (s *Sharded) shard(key string) *Cache {
+	if len(s.shards) == 1 {
+		return s.shards[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+func (s *Sharded) Get(key string) (*model.Order, bool) { return s.shard(key).Get(key) }
+
+func (s *Sharded) Lookup(key string) (*model.Order, time.Time, bool) {
+	return s.shard(key).Lookup(key)
+}
+
+func (s *Sharded) Set(key string, value *model.Order) error { return s.shard(key).Set(key, value) }
+
+func (s *Sharded) Delete(key string) { s.shard(key).Delete(key) }
+
+// Popular returns up to n keys, most looked up first.
+func (s *Sharded) Popular(n int) []string { return s.shards[0].stats.top(n) }
+
+func (s *Sharded) Clear() int {
+	n := 0
+	for _, c := range s.shards {
+		n += c.Clear()
+	}
+	return n
+}
+
+func (s *Sharded) Len() int {
+	n := 0
+	for _, c := range s.shards {
+		n += c.Len()
+	}
+	return n
+}
+
+// SetLimit splits n over the shards, rounding up, so the cache may hold up
+// to a shard count more than n.
+func (s *Sharded) SetLimit(n int) {
+	per := (max(n, 1) + len(s.shards) - 1) / len(s.shards)
+	for _, c := range s.shards {
+		c.SetLimit(per)
+	}
+}
+
+// SetTTL changes the TTL of every shard, for orders already stored too.
+func (s *Sharded) SetTTL(d time.Duration) {
+	for _, c := range s.shards {
+		c.SetTTL(d)
+	}
+}
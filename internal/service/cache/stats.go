@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+)
+
+// maxTracked bounds how many keys accessStats counts, so lookups of
+// arbitrary missing keys cannot grow it without limit.
+const maxTracked = 10000
+
+// accessStats counts lookups per key, hits and misses alike. Once maxTracked
+// keys are counted every count is halved and the keys that reach zero are
+// forgotten, so old popularity fades.
+type accessStats struct {
+	mu     sync.Mutex
+	counts map[string]uint32
+}
+
+func newAccessStats() *accessStats {
+	return &accessStats{counts: make(map[string]uint32)}
+}
+
+func (s *accessStats) record(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.counts[key]; !ok && len(s.counts) >= maxTracked {
+		for k, n := range s.counts {
+			if n /= 2; n == 0 {
+				delete(s.counts, k)
+			} else {
+				s.counts[k] = n
+			}
+		}
+		if len(s.counts) >= maxTracked {
+			return
+		}
+	}
+	s.counts[key]++
+}
+
+// top returns up to n keys, most looked up first.
+func (s *accessStats) top(n int) []string {
+	type count struct {
+		key string
+		n   uint32
+	}
+	s.mu.Lock()
+	all := make([]count, 0, len(s.counts))
+	for k, c := range s.counts {
+		all = append(all, count{k, c})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].n != all[j].n {
+			return all[i].n > all[j].n
+		}
+		return all[i].key < all[j].key
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+	keys := make([]string, len(all))
+	for i, c := range all {
+		keys[i] = c.key
+	}
+	return keys
+}
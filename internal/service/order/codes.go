@@ -0,0 +1,53 @@
+package order
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// currencies are the active ISO 4217 alphabetic codes.
+var currencies = func() map[string]struct{} {
+	codes := strings.Fields(`
+AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB
+BOV BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUC
+CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF
+GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF
+KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU
+MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR
+PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP
+STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS UAH UGX USD USN UYI UYU
+UYW UZS VED VES VND VUV WST XAF XAG XAU XBA XBB XBC XBD XCD XDR XOF XPD XPF
+XPT XSU XTS XUA XXX YER ZAR ZMW ZWL`)
+	set := make(map[string]struct{}, len(codes))
+	for _, c := range codes {
+		set[c] = struct{}{}
+	}
+	return set
+}()
+
+// normalizeCodes trims payment.currency and the locale and brings them to
+// the case they are compared in: "usd " becomes "USD", "RU" becomes "ru".
+func normalizeCodes(o *model.Order) {
+	o.Payment.Currency = strings.ToUpper(strings.TrimSpace(o.Payment.Currency))
+	o.Locale = strings.ToLower(strings.TrimSpace(o.Locale))
+}
+
+// CodeViolations checks payment.currency against ISO 4217 and the locale
+// against locales, which allows any locale when empty. Both are expected
+// normalized, and are not checked when empty.
+func CodeViolations(o *model.Order, locales map[string]struct{}) []Violation {
+	var vs violations
+	if c := o.Payment.Currency; c != "" {
+		if _, ok := currencies[c]; !ok {
+			vs.add("payment.currency", RuleISO4217, fmt.Sprintf("payment.currency %q is not an ISO 4217 code", c))
+		}
+	}
+	if l := o.Locale; l != "" && len(locales) > 0 {
+		if _, ok := locales[l]; !ok {
+			vs.add("locale", RuleAllowed, fmt.Sprintf("locale %q is not one of the configured locales", l))
+		}
+	}
+	return vs
+}
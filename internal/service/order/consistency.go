@@ -0,0 +1,83 @@
+package order
+
+import (
+	"fmt"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// ConsistencyMode decides what Create does with an order that fails a data
+// check, such as payment totals that don't add up (see WithConsistency) or an
+// unknown currency (see WithCodeChecks).
+type ConsistencyMode string
+
+const (
+	// ConsistencyOff skips the checks.
+	ConsistencyOff ConsistencyMode = "off"
+	// ConsistencyWarn stores the order with the problems in its Warnings.
+	ConsistencyWarn ConsistencyMode = "warn"
+	// ConsistencyReject fails Create with a *ValidationError, which the
+	// consumer routes to the DLQ.
+	ConsistencyReject ConsistencyMode = "reject"
+)
+
+// ParseConsistencyMode accepts the names of the ConsistencyMode constants.
+func ParseConsistencyMode(name string) (ConsistencyMode, error) {
+	switch m := ConsistencyMode(name); m {
+	case ConsistencyOff, ConsistencyWarn, ConsistencyReject:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown consistency mode %q, want %q, %q or %q", name, ConsistencyOff, ConsistencyWarn, ConsistencyReject)
+}
+
+// ConsistencyViolations checks that payment.goods_total is the sum of the
+// items' total_price and that payment.amount is goods_total plus
+// delivery_cost and custom_fee. Sums may be off by up to tolerance, e.g. for
+// rounding by the producer.
+func ConsistencyViolations(o *model.Order, tolerance int) []Violation {
+	var vs violations
+	items := 0
+	for _, it := range o.Items {
+		items += it.TotalPrice
+	}
+	p := o.Payment
+	if off(p.GoodsTotal, items, tolerance) {
+		vs.add("payment.goods_total", RuleSum,
+			fmt.Sprintf("payment.goods_total is %d, but the items total %d", p.GoodsTotal, items))
+	}
+	if want := p.GoodsTotal + p.DeliveryCost + p.CustomFee; off(p.Amount, want, tolerance) {
+		vs.add("payment.amount", RuleSum,
+			fmt.Sprintf("payment.amount is %d, but goods_total + delivery_cost + custom_fee is %d", p.Amount, want))
+	}
+	return vs
+}
+
+func off(got, want, tolerance int) bool {
+	d := got - want
+	return d > tolerance || -d > tolerance
+}
+
+// check runs the WithConsistency and WithCodeChecks checks on o. Their
+// findings replace whatever Warnings the payload carried.
+func (s *orderService) check(o *model.Order) error {
+	o.Warnings = nil
+	var rejected violations
+	if s.consistency != ConsistencyOff {
+		flag(o, &rejected, s.consistency, ConsistencyViolations(o, s.tolerance))
+	}
+	if s.codes != ConsistencyOff {
+		flag(o, &rejected, s.codes, CodeViolations(o, s.locales))
+	}
+	return rejected.err()
+}
+
+// flag adds vs to rejected or, in ConsistencyWarn mode, to o.Warnings.
+func flag(o *model.Order, rejected *violations, mode ConsistencyMode, vs []Violation) {
+	for _, v := range vs {
+		if mode == ConsistencyReject {
+			*rejected = append(*rejected, v)
+		} else {
+			o.Warnings = append(o.Warnings, v.Message)
+		}
+	}
+}
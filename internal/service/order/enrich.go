@@ -0,0 +1,106 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// Enricher adjusts an order on its way out of the service: Get, GetMany,
+// List, Search and ListByCustomer run every enricher on a copy of each order
+// they return, so the cache and the repository keep the order as stored.
+// Derived values go into model.Order.Meta.
+type Enricher interface {
+	Enrich(c context.Context, o *model.Order)
+}
+
+// EnricherFunc adapts a function to Enricher.
+type EnricherFunc func(c context.Context, o *model.Order)
+
+func (f EnricherFunc) Enrich(c context.Context, o *model.Order) { f(c, o) }
+
+// WithEnrichers runs e, in order, on every order the service returns.
+func WithEnrichers(e ...Enricher) Option {
+	return func(s *orderService) {
+		s.enrichers = append(s.enrichers, e...)
+	}
+}
+
+const (
+	EnricherDaysInTransit = "days_in_transit"
+	EnricherMaskInternal  = "mask_internal"
+	EnricherShard         = "shard"
+)
+
+// LookupEnricher returns the named built-in enricher. shardKeys are the
+// shardkeys with a dedicated database, which EnricherShard reports.
+func LookupEnricher(name string, shardKeys []string) (Enricher, error) {
+	switch name {
+	case EnricherDaysInTransit:
+		return DaysInTransit(time.Now), nil
+	case EnricherMaskInternal:
+		return EnricherFunc(MaskInternal), nil
+	case EnricherShard:
+		return ShardMeta(shardKeys), nil
+	}
+	return nil, fmt.Errorf("unknown enricher %q", name)
+}
+
+// DaysInTransit sets Meta["days_in_transit"] to the whole days since an
+// order was created, for orders that are paid or shipped.
+func DaysInTransit(now func() time.Time) Enricher {
+	return EnricherFunc(func(_ context.Context, o *model.Order) {
+		if o.Status != model.StatusPaid && o.Status != model.StatusShipped || o.DateCreated.IsZero() {
+			return
+		}
+		setMeta(o, EnricherDaysInTransit, int(now().Sub(o.DateCreated)/(24*time.Hour)))
+	})
+}
+
+// MaskInternal clears the fields meant for internal systems only.
+func MaskInternal(_ context.Context, o *model.Order) {
+	o.InternalSignature = ""
+	o.Payment.RequestID = ""
+}
+
+// ShardMeta sets Meta["shard"] to the shardkey of orders stored in a
+// dedicated shard database and to "primary" for the rest.
+func ShardMeta(shardKeys []string) Enricher {
+	return EnricherFunc(func(_ context.Context, o *model.Order) {
+		shard := "primary"
+		if slices.Contains(shardKeys, o.ShardKey) {
+			shard = o.ShardKey
+		}
+		setMeta(o, EnricherShard, shard)
+	})
+}
+
+func setMeta(o *model.Order, key string, v any) {
+	if o.Meta == nil {
+		o.Meta = map[string]any{}
+	}
+	o.Meta[key] = v
+}
+
+func (s *orderService) enrichOne(c context.Context, o *model.Order) *model.Order {
+	orders := []*model.Order{o}
+	s.enrich(c, orders)
+	return orders[0]
+}
+
+// enrich replaces orders with enriched copies.
+func (s *orderService) enrich(c context.Context, orders []*model.Order) {
+	if len(s.enrichers) == 0 {
+		return
+	}
+	for i, o := range orders {
+		o = o.Clone()
+		for _, e := range s.enrichers {
+			e.Enrich(c, o)
+		}
+		orders[i] = o
+	}
+}
@@ -0,0 +1,44 @@
+package order
+
+import (
+	"encoding/hex"
+	"errors"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidOrderUID = errors.New("invalid order_uid")
+	ErrUIDCollision    = errors.New("could not generate a unique order_uid")
+)
+
+// maxIDAttempts bounds how many generated ids are tried before giving up on collisions.
+const maxIDAttempts = 3
+
+// IDGenerator produces order_uid values for orders submitted without one and
+// decides which caller-supplied ids are acceptable. Tenants with their own
+// UID scheme plug in a custom implementation via WithIDGenerator.
+type IDGenerator interface {
+	NewID() (string, error)
+	Valid(id string) bool
+}
+
+// legacyUIDPattern matches the ids existing producers send (e.g. "b563feb7b2b84b6test").
+var legacyUIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// UUIDv7Generator generates time-ordered UUIDv7 ids rendered as 32 hex chars,
+// matching the dash-less shape of producer ids.
+type UUIDv7Generator struct{}
+
+func (UUIDv7Generator) NewID() (string, error) {
+	u, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(u[:]), nil
+}
+
+func (UUIDv7Generator) Valid(id string) bool {
+	return legacyUIDPattern.MatchString(id)
+}
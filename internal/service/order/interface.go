@@ -2,12 +2,33 @@ package order
 
 import (
 	"context"
+	"time"
 
 	"github.com/merkulovlad/wbtech-go/internal/model"
 )
 
 type Service interface {
 	Get(c context.Context, id string) (*model.Order, error)
+	GetMany(c context.Context, ids []string) ([]*model.Order, error)
 	UpdateCache(c context.Context) error
+	Warm(c context.Context, strategy model.WarmStrategy, n int) (int, error)
+	CacheWarm() bool
+	FlushCache() int
+	SetRefreshAfter(d time.Duration)
 	Create(c context.Context, order *model.Order) error
+	Patch(c context.Context, id string, patch *model.OrderPatch) (*model.Order, error)
+	Delete(c context.Context, id string) error
+	UpdateStatus(c context.Context, id string, status model.OrderStatus) error
+	Archive(c context.Context, id string) error
+	Restore(c context.Context, id string) error
+	AnonymizeCustomer(c context.Context, customerID string) (*model.ErasureResult, error)
+	History(c context.Context, id string) ([]model.OrderRevision, error)
+	RawPayload(c context.Context, id string) ([]byte, error)
+	List(c context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error)
+	Search(c context.Context, query string, page model.Page) (*model.SearchPage, error)
+	ListByCustomer(c context.Context, customerID string, page model.Page) (*model.OrderPage, error)
+	OrdersPerDay(c context.Context, f model.OrderFilter) ([]model.DailyCount, error)
+	GoodsByDeliveryService(c context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error)
+	TopCustomers(c context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error)
+	HealthCheck(c context.Context) error
 }
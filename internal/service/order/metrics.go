@@ -0,0 +1,64 @@
+package order
+
+import (
+	"sync"
+
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+var (
+	ordersCreated = metrics.NewCounterVec("orders_created_total",
+		"Orders stored by Create, by delivery service and locale.", "delivery_service", "locale")
+	goodsTotal = metrics.NewHistogramVec("order_goods_total",
+		"payment.goods_total of the orders stored by Create.",
+		[]float64{100, 500, 1000, 2500, 5000, 10000, 25000, 50000, 100000})
+	orderItems = metrics.NewHistogramVec("order_items",
+		"Items per order stored by Create.", []float64{1, 2, 3, 5, 10, 20, 50, 100})
+	duplicates = metrics.NewCounterVec("orders_duplicate_total",
+		"Orders Create skipped because they matched the stored order.")
+	flagged = metrics.NewCounterVec("orders_flagged_total",
+		"Orders stored by Create with warnings.")
+	patched = metrics.NewCounterVec("orders_patched_total",
+		"Orders changed by Patch.")
+	warmFailures = metrics.NewCounterVec("order_cache_warm_failures_total",
+		"Orders Warm and UpdateCache loaded but could not put in the cache.")
+)
+
+// maxLabelValues caps the distinct delivery services and locales exported;
+// both come from the payload, so later ones are counted as "other".
+const maxLabelValues = 50
+
+var (
+	deliveryServices = boundedLabel{seen: map[string]struct{}{}}
+	locales          = boundedLabel{seen: map[string]struct{}{}}
+)
+
+// boundedLabel passes through the first maxLabelValues values it sees.
+type boundedLabel struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+func (l *boundedLabel) value(v string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.seen[v]; ok {
+		return v
+	}
+	if len(l.seen) >= maxLabelValues {
+		return "other"
+	}
+	l.seen[v] = struct{}{}
+	return v
+}
+
+// observeCreated records an order Create stored.
+func observeCreated(o *model.Order) {
+	ordersCreated.Inc(deliveryServices.value(o.DeliveryService), locales.value(o.Locale))
+	goodsTotal.Observe(float64(o.Payment.GoodsTotal))
+	orderItems.Observe(float64(len(o.Items)))
+	if len(o.Warnings) > 0 {
+		flagged.Inc()
+	}
+}
@@ -2,10 +2,17 @@ package order
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/events"
 	"github.com/merkulovlad/wbtech-go/internal/model"
 	"github.com/merkulovlad/wbtech-go/internal/service/cache"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
 	"golang.org/x/sync/singleflight"
 )
 
@@ -13,23 +20,123 @@ type orderService struct {
 	repo  repository.Repository
 	cache cache.InterfaceCache
 	group singleflight.Group
+	ids   IDGenerator
+	valid Validator
+	warm  atomic.Bool // set once UpdateCache or Warm has succeeded
+	// events receives every change the service stores; nil while nobody listens.
+	events events.Publisher
+	// refreshAfter is the age in nanoseconds past which a cached order is
+	// reloaded in the background; 0 never reloads. See SetRefreshAfter.
+	refreshAfter atomic.Int64
+	// warmCount is the number of recent orders UpdateCache loads.
+	warmCount int
+	// consistency, tolerance, codes and locales configure check.
+	consistency ConsistencyMode
+	tolerance   int
+	codes       ConsistencyMode
+	locales     map[string]struct{}
+	// enrichers adjust the orders read methods return.
+	enrichers []Enricher
 }
 
-func NewOrderService(r repository.Repository, c cache.InterfaceCache) Service {
-	return &orderService{
-		repo:  r,
-		cache: c,
-		group: singleflight.Group{},
+// Option customizes the order service.
+type Option func(*orderService)
+
+// WithIDGenerator replaces the default UUIDv7 order_uid generator.
+func WithIDGenerator(g IDGenerator) Option {
+	return func(s *orderService) {
+		s.ids = g
+	}
+}
+
+// WithValidator replaces the default rule set (ValidateOrder).
+func WithValidator(v Validator) Option {
+	return func(s *orderService) {
+		s.valid = v
+	}
+}
+
+// WithEvents publishes the orders Create stores, the status changes and the
+// deletions on p once they are stored.
+func WithEvents(p events.Publisher) Option {
+	return func(s *orderService) {
+		s.events = p
+	}
+}
+
+// WithRefreshAfter serves cached orders older than d as they are and reloads
+// them from the repository in the background (stale-while-revalidate), so a
+// change that bypassed the cache shows up within d plus one read.
+func WithRefreshAfter(d time.Duration) Option {
+	return func(s *orderService) {
+		s.refreshAfter.Store(int64(d))
 	}
 }
 
+// WithWarmCount makes UpdateCache load the n most recent orders instead of 10.
+func WithWarmCount(n int) Option {
+	return func(s *orderService) {
+		s.warmCount = n
+	}
+}
+
+// WithConsistency checks the payment totals of every order Create stores,
+// allowing sums to be off by tolerance; mode says what happens to orders that
+// fail. The default is ConsistencyOff.
+func WithConsistency(mode ConsistencyMode, tolerance int) Option {
+	return func(s *orderService) {
+		s.consistency, s.tolerance = mode, tolerance
+	}
+}
+
+// WithCodeChecks normalizes the case of payment.currency and the locale of
+// every order Create stores and checks them against ISO 4217 and locales,
+// which allows any locale when empty; mode says what happens to orders that
+// fail. The default is ConsistencyOff, which leaves both as they are.
+func WithCodeChecks(mode ConsistencyMode, locales []string) Option {
+	return func(s *orderService) {
+		s.codes = mode
+		s.locales = make(map[string]struct{}, len(locales))
+		for _, l := range locales {
+			s.locales[strings.ToLower(strings.TrimSpace(l))] = struct{}{}
+		}
+	}
+}
+
+func NewOrderService(r repository.Repository, c cache.InterfaceCache, opts ...Option) Service {
+	return newOrderService(r, c, opts)
+}
+
+func newOrderService(r repository.Repository, c cache.InterfaceCache, opts []Option) *orderService {
+	s := &orderService{
+		repo:        r,
+		cache:       c,
+		group:       singleflight.Group{},
+		ids:         UUIDv7Generator{},
+		valid:       ValidatorFunc(ValidateOrder),
+		warmCount:   10,
+		consistency: ConsistencyOff,
+		codes:       ConsistencyOff,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Get returns the order if it belongs to the tenant c acts for; orders of
+// other tenants are repository.ErrNotFound, whether cached or not.
 func (s *orderService) Get(c context.Context, id string) (*model.Order, error) {
-	if order, exists := s.cache.Get(id); exists {
-		return order, nil
+	tenantID := tenant.FromContext(c)
+	if order, exists, err := s.cached(c, id, tenantID); exists {
+		if err != nil {
+			return nil, err
+		}
+		return s.enrichOne(c, order), nil
 	}
-	res, err, _ := s.group.Do(id, func() (interface{}, error) {
+	res, err, _ := s.group.Do(flightKey(c, id), func() (interface{}, error) {
 		if order, exists := s.cache.Get(id); exists {
-			return order, nil
+			return ownedOrNotFound(order, tenantID)
 		}
 
 		order, err := s.repo.GetOrder(c, id)
@@ -44,23 +151,445 @@ func (s *orderService) Get(c context.Context, id string) (*model.Order, error) {
 	if err != nil {
 		return nil, err
 	}
-	return res.(*model.Order), nil
+	return s.enrichOne(c, res.(*model.Order)), nil
 }
 
-func (s *orderService) Create(c context.Context, order *model.Order) error {
-	return s.repo.UpsertOrder(c, order)
+// cached looks id up in the cache for Get and GetMany and reloads it in the
+// background once it is older than refreshAfter.
+func (s *orderService) cached(c context.Context, id, tenantID string) (*model.Order, bool, error) {
+	refreshAfter := time.Duration(s.refreshAfter.Load())
+	if refreshAfter <= 0 {
+		order, exists := s.cache.Get(id)
+		if !exists {
+			return nil, false, nil
+		}
+		order, err := ownedOrNotFound(order, tenantID)
+		return order, true, err
+	}
+	order, stored, exists := s.cache.Lookup(id)
+	if !exists {
+		return nil, false, nil
+	}
+	order, err := ownedOrNotFound(order, tenantID)
+	if err == nil && time.Since(stored) > refreshAfter {
+		s.refresh(c, id)
+	}
+	return order, true, err
 }
 
-func (s *orderService) UpdateCache(c context.Context) error {
-	orders, err := s.repo.GetRecent(c, 10)
-	if err != nil {
-		return err
+// refresh reloads a stale cached order without holding up the request that
+// found it; concurrent refreshes of one order share a load. A failed load
+// keeps the stale copy, a deleted order is dropped.
+func (s *orderService) refresh(c context.Context, id string) {
+	c = context.WithoutCancel(c)
+	s.group.DoChan("refresh/"+flightKey(c, id), func() (interface{}, error) {
+		order, err := s.repo.GetOrder(c, id)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			s.cache.Delete(id)
+		case err == nil:
+			_ = s.cache.Set(id, order)
+		}
+		return nil, err
+	})
+}
+
+// GetMany returns the orders of the tenant c acts for among ids, in the order
+// of ids; orders that don't exist, and repeats, are left out. Cached orders
+// come from the cache and the rest from one repository call.
+func (s *orderService) GetMany(c context.Context, ids []string) ([]*model.Order, error) {
+	tenantID := tenant.FromContext(c)
+	found := make(map[string]*model.Order, len(ids))
+	seen := make(map[string]bool, len(ids))
+	var misses []string
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		order, exists, err := s.cached(c, id, tenantID)
+		switch {
+		case !exists:
+			misses = append(misses, id)
+		case err == nil:
+			found[id] = order
+		}
+	}
+	if len(misses) > 0 {
+		if err := s.loadMany(c, misses, found); err != nil {
+			return nil, err
+		}
 	}
+
+	orders := make([]*model.Order, 0, len(found))
+	for _, id := range ids {
+		if order, ok := found[id]; ok {
+			orders = append(orders, order)
+			delete(found, id)
+		}
+	}
+	s.enrich(c, orders)
+	return orders, nil
+}
+
+// loadMany loads ids with one repository call into found and the cache. Each
+// id is also a flight of s.group, so a concurrent Get of it waits for this
+// call instead of loading the order again, and an id that is already being
+// loaded takes the answer of that load.
+func (s *orderService) loadMany(c context.Context, ids []string, found map[string]*model.Order) error {
+	var (
+		done    = make(chan struct{})
+		loaded  map[string]*model.Order
+		loadErr error
+	)
+	flights := make([]<-chan singleflight.Result, len(ids))
+	for i, id := range ids {
+		flights[i] = s.group.DoChan(flightKey(c, id), func() (interface{}, error) {
+			<-done
+			if loadErr != nil {
+				return nil, loadErr
+			}
+			if order, ok := loaded[id]; ok {
+				return order, nil
+			}
+			return nil, repository.ErrNotFound
+		})
+	}
+
+	orders, err := s.repo.GetOrders(c, ids)
+	loaded, loadErr = make(map[string]*model.Order, len(orders)), err
 	for _, order := range orders {
-		err = s.cache.Set(order.OrderUID, order)
+		loaded[order.OrderUID] = order
+		_ = s.cache.Set(order.OrderUID, order)
+	}
+	close(done)
+
+	for i, flight := range flights {
+		res := <-flight
+		switch {
+		case res.Err == nil:
+			found[ids[i]] = res.Val.(*model.Order)
+		case !errors.Is(res.Err, repository.ErrNotFound):
+			return res.Err
+		}
+	}
+	return nil
+}
+
+// flightKey keeps concurrent loads of one uid for different tenants apart:
+// they get different answers.
+func flightKey(c context.Context, id string) string {
+	return tenant.FromContext(c) + "/" + id
+}
+
+// ownedOrNotFound hides orders of other tenants than tenantID. The cache is
+// keyed by order_uid alone, which is globally unique.
+func ownedOrNotFound(order *model.Order, tenantID string) (*model.Order, error) {
+	owner := order.TenantID
+	if owner == "" {
+		owner = tenant.Default
+	}
+	if owner != tenantID {
+		return nil, repository.ErrNotFound
+	}
+	return order, nil
+}
+
+// Create validates and stores the order. Orders without an order_uid get one
+// from the IDGenerator; the generated id is written back into order. Rule
+// violations, and failed data checks under ConsistencyReject (see
+// WithConsistency and WithCodeChecks), are returned as *ValidationError; an
+// order older than the stored one (see model.Order.Version) as
+// repository.ErrStaleVersion. The order is stored for the tenant c acts for,
+// whatever its TenantID says, together with a model.EventOrderCreated or
+// model.EventOrderUpdated outbox event in the same transaction, and is then
+// published as a model.EventOrderUpserted event. An order identical to the stored one is
+// accepted without writing or publishing anything. Both are counted in the
+// business metrics at /metrics.
+//
+// A stored order is dropped from the cache rather than overwritten with
+// order: upserts keep the stored status, which order need not carry.
+func (s *orderService) Create(c context.Context, order *model.Order) error {
+	order.TenantID = tenant.FromContext(c)
+	order.Meta = nil
+	if order.OrderUID == "" {
+		id, err := s.newUniqueID(c)
 		if err != nil {
 			return err
 		}
+		order.OrderUID = id
+	} else if !s.ids.Valid(order.OrderUID) {
+		return fmt.Errorf("%w: %q", ErrInvalidOrderUID, order.OrderUID)
+	}
+	if err := s.validate(order); err != nil {
+		return err
+	}
+	if order.Version == 0 {
+		order.Version = time.Now().UnixNano()
+	}
+	err := s.repo.UpsertOrder(c, order)
+	if errors.Is(err, repository.ErrUnchanged) {
+		duplicates.Inc()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	observeCreated(order)
+	s.evictAfter(c, order.OrderUID, nil)
+	s.publish(c, events.Event{Type: model.EventOrderUpserted, OrderUID: order.OrderUID, Order: order})
+	return nil
+}
+
+// Delete permanently removes the order from the database, which records a
+// model.EventOrderDeleted event, and drops it from the cache. The cache entry
+// is dropped even if the order was not in the database, which is
+// repository.ErrNotFound.
+func (s *orderService) Delete(c context.Context, id string) error {
+	if err := s.evictAfter(c, id, s.repo.DeleteOrder(c, id)); err != nil {
+		return err
 	}
+	s.publish(c, events.Event{Type: model.EventOrderDeleted, OrderUID: id})
 	return nil
 }
+
+// UpdateStatus moves the order to status and drops it from the cache, so
+// the next Get sees the new status. An unknown status is a *ValidationError,
+// one the stored order cannot move to a *TransitionError. The repository
+// checks the transition against the status it locks for the update, so
+// neither a lagging replica nor an update racing this one can slip past it.
+func (s *orderService) UpdateStatus(c context.Context, id string, status model.OrderStatus) error {
+	if !s.ids.Valid(id) {
+		return fmt.Errorf("%w: %q", ErrInvalidOrderUID, id)
+	}
+	if !status.Valid() {
+		return StatusViolation()
+	}
+	err := s.repo.UpdateStatus(c, id, status)
+	var terr *repository.StatusTransitionError
+	if errors.As(err, &terr) {
+		return &TransitionError{OrderUID: id, From: terr.From, To: terr.To}
+	}
+	if err := s.evictAfter(c, id, err); err != nil {
+		return err
+	}
+	s.publish(c, events.Event{Type: model.EventOrderStatusChanged, OrderUID: id, Status: status})
+	return nil
+}
+
+// Archive soft-deletes the order, so Get and List stop returning it while the
+// data stays recoverable with Restore. The order is dropped from the cache and
+// a model.EventOrderArchived event is recorded and published.
+func (s *orderService) Archive(c context.Context, id string) error {
+	if err := s.evictAfter(c, id, s.repo.ArchiveOrder(c, id)); err != nil {
+		return err
+	}
+	s.publish(c, events.Event{Type: model.EventOrderArchived, OrderUID: id})
+	return nil
+}
+
+// Restore undoes Archive and records and publishes a model.EventOrderRestored
+// event. The order is loaded into the cache on its next Get, so a copy read
+// while another change races the restore is never cached.
+func (s *orderService) Restore(c context.Context, id string) error {
+	if err := s.evictAfter(c, id, s.repo.RestoreOrder(c, id)); err != nil {
+		return err
+	}
+	s.publish(c, events.Event{Type: model.EventOrderRestored, OrderUID: id})
+	return nil
+}
+
+// publish hands e to the WithEvents publisher, on behalf of the tenant c
+// acts for.
+func (s *orderService) publish(c context.Context, e events.Event) {
+	if s.events == nil {
+		return
+	}
+	e.TenantID = tenant.FromContext(c)
+	s.events.Publish(c, e)
+}
+
+// evictAfter drops id from the cache unless the database operation failed.
+func (s *orderService) evictAfter(c context.Context, id string, err error) error {
+	if err == nil || errors.Is(err, repository.ErrNotFound) {
+		s.group.Forget(flightKey(c, id))
+		s.cache.Delete(id)
+	}
+	return err
+}
+
+// AnonymizeCustomer erases the customer's personal delivery data from all
+// their orders and drops those orders from the cache, so no copy of the old
+// data is served afterwards.
+func (s *orderService) AnonymizeCustomer(c context.Context, customerID string) (*model.ErasureResult, error) {
+	uids, err := s.repo.AnonymizeCustomer(c, customerID)
+	// uids erased before a failure are committed in their database
+	for _, id := range uids {
+		s.group.Forget(flightKey(c, id))
+		s.cache.Delete(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model.ErasureResult{CustomerID: customerID, Orders: len(uids)}, nil
+}
+
+// History returns the versions the order had before its current one.
+func (s *orderService) History(c context.Context, id string) ([]model.OrderRevision, error) {
+	return s.repo.OrderHistory(c, id)
+}
+
+// RawPayload returns the payload the current version was stored from.
+func (s *orderService) RawPayload(c context.Context, id string) ([]byte, error) {
+	return s.repo.GetRawPayload(c, id)
+}
+
+func (s *orderService) newUniqueID(c context.Context) (string, error) {
+	for i := 0; i < maxIDAttempts; i++ {
+		id, err := s.ids.NewID()
+		if err != nil {
+			return "", fmt.Errorf("generate order_uid: %w", err)
+		}
+		exists, err := s.repo.OrderExists(c, id)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return id, nil
+		}
+	}
+	return "", ErrUIDCollision
+}
+
+// List returns one page of the tenant's orders matching f. The repository
+// lists the page shallow and hydrate fills it in, so hot orders come from
+// the cache instead of the child tables.
+func (s *orderService) List(c context.Context, f model.OrderFilter, page model.Page) (*model.OrderPage, error) {
+	page.Shallow = true
+	res, err := s.repo.ListOrders(c, f, page)
+	if err != nil {
+		return nil, err
+	}
+	if res.Orders, err = s.hydrate(c, res.Orders); err != nil {
+		return nil, err
+	}
+	s.enrich(c, res.Orders)
+	return res, nil
+}
+
+// Search finds orders by track number or by recipient name, city or address.
+// Hits are filled in like List pages.
+func (s *orderService) Search(c context.Context, query string, page model.Page) (*model.SearchPage, error) {
+	page.Shallow = true
+	res, err := s.repo.SearchOrders(c, query, page)
+	if err != nil {
+		return nil, err
+	}
+	shallow := make([]*model.Order, len(res.Hits))
+	for i, hit := range res.Hits {
+		shallow[i] = hit.Order
+	}
+	orders, err := s.hydrate(c, shallow)
+	if err != nil {
+		return nil, err
+	}
+	s.enrich(c, orders)
+	byUID := make(map[string]*model.Order, len(orders))
+	for _, o := range orders {
+		byUID[o.OrderUID] = o
+	}
+	hits := res.Hits[:0]
+	for _, hit := range res.Hits {
+		if o, ok := byUID[hit.Order.OrderUID]; ok {
+			hit.Order = o
+			hits = append(hits, hit)
+		}
+	}
+	res.Hits = hits
+	return res, nil
+}
+
+// hydrate swaps shallow listed orders for full ones: the cached copy where it
+// matches the listed version and status, otherwise what one GetOrders call
+// loads for the rest. Orders deleted in between are left out. Loaded orders
+// are not cached, so a listing does not push the hot orders out.
+func (s *orderService) hydrate(c context.Context, shallow []*model.Order) ([]*model.Order, error) {
+	full := make(map[string]*model.Order, len(shallow))
+	var misses []string
+	for _, o := range shallow {
+		cached, ok := s.cache.Get(o.OrderUID)
+		if ok && cached.Version == o.Version && cached.Status == o.Status && cached.TenantID == o.TenantID {
+			full[o.OrderUID] = cached
+			continue
+		}
+		misses = append(misses, o.OrderUID)
+	}
+	if len(misses) > 0 {
+		loaded, err := s.repo.GetOrders(c, misses)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range loaded {
+			full[o.OrderUID] = o
+		}
+	}
+	orders := make([]*model.Order, 0, len(shallow))
+	for _, o := range shallow {
+		if f, ok := full[o.OrderUID]; ok {
+			orders = append(orders, f)
+		}
+	}
+	return orders, nil
+}
+
+func (s *orderService) ListByCustomer(c context.Context, customerID string, page model.Page) (*model.OrderPage, error) {
+	res, err := s.repo.GetOrdersByCustomer(c, customerID, page)
+	if err != nil {
+		return nil, err
+	}
+	s.enrich(c, res.Orders)
+	return res, nil
+}
+
+// Stats are computed by the database on every call and bypass the cache.
+
+func (s *orderService) OrdersPerDay(c context.Context, f model.OrderFilter) ([]model.DailyCount, error) {
+	return s.repo.OrdersPerDay(c, f)
+}
+
+func (s *orderService) GoodsByDeliveryService(c context.Context, f model.OrderFilter) ([]model.DeliveryServiceTotal, error) {
+	return s.repo.GoodsByDeliveryService(c, f)
+}
+
+func (s *orderService) TopCustomers(c context.Context, f model.OrderFilter, limit int) ([]model.CustomerTotal, error) {
+	return s.repo.TopCustomers(c, f, limit)
+}
+
+func (s *orderService) HealthCheck(c context.Context) error {
+	return s.repo.HealthCheck(c)
+}
+
+// UpdateCache loads the most recent orders into the cache, as at startup; see
+// WithWarmCount.
+func (s *orderService) UpdateCache(c context.Context) error {
+	_, err := s.Warm(c, model.WarmRecent, s.warmCount)
+	return err
+}
+
+// FlushCache empties the cache and returns how many orders it held. Reads
+// fall through to the repository until UpdateCache or reads refill it; the
+// cache still counts as warm.
+func (s *orderService) FlushCache() int {
+	return s.cache.Clear()
+}
+
+// CacheWarm reports whether UpdateCache or Warm has completed at least once.
+func (s *orderService) CacheWarm() bool {
+	return s.warm.Load()
+}
+
+// SetRefreshAfter changes the WithRefreshAfter age for the lookups that
+// follow; 0 stops reloading cached orders.
+func (s *orderService) SetRefreshAfter(d time.Duration) {
+	s.refreshAfter.Store(int64(d))
+}
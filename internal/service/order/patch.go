@@ -0,0 +1,97 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/events"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
+)
+
+// patchAttempts is how often Patch reads, merges and stores an order that
+// another write keeps changing before it reports repository.ErrStaleVersion.
+const patchAttempts = 3
+
+// Patch merges the fields patch sets into the stored order and returns the
+// order as stored afterwards. A patch that sets nothing, or sets a field to
+// a blank string, is a *ValidationError naming the field; the merged order
+// must then pass the same rules and data checks as Create. The order is
+// stored only if it is still at the version the patch was merged into: when
+// another write got there first, the patch is merged into that write instead,
+// up to patchAttempts times, so racing patches never undo each other.
+//
+// The merged order is stored as a new version with a model.EventOrderUpdated
+// outbox event, published as a model.EventOrderUpserted event and dropped
+// from the cache. RawPayload returns it as patched.
+func (s *orderService) Patch(c context.Context, id string, patch *model.OrderPatch) (*model.Order, error) {
+	if !s.ids.Valid(id) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidOrderUID, id)
+	}
+	if err := patchViolations(patch).err(); err != nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		order, err := s.patchOnce(c, id, patch)
+		if !errors.Is(err, repository.ErrStaleVersion) || attempt == patchAttempts {
+			return order, err
+		}
+	}
+}
+
+// patchOnce is one attempt of Patch.
+func (s *orderService) patchOnce(c context.Context, id string, patch *model.OrderPatch) (*model.Order, error) {
+	// a lagging replica would make every attempt stale
+	current, err := s.repo.GetPrimaryOrder(c, id)
+	if err != nil {
+		return nil, s.evictAfter(c, id, err)
+	}
+
+	order := current.Clone()
+	patch.Apply(order)
+	order.TenantID = tenant.FromContext(c)
+	order.Meta = nil
+	if err := s.validate(order); err != nil {
+		return nil, err
+	}
+	// a clock behind the stored version must not make the patch stale
+	order.Version = max(time.Now().UnixNano(), current.Version+1)
+	if order.Raw, err = json.Marshal(order); err != nil {
+		return nil, fmt.Errorf("encode patched order: %w", err)
+	}
+
+	err = s.repo.ReplaceOrder(c, order, current.Version)
+	if errors.Is(err, repository.ErrUnchanged) {
+		return s.enrichOne(c, current), nil
+	}
+	if err != nil {
+		return nil, s.evictAfter(c, id, err)
+	}
+	patched.Inc()
+	s.evictAfter(c, id, nil)
+	s.publish(c, events.Event{Type: model.EventOrderUpserted, OrderUID: id, Order: order})
+	return s.enrichOne(c, order), nil
+}
+
+// patchViolations checks patch on its own, before it is merged.
+func patchViolations(patch *model.OrderPatch) violations {
+	var vs violations
+	if patch == nil || patch.Empty() {
+		vs.add("", RuleNonEmpty, "patch must set at least one field")
+		return vs
+	}
+	fields := patch.Strings()
+	for _, path := range slices.Sorted(maps.Keys(fields)) {
+		if strings.TrimSpace(*fields[path]) == "" {
+			vs.add(path, RuleNonEmpty, path+" must not be blank")
+		}
+	}
+	return vs
+}
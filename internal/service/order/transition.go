@@ -0,0 +1,25 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// ErrInvalidTransition matches every *TransitionError via errors.Is.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// TransitionError is returned by UpdateStatus for a status the order cannot
+// move to from the one it has (see model.OrderStatus.CanBecome). The HTTP API
+// answers 409, the consumer DLQs the message as invalid_transition.
+type TransitionError struct {
+	OrderUID string
+	From, To model.OrderStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: order %s cannot go from %s to %s", ErrInvalidTransition, e.OrderUID, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool { return target == ErrInvalidTransition }
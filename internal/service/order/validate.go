@@ -0,0 +1,205 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// ErrValidation matches every *ValidationError via errors.Is.
+var ErrValidation = errors.New("validation failed")
+
+// Rules name what a Violation broke, for clients that react per rule rather
+// than parse messages.
+const (
+	RuleRequired = "required"
+	RuleNonEmpty = "non_empty"
+	RuleMin      = "min"
+	RuleOneOf    = "one_of"
+	RuleSum      = "sum"
+	RuleISO4217  = "iso4217"
+	RuleAllowed  = "allowed"
+)
+
+// Violation is one broken rule: Field is the JSON path of the offending
+// value (e.g. "items[2].price"), Rule one of the Rule constants and Message
+// the human-readable problem.
+type Violation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every rule an order violates. Transports map it to
+// their own failure path: the consumer DLQs the message as schema_validation,
+// the HTTP API answers 400 with the violations as details.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(e.Messages(), "; "))
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+// Messages returns the message of every violation.
+func (e *ValidationError) Messages() []string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Message
+	}
+	return msgs
+}
+
+// violations collects the Violations of one order.
+type violations []Violation
+
+func (vs *violations) add(field, rule, msg string) {
+	*vs = append(*vs, Violation{Field: field, Rule: rule, Message: msg})
+}
+
+func (vs *violations) required(field, value string) {
+	if value == "" {
+		vs.add(field, RuleRequired, field+" is required")
+	}
+}
+
+func (vs violations) err() error {
+	if len(vs) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: vs}
+}
+
+// Validator checks an order against business rules. It is run by Create, so
+// every ingestion path (broker, HTTP, ...) enforces the same rules.
+type Validator interface {
+	Validate(o *model.Order) error
+}
+
+// ValidatorFunc adapts a function to Validator.
+type ValidatorFunc func(o *model.Order) error
+
+func (f ValidatorFunc) Validate(o *model.Order) error { return f(o) }
+
+const (
+	ValidatorDefault = "default"
+	ValidatorStrict  = "strict"
+)
+
+// Validators are the named rule sets selectable via config. When rules change,
+// add the new set here, run it as the ingest canary next to the active one,
+// and promote it once disagreements are understood.
+var Validators = map[string]Validator{
+	ValidatorDefault: ValidatorFunc(ValidateOrder),
+	ValidatorStrict:  ValidatorFunc(ValidateOrderStrict),
+}
+
+// LookupValidator returns the named rule set.
+func LookupValidator(name string) (Validator, error) {
+	v, ok := Validators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown rule set %q", name)
+	}
+	return v, nil
+}
+
+// NewValidator returns the checks Create runs on an order, configured by the
+// WithValidator, WithConsistency and WithCodeChecks options among opts, for
+// paths that store orders without going through the service.
+func NewValidator(opts ...Option) Validator {
+	return ValidatorFunc(newOrderService(nil, nil, opts).validate)
+}
+
+// validate normalizes o as WithCodeChecks asks, then runs the rule set and
+// check on it.
+func (s *orderService) validate(o *model.Order) error {
+	if s.codes != ConsistencyOff {
+		normalizeCodes(o)
+	}
+	if err := s.valid.Validate(o); err != nil {
+		return err
+	}
+	return s.check(o)
+}
+
+// ValidateOrder performs structural validation of an Order and returns a
+// *ValidationError listing every problem found.
+// Keep this function free of stateful checks—only validate what is intrinsic
+// to the order (e.g., required fields, non-zero timestamps, basic ranges).
+func ValidateOrder(o *model.Order) error {
+	if o == nil {
+		return &ValidationError{Violations: []Violation{{Rule: RuleRequired, Message: "order is nil"}}}
+	}
+	return orderViolations(o).err()
+}
+
+func orderViolations(o *model.Order) violations {
+	var vs violations
+
+	// Required non-empty identifiers/strings.
+	vs.required("order_uid", o.OrderUID)
+	vs.required("track_number", o.TrackNumber)
+	vs.required("entry", o.Entry)
+	vs.required("customer_id", o.CustomerID)
+	vs.required("delivery_service", o.DeliveryService)
+	vs.required("shardkey", o.ShardKey)
+	vs.required("oof_shard", o.OofShard)
+
+	// Items must be present (empty order is not actionable).
+	if len(o.Items) == 0 {
+		vs.add("items", RuleNonEmpty, "items must be non-empty")
+	}
+
+	// Timestamp should be set (zero time usually indicates producer bug).
+	if o.DateCreated.IsZero() {
+		vs.add("date_created", RuleRequired, "date_created must be set")
+	}
+
+	// Optional sanity: SmID should not be negative.
+	if o.SmID < 0 {
+		vs.add("sm_id", RuleMin, "sm_id must be >= 0")
+	}
+	if o.Status != "" && !o.Status.Valid() {
+		vs.add("status", RuleOneOf, statusProblem)
+	}
+	return vs
+}
+
+// statusProblem lists the accepted statuses for error messages.
+var statusProblem = func() string {
+	names := make([]string, len(model.Statuses))
+	for i, s := range model.Statuses {
+		names[i] = string(s)
+	}
+	return "status must be one of " + strings.Join(names, ", ")
+}()
+
+// StatusViolation is the *ValidationError for an unknown order status.
+func StatusViolation() error {
+	return &ValidationError{Violations: []Violation{{Field: "status", Rule: RuleOneOf, Message: statusProblem}}}
+}
+
+// ValidateOrderStrict extends the default rules with the delivery and payment
+// fields every downstream consumer relies on.
+func ValidateOrderStrict(o *model.Order) error {
+	if o == nil {
+		return ValidateOrder(o)
+	}
+	vs := orderViolations(o)
+
+	vs.required("delivery.name", o.Delivery.Name)
+	vs.required("delivery.phone", o.Delivery.Phone)
+	vs.required("delivery.address", o.Delivery.Address)
+	vs.required("payment.transaction", o.Payment.Transaction)
+	vs.required("payment.currency", o.Payment.Currency)
+	for i, it := range o.Items {
+		if it.Price < 0 || it.TotalPrice < 0 {
+			vs.add(fmt.Sprintf("items[%d].price", i), RuleMin, fmt.Sprintf("items[%d]: prices must be >= 0", i))
+		}
+	}
+	return vs.err()
+}
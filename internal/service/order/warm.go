@@ -0,0 +1,45 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// Warm loads up to n orders picked by strategy into the cache and returns
+// how many it cached. Popular orders that no longer exist are skipped. An
+// order the cache refuses does not stop the rest: the refusals are counted in
+// order_cache_warm_failures_total and returned joined, one error per order.
+func (s *orderService) Warm(c context.Context, strategy model.WarmStrategy, n int) (int, error) {
+	var (
+		orders []*model.Order
+		err    error
+	)
+	switch strategy {
+	case model.WarmRecent:
+		orders, err = s.repo.GetRecent(c, n)
+	case model.WarmPopular:
+		if ids := s.cache.Popular(n); len(ids) > 0 {
+			orders, err = s.repo.GetOrders(c, ids)
+		}
+	default:
+		return 0, fmt.Errorf("unknown warm strategy %q", strategy)
+	}
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for _, order := range orders {
+		if err := s.cache.Set(order.OrderUID, order); err != nil {
+			warmFailures.Inc()
+			errs = append(errs, fmt.Errorf("cache order %s: %w", order.OrderUID, err))
+		}
+	}
+	cached := len(orders) - len(errs)
+	if cached > 0 || len(errs) == 0 {
+		s.warm.Store(true)
+	}
+	return cached, errors.Join(errs...)
+}
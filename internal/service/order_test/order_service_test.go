@@ -3,15 +3,39 @@ package order_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/db/repository"
+	"github.com/merkulovlad/wbtech-go/internal/events"
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
 	"github.com/merkulovlad/wbtech-go/internal/mocks"
 	"github.com/merkulovlad/wbtech-go/internal/model"
 	"github.com/merkulovlad/wbtech-go/internal/service/order"
+	"github.com/merkulovlad/wbtech-go/internal/tenant"
 	"github.com/stretchr/testify/require"
 )
 
+// validOrder passes ValidateOrder.
+func validOrder(uid string) *model.Order {
+	return &model.Order{
+		OrderUID:        uid,
+		TrackNumber:     "TRK",
+		Entry:           "WBIL",
+		CustomerID:      "c1",
+		DeliveryService: "meest",
+		ShardKey:        "9",
+		OofShard:        "1",
+		DateCreated:     time.Now(),
+		Items:           []model.Item{{ChrtID: 1}},
+	}
+}
+
 func TestOrderService_GetOrder_CacheMissThenSet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -77,6 +101,28 @@ func TestOrderService_GetOrder_CacheHit(t *testing.T) {
 	require.Equal(t, expected, got)
 }
 
+func TestOrderService_GetOrder_HidesOtherTenants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	cached := &model.Order{OrderUID: "123", TenantID: "shop-1"}
+	mockCache.EXPECT().Get("123").Return(cached, true).AnyTimes()
+	mockRepo.EXPECT().GetOrder(gomock.Any(), gomock.Any()).Times(0)
+
+	got, err := svc.Get(tenant.WithID(context.Background(), "shop-1"), "123")
+	require.NoError(t, err)
+	require.Equal(t, cached, got)
+
+	_, err = svc.Get(tenant.WithID(context.Background(), "shop-2"), "123")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = svc.Get(context.Background(), "123")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
 func TestOrderService_Create_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -86,15 +132,21 @@ func TestOrderService_Create_Success(t *testing.T) {
 	svc := order.NewOrderService(mockRepo, mockCache)
 
 	ctx := context.Background()
-	in := &model.Order{OrderUID: "o-1"}
+	in := validOrder("o-1")
 
-	mockRepo.EXPECT().
-		UpsertOrder(gomock.Any(), in).
-		Return(nil).
-		Times(1)
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			UpsertOrder(gomock.Any(), in).
+			Return(nil).
+			Times(1),
+		// the next Get loads the stored order rather than a cached older one
+		mockCache.EXPECT().Delete("o-1"),
+	)
 
 	err := svc.Create(ctx, in)
 	require.NoError(t, err)
+	require.NotZero(t, in.Version, "unversioned orders get the request time")
+	require.Equal(t, tenant.Default, in.TenantID)
 }
 
 func TestOrderService_Create_Error(t *testing.T) {
@@ -106,7 +158,7 @@ func TestOrderService_Create_Error(t *testing.T) {
 	svc := order.NewOrderService(mockRepo, mockCache)
 
 	ctx := context.Background()
-	in := &model.Order{OrderUID: "o-1"}
+	in := validOrder("o-1")
 	wantErr := errors.New("upsert failed")
 
 	mockRepo.EXPECT().
@@ -146,6 +198,723 @@ func TestOrderService_UpdateCache_Success(t *testing.T) {
 		Return(nil).
 		Times(1)
 
+	require.False(t, svc.CacheWarm())
 	err := svc.UpdateCache(ctx)
 	require.NoError(t, err)
+	require.True(t, svc.CacheWarm())
+}
+
+func TestOrderService_UpdateCache_ContinuesPastSetErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	o1 := &model.Order{OrderUID: "id-1"}
+	o2 := &model.Order{OrderUID: "id-2"}
+	o3 := &model.Order{OrderUID: "id-3"}
+	mockRepo.EXPECT().GetRecent(gomock.Any(), 10).Return([]*model.Order{o1, o2, o3}, nil)
+
+	full := errors.New("cache full")
+	mockCache.EXPECT().Set("id-1", o1).Return(full)
+	mockCache.EXPECT().Set("id-2", o2).Return(nil)
+	mockCache.EXPECT().Set("id-3", o3).Return(full)
+
+	n, err := svc.Warm(context.Background(), model.WarmRecent, 10)
+	require.ErrorIs(t, err, full)
+	require.ErrorContains(t, err, "id-1")
+	require.ErrorContains(t, err, "id-3")
+	require.Equal(t, 1, n)
+	require.True(t, svc.CacheWarm())
+}
+
+func TestOrderService_UpdateCache_UsesWarmCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache, order.WithWarmCount(5000))
+
+	mockRepo.EXPECT().GetRecent(gomock.Any(), 5000).Return(nil, nil)
+	require.NoError(t, svc.UpdateCache(context.Background()))
+}
+
+func TestOrderService_Warm_Popular(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	hot := &model.Order{OrderUID: "hot"}
+	mockCache.EXPECT().Popular(3).Return([]string{"hot", "gone"})
+	// "gone" was deleted since it was looked up
+	mockRepo.EXPECT().GetOrders(gomock.Any(), []string{"hot", "gone"}).Return([]*model.Order{hot}, nil)
+	mockCache.EXPECT().Set("hot", hot).Return(nil)
+
+	n, err := svc.Warm(context.Background(), model.WarmPopular, 3)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.True(t, svc.CacheWarm())
+
+	_, err = svc.Warm(context.Background(), "lru", 3)
+	require.Error(t, err)
+}
+
+type seqIDs struct {
+	ids []string
+}
+
+func (g *seqIDs) NewID() (string, error) {
+	id := g.ids[0]
+	g.ids = g.ids[1:]
+	return id, nil
+}
+
+func (g *seqIDs) Valid(id string) bool { return true }
+
+func TestOrderService_Create_GeneratesUIDSkippingCollisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache, order.WithIDGenerator(&seqIDs{ids: []string{"taken", "free"}}))
+
+	in := validOrder("")
+	mockRepo.EXPECT().OrderExists(gomock.Any(), "taken").Return(true, nil)
+	mockRepo.EXPECT().OrderExists(gomock.Any(), "free").Return(false, nil)
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), in).Return(nil)
+	mockCache.EXPECT().Delete("free")
+
+	require.NoError(t, svc.Create(context.Background(), in))
+	require.Equal(t, "free", in.OrderUID)
+}
+
+func TestOrderService_Create_RejectsInvalidUID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), gomock.Any()).Times(0)
+
+	err := svc.Create(context.Background(), &model.Order{OrderUID: "'; DROP TABLE orders; --"})
+	require.ErrorIs(t, err, order.ErrInvalidOrderUID)
+}
+
+func TestOrderService_Delete_InvalidatesCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	gomock.InOrder(
+		mockRepo.EXPECT().DeleteOrder(gomock.Any(), "b1").Return(nil),
+		mockCache.EXPECT().Delete("b1"),
+	)
+	require.NoError(t, svc.Delete(context.Background(), "b1"))
+
+	// a missing order may still be cached, e.g. after a delete on another shard
+	gomock.InOrder(
+		mockRepo.EXPECT().DeleteOrder(gomock.Any(), "gone").Return(repository.ErrNotFound),
+		mockCache.EXPECT().Delete("gone"),
+	)
+	require.ErrorIs(t, svc.Delete(context.Background(), "gone"), repository.ErrNotFound)
+
+	dbErr := errors.New("db down")
+	mockRepo.EXPECT().DeleteOrder(gomock.Any(), "b2").Return(dbErr)
+	require.ErrorIs(t, svc.Delete(context.Background(), "b2"), dbErr)
+}
+
+func TestOrderService_ArchiveRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	gomock.InOrder(
+		mockRepo.EXPECT().ArchiveOrder(gomock.Any(), "b1").Return(nil),
+		mockCache.EXPECT().Delete("b1"),
+	)
+	require.NoError(t, svc.Archive(context.Background(), "b1"))
+
+	// restoring never writes a possibly stale copy into the cache
+	mockRepo.EXPECT().RestoreOrder(gomock.Any(), "b1").Return(nil)
+	mockCache.EXPECT().Delete("b1")
+	mockCache.EXPECT().Set(gomock.Any(), gomock.Any()).Times(0)
+	require.NoError(t, svc.Restore(context.Background(), "b1"))
+
+	mockRepo.EXPECT().RestoreOrder(gomock.Any(), "b2").Return(repository.ErrNotFound)
+	mockCache.EXPECT().Delete("b2")
+	require.ErrorIs(t, svc.Restore(context.Background(), "b2"), repository.ErrNotFound)
+}
+
+func TestOrderService_Create_Validates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), gomock.Any()).Times(0)
+
+	in := validOrder("o-1")
+	in.Items = nil
+	err := order.NewOrderService(mockRepo, mockCache).Create(context.Background(), in)
+	require.ErrorIs(t, err, order.ErrValidation)
+	var verr *order.ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []order.Violation{{Field: "items", Rule: order.RuleNonEmpty, Message: "items must be non-empty"}}, verr.Violations)
+
+	// a stricter rule set rejects what the default accepts
+	strict := order.NewOrderService(mockRepo, mockCache, order.WithValidator(order.Validators[order.ValidatorStrict]))
+	err = strict.Create(context.Background(), validOrder("o-2"))
+	require.ErrorAs(t, err, &verr)
+	require.NotEmpty(t, verr.Violations)
+	for _, v := range verr.Violations {
+		require.NotEmpty(t, v.Field, v.Message)
+		require.NotEmpty(t, v.Rule, v.Message)
+	}
+}
+
+func TestOrderService_Create_ChecksPaymentConsistency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	inconsistent := func(uid string) *model.Order {
+		o := validOrder(uid)
+		o.Items = []model.Item{{ChrtID: 1, TotalPrice: 300}, {ChrtID: 2, TotalPrice: 17}}
+		o.Payment = model.Payment{Amount: 1800, GoodsTotal: 317, DeliveryCost: 1500}
+		o.Warnings = []string{"from the payload"}
+		return o
+	}
+	want := []string{"payment.amount is 1800, but goods_total + delivery_cost + custom_fee is 1817"}
+
+	warn := order.NewOrderService(mockRepo, mockCache, order.WithConsistency(order.ConsistencyWarn, 0))
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+		require.Equal(t, want, o.Warnings)
+		return nil
+	})
+	mockCache.EXPECT().Delete("o-1")
+	require.NoError(t, warn.Create(context.Background(), inconsistent("o-1")))
+
+	// within tolerance nothing is flagged, and payload warnings are dropped
+	lenient := order.NewOrderService(mockRepo, mockCache, order.WithConsistency(order.ConsistencyWarn, 20))
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+		require.Empty(t, o.Warnings)
+		return nil
+	})
+	mockCache.EXPECT().Delete("o-2")
+	require.NoError(t, lenient.Create(context.Background(), inconsistent("o-2")))
+
+	reject := order.NewOrderService(mockRepo, mockCache, order.WithConsistency(order.ConsistencyReject, 0))
+	in := inconsistent("o-3")
+	in.Payment.GoodsTotal = 300
+	err := reject.Create(context.Background(), in)
+	var verr *order.ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []order.Violation{
+		{Field: "payment.goods_total", Rule: order.RuleSum, Message: "payment.goods_total is 300, but the items total 317"},
+	}, verr.Violations)
+}
+
+func TestOrderService_Create_NormalizesAndChecksCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	withCodes := func(uid, currency, locale string) *model.Order {
+		o := validOrder(uid)
+		o.Payment.Currency, o.Locale = currency, locale
+		return o
+	}
+
+	warn := order.NewOrderService(mockRepo, mockCache, order.WithCodeChecks(order.ConsistencyWarn, []string{"en", "RU"}))
+	var stored []*model.Order
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order) error {
+		stored = append(stored, o)
+		return nil
+	}).Times(2)
+	mockCache.EXPECT().Delete(gomock.Any()).Times(2)
+	require.NoError(t, warn.Create(context.Background(), withCodes("o-1", " usd", "Ru")))
+	require.NoError(t, warn.Create(context.Background(), withCodes("o-2", "US$", "kz")))
+	require.Equal(t, "USD", stored[0].Payment.Currency)
+	require.Equal(t, "ru", stored[0].Locale)
+	require.Empty(t, stored[0].Warnings)
+	require.Equal(t, []string{
+		`payment.currency "US$" is not an ISO 4217 code`,
+		`locale "kz" is not one of the configured locales`,
+	}, stored[1].Warnings)
+
+	reject := order.NewOrderService(mockRepo, mockCache, order.WithCodeChecks(order.ConsistencyReject, nil))
+	err := reject.Create(context.Background(), withCodes("o-3", "rubles", "kz"))
+	var verr *order.ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []order.Violation{
+		{Field: "payment.currency", Rule: order.RuleISO4217, Message: `payment.currency "RUBLES" is not an ISO 4217 code`},
+	}, verr.Violations)
+}
+
+func TestNewValidator_RunsTheConfiguredChecks(t *testing.T) {
+	strict, err := order.LookupValidator(order.ValidatorStrict)
+	require.NoError(t, err)
+	valid := order.NewValidator(order.WithValidator(strict), order.WithCodeChecks(order.ConsistencyReject, nil))
+
+	o := validOrder("o-1")
+	o.Delivery = model.Delivery{Name: "Test", Phone: "+9720000000", Address: "Ploshad Mira 15"}
+	o.Payment.Transaction, o.Payment.Currency = "o-1", " usd"
+	require.NoError(t, valid.Validate(o))
+	require.Equal(t, "USD", o.Payment.Currency)
+
+	o.Payment.Currency = "rubles"
+	var verr *order.ValidationError
+	require.ErrorAs(t, valid.Validate(o), &verr)
+	require.Equal(t, "payment.currency", verr.Violations[0].Field)
+
+	o.Delivery.Phone = ""
+	require.ErrorAs(t, valid.Validate(o), &verr)
+	require.Equal(t, "delivery.phone", verr.Violations[0].Field)
+}
+
+func TestOrderService_UpdateStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	gomock.InOrder(
+		mockRepo.EXPECT().UpdateStatus(gomock.Any(), "b1", model.StatusPaid).Return(nil),
+		mockCache.EXPECT().Delete("b1"),
+	)
+	require.NoError(t, svc.UpdateStatus(context.Background(), "b1", model.StatusPaid))
+
+	// the repository rejects a transition from the status it locked
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), "b2", model.StatusDelivered).
+		Return(&repository.StatusTransitionError{OrderUID: "b2", From: model.StatusPaid, To: model.StatusDelivered})
+	err := svc.UpdateStatus(context.Background(), "b2", model.StatusDelivered)
+	require.ErrorIs(t, err, order.ErrInvalidTransition)
+	var terr *order.TransitionError
+	require.ErrorAs(t, err, &terr)
+	require.Equal(t, &order.TransitionError{OrderUID: "b2", From: model.StatusPaid, To: model.StatusDelivered}, terr)
+
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), "gone", model.StatusPaid).Return(repository.ErrNotFound)
+	mockCache.EXPECT().Delete("gone")
+	require.ErrorIs(t, svc.UpdateStatus(context.Background(), "gone", model.StatusPaid), repository.ErrNotFound)
+
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	require.ErrorIs(t, svc.UpdateStatus(context.Background(), "b1", "lost"), order.ErrValidation)
+	require.ErrorIs(t, svc.UpdateStatus(context.Background(), "'; --", model.StatusPaid), order.ErrInvalidOrderUID)
+
+	// payloads may name an initial status, but only a known one
+	in := validOrder("o-1")
+	in.Status = "lost"
+	require.ErrorIs(t, svc.Create(context.Background(), in), order.ErrValidation)
+}
+
+func TestOrderService_Patch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	stored := validOrder("p1")
+	stored.Delivery = model.Delivery{Name: "Test", City: "Kiryat Mozkin", Address: "Ploshad Mira 15"}
+	stored.Version = time.Now().Add(time.Hour).UnixNano()
+	var saved *model.Order
+	gomock.InOrder(
+		mockRepo.EXPECT().GetPrimaryOrder(gomock.Any(), "p1").Return(stored, nil),
+		mockRepo.EXPECT().ReplaceOrder(gomock.Any(), gomock.Any(), stored.Version).DoAndReturn(func(_ context.Context, o *model.Order, _ int64) error {
+			saved = o
+			return nil
+		}),
+		mockCache.EXPECT().Delete("p1"),
+	)
+	address := "Ploshad Mira 16"
+	got, err := svc.Patch(context.Background(), "p1", &model.OrderPatch{Delivery: &model.DeliveryPatch{Address: &address}})
+	require.NoError(t, err)
+	require.Equal(t, "Ploshad Mira 16", saved.Delivery.Address)
+	require.Equal(t, "Kiryat Mozkin", saved.Delivery.City)
+	require.Equal(t, "TRK", saved.TrackNumber)
+	require.Greater(t, saved.Version, stored.Version)
+	require.NotEmpty(t, saved.Raw)
+	require.Equal(t, "Ploshad Mira 15", stored.Delivery.Address, "the stored order is not changed in place")
+	require.Equal(t, saved.Delivery, got.Delivery)
+
+	// a blank or empty patch is rejected before anything is read
+	blank := " "
+	_, err = svc.Patch(context.Background(), "p1", &model.OrderPatch{TrackNumber: &blank, Delivery: &model.DeliveryPatch{City: &blank}})
+	var verr *order.ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []string{"delivery.city", "track_number"}, []string{verr.Violations[0].Field, verr.Violations[1].Field})
+	_, err = svc.Patch(context.Background(), "p1", &model.OrderPatch{})
+	require.ErrorIs(t, err, order.ErrValidation)
+
+	// the merged order must still pass the rules
+	mockRepo.EXPECT().GetPrimaryOrder(gomock.Any(), "p1").Return(stored, nil)
+	negative := -1
+	_, err = svc.Patch(context.Background(), "p1", &model.OrderPatch{SmID: &negative})
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, "sm_id", verr.Violations[0].Field)
+
+	mockRepo.EXPECT().GetPrimaryOrder(gomock.Any(), "gone").Return(nil, repository.ErrNotFound)
+	mockCache.EXPECT().Delete("gone")
+	_, err = svc.Patch(context.Background(), "gone", &model.OrderPatch{Delivery: &model.DeliveryPatch{Address: &address}})
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestOrderService_Patch_RacingPatchesKeepEachOther(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	mockCache.EXPECT().Delete("p1").AnyTimes()
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	// the stored order, replaced like the database does: only at the version read
+	var mu sync.Mutex
+	stored := validOrder("p1")
+	stored.Version = 1
+	mockRepo.EXPECT().GetPrimaryOrder(gomock.Any(), "p1").DoAndReturn(func(context.Context, string) (*model.Order, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return stored.Clone(), nil
+	}).AnyTimes()
+
+	// both patches read version 1 before either is stored
+	var read sync.WaitGroup
+	read.Add(2)
+	var calls atomic.Int32
+	mockRepo.EXPECT().ReplaceOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *model.Order, current int64) error {
+		if calls.Add(1) <= 2 {
+			read.Done()
+			read.Wait()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if stored.Version != current {
+			return fmt.Errorf("%w: p1", repository.ErrStaleVersion)
+		}
+		stored = o.Clone()
+		return nil
+	}).Times(3)
+
+	track, city := "TRK-2", "Kazan"
+	errs := make(chan error, 2)
+	for _, p := range []*model.OrderPatch{{TrackNumber: &track}, {Delivery: &model.DeliveryPatch{City: &city}}} {
+		go func() {
+			_, err := svc.Patch(context.Background(), "p1", p)
+			errs <- err
+		}()
+	}
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	require.Equal(t, "TRK-2", stored.TrackNumber)
+	require.Equal(t, "Kazan", stored.Delivery.City)
+
+	// an order that keeps changing is a conflict after patchAttempts tries
+	mockRepo.EXPECT().ReplaceOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("%w: p1", repository.ErrStaleVersion)).Times(3)
+	_, err := svc.Patch(context.Background(), "p1", &model.OrderPatch{TrackNumber: &city})
+	require.ErrorIs(t, err, repository.ErrStaleVersion)
+}
+
+func TestOrderService_AnonymizeCustomer_EvictsErasedOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	mockRepo.EXPECT().AnonymizeCustomer(gomock.Any(), "c1").Return([]string{"a", "b"}, nil)
+	mockCache.EXPECT().Delete("a")
+	mockCache.EXPECT().Delete("b")
+	res, err := svc.AnonymizeCustomer(context.Background(), "c1")
+	require.NoError(t, err)
+	require.Equal(t, &model.ErasureResult{CustomerID: "c1", Orders: 2}, res)
+
+	// orders erased in the shards that succeeded are evicted as well
+	dbErr := errors.New("shard 2 down")
+	mockRepo.EXPECT().AnonymizeCustomer(gomock.Any(), "c2").Return([]string{"c"}, dbErr)
+	mockCache.EXPECT().Delete("c")
+	_, err = svc.AnonymizeCustomer(context.Background(), "c2")
+	require.ErrorIs(t, err, dbErr)
+}
+
+func TestOrderService_GetManyUsesOneRepositoryCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	a, c := &model.Order{OrderUID: "a"}, &model.Order{OrderUID: "c"}
+	mockCache.EXPECT().Get("a").Return(a, true)
+	mockCache.EXPECT().Get("b").Return(nil, false)
+	mockCache.EXPECT().Get("c").Return(nil, false)
+	mockRepo.EXPECT().GetOrders(gomock.Any(), []string{"c", "b"}).Return([]*model.Order{c}, nil).Times(1)
+	mockCache.EXPECT().Set("c", c).Return(nil)
+	got, err := svc.GetMany(context.Background(), []string{"c", "a", "b", "a"})
+	require.NoError(t, err)
+	require.Equal(t, []*model.Order{c, a}, got)
+
+	dbErr := errors.New("db down")
+	mockCache.EXPECT().Get("d").Return(nil, false)
+	mockRepo.EXPECT().GetOrders(gomock.Any(), []string{"d"}).Return(nil, dbErr)
+	_, err = svc.GetMany(context.Background(), []string{"d"})
+	require.ErrorIs(t, err, dbErr)
+}
+
+func TestOrderService_GetManySharesLoadsWithGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	mockCache.EXPECT().Get("b").Return(nil, false).AnyTimes()
+	mockCache.EXPECT().Set("b", gomock.Any()).Return(nil).AnyTimes()
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	fromGet := &model.Order{OrderUID: "b"}
+	asked, done := make(chan struct{}), make(chan struct{})
+	var (
+		many    []*model.Order
+		manyErr error
+	)
+	// GetMany has joined the flight of Get once it asks the repository
+	mockRepo.EXPECT().GetOrders(gomock.Any(), []string{"b"}).DoAndReturn(func(context.Context, []string) ([]*model.Order, error) {
+		close(asked)
+		return []*model.Order{{OrderUID: "b"}}, nil
+	})
+	mockRepo.EXPECT().GetOrder(gomock.Any(), "b").DoAndReturn(func(context.Context, string) (*model.Order, error) {
+		go func() {
+			defer close(done)
+			many, manyErr = svc.GetMany(context.Background(), []string{"b"})
+		}()
+		<-asked
+		return fromGet, nil
+	})
+	got, err := svc.Get(context.Background(), "b")
+	require.NoError(t, err)
+	require.Same(t, fromGet, got)
+	<-done
+	require.NoError(t, manyErr)
+	require.Len(t, many, 1)
+	require.Same(t, fromGet, many[0])
+}
+
+func TestOrderService_PublishesStoredChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	var got []string
+	bus := events.NewBus()
+	bus.Subscribe(func(_ context.Context, e events.Event) {
+		got = append(got, e.Type+" "+e.OrderUID+" "+e.TenantID)
+	})
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache, order.WithEvents(bus))
+
+	stored, stale, same := validOrder("o-1"), validOrder("o-2"), validOrder("o-3")
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), stored).Return(nil)
+	mockCache.EXPECT().Delete("o-1").Times(3)
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), stale).Return(repository.ErrStaleVersion)
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), same).Return(fmt.Errorf("%w: o-3", repository.ErrUnchanged))
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), "o-1", model.StatusPaid).Return(nil)
+	mockRepo.EXPECT().DeleteOrder(gomock.Any(), "o-1").Return(nil)
+	mockRepo.EXPECT().DeleteOrder(gomock.Any(), "o-2").Return(errors.New("db down"))
+	mockRepo.EXPECT().ArchiveOrder(gomock.Any(), "o-3").Return(nil)
+	mockRepo.EXPECT().RestoreOrder(gomock.Any(), "o-3").Return(nil)
+	mockRepo.EXPECT().RestoreOrder(gomock.Any(), "o-4").Return(repository.ErrNotFound)
+	mockCache.EXPECT().Delete("o-3").Times(2)
+	mockCache.EXPECT().Delete("o-4")
+
+	require.NoError(t, svc.Create(context.Background(), stored))
+	require.ErrorIs(t, svc.Create(context.Background(), stale), repository.ErrStaleVersion)
+	// a duplicate is accepted but is not news
+	require.NoError(t, svc.Create(context.Background(), same))
+	require.NoError(t, svc.UpdateStatus(context.Background(), "o-1", model.StatusPaid))
+	require.NoError(t, svc.Delete(context.Background(), "o-1"))
+	require.Error(t, svc.Delete(context.Background(), "o-2"))
+	require.NoError(t, svc.Archive(context.Background(), "o-3"))
+	require.NoError(t, svc.Restore(context.Background(), "o-3"))
+	require.Error(t, svc.Restore(context.Background(), "o-4"))
+
+	require.Equal(t, []string{
+		"order.upserted o-1 default",
+		"order.status_changed o-1 default",
+		"order.deleted o-1 default",
+		"order.archived o-3 default",
+		"order.restored o-3 default",
+	}, got)
+}
+
+func TestOrderService_Create_CountsBusinessMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	ord := validOrder("m-1")
+	ord.DeliveryService, ord.Locale = "metrics-test", "kz"
+	ord.Payment.GoodsTotal = 700
+	mockRepo.EXPECT().UpsertOrder(gomock.Any(), ord).Return(nil)
+	mockCache.EXPECT().Delete("m-1")
+	require.NoError(t, svc.Create(context.Background(), ord))
+
+	var b strings.Builder
+	_, err := metrics.Default.WriteTo(&b)
+	require.NoError(t, err)
+	out := b.String()
+	require.Contains(t, out, `orders_created_total{delivery_service="metrics-test",locale="kz"} 1`+"\n")
+	require.Contains(t, out, `order_goods_total_bucket{le="1000"}`)
+	require.Contains(t, out, `order_items_bucket{le="1"}`)
+	require.Contains(t, out, "orders_duplicate_total")
+}
+
+func TestOrderService_Get_ServesStaleAndRefreshesInBackground(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache, order.WithRefreshAfter(time.Minute))
+
+	cached := &model.Order{OrderUID: "b1", TrackNumber: "OLD"}
+	fresh := &model.Order{OrderUID: "b1", TrackNumber: "NEW"}
+	mockCache.EXPECT().Lookup("b1").Return(cached, time.Now(), true)
+	got, err := svc.Get(context.Background(), "b1")
+	require.NoError(t, err)
+	require.Same(t, cached, got)
+
+	// a stale copy is returned at once, a fresh one lands in the cache after
+	mockCache.EXPECT().Lookup("b1").Return(cached, time.Now().Add(-2*time.Minute), true)
+	release := make(chan struct{})
+	mockRepo.EXPECT().GetOrder(gomock.Any(), "b1").DoAndReturn(func(ctx context.Context, _ string) (*model.Order, error) {
+		<-release
+		return fresh, ctx.Err()
+	})
+	stored := make(chan *model.Order)
+	mockCache.EXPECT().Set("b1", fresh).DoAndReturn(func(_ string, o *model.Order) error {
+		stored <- o
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	got, err = svc.Get(ctx, "b1")
+	cancel()
+	require.NoError(t, err)
+	require.Same(t, cached, got)
+	close(release)
+	require.Same(t, fresh, <-stored)
+}
+
+func TestOrderService_List_HydratesHotOrdersFromCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	svc := order.NewOrderService(mockRepo, mockCache)
+
+	hot := &model.Order{OrderUID: "hot", Version: 2, Items: []model.Item{{ChrtID: 1}}}
+	outdated := &model.Order{OrderUID: "outdated", Version: 1}
+	shallow := []*model.Order{{OrderUID: "hot", Version: 2}, {OrderUID: "outdated", Version: 2}, {OrderUID: "cold"}, {OrderUID: "gone"}}
+	loaded := []*model.Order{{OrderUID: "outdated", Version: 2, Items: []model.Item{{ChrtID: 2}}}, {OrderUID: "cold", Items: []model.Item{{ChrtID: 3}}}}
+
+	mockRepo.EXPECT().ListOrders(gomock.Any(), model.OrderFilter{}, model.Page{Limit: 4, Shallow: true}).
+		Return(&model.OrderPage{Orders: shallow, NextCursor: "next"}, nil)
+	mockCache.EXPECT().Get("hot").Return(hot, true)
+	mockCache.EXPECT().Get("outdated").Return(outdated, true)
+	mockCache.EXPECT().Get("cold").Return(nil, false)
+	mockCache.EXPECT().Get("gone").Return(nil, false)
+	// only the orders the cache can't answer are loaded, in one call
+	mockRepo.EXPECT().GetOrders(gomock.Any(), []string{"outdated", "cold", "gone"}).Return(loaded, nil)
+
+	page, err := svc.List(context.Background(), model.OrderFilter{}, model.Page{Limit: 4})
+	require.NoError(t, err)
+	require.Equal(t, "next", page.NextCursor)
+	require.Equal(t, []*model.Order{hot, loaded[0], loaded[1]}, page.Orders)
+
+	mockRepo.EXPECT().SearchOrders(gomock.Any(), "TRK", model.Page{Shallow: true}).
+		Return(&model.SearchPage{Hits: []model.SearchHit{{Order: &model.Order{OrderUID: "hot", Version: 2}, TrackNumber: true}}}, nil)
+	mockCache.EXPECT().Get("hot").Return(hot, true)
+
+	res, err := svc.Search(context.Background(), "TRK", model.Page{})
+	require.NoError(t, err)
+	require.Equal(t, []model.SearchHit{{Order: hot, TrackNumber: true}}, res.Hits)
+}
+
+func TestOrderStatus_CanBecome(t *testing.T) {
+	for _, tc := range []struct {
+		from, to model.OrderStatus
+		ok       bool
+	}{
+		{model.StatusCreated, model.StatusPaid, true},
+		{model.StatusPaid, model.StatusShipped, true},
+		{model.StatusShipped, model.StatusDelivered, true},
+		{model.StatusDelivered, model.StatusCancelled, true},
+		{model.StatusCreated, model.StatusCancelled, true},
+		{model.StatusPaid, model.StatusPaid, true},
+		{model.StatusCreated, model.StatusShipped, false},
+		{model.StatusShipped, model.StatusPaid, false},
+		{model.StatusDelivered, model.StatusShipped, false},
+		{model.StatusCancelled, model.StatusCreated, false},
+	} {
+		require.Equal(t, tc.ok, tc.from.CanBecome(tc.to), "%s → %s", tc.from, tc.to)
+	}
+}
+
+func TestOrderService_EnrichersWorkOnCopies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockCache := mocks.NewMockInterfaceCache(ctrl)
+	now := time.Date(2025, 8, 20, 12, 0, 0, 0, time.UTC)
+	svc := order.NewOrderService(mockRepo, mockCache, order.WithEnrichers(
+		order.DaysInTransit(func() time.Time { return now }),
+		order.EnricherFunc(order.MaskInternal),
+		order.ShardMeta([]string{"9"}),
+	))
+
+	cached := validOrder("e-1")
+	cached.Status = model.StatusShipped
+	cached.DateCreated = now.Add(-50 * time.Hour)
+	cached.InternalSignature = "sig"
+	mockCache.EXPECT().Get("e-1").Return(cached, true)
+	got, err := svc.Get(context.Background(), "e-1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"days_in_transit": 2, "shard": "9"}, got.Meta)
+	require.Empty(t, got.InternalSignature)
+	require.Nil(t, cached.Meta)
+	require.Equal(t, "sig", cached.InternalSignature)
+
+	listed := validOrder("e-2")
+	listed.ShardKey = "1"
+	mockRepo.EXPECT().GetOrdersByCustomer(gomock.Any(), "c1", gomock.Any()).
+		Return(&model.OrderPage{Orders: []*model.Order{listed}}, nil)
+	page, err := svc.ListByCustomer(context.Background(), "c1", model.Page{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"shard": "primary"}, page.Orders[0].Meta)
 }
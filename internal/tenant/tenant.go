@@ -0,0 +1,41 @@
+// Package tenant carries the shop (tenant) an operation acts for through
+// context.Context, from the HTTP path or broker header down to the SQL.
+//
+// Every order belongs to exactly one tenant. Code that finds no tenant in the
+// context acts for Default, the tenant all orders stored before
+// multi-tenancy belong to, so single-shop deployments need no configuration.
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant of requests and messages that don't name one.
+const Default = "default"
+
+// Header names the tenant of a broker message.
+const Header = "X-Tenant-ID"
+
+// idPattern is the charset allowed in tenant ids; they appear in URLs and logs.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type ctxKey struct{}
+
+// Valid reports whether id can be used as a tenant id.
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// WithID returns a copy of ctx acting for tenant id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant ctx acts for, or Default.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(ctxKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
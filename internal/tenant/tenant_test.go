@@ -0,0 +1,20 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	require.Equal(t, Default, FromContext(context.Background()))
+	require.Equal(t, "shop-1", FromContext(WithID(context.Background(), "shop-1")))
+	require.Equal(t, Default, FromContext(WithID(context.Background(), "")))
+}
+
+func TestValid(t *testing.T) {
+	require.True(t, Valid("shop_1"))
+	require.False(t, Valid(""))
+	require.False(t, Valid("' OR 1=1"))
+}
@@ -0,0 +1,96 @@
+// Package warmer re-warms the order cache on a timer, so the orders worth
+// caching stay cached after they are evicted or invalidated.
+//
+// A pass loads a fixed number of orders picked by the configured
+// model.WarmStrategy. A failed pass is logged and retried on the next tick.
+package warmer
+
+import (
+	"context"
+	"time"
+
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+// Warmer is the part of order.Service the job drives.
+type Warmer interface {
+	Warm(c context.Context, strategy model.WarmStrategy, n int) (int, error)
+}
+
+// Job warms the cache on a timer.
+type Job struct {
+	svc      Warmer
+	log      logger.InterfaceLogger
+	strategy model.WarmStrategy
+	count    int
+	interval time.Duration
+	reset    chan time.Duration
+}
+
+// NewJob fails on an unknown cfg.WarmStrategy.
+func NewJob(svc Warmer, cfg *config.CacheConfig, log logger.InterfaceLogger) (*Job, error) {
+	strategy, err := model.ParseWarmStrategy(cfg.WarmStrategy)
+	if err != nil {
+		return nil, err
+	}
+	j := &Job{
+		svc:      svc,
+		log:      log,
+		strategy: strategy,
+		count:    cfg.WarmCount,
+		interval: cfg.WarmInterval,
+		reset:    make(chan time.Duration, 1),
+	}
+	if j.count <= 0 {
+		j.count = 10
+	}
+	if j.interval <= 0 {
+		j.interval = 5 * time.Minute
+	}
+	return j, nil
+}
+
+// Run warms the cache every interval until ctx is done. The first pass waits
+// one interval: the cache is warmed at startup already.
+func (j *Job) Run(ctx context.Context) error {
+	t := time.NewTicker(j.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case d := <-j.reset:
+			t.Reset(d)
+			continue
+		case <-t.C:
+		}
+		j.RunOnce(ctx)
+	}
+}
+
+// SetInterval makes Run wait d from now for the next pass, and d between
+// passes after it. A non-positive d is ignored.
+func (j *Job) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	// only the latest interval matters
+	select {
+	case <-j.reset:
+	default:
+	}
+	j.reset <- d
+}
+
+// RunOnce makes one pass and returns how many orders it loaded.
+func (j *Job) RunOnce(ctx context.Context) int {
+	n, err := j.svc.Warm(ctx, j.strategy, j.count)
+	if err != nil {
+		j.log.Errorf("warmer: %s warm cached %d orders, the rest failed: %v", j.strategy, n, err)
+		return n
+	}
+	j.log.Debugf("warmer: loaded %d %s orders", n, j.strategy)
+	return n
+}
@@ -0,0 +1,61 @@
+package warmer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob_RunOnceWarmsWithConfiguredStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+	job, err := NewJob(svc, &config.CacheConfig{WarmStrategy: "popular", WarmCount: 50}, log)
+	require.NoError(t, err)
+
+	gomock.InOrder(
+		svc.EXPECT().Warm(gomock.Any(), model.WarmPopular, 50).Return(42, nil),
+		svc.EXPECT().Warm(gomock.Any(), model.WarmPopular, 50).Return(3, errors.New("cache full")),
+	)
+	require.Equal(t, 42, job.RunOnce(context.Background()))
+	require.Equal(t, 3, job.RunOnce(context.Background()))
+}
+
+func TestNewJob_RejectsUnknownStrategy(t *testing.T) {
+	_, err := NewJob(nil, &config.CacheConfig{WarmStrategy: "lru"}, nil)
+	require.ErrorContains(t, err, `unknown warm strategy "lru"`)
+}
+
+func TestJob_SetIntervalReschedules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockService(ctrl)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+	job, err := NewJob(svc, &config.CacheConfig{WarmStrategy: "recent", WarmCount: 5, WarmInterval: time.Hour}, log)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	warmed := make(chan struct{})
+	svc.EXPECT().Warm(gomock.Any(), model.WarmRecent, 5).DoAndReturn(func(context.Context, model.WarmStrategy, int) (int, error) {
+		cancel()
+		close(warmed)
+		return 5, nil
+	})
+	job.SetInterval(time.Millisecond)
+	require.ErrorIs(t, job.Run(ctx), context.Canceled)
+	<-warmed
+}
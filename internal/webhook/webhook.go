@@ -0,0 +1,270 @@
+// Package webhook notifies external subscribers of stored orders and status
+// changes with signed HTTP POSTs.
+//
+// The Dispatcher subscribes to the order service's event bus and queues one
+// delivery per event and subscriber URL; a pool of workers sends them, so a
+// slow subscriber never holds up ingestion. A delivery that fails with a
+// network error, a 5xx, 408 or 429 is retried with jittered exponential
+// backoff; other answers fail it at once. Every delivery is recorded in
+// webhook_deliveries with the outcome of its latest attempt. Deliveries still
+// queued when the process stops are lost.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/events"
+	"github.com/merkulovlad/wbtech-go/internal/logger"
+	"github.com/merkulovlad/wbtech-go/internal/metrics"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+)
+
+const (
+	// HeaderSignature is "sha256=<hex HMAC-SHA256 of the body>", keyed with
+	// the shared secret.
+	HeaderSignature = "X-Webhook-Signature"
+	// HeaderEvent is the event type, e.g. model.EventOrderUpserted.
+	HeaderEvent = "X-Webhook-Event"
+	// HeaderID is Payload.ID; retries of one delivery repeat it.
+	HeaderID = "X-Webhook-ID"
+)
+
+var deliveries = metrics.NewCounterVec("webhook_deliveries_total",
+	"Webhook delivery attempts by result: delivered, retried, failed or dropped (queue full).", "result")
+
+// Payload is the JSON body of every webhook call.
+type Payload struct {
+	// ID identifies the event, so subscribers can drop repeats.
+	ID         string            `json:"id"`
+	Event      string            `json:"event"`
+	TenantID   string            `json:"tenant_id"`
+	OrderUID   string            `json:"order_uid"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Status     model.OrderStatus `json:"status,omitempty"`
+	// Order is the order as stored, for model.EventOrderUpserted.
+	Order *model.Order `json:"order,omitempty"`
+}
+
+// Store records deliveries; repository.Repository is one.
+type Store interface {
+	SaveWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error
+}
+
+// Sign returns the HeaderSignature value for body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return fmt.Sprintf("sha256=%x", mac.Sum(nil))
+}
+
+// Dispatcher delivers order events to the configured URLs.
+type Dispatcher struct {
+	urls      []string
+	secret    []byte
+	client    *http.Client
+	store     Store
+	log       logger.InterfaceLogger
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	workers   int
+	queue     chan *delivery
+	now       func() time.Time
+	sleep     func(ctx context.Context, d time.Duration) error
+}
+
+type delivery struct {
+	record model.WebhookDelivery
+	id     string
+	body   []byte
+}
+
+func NewDispatcher(cfg *config.WebhookConfig, store Store, log logger.InterfaceLogger) *Dispatcher {
+	d := &Dispatcher{
+		urls:      cfg.URLs,
+		secret:    []byte(cfg.Secret),
+		client:    &http.Client{Timeout: cfg.Timeout},
+		store:     store,
+		log:       log,
+		attempts:  cfg.Attempts,
+		baseDelay: cfg.RetryBaseDelay,
+		maxDelay:  cfg.RetryMaxDelay,
+		workers:   cfg.Workers,
+		now:       time.Now,
+		sleep:     sleepCtx,
+	}
+	if d.attempts <= 0 {
+		d.attempts = 5
+	}
+	if d.baseDelay <= 0 {
+		d.baseDelay = time.Second
+	}
+	if d.maxDelay < d.baseDelay {
+		d.maxDelay = d.baseDelay
+	}
+	if d.workers <= 0 {
+		d.workers = 4
+	}
+	queue := cfg.Queue
+	if queue <= 0 {
+		queue = 1000
+	}
+	d.queue = make(chan *delivery, queue)
+	return d
+}
+
+// Subscriber queues stored orders and status changes for every URL; other
+// events are ignored. It never blocks: with the queue full the event is
+// dropped for that URL.
+func (d *Dispatcher) Subscriber() events.Subscriber {
+	return func(_ context.Context, e events.Event) {
+		if e.Type != model.EventOrderUpserted && e.Type != model.EventOrderStatusChanged {
+			return
+		}
+		p := Payload{
+			ID:         uuid.NewString(),
+			Event:      e.Type,
+			TenantID:   e.TenantID,
+			OrderUID:   e.OrderUID,
+			OccurredAt: d.now().UTC(),
+			Status:     e.Status,
+			Order:      e.Order,
+		}
+		body, err := json.Marshal(p)
+		if err != nil {
+			d.log.Errorf("webhook: encode %s of order %s: %v", e.Type, e.OrderUID, err)
+			return
+		}
+		for _, url := range d.urls {
+			dl := &delivery{id: p.ID, body: body, record: model.WebhookDelivery{
+				URL:       url,
+				EventType: e.Type,
+				TenantID:  e.TenantID,
+				OrderUID:  e.OrderUID,
+				Status:    model.WebhookPending,
+			}}
+			select {
+			case d.queue <- dl:
+			default:
+				deliveries.Inc("dropped")
+				d.log.Warnf("webhook: queue full, dropped %s of order %s for %s", e.Type, e.OrderUID, url)
+			}
+		}
+	}
+}
+
+// Run delivers queued events until ctx is done; a delivery interrupted by
+// then is recorded as pending.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for range d.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case dl := <-d.queue:
+					d.deliver(ctx, dl)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// deliver tries dl until it succeeds, fails for good or ctx is done, and
+// records the outcome of every attempt.
+func (d *Dispatcher) deliver(ctx context.Context, dl *delivery) {
+	rec := &dl.record
+	for n := 1; ; n++ {
+		code, retryable, err := d.post(ctx, dl)
+		rec.Attempts, rec.ResponseCode, rec.LastError = n, code, ""
+		switch {
+		case err == nil:
+			rec.Status = model.WebhookDelivered
+			at := d.now().UTC()
+			rec.DeliveredAt = &at
+			deliveries.Inc("delivered")
+		case ctx.Err() != nil:
+			// interrupted by shutdown: stays pending
+			rec.LastError = err.Error()
+		case !retryable || n >= d.attempts:
+			rec.Status, rec.LastError = model.WebhookFailed, err.Error()
+			deliveries.Inc("failed")
+			d.log.Errorf("webhook: %s of order %s to %s failed after %d attempts: %v", rec.EventType, rec.OrderUID, rec.URL, n, err)
+		default:
+			rec.LastError = err.Error()
+			deliveries.Inc("retried")
+		}
+		if serr := d.store.SaveWebhookDelivery(context.WithoutCancel(ctx), rec); serr != nil {
+			d.log.Errorf("webhook: record delivery of order %s to %s: %v", rec.OrderUID, rec.URL, serr)
+		}
+		if rec.Status != model.WebhookPending || ctx.Err() != nil {
+			return
+		}
+		wait := d.backoff(n - 1)
+		d.log.Warnf("webhook: %s of order %s to %s: retry %d/%d in %s: %v", rec.EventType, rec.OrderUID, rec.URL, n, d.attempts-1, wait, err)
+		if d.sleep(ctx, wait) != nil {
+			return
+		}
+	}
+}
+
+// post makes one attempt and reports the response status, if any, and
+// whether a failure is worth retrying.
+func (d *Dispatcher) post(ctx context.Context, dl *delivery) (code int, retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dl.record.URL, bytes.NewReader(dl.body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, dl.record.EventType)
+	req.Header.Set(HeaderID, dl.id)
+	req.Header.Set(HeaderSignature, Sign(d.secret, dl.body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // lets the connection be reused
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retryable = resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retryable, fmt.Errorf("answered %s", resp.Status)
+}
+
+// backoff returns the jittered delay before retry n (starting at 0).
+func (d *Dispatcher) backoff(n int) time.Duration {
+	ceiling := d.baseDelay << n
+	if ceiling > d.maxDelay || ceiling <= 0 {
+		ceiling = d.maxDelay
+	}
+	return rand.N(ceiling)
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
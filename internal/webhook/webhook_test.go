@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/merkulovlad/wbtech-go/internal/config/config"
+	"github.com/merkulovlad/wbtech-go/internal/events"
+	"github.com/merkulovlad/wbtech-go/internal/mocks"
+	"github.com/merkulovlad/wbtech-go/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+// memStore keeps a copy of every saved delivery.
+type memStore struct {
+	mu    sync.Mutex
+	saved []model.WebhookDelivery
+}
+
+func (s *memStore) SaveWebhookDelivery(_ context.Context, d *model.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d.ID == 0 {
+		d.ID = int64(len(s.saved) + 1)
+	}
+	s.saved = append(s.saved, *d)
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, urls ...string) (*Dispatcher, *memStore) {
+	ctrl := gomock.NewController(t)
+	log := mocks.NewMockInterfaceLogger(ctrl)
+	log.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+	store := &memStore{}
+	d := NewDispatcher(&config.WebhookConfig{URLs: urls, Secret: "s3cret", Timeout: time.Second, Attempts: 3, Queue: 1}, store, log)
+	d.sleep = func(context.Context, time.Duration) error { return nil }
+	return d, store
+}
+
+func TestDispatcher_RetriesUntilDelivered(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		calls  int
+		body   []byte
+		header http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	d, store := newTestDispatcher(t, srv.URL)
+	d.Subscriber()(context.Background(), events.Event{
+		Type: model.EventOrderStatusChanged, TenantID: "shop", OrderUID: "o-1", Status: model.StatusPaid,
+	})
+	d.deliver(context.Background(), <-d.queue)
+
+	require.Equal(t, 2, calls)
+	require.Equal(t, Sign([]byte("s3cret"), body), header.Get(HeaderSignature))
+	require.Equal(t, model.EventOrderStatusChanged, header.Get(HeaderEvent))
+	var got Payload
+	require.NoError(t, json.Unmarshal(body, &got))
+	require.Equal(t, got.ID, header.Get(HeaderID))
+	require.Equal(t, "o-1", got.OrderUID)
+	require.Equal(t, model.StatusPaid, got.Status)
+	require.Len(t, store.saved, 2)
+	first, last := store.saved[0], store.saved[1]
+	require.Equal(t, model.WebhookPending, first.Status)
+	require.Equal(t, http.StatusServiceUnavailable, first.ResponseCode)
+	require.Equal(t, "answered 503 Service Unavailable", first.LastError)
+	require.Equal(t, first.ID, last.ID)
+	require.Equal(t, model.WebhookDelivered, last.Status)
+	require.Equal(t, 2, last.Attempts)
+	require.Empty(t, last.LastError)
+	require.NotNil(t, last.DeliveredAt)
+}
+
+func TestDispatcher_GivesUp(t *testing.T) {
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	// a 4xx is not retried
+	d, store := newTestDispatcher(t, srv.URL)
+	stored := events.Event{Type: model.EventOrderUpserted, OrderUID: "o-1", Order: &model.Order{OrderUID: "o-1"}}
+	d.Subscriber()(context.Background(), stored)
+	d.deliver(context.Background(), <-d.queue)
+	require.Len(t, store.saved, 1)
+	require.Equal(t, model.WebhookFailed, store.saved[0].Status)
+
+	// a 5xx is retried up to the attempts
+	status = http.StatusBadGateway
+	d, store = newTestDispatcher(t, srv.URL)
+	d.Subscriber()(context.Background(), stored)
+	d.deliver(context.Background(), <-d.queue)
+	require.Len(t, store.saved, 3)
+	require.Equal(t, model.WebhookFailed, store.saved[2].Status)
+	require.Equal(t, 3, store.saved[2].Attempts)
+}
+
+func TestDispatcher_SubscriberQueuesWithoutBlocking(t *testing.T) {
+	d, _ := newTestDispatcher(t, "http://a.example", "http://b.example")
+	sub := d.Subscriber()
+	sub(context.Background(), events.Event{Type: model.EventOrderDeleted, OrderUID: "o-1"})
+	require.Empty(t, d.queue)
+
+	before := deliveries.Value("dropped")
+	sub(context.Background(), events.Event{Type: model.EventOrderStatusChanged, OrderUID: "o-1", Status: model.StatusPaid})
+	require.Len(t, d.queue, 1)
+	require.Equal(t, before+1, deliveries.Value("dropped"))
+	require.Equal(t, "http://a.example", (<-d.queue).record.URL)
+}